@@ -4,6 +4,8 @@ package main
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/handlers"
@@ -36,6 +38,9 @@ func main() {
 	app := fiber.New(fiber.Config{
 		AppName:      "Sistem Mitigasi Bencana (API Kecamatan) v1.0",
 		ErrorHandler: customErrorHandler,
+		// Body dibaca oleh middleware.BodyLimit agar rute unggahan bisa diberi batas lebih besar
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
 
 	// Middleware
@@ -47,9 +52,28 @@ func main() {
 		AllowMethods: "GET, POST, PUT, DELETE, PATCH",
 	}))
 
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, ruteUnggahan))
+
 	// Setup routes
 	setupRoutes(app)
 
+	// Cek berkala warga prioritas yang belum tertangani
+	go handlers.StartEskalasiWorker(time.Minute)
+
+	// Warga rentan yang tidak membalas peringatan didahulukan
+	go handlers.StartKeselamatanWorker(time.Minute)
+
+	// Akhiri shift kedaluwarsa & hapus ping lokasi lama
+	go handlers.StartPelacakanWorker(time.Minute)
+
+	// Event dari Kota (status permintaan, penugasan pasokan, arahan, peringatan dini)
+	go handlers.StartEventKotaConsumer(kafkaBroker())
+
+	// Bacaan sensor lewat MQTT (opsional, mis. Mosquitto lokal di localhost:1883)
+	if broker := os.Getenv("MQTT_BROKER"); broker != "" {
+		go handlers.StartSensorMQTT(broker)
+	}
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -86,6 +110,20 @@ func setupRoutes(app *fiber.App) {
 	warga.Post("/", middleware.RoleMiddleware([]string{"RT", "RW"}), handlers.CreateWarga)
 	warga.Put("/:id", middleware.RoleMiddleware([]string{"RT", "RW"}), handlers.UpdateWarga)
 	warga.Delete("/:id", middleware.RoleMiddleware([]string{"RT", "RW"}), handlers.DeleteWarga)
+	warga.Get("/:id/riwayat", middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}), handlers.GetRiwayatWarga)
+	warga.Post("/:id/restore", middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}), handlers.RestoreWarga)
+	warga.Post("/:id/rollback", middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}), handlers.RollbackWarga)
+	warga.Get("/:id/qr", middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}), handlers.GetWargaQR)
+
+	// Kartu Keluarga routes
+	keluarga := api.Group("/keluarga", middleware.AuthMiddleware)
+	keluarga.Get("/", middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}), handlers.GetAllKeluarga)
+	keluarga.Get("/:id", handlers.GetKeluargaByID)
+	keluarga.Post("/", middleware.RoleMiddleware([]string{"RT", "RW"}), handlers.CreateKeluarga)
+	keluarga.Put("/:id", middleware.RoleMiddleware([]string{"RT", "RW"}), handlers.UpdateKeluarga)
+	keluarga.Delete("/:id", middleware.RoleMiddleware([]string{"RT", "RW"}), handlers.DeleteKeluarga)
+	keluarga.Post("/:id/anggota", middleware.RoleMiddleware([]string{"RT", "RW"}), handlers.AddAnggotaKeluarga)
+	keluarga.Delete("/:id/anggota/:warga_id", middleware.RoleMiddleware([]string{"RT", "RW"}), handlers.RemoveAnggotaKeluarga)
 
 	// Kejadian Bencana routes
 	bencana := api.Group("/bencana", middleware.AuthMiddleware)
@@ -94,13 +132,199 @@ func setupRoutes(app *fiber.App) {
 	bencana.Post("/", middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}), handlers.CreateBencana)
 	bencana.Put("/:id/status", handlers.UpdateStatusBencana)
 	bencana.Get("/active", handlers.GetActiveBencana)
+	bencana.Get("/:id/playbook", handlers.GetPlaybookBencana)
+	bencana.Get("/:id/tugas", handlers.GetTugasPlaybook)
+	bencana.Post("/:id/tugas", middleware.RoleMiddleware([]string{"RW", "Admin_Kecamatan"}), handlers.CreateTugasPlaybook)
+	bencana.Put("/:id/tugas/:tugas_id", middleware.RoleMiddleware([]string{"RT", "RW", "Relawan", "Admin_Kecamatan"}), handlers.UpdateTugasPlaybook)
+	bencana.Get("/:id/timeline", handlers.GetTimelineBencana)
+	bencana.Post("/:id/timeline", middleware.BodyLimit(handlers.BatasBodyTimeline(), nil), middleware.RoleMiddleware([]string{"RT", "RW", "Relawan", "Admin_Kecamatan"}), handlers.CreateTimelineBencana)
+	bencana.Delete("/:id/timeline/:entri_id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.DeleteTimelineBencana)
+	bencana.Get("/:id/lampiran/:lampiran_id", handlers.GetLampiranBencana)
+	bencana.Get("/:id/dampak", handlers.GetDampakBencana)
+	bencana.Get("/:id/sitrep", handlers.GetSitrepBencana)
+	bencana.Get("/:id/notifikasi", handlers.GetNotifikasiBencana)
+	bencana.Get("/:id/keselamatan", handlers.GetKeselamatanBencana)
+	bencana.Put("/:id/keselamatan/:warga_id", middleware.RoleMiddleware([]string{"RT", "RW", "Relawan", "Admin_Kecamatan"}), handlers.UpdateKeselamatanWarga)
+	bencana.Get("/:id/duplikat", handlers.GetDuplikatBencana)
+	bencana.Post("/:id/gabung", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.GabungBencana)
+	bencana.Get("/:id/penggabungan", handlers.GetPenggabunganBencana)
 
 	// Evakuasi routes
 	evakuasi := api.Group("/evakuasi", middleware.AuthMiddleware)
 	evakuasi.Get("/prioritas/:bencana_id", handlers.GetPrioritasEvakuasi)
+	evakuasi.Get("/prioritas/:bencana_id/keluarga", handlers.GetPrioritasKeluarga)
 	evakuasi.Post("/log", middleware.RoleMiddleware([]string{"Relawan"}), handlers.CreateLogEvakuasi)
+	evakuasi.Post("/log/keluarga", middleware.RoleMiddleware([]string{"Relawan"}), handlers.CreateLogEvakuasiKeluarga)
 	evakuasi.Put("/log/:id", middleware.RoleMiddleware([]string{"Relawan"}), handlers.UpdateLogEvakuasi)
 	evakuasi.Get("/log/:bencana_id", handlers.GetLogEvakuasi)
+	evakuasi.Get("/warga/:warga_id/riwayat", handlers.GetRiwayatStatusWarga)
+
+	// Titik kumpul / shelter routes
+	titikKumpul := api.Group("/titik-kumpul", middleware.AuthMiddleware)
+	titikKumpul.Get("/", handlers.GetAllTitikKumpul)
+	titikKumpul.Get("/saran", handlers.GetSaranTitikKumpul)
+	titikKumpul.Get("/:id", handlers.GetTitikKumpulByID)
+	titikKumpul.Post("/", middleware.RoleMiddleware([]string{"RW", "Admin_Kecamatan"}), handlers.CreateTitikKumpul)
+	titikKumpul.Put("/:id", middleware.RoleMiddleware([]string{"RW", "Admin_Kecamatan"}), handlers.UpdateTitikKumpul)
+	titikKumpul.Delete("/:id", middleware.RoleMiddleware([]string{"RW", "Admin_Kecamatan"}), handlers.DeleteTitikKumpul)
+	titikKumpul.Get("/:id/pengungsi", handlers.GetPengungsiTitikKumpul)
+	titikKumpul.Post("/:id/checkin", middleware.RoleMiddleware([]string{"RT", "RW", "Relawan", "Admin_Kecamatan"}), handlers.CheckInPengungsi)
+
+	// Registri pengungsi (petugas berwenang saja)
+	pengungsi := api.Group("/pengungsi", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"RT", "RW", "Relawan", "Admin_Kecamatan"}))
+	pengungsi.Get("/cari", handlers.CariPengungsi)
+	pengungsi.Get("/keluarga/:kartu_keluarga_id", handlers.GetPengungsiKeluarga)
+	pengungsi.Post("/:id/pindah", handlers.PindahPengungsi)
+	pengungsi.Post("/:id/keluar", handlers.CheckOutPengungsi)
+
+	// Orang hilang & rekonsiliasi warga
+	orangHilang := api.Group("/orang-hilang", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"RT", "RW", "Relawan", "Admin_Kecamatan"}))
+	orangHilang.Get("/rekonsiliasi/:bencana_id", handlers.GetRekonsiliasiWarga)
+	orangHilang.Get("/", handlers.GetAllLaporanOrangHilang)
+	orangHilang.Post("/", handlers.CreateLaporanOrangHilang)
+	orangHilang.Get("/:id", handlers.GetLaporanOrangHilangByID)
+	orangHilang.Put("/:id/ditemukan", handlers.TandaiDitemukan)
+
+	// Logistik: gudang/posko, katalog barang, stok, distribusi
+	logistik := api.Group("/logistik", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"RW", "Relawan", "Admin_Kecamatan"}))
+	logistik.Get("/gudang", handlers.GetAllGudangLogistik)
+	logistik.Get("/gudang/:id", handlers.GetGudangLogistikByID)
+	logistik.Post("/gudang", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.CreateGudangLogistik)
+	logistik.Put("/gudang/:id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.UpdateGudangLogistik)
+	logistik.Delete("/gudang/:id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.DeleteGudangLogistik)
+	logistik.Post("/gudang/:id/mutasi", handlers.CreateMutasiStok)
+	logistik.Post("/gudang/:id/distribusi", handlers.CreateDistribusiBantuan)
+	logistik.Get("/barang", handlers.GetAllBarangLogistik)
+	logistik.Post("/barang", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.CreateBarangLogistik)
+	logistik.Put("/barang/:id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.UpdateBarangLogistik)
+	logistik.Get("/stok", handlers.GetStokLogistik)
+	logistik.Get("/mutasi", handlers.GetMutasiStok)
+	logistik.Get("/distribusi", handlers.GetAllDistribusiBantuan)
+
+	// Permintaan sumber daya ke Kota & pasokan untuk kecamatan lain
+	permintaan := api.Group("/permintaan-sumber-daya", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"RW", "Admin_Kecamatan"}))
+	permintaan.Get("/", handlers.GetAllPermintaanSumberDaya)
+	permintaan.Post("/", handlers.CreatePermintaanSumberDaya)
+	permintaan.Get("/:id", handlers.GetPermintaanSumberDayaByID)
+	permintaan.Put("/:id/terima", handlers.TerimaPermintaanSumberDaya)
+	permintaan.Put("/:id/batal", handlers.BatalkanPermintaanSumberDaya)
+
+	pasokan := api.Group("/pasokan-sumber-daya", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"Admin_Kecamatan"}))
+	pasokan.Get("/", handlers.GetAllPasokanSumberDaya)
+	pasokan.Put("/:id/kirim", handlers.KirimPasokanSumberDaya)
+
+	// Arahan & status siaga dari Kota
+	arahan := api.Group("/arahan", middleware.AuthMiddleware)
+	arahan.Get("/", handlers.GetAllArahan)
+	arahan.Get("/tingkat-siaga", handlers.GetTingkatSiaga)
+	arahan.Put("/:id/konfirmasi", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.KonfirmasiArahan)
+
+	// Peringatan resmi BMKG yang diteruskan Kota
+	api.Get("/peringatan-dini", middleware.AuthMiddleware, handlers.GetAllPeringatanDini)
+
+	// Profil & shift relawan (relawan sendiri)
+	relawanSaya := api.Group("/relawan/saya", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"Relawan"}))
+	relawanSaya.Get("/", handlers.GetProfilRelawanSaya)
+	relawanSaya.Put("/", handlers.UpdateProfilRelawanSaya)
+	relawanSaya.Put("/lokasi", handlers.UpdateLokasiRelawanSaya)
+	relawanSaya.Post("/check-in", handlers.CheckInShift)
+	relawanSaya.Post("/check-out", handlers.CheckOutShift)
+	relawanSaya.Post("/ping", handlers.PingLokasi)
+
+	// Manajemen relawan (koordinator)
+	relawan := api.Group("/relawan", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"Admin_Kecamatan", "RW"}))
+	relawan.Get("/", handlers.GetAllRelawan)
+	relawan.Get("/tersedia", handlers.GetRelawanTersedia)
+	relawan.Get("/shift", handlers.GetShiftRelawan)
+	relawan.Get("/peta/:bencana_id", handlers.GetPetaRelawan)
+	relawan.Get("/:id", handlers.GetRelawanByID)
+	relawan.Get("/:id/jejak", handlers.GetJejakRelawan)
+	relawan.Put("/:id", handlers.UpdateProfilRelawan)
+
+	// Dispatch relawan (koordinator)
+	dispatch := api.Group("/dispatch", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"Admin_Kecamatan", "RW"}))
+	dispatch.Get("/:bencana_id", handlers.GetTugasBencana)
+	dispatch.Post("/:bencana_id/assign", handlers.AssignTugas)
+	dispatch.Post("/:bencana_id/auto", handlers.AutoAssignTugas)
+	dispatch.Get("/:bencana_id/rute/:relawan_id", handlers.GetRuteRelawan)
+	dispatch.Get("/:bencana_id/eskalasi", handlers.GetEskalasi)
+	dispatch.Get("/tugas/:id/jejak", handlers.GetJejakTugas)
+	dispatch.Put("/eskalasi/:id/tangani", handlers.TanganiEskalasi)
+
+	// Tugas evakuasi (relawan)
+	tugas := api.Group("/tugas", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"Relawan"}))
+	tugas.Get("/saya", handlers.GetTugasSaya)
+	tugas.Get("/saya/rute", handlers.GetRuteSaya)
+	tugas.Post("/:id/terima", handlers.TerimaTugas)
+	tugas.Post("/:id/tolak", handlers.TolakTugas)
+
+	// Laporan bahaya dari warga tanpa akun (publik, dibatasi per IP)
+	api.Post("/laporan-warga", middleware.RateLimit("LAPORAN_WARGA_MAKS_PER_MENIT", 10, time.Minute), middleware.BodyLimit(handlers.BatasBodyLaporanWarga(), nil), handlers.CreateLaporanWarga)
+	api.Get("/laporan-warga/status/:kode", middleware.RateLimit("LAPORAN_WARGA_MAKS_CEK_PER_MENIT", 30, time.Minute), handlers.GetStatusLaporanWarga)
+
+	// Antrean verifikasi laporan warga
+	laporanWarga := api.Group("/laporan-warga", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}))
+	laporanWarga.Get("/", handlers.GetAllLaporanWarga)
+	laporanWarga.Get("/:id", handlers.GetLaporanWargaByID)
+	laporanWarga.Get("/:id/foto", handlers.GetFotoLaporanWarga)
+	laporanWarga.Put("/:id/konfirmasi", handlers.KonfirmasiLaporanWarga)
+	laporanWarga.Put("/:id/tolak", handlers.TolakLaporanWarga)
+	laporanWarga.Put("/:id/gabung", handlers.GabungLaporanWarga)
+
+	// Balasan warga atas notifikasi darurat lewat tautan bertoken (publik, dibatasi per IP)
+	api.Get("/keselamatan/:token", middleware.RateLimit("KESELAMATAN_MAKS_PER_MENIT", 30, time.Minute), handlers.GetTautanKeselamatan)
+	api.Post("/keselamatan/:token", middleware.RateLimit("KESELAMATAN_MAKS_PER_MENIT", 30, time.Minute), handlers.ResponTautanKeselamatan)
+
+	// Webhook SMS/WhatsApp dari gateway PESAN_GATEWAY (diautentikasi oleh gateway)
+	api.Get("/pesan-masuk/lokal/keluar", middleware.AuthMiddleware, middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.GetPesanKeluarLokal)
+	api.Post("/pesan-masuk/:kanal", middleware.RateLimit("PESAN_MASUK_MAKS_PER_MENIT", 60, time.Minute), handlers.TerimaPesanMasuk)
+
+	// Bacaan sensor (publik, diautentikasi dengan X-Sensor-Token)
+	api.Post("/sensor/bacaan", handlers.IngestBacaanSensor)
+
+	// Registri sensor, aturan ambang & peringatan dini
+	sensor := api.Group("/sensor", middleware.AuthMiddleware)
+	sensor.Get("/", handlers.GetAllSensor)
+	sensor.Get("/peringatan", handlers.GetAllPeringatanSensor)
+	sensor.Put("/peringatan/:id/konfirmasi", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.KonfirmasiPeringatanSensor)
+	sensor.Put("/peringatan/:id/tolak", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.TolakPeringatanSensor)
+	sensor.Get("/:id", handlers.GetSensorByID)
+	sensor.Get("/:id/bacaan", handlers.GetBacaanSensor)
+	sensor.Post("/", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.CreateSensor)
+	sensor.Put("/:id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.UpdateSensor)
+	sensor.Delete("/:id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.DeleteSensor)
+	sensor.Post("/:id/token", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.RegenerateTokenSensor)
+	sensor.Post("/:id/aturan", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.CreateAturanSensor)
+	sensor.Put("/:id/aturan/:aturan_id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.UpdateAturanSensor)
+	sensor.Delete("/:id/aturan/:aturan_id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.DeleteAturanSensor)
+
+	// Taksonomi jenis bencana (dikelola Kota)
+	jenisBencana := api.Group("/jenis-bencana", middleware.AuthMiddleware)
+	jenisBencana.Get("/", handlers.GetAllJenisBencana)
+	jenisBencana.Get("/:kode", handlers.GetJenisBencanaByKode)
+
+	// Zona bahaya (GeoJSON) & paparan warga
+	zonaBahaya := api.Group("/zona-bahaya", middleware.AuthMiddleware)
+	zonaBahaya.Get("/", handlers.GetAllZonaBahaya)
+	zonaBahaya.Get("/rekap", handlers.GetRekapPaparan)
+	zonaBahaya.Post("/", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.CreateZonaBahaya)
+	zonaBahaya.Post("/impor", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.ImporZonaBahaya)
+	zonaBahaya.Post("/hitung-ulang", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.HitungUlangPaparan)
+	zonaBahaya.Get("/:id", handlers.GetZonaBahayaByID)
+	zonaBahaya.Get("/:id/warga", middleware.RoleMiddleware([]string{"RT", "RW", "Relawan", "Admin_Kecamatan"}), handlers.GetWargaZonaBahaya)
+	zonaBahaya.Put("/:id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.UpdateZonaBahaya)
+	zonaBahaya.Delete("/:id", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.DeleteZonaBahaya)
+
+	// Jitupasna: asesmen kerusakan & kebutuhan pascabencana (petugas RT)
+	jitupasna := api.Group("/jitupasna", middleware.AuthMiddleware)
+	jitupasna.Get("/", handlers.GetAllAsesmen)
+	jitupasna.Get("/referensi", handlers.GetReferensiJitupasna)
+	jitupasna.Get("/rekap/:bencana_id", handlers.GetRekapJitupasna)
+	jitupasna.Get("/:id", handlers.GetAsesmenByID)
+	jitupasna.Post("/", middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}), handlers.CreateAsesmen)
+	jitupasna.Put("/:id", middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}), handlers.UpdateAsesmen)
+	jitupasna.Put("/:id/verifikasi", middleware.RoleMiddleware([]string{"Admin_Kecamatan"}), handlers.VerifikasiAsesmen)
+	jitupasna.Delete("/:id", middleware.RoleMiddleware([]string{"RT", "RW", "Admin_Kecamatan"}), handlers.DeleteAsesmen)
 
 	// Notifikasi & Broadcast routes
 	notif := api.Group("/notifikasi", middleware.AuthMiddleware)
@@ -115,12 +339,22 @@ func setupRoutes(app *fiber.App) {
 	logs.Get("/", handlers.GetSystemLogs)
 
 	//
-	// Menghubungkan ke localhost:9092 (Container Docker Anda)
-	messaging.InitKafkaProducer("localhost:9092", "sync-events")
+	// Menghubungkan ke KAFKA_BROKER (default localhost:9092, Container Docker Anda)
+	messaging.InitKafkaProducer(kafkaBroker(), "sync-events")
 
 	// !! RUTE KOTA (monitoring/kota, monitoring/kecamatan, rekap) DIHAPUS DARI SINI !!
 }
 
+// ruteUnggahan reports whether c is an upload route that sets its own (larger) body limit
+func ruteUnggahan(c *fiber.Ctx) bool {
+	if c.Method() != fiber.MethodPost {
+		return false
+	}
+	path := strings.TrimSuffix(c.Path(), "/")
+	return path == "/api/v1/laporan-warga" ||
+		(strings.HasPrefix(path, "/api/v1/bencana/") && strings.HasSuffix(path, "/timeline"))
+}
+
 func customErrorHandler(c *fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
 	message := "Internal Server Error"
@@ -135,3 +369,11 @@ func customErrorHandler(c *fiber.Ctx, err error) error {
 		"message": message,
 	})
 }
+
+// kafkaBroker returns the Kafka broker address from KAFKA_BROKER
+func kafkaBroker() string {
+	if broker := os.Getenv("KAFKA_BROKER"); broker != "" {
+		return broker
+	}
+	return "localhost:9092"
+}
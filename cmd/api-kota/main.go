@@ -7,6 +7,7 @@ import (
 
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/handlers"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/middleware"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -49,6 +50,12 @@ func main() {
 	// Setup routes
 	setupRoutesKota(app)
 
+	// Keputusan BPBD dikirim ke kecamatan lewat topic Kota
+	messaging.InitKafkaDownstream("localhost:9092", messaging.TopicKota)
+
+	// Tarik berkala feed peringatan BMKG (FEED_PERINGATAN)
+	go handlers.StartFeedPeringatanWorker()
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -91,6 +98,8 @@ func setupRoutesKota(app *fiber.App) {
 			"message": "Handler CreateKecamatan belum diimplementasi",
 		})
 	})
+	master.Get("/:id/wilayah", handlers.GetWilayahKecamatan)
+	master.Put("/:id/wilayah", middleware.RoleMiddleware([]string{"BPBD"}), handlers.UpdateWilayahKecamatan)
 
 	// Monitoring routes (Sesuai README dan kode Anda)
 	// monitoring := api.Group("/monitoring", middleware.AuthMiddleware)
@@ -104,6 +113,42 @@ func setupRoutesKota(app *fiber.App) {
 	// 'handlers.GetRekapWilayah' membaca dari tabel RekapDataWilayah
 	// yang diisi oleh Sync Worker, jadi ini sudah benar.
 	monitoring.Get("/statistik", handlers.GetRekapWilayah) // Sesuai README
+	monitoring.Get("/titik-kumpul", handlers.GetOkupansiTitikKumpulKota)
+	monitoring.Get("/orang-hilang", handlers.GetRekapOrangHilangKota)
+	monitoring.Get("/logistik", handlers.GetStokLogistikKota)
+	monitoring.Get("/paparan", handlers.GetRekapPaparanKota)
+	monitoring.Get("/jitupasna", handlers.GetRekapJitupasnaKota)
+	monitoring.Get("/penggabungan-bencana", handlers.GetPenggabunganBencanaKota)
+
+	// Permintaan sumber daya antar kecamatan (keputusan oleh BPBD)
+	permintaan := api.Group("/permintaan-sumber-daya", middleware.AuthMiddleware)
+	permintaan.Get("/", handlers.GetPermintaanSumberDayaKota)
+	permintaan.Get("/:id", handlers.GetPermintaanSumberDayaKotaByID)
+	permintaan.Put("/:id/setujui", middleware.RoleMiddleware([]string{"BPBD"}), handlers.SetujuiPermintaanSumberDaya)
+	permintaan.Put("/:id/tolak", middleware.RoleMiddleware([]string{"BPBD"}), handlers.TolakPermintaanSumberDaya)
+	permintaan.Put("/:id/kirim", middleware.RoleMiddleware([]string{"BPBD"}), handlers.KirimPermintaanSumberDaya)
+
+	// Arahan, status siaga & alokasi sumber daya ke kecamatan
+	arahan := api.Group("/arahan", middleware.AuthMiddleware)
+	arahan.Get("/", handlers.GetAllArahanKota)
+	arahan.Get("/:id", handlers.GetArahanKotaByID)
+	arahan.Post("/", middleware.RoleMiddleware([]string{"BPBD", "Pemkot"}), handlers.CreateArahanKota)
+
+	// Taksonomi jenis bencana + playbook, disebarkan ke semua kecamatan
+	jenisBencana := api.Group("/jenis-bencana", middleware.AuthMiddleware)
+	jenisBencana.Get("/", handlers.GetAllJenisBencana)
+	jenisBencana.Post("/sinkron", middleware.RoleMiddleware([]string{"BPBD"}), handlers.SinkronJenisBencana)
+	jenisBencana.Get("/:kode", handlers.GetJenisBencanaByKode)
+	jenisBencana.Post("/", middleware.RoleMiddleware([]string{"BPBD"}), handlers.CreateJenisBencana)
+	jenisBencana.Put("/:kode", middleware.RoleMiddleware([]string{"BPBD"}), handlers.UpdateJenisBencana)
+	jenisBencana.Delete("/:kode", middleware.RoleMiddleware([]string{"BPBD"}), handlers.DeleteJenisBencana)
+
+	// Peringatan resmi (CAP/XML & JSON BMKG). Push publik dengan X-Feed-Token.
+	api.Post("/peringatan-dini/push", handlers.PushPeringatanDini)
+	peringatanDini := api.Group("/peringatan-dini", middleware.AuthMiddleware)
+	peringatanDini.Get("/", handlers.GetAllPeringatanDiniKota)
+	peringatanDini.Post("/tarik", middleware.RoleMiddleware([]string{"BPBD"}), handlers.TarikFeedPeringatan)
+	peringatanDini.Get("/:id", handlers.GetPeringatanDiniKotaByID)
 
 	// Reports routes (Sesuai README)
 	reports := api.Group("/reports", middleware.AuthMiddleware)
@@ -3,13 +3,18 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"time"
 
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
 	"github.com/joho/godotenv"
 	"github.com/segmentio/kafka-go"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Struktur Pesan yang diterima dari Kafka
@@ -36,6 +41,9 @@ func main() {
 	})
 	defer reader.Close()
 
+	// Status permintaan sumber daya diteruskan balik ke kecamatan lewat topic Kota
+	messaging.InitKafkaDownstream("localhost:9092", messaging.TopicKota)
+
 	log.Println("🚀 Sync Worker Berjalan (Event-Driven Mode)... Menunggu Event dari Kafka...")
 
 	// 3. Loop Abadi (Mendengarkan Stream)
@@ -64,22 +72,77 @@ func processEvent(db *gorm.DB, messageBytes []byte) {
 	switch event.Action {
 	case "CREATE_BENCANA":
 		log.Printf("⚡ Bencana terdeteksi di Kecamatan ID %d. Mengupdate Monitoring Kota...", event.KecamatanID)
-		updateMonitoringKota(db, event.KecamatanID)
+		updateMonitoringKota(db, event)
 
 	case "CREATE_WARGA":
 		log.Printf("bust Warga bertambah di Kecamatan ID %d. Mengupdate Rekap...", event.KecamatanID)
 		// Implementasi update rekap di sini
 
+	case "UPDATE_OKUPANSI_TITIK_KUMPUL":
+		log.Printf("🏕️ Okupansi titik kumpul berubah di Kecamatan ID %d. Mengupdate DB Kota...", event.KecamatanID)
+		updateOkupansiTitikKumpul(db, event)
+
+	case "DELETE_TITIK_KUMPUL":
+		log.Printf("🏕️ Titik kumpul dihapus di Kecamatan ID %d. Mengupdate DB Kota...", event.KecamatanID)
+		hapusTitikKumpul(db, event)
+
+	case "UPDATE_ORANG_HILANG":
+		log.Printf("🔎 Jumlah orang hilang berubah di Kecamatan ID %d. Mengupdate DB Kota...", event.KecamatanID)
+		updateOrangHilang(db, event)
+
+	case "UPDATE_STOK_LOGISTIK":
+		log.Printf("📦 Stok logistik berubah di Kecamatan ID %d. Mengupdate DB Kota...", event.KecamatanID)
+		updateStokLogistik(db, event)
+
+	case "CREATE_PERMINTAAN_SUMBER_DAYA":
+		log.Printf("🆘 Permintaan sumber daya baru dari Kecamatan ID %d", event.KecamatanID)
+		simpanPermintaanSumberDaya(db, event)
+
+	case "UPDATE_STATUS_PERMINTAAN_SUMBER_DAYA":
+		log.Printf("🔁 Status permintaan sumber daya dari Kecamatan ID %d", event.KecamatanID)
+		updateStatusPermintaan(db, event)
+
+	case "REKAP_PAPARAN":
+		log.Printf("🗺️ Rekap paparan zona bahaya dari Kecamatan ID %d", event.KecamatanID)
+		simpanRekapPaparan(db, event)
+
+	case "REKAP_JITUPASNA":
+		log.Printf("🏚️ Rekap Jitupasna dari Kecamatan ID %d", event.KecamatanID)
+		simpanRekapJitupasna(db, event)
+
+	case "MERGE_BENCANA":
+		log.Printf("🔗 Penggabungan bencana ganda di Kecamatan ID %d", event.KecamatanID)
+		gabungBencanaKota(db, event)
+
+	case "ACK_ARAHAN_KOTA":
+		log.Printf("📨 Tanda terima arahan dari Kecamatan ID %d", event.KecamatanID)
+		simpanPenerimaanArahan(db, event)
+
 	default:
 		log.Printf("⚠️ Action tidak dikenal: %s", event.Action)
 	}
 }
 
 // Fungsi Update DB Kota (Versi Sederhana: Increment)
-func updateMonitoringKota(db *gorm.DB, kecID uint) {
+func updateMonitoringKota(db *gorm.DB, event EventMessage) {
 	// Upsert logika untuk menambah jumlah bencana
 	// Karena ini event driven, idealnya payload berisi data lengkap
 	// Tapi untuk simpel, kita increment saja count-nya
+	kecID := event.KecamatanID
+
+	// Nama jenis mengikuti taksonomi Kota bila kodenya dikenal
+	var bencana models.KejadianBencana
+	if err := decodePayload(event.Payload, &bencana); err != nil {
+		log.Printf("❌ Payload bencana tidak valid: %v", err)
+		return
+	}
+	jenisBencana := bencana.JenisBencana
+	if bencana.KodeJenis != "" {
+		var jenis models.JenisBencana
+		if db.Where("kode = ?", bencana.KodeJenis).First(&jenis).Error == nil {
+			jenisBencana = jenis.Nama
+		}
+	}
 
 	// Cek apakah data monitoring sudah ada
 	var monitor models.MonitoringBencanaKota
@@ -88,7 +151,7 @@ func updateMonitoringKota(db *gorm.DB, kecID uint) {
 	if result.Error == gorm.ErrRecordNotFound {
 		monitor = models.MonitoringBencanaKota{
 			KecamatanID:  kecID,
-			JenisBencana: "Update Terbaru",
+			JenisBencana: jenisBencana,
 			StatusLevel:  "Waspada",
 			TotalBencana: 1,
 		}
@@ -96,8 +159,439 @@ func updateMonitoringKota(db *gorm.DB, kecID uint) {
 	} else {
 		// Update existing
 		monitor.TotalBencana += 1
+		monitor.JenisBencana = jenisBencana
 		monitor.StatusLevel = "Siaga" // Contoh logika
 		db.Save(&monitor)
 	}
 	log.Println("✅ Database Kota Terupdate!")
 }
+
+// decodePayload mengubah payload generik menjadi struct yang dikirim API Kecamatan
+func decodePayload(payload map[string]interface{}, dest interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+// Upsert okupansi satu titik kumpul (kunci: kecamatan_id + titik_kumpul_id)
+func updateOkupansiTitikKumpul(db *gorm.DB, event EventMessage) {
+	var data models.OkupansiTitikKumpulEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload okupansi tidak valid: %v", err)
+		return
+	}
+
+	diterapkan, err := simpanTitikKumpulKota(db, event.KecamatanID, data, false)
+	if err != nil {
+		log.Printf("❌ Gagal update okupansi titik kumpul: %v", err)
+		return
+	}
+	if !diterapkan {
+		log.Printf("⏭️ Event okupansi titik kumpul %d lebih lama dari data Kota, dilewati", data.TitikKumpulID)
+		return
+	}
+	log.Println("✅ Okupansi titik kumpul Kota Terupdate!")
+}
+
+// Hapus titik kumpul yang sudah dihapus di kecamatan
+func hapusTitikKumpul(db *gorm.DB, event EventMessage) {
+	var data models.OkupansiTitikKumpulEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload titik kumpul tidak valid: %v", err)
+		return
+	}
+
+	diterapkan, err := simpanTitikKumpulKota(db, event.KecamatanID, data, true)
+	if err != nil {
+		log.Printf("❌ Gagal menghapus titik kumpul: %v", err)
+		return
+	}
+	if !diterapkan {
+		log.Printf("⏭️ Event hapus titik kumpul %d lebih lama dari data Kota, dilewati", data.TitikKumpulID)
+		return
+	}
+	log.Println("✅ Titik kumpul Kota Terhapus!")
+}
+
+// simpanTitikKumpulKota applies a shelter event unless kota already holds a newer version.
+// Titik kumpul yang dihapus disimpan sebagai baris soft-delete beserta versinya sehingga
+// upsert yang terlambat tidak menghidupkannya kembali.
+func simpanTitikKumpulKota(db *gorm.DB, kecamatanID uint, data models.OkupansiTitikKumpulEvent, hapus bool) (bool, error) {
+	diterapkan := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var titik models.OkupansiTitikKumpulKota
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kecamatan_id = ? AND titik_kumpul_id = ?", kecamatanID, data.TitikKumpulID).
+			First(&titik).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if titik.ID != 0 && data.Versi < titik.Versi {
+			return nil
+		}
+
+		now := time.Now()
+		titik.KecamatanID = kecamatanID
+		titik.TitikKumpulID = data.TitikKumpulID
+		titik.Versi = data.Versi
+		titik.LastSync = &now
+		if hapus {
+			titik.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+			if titik.Nama == "" {
+				titik.Nama = "(dihapus)"
+			}
+		} else {
+			titik.Nama = data.Nama
+			titik.Jenis = data.Jenis
+			titik.Latitude = data.Latitude
+			titik.Longitude = data.Longitude
+			titik.Kapasitas = data.Kapasitas
+			titik.Terisi = data.Terisi
+			titik.Aktif = data.Aktif
+			titik.DeletedAt = gorm.DeletedAt{}
+		}
+		diterapkan = true
+		return tx.Unscoped().Save(&titik).Error
+	})
+	return diterapkan, err
+}
+
+// Upsert jumlah orang hilang per bencana (kunci: kecamatan_id + bencana_id)
+func updateOrangHilang(db *gorm.DB, event EventMessage) {
+	var data models.OrangHilangEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload orang hilang tidak valid: %v", err)
+		return
+	}
+
+	now := time.Now()
+	rekap := models.RekapOrangHilangKota{
+		KecamatanID:     event.KecamatanID,
+		BencanaID:       data.BencanaID,
+		JumlahHilang:    data.JumlahHilang,
+		JumlahDitemukan: data.JumlahDitemukan,
+		LastSync:        &now,
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kecamatan_id"}, {Name: "bencana_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"jumlah_hilang", "jumlah_ditemukan", "last_sync", "updated_at"}),
+	}).Create(&rekap).Error
+	if err != nil {
+		log.Printf("❌ Gagal update rekap orang hilang: %v", err)
+		return
+	}
+	log.Println("✅ Rekap orang hilang Kota Terupdate!")
+}
+
+// Ganti seluruh saldo satu gudang kecamatan (payload berisi saldo lengkap gudang)
+func updateStokLogistik(db *gorm.DB, event EventMessage) {
+	var data models.StokLogistikEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload stok logistik tidak valid: %v", err)
+		return
+	}
+
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("kecamatan_id = ? AND gudang_id = ?", event.KecamatanID, data.GudangID).
+			Delete(&models.StokLogistikKota{}).Error; err != nil {
+			return err
+		}
+		for _, item := range data.Item {
+			stok := models.StokLogistikKota{
+				KecamatanID: event.KecamatanID,
+				GudangID:    data.GudangID,
+				GudangNama:  data.GudangNama,
+				BarangKode:  item.BarangKode,
+				BarangNama:  item.BarangNama,
+				Kategori:    item.Kategori,
+				Satuan:      item.Satuan,
+				Jumlah:      item.Jumlah,
+				StokMinimum: item.StokMinimum,
+				LastSync:    &now,
+			}
+			if err := tx.Create(&stok).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("❌ Gagal update stok logistik: %v", err)
+		return
+	}
+	log.Println("✅ Stok logistik Kota Terupdate!")
+}
+
+// Simpan permintaan sumber daya baru (kunci: kecamatan_id + permintaan_id) untuk diputuskan BPBD
+func simpanPermintaanSumberDaya(db *gorm.DB, event EventMessage) {
+	var data models.PermintaanSumberDayaEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload permintaan sumber daya tidak valid: %v", err)
+		return
+	}
+
+	permintaan := models.PermintaanSumberDayaKota{
+		KecamatanID:   event.KecamatanID,
+		PermintaanID:  data.PermintaanID,
+		BencanaID:     data.BencanaID,
+		Jenis:         data.Jenis,
+		Deskripsi:     data.Deskripsi,
+		BarangKode:    data.BarangKode,
+		Jumlah:        data.Jumlah,
+		Satuan:        data.Satuan,
+		Urgensi:       data.Urgensi,
+		LokasiTujuan:  data.LokasiTujuan,
+		Latitude:      data.Latitude,
+		Longitude:     data.Longitude,
+		Status:        services.PermintaanDiajukan,
+		WaktuDiajukan: data.WaktuDiajukan,
+	}
+
+	// Event ganda tidak menimpa permintaan yang sudah diputuskan
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&permintaan).Error; err != nil {
+		log.Printf("❌ Gagal simpan permintaan sumber daya: %v", err)
+		return
+	}
+	log.Println("✅ Permintaan sumber daya tersimpan di DB Kota!")
+}
+
+// Terapkan status dari kecamatan lalu teruskan ke pihak lainnya:
+// peminta (Diterima/Dibatalkan) -> pemasok, pemasok (Dikirim) -> peminta
+func updateStatusPermintaan(db *gorm.DB, event EventMessage) {
+	var data models.StatusPermintaanEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload status permintaan tidak valid: %v", err)
+		return
+	}
+
+	var permintaan models.PermintaanSumberDayaKota
+	query := db.Preload("Kecamatan").Preload("KecamatanPemasok")
+	dariPeminta := data.PermintaanKotaID == 0
+	if dariPeminta {
+		query = query.Where("kecamatan_id = ? AND permintaan_id = ?", event.KecamatanID, data.PermintaanID)
+	} else {
+		query = query.Where("id = ? AND kecamatan_pemasok_id = ?", data.PermintaanKotaID, event.KecamatanID)
+	}
+	if err := query.First(&permintaan).Error; err != nil {
+		log.Printf("⚠️ Permintaan untuk status %s tidak ditemukan: %v", data.Status, err)
+		return
+	}
+
+	// Peminta hanya boleh menerima/membatalkan, pemasok hanya boleh mengirim
+	boleh := data.Status == services.PermintaanDikirim
+	if dariPeminta {
+		boleh = data.Status == services.PermintaanDiterima || data.Status == services.PermintaanDibatalkan
+	}
+	if !boleh {
+		return
+	}
+	// Pembatalan selalu dijawab ke peminta: peminta menunggu konfirmasi sebelum
+	// statusnya berubah, dan pemasok bisa saja sudah mengirim lebih dulu
+	batal := dariPeminta && data.Status == services.PermintaanDibatalkan
+	if permintaan.Status == data.Status {
+		if batal {
+			jawabPembatalan(permintaan, data.Catatan, data.Waktu)
+		}
+		return
+	}
+	if err := services.ValidateTransisiPermintaan(permintaan.Status, data.Status); err != nil {
+		log.Printf("⚠️ Status permintaan %d diabaikan: %v", permintaan.ID, err)
+		if batal {
+			jawabPembatalan(permintaan, "Pembatalan ditolak: permintaan sudah berstatus "+permintaan.Status, time.Now())
+		}
+		return
+	}
+
+	updates := map[string]interface{}{"status": data.Status}
+	switch data.Status {
+	case services.PermintaanDikirim:
+		updates["waktu_dikirim"] = data.Waktu
+	case services.PermintaanDiterima:
+		updates["waktu_diterima"] = data.Waktu
+	}
+	if err := db.Model(&permintaan).Updates(updates).Error; err != nil {
+		log.Printf("❌ Gagal update status permintaan: %v", err)
+		return
+	}
+	permintaan.Status = data.Status
+
+	if dariPeminta {
+		if permintaan.KecamatanPemasokID != nil {
+			messaging.PublishToKecamatan("STATUS_PASOKAN", *permintaan.KecamatanPemasokID, permintaan.EventStatus(data.Catatan, data.Waktu))
+		}
+		if batal {
+			jawabPembatalan(permintaan, data.Catatan, data.Waktu)
+		}
+	} else {
+		messaging.PublishToKecamatan("STATUS_PERMINTAAN_SUMBER_DAYA", permintaan.KecamatanID, permintaan.EventStatus(data.Catatan, data.Waktu))
+	}
+	log.Println("✅ Status permintaan sumber daya Kota Terupdate!")
+}
+
+// Jawab pembatalan dari peminta dengan status permintaan saat ini di DB Kota
+func jawabPembatalan(permintaan models.PermintaanSumberDayaKota, catatan string, waktu time.Time) {
+	event := permintaan.EventStatus(catatan, waktu)
+	event.JawabanPembatalan = true
+	messaging.PublishToKecamatan("STATUS_PERMINTAAN_SUMBER_DAYA", permintaan.KecamatanID, event)
+}
+
+// Catat bahwa kecamatan sudah menerima / mengonfirmasi arahan Kota
+func simpanPenerimaanArahan(db *gorm.DB, event EventMessage) {
+	var data models.PenerimaanArahanEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload tanda terima arahan tidak valid: %v", err)
+		return
+	}
+
+	penerimaan := models.PenerimaanArahanKota{
+		ArahanKotaID: data.ArahanKotaID,
+		KecamatanID:  event.KecamatanID,
+	}
+	kolom := []string{"updated_at"}
+	switch data.Status {
+	case "Diterima":
+		penerimaan.WaktuDiterima = &data.Waktu
+		kolom = append(kolom, "waktu_diterima")
+	case "Dikonfirmasi":
+		penerimaan.WaktuDiterima = &data.Waktu // Bila event Diterima terlewat
+		penerimaan.WaktuDikonfirmasi = &data.Waktu
+		penerimaan.DikonfirmasiOleh = data.Oleh
+		kolom = append(kolom, "waktu_dikonfirmasi", "dikonfirmasi_oleh")
+	default:
+		log.Printf("⚠️ Status tanda terima arahan tidak dikenal: %s", data.Status)
+		return
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "arahan_kota_id"}, {Name: "kecamatan_id"}},
+		DoUpdates: clause.AssignmentColumns(kolom),
+	}).Create(&penerimaan).Error
+	if err != nil {
+		log.Printf("❌ Gagal menyimpan tanda terima arahan: %v", err)
+		return
+	}
+	log.Println("✅ Tanda terima arahan Kota tersimpan!")
+}
+
+// simpanRekapPaparan replaces the hazard exposure recap of one kecamatan
+func simpanRekapPaparan(db *gorm.DB, event EventMessage) {
+	var data models.RekapPaparanEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload rekap paparan tidak valid: %v", err)
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Rekap dikirim utuh, jenis/kelas yang tidak ada lagi berarti nol
+		if err := tx.Where("kecamatan_id = ?", event.KecamatanID).Delete(&models.RekapPaparanKota{}).Error; err != nil {
+			return err
+		}
+		for _, r := range data.Rekap {
+			if err := tx.Create(&models.RekapPaparanKota{
+				KecamatanID:  event.KecamatanID,
+				JenisBahaya:  r.JenisBahaya,
+				KelasRisiko:  r.KelasRisiko,
+				JumlahWarga:  r.JumlahWarga,
+				JumlahRentan: r.JumlahRentan,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("❌ Gagal menyimpan rekap paparan: %v", err)
+		return
+	}
+	log.Println("✅ Rekap paparan zona bahaya Kota terupdate!")
+}
+
+// simpanRekapJitupasna replaces the damage and needs recap of one bencana of a kecamatan
+func simpanRekapJitupasna(db *gorm.DB, event EventMessage) {
+	var data models.RekapJitupasnaEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload rekap Jitupasna tidak valid: %v", err)
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("kecamatan_id = ? AND bencana_id = ?", event.KecamatanID, data.BencanaID).
+			Delete(&models.RekapJitupasnaKota{}).Error; err != nil {
+			return err
+		}
+		for _, r := range data.Rekap {
+			if err := tx.Create(&models.RekapJitupasnaKota{
+				KecamatanID:    event.KecamatanID,
+				BencanaID:      data.BencanaID,
+				JenisBencana:   data.JenisBencana,
+				KodeJenis:      data.KodeJenis,
+				WaktuMulai:     data.WaktuMulai,
+				Sektor:         r.Sektor,
+				Subsektor:      r.Subsektor,
+				JumlahObjek:    r.JumlahObjek,
+				JumlahKK:       r.JumlahKK,
+				RusakRingan:    r.RusakRingan,
+				RusakSedang:    r.RusakSedang,
+				RusakBerat:     r.RusakBerat,
+				Pekerja:        r.Pekerja,
+				NilaiKerusakan: r.NilaiKerusakan,
+				NilaiKerugian:  r.NilaiKerugian,
+				NilaiKebutuhan: r.NilaiKebutuhan,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("❌ Gagal menyimpan rekap Jitupasna: %v", err)
+		return
+	}
+	log.Println("✅ Rekap Jitupasna Kota terupdate!")
+}
+
+// gabungBencanaKota records a merge of two bencana and drops the recaps of the merged one.
+// Rekap bencana yang dipertahankan dikirim ulang oleh kecamatan.
+func gabungBencanaKota(db *gorm.DB, event EventMessage) {
+	var data models.BencanaDigabungEvent
+	if err := decodePayload(event.Payload, &data); err != nil {
+		log.Printf("❌ Payload penggabungan bencana tidak valid: %v", err)
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.PenggabunganBencanaKota{
+			KecamatanID:  event.KecamatanID,
+			BencanaID:    data.BencanaID,
+			DigabungKeID: data.DigabungKeID,
+			JenisBencana: data.JenisBencana,
+			Alasan:       data.Alasan,
+			Waktu:        data.Waktu,
+		}).Error; err != nil {
+			return err
+		}
+
+		if data.KurangiTotal {
+			if err := tx.Model(&models.MonitoringBencanaKota{}).
+				Where("kecamatan_id = ? AND total_bencana > 0", event.KecamatanID).
+				Update("total_bencana", gorm.Expr("total_bencana - 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		kunci := "kecamatan_id = ? AND bencana_id = ?"
+		if err := tx.Where(kunci, event.KecamatanID, data.BencanaID).Delete(&models.RekapOrangHilangKota{}).Error; err != nil {
+			return err
+		}
+		return tx.Where(kunci, event.KecamatanID, data.BencanaID).Delete(&models.RekapJitupasnaKota{}).Error
+	})
+	if err != nil {
+		log.Printf("❌ Gagal menyimpan penggabungan bencana: %v", err)
+		return
+	}
+	log.Println("✅ Penggabungan bencana tercatat di Kota!")
+}
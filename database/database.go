@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -66,35 +68,243 @@ func CloseDB() {
 // FUNGSI MIGRASI KHUSUS UNTUK API KECAMATAN
 // -----------------------------------------------------------------
 func AutoMigrateKecamatan() {
+	migrasiStatusEvakuasi()
+	rapikanLogEvakuasiGanda()
+
 	err := DB.AutoMigrate(
-		&models.User{},            // Tabel User (RT, RW, Relawan)
-		&models.WargaRentan{},     // Tabel Warga
-		&models.KejadianBencana{}, // Tabel Bencana
-		&models.LogEvakuasi{},     // Tabel Log Evakuasi
-		&models.SystemLog{},       // Log sistem lokal
+		&models.User{},               // Tabel User (RT, RW, Relawan)
+		&models.KartuKeluarga{},      // Tabel Kartu Keluarga (rumah tangga)
+		&models.WargaRentan{},        // Tabel Warga
+		&models.KategoriWarga{},      // Kategori rentan per warga (bisa lebih dari satu)
+		&models.KebutuhanPerawatan{}, // Kebutuhan perawatan khusus per warga
+		&models.RiwayatWarga{},       // Riwayat versi data warga
+		&models.JenisBencana{},       // Taksonomi jenis bencana + playbook (salinan dari Kota)
+		&models.KejadianBencana{},    // Tabel Bencana
+		&models.TugasBencana{},       // Checklist playbook per bencana
+		&models.TimelineBencana{},    // Kronologi update, angka korban & kerusakan
+		&models.LampiranBencana{},    // Foto/dokumen timeline (isi di blob store)
+		&models.AsesmenKerusakan{},   // Jitupasna: kerusakan rumah, fasilitas & usaha
+		&models.KerugianPenghidupan{},
+		&models.KebutuhanPascabencana{},
+		&models.NotifikasiBencana{},       // Riwayat notifikasi per bencana
+		&models.PenerimaNotifikasi{},      // Penerima notifikasi darurat + token tautan balasan
+		&models.StatusKeselamatan{},       // Roster keselamatan warga per bencana
+		&models.PenggabunganBencana{},     // Jejak audit penggabungan laporan ganda
+		&models.LaporanWarga{},            // Laporan publik/SMS/WhatsApp menunggu verifikasi
+		&models.LogEvakuasi{},             // Tabel Log Evakuasi
+		&models.TitikKumpul{},             // Titik kumpul / shelter evakuasi
+		&models.RegistrasiPengungsi{},     // Registri check-in/pindah/keluar titik kumpul
+		&models.LaporanOrangHilang{},      // Laporan orang hilang
+		&models.GudangLogistik{},          // Gudang / posko logistik
+		&models.BarangLogistik{},          // Katalog barang bantuan
+		&models.StokLogistik{},            // Saldo stok per gudang
+		&models.MutasiStok{},              // Kartu stok (barang masuk/keluar)
+		&models.DistribusiBantuan{},       // Penyaluran bantuan
+		&models.DistribusiItem{},          // Barang per penyaluran
+		&models.PermintaanSumberDaya{},    // Permintaan sumber daya ke Kota
+		&models.PasokanSumberDaya{},       // Pasokan untuk permintaan kecamatan lain
+		&models.ArahanKecamatan{},         // Arahan & status siaga dari Kota
+		&models.ProfilRelawan{},           // Profil kesiapan relawan
+		&models.KeahlianRelawan{},         // Keahlian relawan
+		&models.PeralatanRelawan{},        // Peralatan relawan
+		&models.JadwalRelawan{},           // Jadwal ketersediaan relawan
+		&models.ShiftRelawan{},            // Riwayat shift relawan
+		&models.LokasiRelawan{},           // Ping GPS relawan selama shift
+		&models.Sensor{},                  // Registri sensor peringatan dini
+		&models.BacaanSensor{},            // Deret waktu bacaan sensor
+		&models.AturanAmbangSensor{},      // Aturan ambang batas sensor
+		&models.PeringatanSensor{},        // Peringatan sensor menunggu konfirmasi
+		&models.PeringatanDiniKecamatan{}, // Peringatan resmi BMKG dari Kota
+		&models.ZonaBahaya{},              // Poligon zona bahaya per jenis & kelas risiko
+		&models.PaparanWarga{},            // Zona bahaya tempat rumah warga berada
+		&models.RiwayatStatusEvakuasi{},   // Riwayat transisi status evakuasi
+		&models.TugasEvakuasi{},           // Penugasan evakuasi ke relawan
+		&models.EskalasiEvakuasi{},        // Eskalasi warga prioritas yang belum tertangani
+		&models.SystemLog{},               // Log sistem lokal
 	)
 
 	if err != nil {
 		log.Fatal("Gagal migrasi database Kecamatan:", err)
 	}
 
+	// Warga lama hanya punya satu kategori di kolom kategori_rentan.
+	// INSERT IGNORE membuat backfill ini aman dijalankan berulang kali.
+	if err := DB.Exec("INSERT IGNORE INTO kategori_wargas (warga_id, kategori) SELECT id, kategori_rentan FROM warga_rentans").Error; err != nil {
+		log.Fatal("Gagal backfill kategori warga:", err)
+	}
+
+	// Relawan yang terdaftar sebelum ada profil relawan mendapat profil kosong.
+	// NOT IN membuat backfill ini aman dijalankan berulang kali.
+	if err := DB.Exec(`INSERT INTO profil_relawans (user_id, created_at, updated_at)
+		SELECT id, NOW(), NOW() FROM users
+		WHERE role = 'Relawan' AND deleted_at IS NULL
+		AND id NOT IN (SELECT user_id FROM profil_relawans)`).Error; err != nil {
+		log.Fatal("Gagal backfill profil relawan:", err)
+	}
+
+	// Warga yang sudah "Di Titik Kumpul" sebelum ada registri pengungsi dicatat sebagai check-in.
+	// NOT EXISTS membuat backfill ini aman dijalankan berulang kali.
+	if err := DB.Exec(`INSERT INTO registrasi_pengungsis
+		(titik_kumpul_id, bencana_id, warga_id, kartu_keluarga_id, nama, status, waktu_masuk, petugas_masuk_id, created_at, updated_at)
+		SELECT l.titik_kumpul_id, l.bencana_id, l.warga_id, l.kartu_keluarga_id, w.nama, 'Di Lokasi', l.waktu_update, l.relawan_id, NOW(), NOW()
+		FROM log_evakuasis l JOIN warga_rentans w ON w.id = l.warga_id
+		WHERE l.status_terkini = 'Di Titik Kumpul' AND l.titik_kumpul_id IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM registrasi_pengungsis r WHERE r.bencana_id = l.bencana_id AND r.warga_id = l.warga_id)`).Error; err != nil {
+		log.Fatal("Gagal backfill registrasi pengungsi:", err)
+	}
+
+	seedJenisBencana()
+	normalisasiJenisBencana()
+
 	log.Println("✅ Migrasi database Kecamatan berhasil")
 }
 
+// seedJenisBencana mengisi taksonomi bawaan. Jenis yang sudah ada (mis. diubah Kota) tidak ditimpa.
+func seedJenisBencana() {
+	for _, jenis := range models.JenisBencanaBawaan() {
+		jenis.Aktif = true
+		if err := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&jenis).Error; err != nil {
+			log.Fatal("Gagal seed jenis bencana:", err)
+		}
+	}
+}
+
+// normalisasiJenisBencana memetakan jenis_bencana lama yang diketik bebas
+// ("banjir", "BANJIR", "flood") ke nama baku dan kodenya. Aman dijalankan berulang kali.
+func normalisasiJenisBencana() {
+	var daftar []models.JenisBencana
+	DB.Find(&daftar)
+	for _, jenis := range daftar {
+		ejaan := []string{strings.ToLower(jenis.Kode), strings.ToLower(jenis.Nama)}
+		for _, alias := range jenis.Alias {
+			ejaan = append(ejaan, strings.ToLower(alias))
+		}
+		if err := DB.Exec("UPDATE kejadian_bencanas SET jenis_bencana = ?, kode_jenis = ? WHERE (kode_jenis IS NULL OR kode_jenis = '') AND LOWER(TRIM(jenis_bencana)) IN ?",
+			jenis.Nama, jenis.Kode, ejaan).Error; err != nil {
+			log.Fatal("Gagal normalisasi jenis bencana:", err)
+		}
+	}
+}
+
+// migrasiStatusEvakuasi memperbaiki typo enum lama "Teevakuasi" -> "Terevakuasi".
+// Enum diperluas dulu agar data lama tetap valid, lalu AutoMigrate menyempitkannya lagi.
+// Hanya dijalankan selama masih ada baris "Teevakuasi" (ALTER membangun ulang tabel).
+func migrasiStatusEvakuasi() {
+	if !DB.Migrator().HasTable("log_evakuasis") {
+		return
+	}
+	var sisa int64
+	if err := DB.Table("log_evakuasis").Where("status_terkini = ?", "Teevakuasi").Count(&sisa).Error; err != nil {
+		log.Fatal("Gagal memeriksa status evakuasi:", err)
+	}
+	if sisa == 0 {
+		return
+	}
+
+	steps := []string{
+		"ALTER TABLE log_evakuasis MODIFY status_terkini ENUM('Menunggu','Dalam Proses','Teevakuasi','Terevakuasi','Di Titik Kumpul') NOT NULL",
+		"UPDATE log_evakuasis SET status_terkini = 'Terevakuasi' WHERE status_terkini = 'Teevakuasi'",
+	}
+	for _, step := range steps {
+		if err := DB.Exec(step).Error; err != nil {
+			log.Fatal("Gagal migrasi status evakuasi:", err)
+		}
+	}
+}
+
+// urutanStatusLog adalah posisi status pada alur evakuasi, dipakai saat merapikan log ganda
+var urutanStatusLog = map[string]int{
+	"Menunggu":        0,
+	"Dalam Proses":    1,
+	"Terevakuasi":     2,
+	"Di Titik Kumpul": 3,
+}
+
+// rapikanLogEvakuasiGanda menyisakan satu log per warga per bencana sebelum unique index
+// idx_log_bencana_warga dibuat: log dengan status paling jauh dipertahankan dan riwayat
+// log lainnya dipindahkan ke log tersebut.
+func rapikanLogEvakuasiGanda() {
+	m := DB.Migrator()
+	if !m.HasTable("log_evakuasis") || m.HasIndex(&models.LogEvakuasi{}, "idx_log_bencana_warga") {
+		return
+	}
+
+	var ganda []struct {
+		BencanaID uint
+		WargaID   uint
+	}
+	if err := DB.Table("log_evakuasis").Select("bencana_id, warga_id").
+		Group("bencana_id, warga_id").Having("COUNT(*) > 1").
+		Scan(&ganda).Error; err != nil {
+		log.Fatal("Gagal memeriksa log evakuasi ganda:", err)
+	}
+
+	adaRiwayat := m.HasTable("riwayat_status_evakuasis")
+	for _, g := range ganda {
+		var daftar []models.LogEvakuasi
+		if err := DB.Where("bencana_id = ? AND warga_id = ?", g.BencanaID, g.WargaID).Find(&daftar).Error; err != nil {
+			log.Fatal("Gagal membaca log evakuasi ganda:", err)
+		}
+		simpan := daftar[0]
+		for _, l := range daftar[1:] {
+			us, ut := urutanStatusLog[l.StatusTerkini], urutanStatusLog[simpan.StatusTerkini]
+			if us > ut || (us == ut && l.WaktuUpdate.After(simpan.WaktuUpdate)) {
+				simpan = l
+			}
+		}
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			for _, l := range daftar {
+				if l.ID == simpan.ID {
+					continue
+				}
+				if adaRiwayat {
+					if err := tx.Model(&models.RiwayatStatusEvakuasi{}).Where("log_evakuasi_id = ?", l.ID).
+						Update("log_evakuasi_id", simpan.ID).Error; err != nil {
+						return err
+					}
+				}
+				if err := tx.Delete(&models.LogEvakuasi{}, l.ID).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatal("Gagal merapikan log evakuasi ganda:", err)
+		}
+	}
+	if len(ganda) > 0 {
+		log.Printf("⚠️ %d log evakuasi ganda dirapikan (satu log per warga per bencana)", len(ganda))
+	}
+}
+
 // -----------------------------------------------------------------
 // FUNGSI MIGRASI KHUSUS UNTUK API KOTA (AGREGASI)
 // -----------------------------------------------------------------
 func AutoMigrateKota() {
 	err := DB.AutoMigrate(
-		&models.MasterKecamatan{},       // Tabel Master Kecamatan
-		&models.AdminKota{},             // User untuk Pemkot/BPBD
-		&models.RekapDataWilayah{},      // Tabel Agregasi Warga
-		&models.MonitoringBencanaKota{}, // Tabel Agregasi Bencana
+		&models.MasterKecamatan{},          // Tabel Master Kecamatan
+		&models.AdminKota{},                // User untuk Pemkot/BPBD
+		&models.RekapDataWilayah{},         // Tabel Agregasi Warga
+		&models.MonitoringBencanaKota{},    // Tabel Agregasi Bencana
+		&models.OkupansiTitikKumpulKota{},  // Agregasi okupansi titik kumpul
+		&models.RekapOrangHilangKota{},     // Agregasi jumlah orang hilang
+		&models.StokLogistikKota{},         // Saldo stok logistik per gudang kecamatan
+		&models.PermintaanSumberDayaKota{}, // Permintaan sumber daya antar kecamatan
+		&models.ArahanKota{},               // Arahan & status siaga ke kecamatan
+		&models.PenerimaanArahanKota{},     // Tanda terima arahan per kecamatan
+		&models.PeringatanDini{},           // Peringatan resmi BMKG yang diimpor
+		&models.RekapPaparanKota{},         // Jumlah warga di zona bahaya per kecamatan
+		&models.RekapJitupasnaKota{},       // Rekap Jitupasna terverifikasi per bencana & sektor
+		&models.PenggabunganBencanaKota{},  // Penggabungan bencana ganda di semua kecamatan
+		&models.JenisBencana{},             // Taksonomi jenis bencana + playbook (master)
 	)
 
 	if err != nil {
 		log.Fatal("Gagal migrasi database Kota:", err)
 	}
 
+	seedJenisBencana()
+
 	log.Println("✅ Migrasi database Kota berhasil")
 }
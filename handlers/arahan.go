@@ -0,0 +1,205 @@
+// handlers/arahan.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Arahan dari Kota diterima lewat topic Kafka Kota -> kecamatan (lihat event_kota.go),
+// disimpan lokal, lalu disebarkan ke SSE dan WhatsApp.
+
+// arahanListSpec defines sorting and search for directives (dipakai juga oleh API Kota)
+var arahanListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":         "id",
+		"jenis":      "jenis",
+		"created_at": "created_at",
+	},
+	DefaultSort: "-created_at",
+	Search:      []string{"judul LIKE ?", "isi LIKE ?"},
+}
+
+// GetAllArahan returns directives received from Kota, paginated (see list_query.go).
+// Query: jenis, belum_dikonfirmasi=true
+func GetAllArahan(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.ArahanKecamatan{})
+
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	if c.QueryBool("belum_dikonfirmasi") {
+		query = query.Where("waktu_dikonfirmasi IS NULL")
+	}
+
+	return listPage[models.ArahanKecamatan](c, query, arahanListSpec, "Failed to fetch arahan")
+}
+
+// GetTingkatSiaga returns the warning level currently set by Kota for this kecamatan
+func GetTingkatSiaga(c *fiber.Ctx) error {
+	var arahan models.ArahanKecamatan
+	err := database.DB.
+		Where("jenis = ?", "Tingkat Siaga").
+		Where("berlaku_sampai IS NULL OR berlaku_sampai > ?", time.Now()).
+		Order("waktu_dikirim DESC").
+		First(&arahan).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(fiber.Map{
+				"error": false,
+				"data": fiber.Map{
+					"tingkat_siaga": "Normal",
+					"arahan":        nil,
+				},
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch tingkat siaga",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"tingkat_siaga": arahan.TingkatSiaga,
+			"arahan":        arahan,
+		},
+	})
+}
+
+// KonfirmasiArahan records that this kecamatan has acted on a directive and reports it to Kota
+func KonfirmasiArahan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var arahan models.ArahanKecamatan
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&arahan, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newResponseError(fiber.StatusNotFound, "Arahan not found", nil)
+			}
+			return err
+		}
+		if arahan.WaktuDikonfirmasi != nil {
+			return newResponseError(fiber.StatusConflict, "Arahan already confirmed", fiber.Map{"waktu_dikonfirmasi": arahan.WaktuDikonfirmasi})
+		}
+
+		arahan.DikonfirmasiOleh = &userID
+		arahan.WaktuDikonfirmasi = &now
+		return tx.Save(&arahan).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to confirm arahan")
+	}
+
+	var user models.User
+	database.DB.First(&user, userID)
+
+	logActivity(userID, fmt.Sprintf("Konfirmasi arahan Kota #%d: %s", arahan.ArahanKotaID, arahan.Judul))
+	go messaging.PublishEvent("ACK_ARAHAN_KOTA", kecamatanID(), models.PenerimaanArahanEvent{
+		ArahanKotaID: arahan.ArahanKotaID,
+		Status:       "Dikonfirmasi",
+		Oleh:         user.NamaLengkap,
+		Waktu:        now,
+	})
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Arahan confirmed",
+		"data":    arahan,
+	})
+}
+
+// terimaArahanKota stores a directive from Kota, rebroadcasts it and sends the receipt back
+func terimaArahanKota(data models.ArahanKotaEvent) error {
+	now := time.Now()
+	arahan := models.ArahanKecamatan{
+		ArahanKotaID:    data.ArahanKotaID,
+		Jenis:           data.Jenis,
+		Judul:           data.Judul,
+		Isi:             data.Isi,
+		TingkatSiaga:    data.TingkatSiaga,
+		JenisBencana:    data.JenisBencana,
+		SumberDaya:      data.SumberDaya,
+		Jumlah:          data.Jumlah,
+		Satuan:          data.Satuan,
+		SebarkanKeWarga: data.SebarkanKeWarga,
+		BerlakuSampai:   data.BerlakuSampai,
+		WaktuDikirim:    data.WaktuDikirim,
+		WaktuDiterima:   now,
+	}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&arahan)
+	if result.Error != nil {
+		return fmt.Errorf("simpan arahan Kota: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil // Event ganda
+	}
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":          "arahan_kota",
+		"arahan_id":     arahan.ID,
+		"jenis":         arahan.Jenis,
+		"judul":         arahan.Judul,
+		"isi":           arahan.Isi,
+		"tingkat_siaga": arahan.TingkatSiaga,
+		"sumber_daya":   arahan.SumberDaya,
+		"jumlah":        arahan.Jumlah,
+		"satuan":        arahan.Satuan,
+		"waktu":         now.Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+
+	teks := pesanArahan(arahan)
+
+	// Petugas selalu diberi tahu; warga hanya bila diminta Kota atau status Siaga/Awas,
+	// selain itu cukup warga di zona bahaya jenis bencana yang disebut arahan
+	var nomor []string
+	database.DB.Model(&models.User{}).Where("no_hp IS NOT NULL AND no_hp != ''").Pluck("no_hp", &nomor)
+	if arahan.SebarkanKeWarga || arahan.TingkatSiaga == "Siaga" || arahan.TingkatSiaga == "Awas" {
+		var nomorWarga []string
+		database.DB.Model(&models.WargaRentan{}).Where("no_hp IS NOT NULL AND no_hp != ''").Pluck("no_hp", &nomorWarga)
+		nomor = append(nomor, nomorWarga...)
+	} else if arahan.JenisBencana != "" {
+		nomor = append(nomor, nomorWargaTerpapar(jenisBahayaUntuk(arahan.JenisBencana))...)
+	}
+	kirimWhatsApp(nomor, teks)
+
+	messaging.PublishEvent("ACK_ARAHAN_KOTA", kecamatanID(), models.PenerimaanArahanEvent{
+		ArahanKotaID: arahan.ArahanKotaID,
+		Status:       "Diterima",
+		Waktu:        now,
+	})
+	return nil
+}
+
+// pesanArahan formats a directive as a short text message
+func pesanArahan(a models.ArahanKecamatan) string {
+	switch a.Jenis {
+	case "Tingkat Siaga":
+		return fmt.Sprintf("[BPBD] Status %s: %s. %s", a.TingkatSiaga, a.Judul, a.Isi)
+	case "Alokasi Sumber Daya":
+		return fmt.Sprintf("[BPBD] Alokasi %s %.0f %s: %s. %s", a.SumberDaya, a.Jumlah, a.Satuan, a.Judul, a.Isi)
+	default:
+		return fmt.Sprintf("[BPBD] %s: %s", a.Judul, a.Isi)
+	}
+}
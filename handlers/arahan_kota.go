@@ -0,0 +1,200 @@
+// handlers/arahan_kota.go
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// FUNGSI DI FILE INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA.
+
+// Tingkat siaga yang dikenal, dari terendah
+var tingkatSiagaValid = map[string]bool{
+	"Normal": true, "Waspada": true, "Siaga": true, "Awas": true,
+}
+
+// GetAllArahanKota returns directives sent by Kota, paginated (see list_query.go)
+func GetAllArahanKota(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.ArahanKota{})
+
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+
+	return listPage[models.ArahanKota](c, query, arahanListSpec, "Failed to fetch arahan", "Target")
+}
+
+// GetArahanKotaByID returns a directive with the receipt status of every target kecamatan
+func GetArahanKotaByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var arahan models.ArahanKota
+	if err := database.DB.Preload("Target").Preload("Penerimaan").First(&arahan, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Arahan not found",
+		})
+	}
+
+	target := arahan.Target
+	if len(target) == 0 {
+		database.DB.Order("nama").Find(&target)
+	}
+	penerimaan := make(map[uint]models.PenerimaanArahanKota, len(arahan.Penerimaan))
+	for _, p := range arahan.Penerimaan {
+		penerimaan[p.KecamatanID] = p
+	}
+
+	status := make([]fiber.Map, 0, len(target))
+	var diterima, dikonfirmasi int
+	for _, k := range target {
+		p := penerimaan[k.ID]
+		if p.WaktuDiterima != nil {
+			diterima++
+		}
+		if p.WaktuDikonfirmasi != nil {
+			dikonfirmasi++
+		}
+		status = append(status, fiber.Map{
+			"kecamatan_id":       k.ID,
+			"kecamatan_nama":     k.Nama,
+			"waktu_diterima":     p.WaktuDiterima,
+			"waktu_dikonfirmasi": p.WaktuDikonfirmasi,
+			"dikonfirmasi_oleh":  p.DikonfirmasiOleh,
+		})
+	}
+	arahan.Penerimaan = nil
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"arahan":       arahan,
+			"penerimaan":   status,
+			"diterima":     diterima,
+			"dikonfirmasi": dikonfirmasi,
+			"total_target": len(target),
+		},
+	})
+}
+
+// CreateArahanKota sends a warning level, directive or resource allocation to
+// the chosen kecamatan (kecamatan_ids kosong = semua kecamatan)
+func CreateArahanKota(c *fiber.Ctx) error {
+	var req models.CreateArahanKotaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	switch req.Jenis {
+	case "Tingkat Siaga":
+		if !tingkatSiagaValid[req.TingkatSiaga] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "tingkat_siaga must be Normal, Waspada, Siaga or Awas",
+				"field":   "tingkat_siaga",
+			})
+		}
+	case "Alokasi Sumber Daya":
+		if strings.TrimSpace(req.SumberDaya) == "" || req.Jumlah <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "sumber_daya and a positive jumlah are required for an allocation",
+				"field":   "sumber_daya",
+			})
+		}
+	case "Arahan":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "jenis must be Tingkat Siaga, Arahan or Alokasi Sumber Daya",
+			"field":   "jenis",
+		})
+	}
+	if strings.TrimSpace(req.Judul) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "judul is required",
+			"field":   "judul",
+		})
+	}
+
+	var target []models.MasterKecamatan
+	if len(req.KecamatanIDs) > 0 {
+		database.DB.Where("id IN ?", req.KecamatanIDs).Find(&target)
+		if len(target) != len(req.KecamatanIDs) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Some kecamatan_ids were not found",
+				"field":   "kecamatan_ids",
+			})
+		}
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	arahan := models.ArahanKota{
+		Jenis:           req.Jenis,
+		Judul:           strings.TrimSpace(req.Judul),
+		Isi:             req.Isi,
+		TingkatSiaga:    req.TingkatSiaga,
+		JenisBencana:    req.JenisBencana,
+		SumberDaya:      req.SumberDaya,
+		Jumlah:          req.Jumlah,
+		Satuan:          req.Satuan,
+		SebarkanKeWarga: req.SebarkanKeWarga,
+		BerlakuSampai:   req.BerlakuSampai,
+		DikirimOleh:     userID,
+		Target:          target,
+	}
+
+	if err := database.DB.Create(&arahan).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create arahan",
+		})
+	}
+
+	event := models.ArahanKotaEvent{
+		ArahanKotaID:    arahan.ID,
+		Jenis:           arahan.Jenis,
+		Judul:           arahan.Judul,
+		Isi:             arahan.Isi,
+		TingkatSiaga:    arahan.TingkatSiaga,
+		JenisBencana:    arahan.JenisBencana,
+		SumberDaya:      arahan.SumberDaya,
+		Jumlah:          arahan.Jumlah,
+		Satuan:          arahan.Satuan,
+		SebarkanKeWarga: arahan.SebarkanKeWarga,
+		BerlakuSampai:   arahan.BerlakuSampai,
+		WaktuDikirim:    arahan.CreatedAt,
+	}
+	go func() {
+		if len(target) == 0 {
+			messaging.PublishToKecamatan("ARAHAN_KOTA", 0, event)
+			return
+		}
+		for _, k := range target {
+			messaging.PublishToKecamatan("ARAHAN_KOTA", k.ID, event)
+		}
+	}()
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Arahan sent to kecamatan",
+		"data":    arahan,
+	})
+}
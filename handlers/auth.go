@@ -7,6 +7,7 @@ import (
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // Login handler
@@ -102,8 +103,17 @@ func Register(c *fiber.Ctx) error {
 		WilayahTugas: req.WilayahTugas,
 	} // <-- TAMBAHKAN BLOK INI (5)
 
-	// Create user
-	if err := database.DB.Create(&user).Error; err != nil {
+	// Create user; relawan langsung mendapat profil kosong (lihat relawan.go)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if user.Role == "Relawan" {
+			return tx.Create(&models.ProfilRelawan{UserID: user.ID}).Error
+		}
+		return nil
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to create user",
@@ -2,20 +2,52 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
-// GetAllBencana returns all kejadian bencana
-func GetAllBencana(c *fiber.Ctx) error {
-	var bencana []models.KejadianBencana
+// bencanaListSpec defines sorting and free-text search for the bencana list
+var bencanaListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":            "id",
+		"waktu_mulai":   "waktu_mulai",
+		"jenis_bencana": "jenis_bencana",
+		"status":        "status",
+		"level":         "level",
+		"created_at":    "created_at",
+	},
+	DefaultSort: "-waktu_mulai",
+	Search:      []string{"jenis_bencana LIKE ?", "deskripsi LIKE ?"},
+}
+
+// evakuasiListSpec defines sorting and free-text search for evacuation logs
+var evakuasiListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":             "id",
+		"waktu_update":   "waktu_update",
+		"status_terkini": "status_terkini",
+		"warga_id":       "warga_id",
+		"relawan_id":     "relawan_id",
+	},
+	DefaultSort: "-waktu_update",
+	Search: []string{
+		"warga_id IN (SELECT id FROM warga_rentans WHERE nama LIKE ?)",
+		"warga_id IN (SELECT id FROM warga_rentans WHERE alamat LIKE ?)",
+	},
+}
 
-	query := database.DB.Preload("UserPelapor")
+// GetAllBencana returns kejadian bencana, paginated (see list_query.go)
+func GetAllBencana(c *fiber.Ctx) error {
+	query := database.DB
 
 	// Filter by status
 	if status := c.Query("status"); status != "" {
@@ -27,18 +59,7 @@ func GetAllBencana(c *fiber.Ctx) error {
 		query = query.Where("level = ?", level)
 	}
 
-	if err := query.Order("waktu_mulai DESC").Find(&bencana).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to fetch bencana data",
-		})
-	}
-
-	return c.JSON(fiber.Map{
-		"error": false,
-		"data":  bencana,
-		"total": len(bencana),
-	})
+	return listPage[models.KejadianBencana](c, query, bencanaListSpec, "Failed to fetch bencana data", "UserPelapor")
 }
 
 // GetBencanaByID returns single bencana by ID
@@ -97,18 +118,60 @@ func CreateBencana(c *fiber.Ctx) error {
 		})
 	}
 
+	// Jenis bencana harus dikenal taksonomi agar Kota tidak melihat "banjir" dan "Banjir" sebagai dua jenis
+	jenis := cariJenisBencana(req.JenisBencana)
+	if jenis == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       true,
+			"message":     "Unknown jenis_bencana",
+			"field":       "jenis_bencana",
+			"jenis_valid": namaJenisBencanaAktif(),
+		})
+	}
+
 	userID := c.Locals("userID").(uint)
 
+	// Pelapor memilih bergabung ke bencana yang sudah dilaporkan
+	if req.GabungKe != nil {
+		return gabungLaporanBencana(c, req, jenis, userID)
+	}
+
 	bencana := models.KejadianBencana{
-		JenisBencana:  req.JenisBencana,
+		JenisBencana:  jenis.Nama,
+		KodeJenis:     jenis.Kode,
 		Level:         req.Level,
 		WaktuMulai:    time.Now(),
 		Status:        "Aktif",
 		UserPelaporID: userID,
 		Deskripsi:     req.Deskripsi,
+		RT:            req.RT,
+		RW:            req.RW,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+	}
+
+	// Laporan yang mirip bencana terbuka ditawarkan untuk bergabung dulu (buat_baru=true untuk melewati)
+	if !req.BuatBaru {
+		if kandidat := cariDuplikatBencana(database.DB, bencana); len(kandidat) > 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":    true,
+				"message":  "Similar bencana already reported; resend with gabung_ke to join it or buat_baru=true to create a new one",
+				"duplikat": kandidat,
+			})
+		}
 	}
 
-	if err := database.DB.Create(&bencana).Error; err != nil {
+	// Checklist playbook langsung dibuat sebagai tugas bencana
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&bencana).Error; err != nil {
+			return err
+		}
+		if err := catatTimeline(tx, bencana.ID, "Bencana dilaporkan: "+bencana.Deskripsi, &userID); err != nil {
+			return err
+		}
+		return buatTugasPlaybook(tx, bencana)
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to create bencana",
@@ -116,8 +179,7 @@ func CreateBencana(c *fiber.Ctx) error {
 	}
 	// Kirim sinyal ke Kafka bahwa ada bencana baru
 	// Kita gunakan goroutine (go ...) agar tidak memperlambat respon ke user
-	go messaging.PublishEvent("CREATE_BENCANA", 1, bencana)
-	// Catatan: Angka '1' harusnya ID Kecamatan dinamis dari ENV/Token
+	go messaging.PublishEvent("CREATE_BENCANA", kecamatanID(), bencana)
 
 	// Preload user pelapor
 	database.DB.Preload("UserPelapor").First(&bencana, bencana.ID)
@@ -158,6 +220,14 @@ func UpdateStatusBencana(c *fiber.Ctx) error {
 		})
 	}
 
+	if bencana.Status == services.BencanaDigabung {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":          true,
+			"message":        "Bencana has been merged; update the bencana it was merged into",
+			"digabung_ke_id": bencana.DigabungKeID,
+		})
+	}
+
 	var req struct {
 		Status string `json:"status"`
 	}
@@ -168,14 +238,78 @@ func UpdateStatusBencana(c *fiber.Ctx) error {
 			"message": "Invalid request body",
 		})
 	}
+	if !services.IsStatusBencanaValid(req.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unknown status: " + req.Status,
+			"field":   "status",
+		})
+	}
+	if req.Status == services.BencanaDigabung {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Use POST /bencana/:id/gabung to merge bencana",
+		})
+	}
+	if req.Status == bencana.Status {
+		return c.JSON(fiber.Map{
+			"error":   false,
+			"message": "Bencana status unchanged",
+			"data":    bencana,
+		})
+	}
+
+	// Draft dari sensor harus lewat konfirmasi agar event, playbook dan notifikasinya ikut berjalan
+	if bencana.Status == services.BencanaDraft {
+		var peringatan models.PeringatanSensor
+		database.DB.Where("bencana_id = ?", bencana.ID).Order("id DESC").First(&peringatan)
+		message := "Draft bencana must be rejected through PUT /sensor/peringatan/:id/tolak"
+		if req.Status == services.BencanaAktif {
+			message = "Draft bencana must be confirmed through PUT /sensor/peringatan/:id/konfirmasi"
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":         true,
+			"message":       message,
+			"peringatan_id": peringatan.ID,
+		})
+	}
+	if err := services.ValidateTransisiBencana(bencana.Status, req.Status); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+			"status":  bencana.Status,
+		})
+	}
+
+	// Bencana baru boleh ditutup bila semua warga sudah menuntaskan status wajib playbook
+	if req.Status == "Selesai" && !c.QueryBool("force") {
+		if jenis := playbookBencana(database.DB, bencana); jenis != nil {
+			if _, belum := progresStatusWajib(bencana.ID, jenis.Playbook.StatusWajib); belum > 0 {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error":        true,
+					"message":      "Some warga have not completed the required evacuation steps; use ?force=true to close anyway",
+					"belum_tuntas": belum,
+					"status_wajib": jenis.Playbook.StatusWajib,
+				})
+			}
+		}
+	}
 
+	statusLama := bencana.Status
 	bencana.Status = req.Status
 	if req.Status == "Selesai" {
 		now := time.Now()
 		bencana.WaktuSelesai = &now
 	}
 
-	if err := database.DB.Save(&bencana).Error; err != nil {
+	userID := c.Locals("userID").(uint)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&bencana).Error; err != nil {
+			return err
+		}
+		return catatTimeline(tx, bencana.ID, "Status bencana diubah dari "+statusLama+" menjadi "+bencana.Status, &userID)
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to update bencana status",
@@ -183,9 +317,14 @@ func UpdateStatusBencana(c *fiber.Ctx) error {
 	}
 
 	// Log activity
-	userID := c.Locals("userID").(uint)
 	logActivity(userID, "Mengubah status bencana menjadi: "+req.Status)
 
+	// Penghuni titik kumpul dihitung dari bencana aktif saja
+	if req.Status == "Selesai" {
+		go hitungUlangSemuaOkupansi()
+		go akhiriShiftBencana(bencana.ID)
+	}
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "Bencana status updated successfully",
@@ -212,18 +351,12 @@ func GetPrioritasEvakuasi(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get warga with priority score
+	// Get warga with priority score; warga di zona bahaya jenis bencana ini didahulukan,
+	// warga yang meminta bantuan atau tidak membalas peringatan paling atas
 	var warga []models.WargaRentan
-	query := database.DB.Where("kategori_rentan != ?", "Non-Rentan")
-
-	// Filter based on bencana level
-	if bencana.Level == "Lokal_RT" {
-		// Get RT from user who reported
-		var user models.User
-		database.DB.First(&user, bencana.UserPelaporID)
-		// Assuming wilayah_tugas format: "RT 001/RW 001"
-		// This needs to be parsed properly
-		query = query.Where("rt = ?", extractRT(user.WilayahTugas))
+	query := preloadKeselamatan(preloadPaparan(queryWargaTerdampak(bencana), bencana.JenisBencana), bencana.ID)
+	if c.QueryBool("hanya_terpapar") {
+		query = filterTerpapar(query, bencana.JenisBencana)
 	}
 
 	if err := query.Order("skor_prioritas DESC, nama ASC").Find(&warga).Error; err != nil {
@@ -232,34 +365,51 @@ func GetPrioritasEvakuasi(c *fiber.Ctx) error {
 			"message": "Failed to fetch evacuation priority",
 		})
 	}
+	urutkanMenurutPaparan(warga)
+	urutkanMenurutKeselamatan(warga)
 
 	return c.JSON(fiber.Map{
-		"error":     false,
-		"bencana":   bencana,
-		"prioritas": warga,
-		"total":     len(warga),
+		"error":        false,
+		"bencana":      bencana,
+		"prioritas":    warga,
+		"perlengkapan": rekapPerlengkapan(warga),
+		"total":        len(warga),
 	})
 }
 
-// CreateLogEvakuasi creates evacuation log
+// CreateLogEvakuasi creates the evacuation log of a warga for an active bencana
 func CreateLogEvakuasi(c *fiber.Ctx) error {
-	var log models.LogEvakuasi
+	var req models.CreateLogEvakuasiRequest
 
-	if err := c.BodyParser(&log); err != nil {
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
 			"message": "Invalid request body",
 		})
 	}
 
-	log.RelawanID = c.Locals("userID").(uint)
-	log.WaktuUpdate = time.Now()
+	relawanID := c.Locals("userID").(uint)
 
-	if err := database.DB.Create(&log).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to create evacuation log",
-		})
+	log := models.LogEvakuasi{
+		BencanaID:     req.BencanaID,
+		WargaID:       req.WargaID,
+		RelawanID:     relawanID,
+		StatusTerkini: req.StatusTerkini,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := cekBencanaAktif(tx, req.BencanaID); err != nil {
+			return err
+		}
+		warga, err := cekWargaAda(tx, req.WargaID)
+		if err != nil {
+			return err
+		}
+		log.KartuKeluargaID = warga.KartuKeluargaID
+		return buatLogEvakuasi(tx, &log, relawanID, req.Catatan)
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to create evacuation log")
 	}
 
 	// Preload relations
@@ -275,7 +425,7 @@ func CreateLogEvakuasi(c *fiber.Ctx) error {
 	})
 }
 
-// UpdateLogEvakuasi updates evacuation log
+// UpdateLogEvakuasi moves an evacuation log to its next status
 func UpdateLogEvakuasi(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -285,18 +435,7 @@ func UpdateLogEvakuasi(c *fiber.Ctx) error {
 		})
 	}
 
-	var log models.LogEvakuasi
-	if err := database.DB.First(&log, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":   true,
-			"message": "Log not found",
-		})
-	}
-
-	var req struct {
-		StatusTerkini string `json:"status_terkini"`
-	}
-
+	var req models.UpdateLogEvakuasiRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
@@ -304,18 +443,34 @@ func UpdateLogEvakuasi(c *fiber.Ctx) error {
 		})
 	}
 
-	log.StatusTerkini = req.StatusTerkini
-	log.WaktuUpdate = time.Now()
+	userID := c.Locals("userID").(uint)
 
-	if err := database.DB.Save(&log).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to update evacuation log",
-		})
+	var log *models.LogEvakuasi
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if log, err = lockLogEvakuasi(tx, "id = ?", id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newResponseError(fiber.StatusNotFound, "Log not found", nil)
+			}
+			return err
+		}
+		if err := ubahStatusEvakuasi(tx, log, req.StatusTerkini, userID, req.Catatan); err != nil {
+			return err
+		}
+		if req.StatusTerkini == services.StatusDiTitikKumpul {
+			return tempatkanDiTitikKumpul(tx, log, req.TitikKumpulID, userID, c.QueryBool("force"))
+		}
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to update evacuation log")
+	}
+
+	if log.TitikKumpulID != nil {
+		go publishOkupansiTitikKumpul(*log.TitikKumpulID)
 	}
 
 	// Log activity
-	userID := c.Locals("userID").(uint)
 	logActivity(userID, "Update status evakuasi")
 
 	return c.JSON(fiber.Map{
@@ -325,7 +480,7 @@ func UpdateLogEvakuasi(c *fiber.Ctx) error {
 	})
 }
 
-// GetLogEvakuasi returns all evacuation logs for a bencana
+// GetLogEvakuasi returns the evacuation logs for a bencana, paginated (see list_query.go)
 func GetLogEvakuasi(c *fiber.Ctx) error {
 	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
 	if err != nil {
@@ -335,30 +490,99 @@ func GetLogEvakuasi(c *fiber.Ctx) error {
 		})
 	}
 
-	var logs []models.LogEvakuasi
-	if err := database.DB.Where("bencana_id = ?", bencanaID).
-		Preload("Warga").
-		Preload("Relawan").
-		Order("waktu_update DESC").
-		Find(&logs).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to fetch evacuation logs",
-		})
+	query := database.DB.Where("bencana_id = ?", bencanaID)
+
+	// Filter by status
+	if status := c.Query("status_terkini"); status != "" {
+		query = query.Where("status_terkini = ?", status)
 	}
 
-	return c.JSON(fiber.Map{
-		"error": false,
-		"data":  logs,
-		"total": len(logs),
-	})
+	return listPage[models.LogEvakuasi](c, query, evakuasiListSpec, "Failed to fetch evacuation logs", "Warga", "Relawan")
 }
 
 // Helper functions
+
+// queryWargaTerdampak returns the query for vulnerable warga affected by a bencana
+func queryWargaTerdampak(bencana models.KejadianBencana) *gorm.DB {
+	return queryWargaWilayahBencana(bencana).
+		Where("kategori_rentan != ?", "Non-Rentan").
+		Preload("Kategori").
+		Preload("KebutuhanPerawatan")
+}
+
+// queryWargaWilayahBencana returns the query for every warga in the area of a bencana
+func queryWargaWilayahBencana(bencana models.KejadianBencana) *gorm.DB {
+	query := database.DB.Model(&models.WargaRentan{})
+
+	// Filter based on bencana level
+	if bencana.Level == "Lokal_RT" {
+		// Get RT from user who reported
+		var user models.User
+		database.DB.First(&user, bencana.UserPelaporID)
+		// Assuming wilayah_tugas format: "RT 001/RW 001"
+		// This needs to be parsed properly
+		query = query.Where("rt = ?", extractRT(user.WilayahTugas))
+	}
+
+	return query
+}
+
+// rekapPerlengkapan counts the equipment relawan need to bring for the given warga
+func rekapPerlengkapan(warga []models.WargaRentan) map[string]int {
+	rekap := make(map[string]int)
+	for _, w := range warga {
+		if w.KebutuhanPerawatan == nil {
+			continue
+		}
+		for _, item := range w.KebutuhanPerawatan.Perlengkapan {
+			rekap[item]++
+		}
+	}
+	return rekap
+}
+
 func triggerBencanaNotification(bencana models.KejadianBencana) {
-	// Implementation for sending notifications via WhatsApp or other channels
-	// This would integrate with notification service
 	// (TETAP DI SINI - Logika notifikasi lokal)
+	// Teks mengikuti template playbook jenis bencana; petugas selalu diberi tahu
+	teks := fmt.Sprintf("[BENCANA] %s dilaporkan. %s", bencana.JenisBencana, bencana.Deskripsi)
+	if jenis := playbookBencana(database.DB, bencana); jenis != nil && jenis.Playbook.TemplateNotifikasi != "" {
+		teks = services.RenderTemplateNotifikasi(jenis.Playbook.TemplateNotifikasi, bencana)
+	}
+	var nomor []string
+	database.DB.Model(&models.User{}).Where("no_hp IS NOT NULL AND no_hp != ''").Pluck("no_hp", &nomor)
+	kirimWhatsApp(nomor, teks)
+	penerima := len(nomor)
+
+	// Warga yang rumahnya berada di zona bahaya jenis bencana ini diberi tahu lebih dulu
+	var warga []models.WargaRentan
+	preloadPaparan(filterTerpapar(queryWargaWilayahBencana(bencana), bencana.JenisBencana), bencana.JenisBencana).
+		Where("no_hp IS NOT NULL AND no_hp != ''").
+		Find(&warga)
+
+	for _, w := range warga {
+		if len(w.Paparan) == 0 {
+			continue
+		}
+		zona := w.Paparan[0]
+		for _, p := range w.Paparan[1:] {
+			if services.PeringkatRisiko(p.KelasRisiko) > services.PeringkatRisiko(zona.KelasRisiko) {
+				zona = p
+			}
+		}
+		kirimWhatsApp([]string{w.NoHP}, fmt.Sprintf("%s Rumah Anda berada di zona rawan %s (risiko %s).",
+			teks, zona.JenisBahaya, zona.KelasRisiko))
+		penerima++
+	}
+
+	database.DB.Create(&models.NotifikasiBencana{
+		BencanaID:      bencana.ID,
+		Jenis:          "Laporan",
+		Level:          bencana.Level,
+		Pesan:          teks,
+		JumlahPenerima: penerima,
+		DikirimOleh:    &bencana.UserPelaporID,
+		Waktu:          time.Now(),
+	})
 }
 
 // DIHAPUS - Fungsinya dipindahkan ke Sync Worker
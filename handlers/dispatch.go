@@ -0,0 +1,690 @@
+// handlers/dispatch.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Status tugas yang masih dikerjakan relawan
+var statusTugasAktif = []string{"Ditugaskan", "Diterima"}
+
+// tugasListSpec defines sorting for task lists
+var tugasListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":               "id",
+		"skor_prioritas":   "skor_prioritas",
+		"waktu_ditugaskan": "waktu_ditugaskan",
+		"status":           "status",
+		"relawan_id":       "relawan_id",
+	},
+	DefaultSort: "-skor_prioritas,waktu_ditugaskan",
+	Search:      []string{"warga_id IN (SELECT id FROM warga_rentans WHERE nama LIKE ?)"},
+}
+
+// GetTugasBencana returns the tasks of a bencana for the coordinator, paginated
+func GetTugasBencana(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid bencana ID",
+		})
+	}
+
+	query := database.DB.Where("bencana_id = ?", bencanaID)
+
+	// Filter by status
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Filter by relawan
+	if relawanID := c.Query("relawan_id"); relawanID != "" {
+		query = query.Where("relawan_id = ?", relawanID)
+	}
+
+	return listPage[models.TugasEvakuasi](c, query, tugasListSpec, "Failed to fetch tasks", "Warga", "Relawan")
+}
+
+// GetTugasSaya returns the active tasks of the logged in relawan
+func GetTugasSaya(c *fiber.Ctx) error {
+	relawanID := c.Locals("userID").(uint)
+
+	query := database.DB.Where("relawan_id = ?", relawanID)
+
+	// Default: only tasks still to be done; ?status=Selesai etc. for the others
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", statusTugasAktif)
+	}
+
+	return listPage[models.TugasEvakuasi](c, query, tugasListSpec, "Failed to fetch tasks", "Bencana", "Warga", "Warga.KebutuhanPerawatan")
+}
+
+// AssignTugas manually assigns a warga to a relawan
+func AssignTugas(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid bencana ID",
+		})
+	}
+
+	var req models.AssignTugasRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	var tugas models.TugasEvakuasi
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := cekBencanaAktif(tx, uint(bencanaID)); err != nil {
+			return err
+		}
+		warga, err := cekWargaAda(tx, req.WargaID)
+		if err != nil {
+			return err
+		}
+
+		var relawan models.User
+		if err := tx.Where("role = ?", "Relawan").First(&relawan, req.RelawanID).Error; err != nil {
+			return newResponseError(fiber.StatusBadRequest, "Relawan not found", fiber.Map{"field": "relawan_id"})
+		}
+
+		if err := cekBelumDitugaskan(tx, uint(bencanaID), warga.ID); err != nil {
+			return err
+		}
+
+		tugas = models.TugasEvakuasi{
+			BencanaID:       uint(bencanaID),
+			WargaID:         warga.ID,
+			RelawanID:       relawan.ID,
+			DitugaskanOleh:  userID,
+			Metode:          "Manual",
+			Status:          "Ditugaskan",
+			SkorPrioritas:   warga.SkorPrioritas,
+			WaktuDitugaskan: time.Now(),
+		}
+		if lat, lng, ok := posisiRelawan(tx, relawan.ID); ok && services.AdaKoordinat(warga.Latitude, warga.Longitude) {
+			jarak := services.JarakKm(lat, lng, warga.Latitude, warga.Longitude)
+			tugas.JarakKm = &jarak
+		}
+		return tx.Create(&tugas).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to assign task")
+	}
+
+	logActivity(userID, "Menugaskan relawan untuk evakuasi warga")
+
+	database.DB.Preload("Warga").Preload("Relawan").First(&tugas, tugas.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Task assigned successfully",
+		"data":    tugas,
+	})
+}
+
+// AutoAssignTugas assigns every unassigned prioritized warga to the nearest relawan
+// with spare capacity
+func AutoAssignTugas(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid bencana ID",
+		})
+	}
+
+	var req models.AutoAssignRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	var tugasBaru []models.TugasEvakuasi
+	var belumTertampung int
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		bencana, err := cekBencanaAktif(tx, uint(bencanaID))
+		if err != nil {
+			return err
+		}
+
+		warga, err := wargaBelumDitugaskan(tx, *bencana)
+		if err != nil {
+			return err
+		}
+		if req.Limit > 0 && len(warga) > req.Limit {
+			warga = warga[:req.Limit]
+		}
+
+		maxBeban := envInt("MAX_TUGAS_RELAWAN", 5)
+		kandidat, err := kandidatRelawan(tx, maxBeban)
+		if err != nil {
+			return err
+		}
+
+		// Relawan yang pernah menolak warga ini tidak ditugaskan lagi ke warga yang sama
+		var ditolakRows []models.TugasEvakuasi
+		if err := tx.Where("bencana_id = ? AND status = ?", bencana.ID, "Ditolak").Find(&ditolakRows).Error; err != nil {
+			return err
+		}
+		ditolak := make(map[[2]uint]bool, len(ditolakRows))
+		for _, t := range ditolakRows {
+			ditolak[[2]uint{t.WargaID, t.RelawanID}] = true
+		}
+
+		target := make([]services.TargetWarga, 0, len(warga))
+		skor := make(map[uint]int, len(warga))
+		for _, w := range warga {
+			target = append(target, services.TargetWarga{
+				ID:                   w.ID,
+				Latitude:             w.Latitude,
+				Longitude:            w.Longitude,
+				SkorPrioritas:        w.SkorPrioritas,
+				PeringkatPaparan:     peringkatPaparan(w),
+				PeringkatKeselamatan: peringkatKeselamatanWarga(w),
+			})
+			skor[w.ID] = w.SkorPrioritas
+		}
+
+		penugasan := services.AlokasiTugas(target, kandidat, maxBeban, ditolak)
+		belumTertampung = len(target) - len(penugasan)
+
+		now := time.Now()
+		for _, p := range penugasan {
+			tugasBaru = append(tugasBaru, models.TugasEvakuasi{
+				BencanaID:       bencana.ID,
+				WargaID:         p.WargaID,
+				RelawanID:       p.RelawanID,
+				DitugaskanOleh:  userID,
+				Metode:          "Otomatis",
+				Status:          "Ditugaskan",
+				SkorPrioritas:   skor[p.WargaID],
+				JarakKm:         p.JarakKm,
+				WaktuDitugaskan: now,
+			})
+		}
+		if len(tugasBaru) == 0 {
+			return nil
+		}
+		return tx.Create(&tugasBaru).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to auto assign tasks")
+	}
+
+	logActivity(userID, "Penugasan relawan otomatis: "+strconv.Itoa(len(tugasBaru))+" warga")
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":            false,
+		"message":          "Tasks assigned successfully",
+		"data":             tugasBaru,
+		"total":            len(tugasBaru),
+		"belum_tertampung": belumTertampung,
+	})
+}
+
+// TerimaTugas accepts a task; the warga's evacuation log moves to "Dalam Proses"
+func TerimaTugas(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	relawanID := c.Locals("userID").(uint)
+
+	var tugas *models.TugasEvakuasi
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if tugas, err = tugasMilikRelawan(tx, uint(id), relawanID); err != nil {
+			return err
+		}
+		if tugas.Status != "Ditugaskan" {
+			return newResponseError(fiber.StatusUnprocessableEntity, "Only tasks with status Ditugaskan can be accepted", fiber.Map{"status": tugas.Status})
+		}
+
+		now := time.Now()
+		tugas.Status = "Diterima"
+		tugas.WaktuDirespon = &now
+		if err := tx.Save(tugas).Error; err != nil {
+			return err
+		}
+
+		// Mulai evakuasi: buat log baru atau lanjutkan log yang masih Menunggu
+		logEvakuasi, err := lockLogEvakuasi(tx, "bencana_id = ? AND warga_id = ?", tugas.BencanaID, tugas.WargaID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			warga, err := cekWargaAda(tx, tugas.WargaID)
+			if err != nil {
+				return err
+			}
+			return buatLogEvakuasi(tx, &models.LogEvakuasi{
+				BencanaID:       tugas.BencanaID,
+				WargaID:         tugas.WargaID,
+				KartuKeluargaID: warga.KartuKeluargaID,
+				RelawanID:       relawanID,
+				StatusTerkini:   services.StatusDalamProses,
+			}, relawanID, "Tugas diterima relawan")
+		case err != nil:
+			return err
+		case logEvakuasi.StatusTerkini == services.StatusMenunggu:
+			if err := tx.Model(logEvakuasi).Update("relawan_id", relawanID).Error; err != nil {
+				return err
+			}
+			return ubahStatusEvakuasi(tx, logEvakuasi, services.StatusDalamProses, relawanID, "Tugas diterima relawan")
+		}
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to accept task")
+	}
+
+	logActivity(relawanID, "Menerima tugas evakuasi")
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Task accepted successfully",
+		"data":    tugas,
+	})
+}
+
+// TolakTugas declines a task so the warga goes back to the unassigned pool
+func TolakTugas(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.TolakTugasRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	relawanID := c.Locals("userID").(uint)
+
+	var tugas *models.TugasEvakuasi
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if tugas, err = tugasMilikRelawan(tx, uint(id), relawanID); err != nil {
+			return err
+		}
+		if tugas.Status != "Ditugaskan" && tugas.Status != "Diterima" {
+			return newResponseError(fiber.StatusUnprocessableEntity, "Only active tasks can be declined", fiber.Map{"status": tugas.Status})
+		}
+		sudahDiterima := tugas.Status == "Diterima"
+
+		now := time.Now()
+		tugas.Status = "Ditolak"
+		tugas.AlasanTolak = req.Alasan
+		tugas.WaktuDirespon = &now
+		if err := tx.Save(tugas).Error; err != nil {
+			return err
+		}
+
+		// Evakuasi yang sudah dimulai relawan ini dikembalikan ke antrian
+		if sudahDiterima {
+			logEvakuasi, err := lockLogEvakuasi(tx, "bencana_id = ? AND warga_id = ?", tugas.BencanaID, tugas.WargaID)
+			if err == nil && logEvakuasi.StatusTerkini == services.StatusDalamProses && logEvakuasi.RelawanID == relawanID {
+				return ubahStatusEvakuasi(tx, logEvakuasi, services.StatusMenunggu, relawanID, "Tugas ditolak: "+req.Alasan)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to decline task")
+	}
+
+	logActivity(relawanID, "Menolak tugas evakuasi")
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Task declined successfully",
+		"data":    tugas,
+	})
+}
+
+// GetEskalasi returns the escalations of a bencana (unhandled only unless ?semua=true)
+func GetEskalasi(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid bencana ID",
+		})
+	}
+
+	query := database.DB.Where("bencana_id = ?", bencanaID)
+	if !c.QueryBool("semua") {
+		query = query.Where("ditangani = ?", false)
+	}
+
+	var eskalasi []models.EskalasiEvakuasi
+	if err := query.Preload("Warga").Order("waktu DESC").Find(&eskalasi).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch escalations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  eskalasi,
+		"total": len(eskalasi),
+	})
+}
+
+// TanganiEskalasi marks an escalation as handled by the coordinator
+func TanganiEskalasi(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var eskalasi models.EskalasiEvakuasi
+	if err := database.DB.First(&eskalasi, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Eskalasi not found",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	eskalasi.Ditangani = true
+	eskalasi.DitanganiOleh = &userID
+
+	if err := database.DB.Save(&eskalasi).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update eskalasi",
+		})
+	}
+
+	logActivity(userID, "Menangani eskalasi evakuasi")
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Eskalasi handled successfully",
+		"data":    eskalasi,
+	})
+}
+
+// StartEskalasiWorker periodically escalates unassigned or stalled high-priority warga.
+// Dijalankan sebagai goroutine dari main API Kecamatan.
+func StartEskalasiWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := cekEskalasi(time.Now()); err != nil {
+			log.Printf("❌ Gagal cek eskalasi evakuasi: %v", err)
+		}
+	}
+}
+
+// cekEskalasi runs one escalation pass over all active bencana
+func cekEskalasi(now time.Time) error {
+	batasRespon := now.Add(-time.Duration(envInt("ESKALASI_RESPON_MENIT", 10)) * time.Minute)
+	batasMacet := now.Add(-time.Duration(envInt("ESKALASI_MACET_MENIT", 30)) * time.Minute)
+	batasBelumDitugaskan := now.Add(-time.Duration(envInt("ESKALASI_BELUM_DITUGASKAN_MENIT", 15)) * time.Minute)
+	skorMin := envInt("ESKALASI_SKOR_MIN", 90)
+
+	var bencanaAktif []models.KejadianBencana
+	if err := database.DB.Where("status = ?", "Aktif").Find(&bencanaAktif).Error; err != nil {
+		return err
+	}
+
+	for _, bencana := range bencanaAktif {
+		// 1. Tugas yang tidak direspon relawan
+		var tidakDirespon []models.TugasEvakuasi
+		if err := database.DB.Where("bencana_id = ? AND status = ? AND waktu_ditugaskan < ? AND skor_prioritas >= ?",
+			bencana.ID, "Ditugaskan", batasRespon, skorMin).Find(&tidakDirespon).Error; err != nil {
+			return err
+		}
+		for _, t := range tidakDirespon {
+			if err := buatEskalasi(bencana.ID, t.WargaID, &t.ID, "Tidak Direspon", now); err != nil {
+				return err
+			}
+		}
+
+		// 2. Tugas yang diterima tapi evakuasinya tidak maju
+		var macet []models.TugasEvakuasi
+		if err := database.DB.Where("bencana_id = ? AND status = ? AND waktu_direspon < ? AND skor_prioritas >= ?",
+			bencana.ID, "Diterima", batasMacet, skorMin).Find(&macet).Error; err != nil {
+			return err
+		}
+		for _, t := range macet {
+			if err := buatEskalasi(bencana.ID, t.WargaID, &t.ID, "Macet", now); err != nil {
+				return err
+			}
+		}
+
+		// 3. Warga prioritas tinggi yang belum ditugaskan sama sekali
+		if bencana.WaktuMulai.Before(batasBelumDitugaskan) {
+			warga, err := wargaBelumDitugaskan(database.DB, bencana)
+			if err != nil {
+				return err
+			}
+			for _, w := range warga {
+				if w.SkorPrioritas < skorMin {
+					continue
+				}
+				if err := buatEskalasi(bencana.ID, w.ID, nil, "Belum Ditugaskan", now); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// buatEskalasi records an escalation once (until handled) and alerts connected coordinators
+func buatEskalasi(bencanaID, wargaID uint, tugasID *uint, alasan string, now time.Time) error {
+	var count int64
+	if err := database.DB.Model(&models.EskalasiEvakuasi{}).
+		Where("bencana_id = ? AND warga_id = ? AND alasan = ? AND ditangani = ?", bencanaID, wargaID, alasan, false).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	eskalasi := models.EskalasiEvakuasi{
+		BencanaID: bencanaID,
+		WargaID:   wargaID,
+		TugasID:   tugasID,
+		Alasan:    alasan,
+		Waktu:     now,
+	}
+	if err := database.DB.Create(&eskalasi).Error; err != nil {
+		return fmt.Errorf("simpan eskalasi: %w", err)
+	}
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":        "eskalasi",
+		"eskalasi_id": eskalasi.ID,
+		"bencana_id":  bencanaID,
+		"warga_id":    wargaID,
+		"alasan":      alasan,
+		"waktu":       now.Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+	return nil
+}
+
+// selesaikanTugas closes the active task of a warga once it has been evacuated
+func selesaikanTugas(tx *gorm.DB, bencanaID, wargaID uint) error {
+	now := time.Now()
+	return tx.Model(&models.TugasEvakuasi{}).
+		Where("bencana_id = ? AND warga_id = ? AND status IN ?", bencanaID, wargaID, statusTugasAktif).
+		Updates(map[string]interface{}{
+			"status":        "Selesai",
+			"waktu_selesai": now,
+		}).Error
+}
+
+// cekBelumDitugaskan rejects a new task when the warga already has an active one
+func cekBelumDitugaskan(tx *gorm.DB, bencanaID, wargaID uint) error {
+	var existing models.TugasEvakuasi
+	err := tx.Where("bencana_id = ? AND warga_id = ? AND status IN ?", bencanaID, wargaID, statusTugasAktif).First(&existing).Error
+	if err == nil {
+		return newResponseError(fiber.StatusConflict, "Warga already has an active task", fiber.Map{
+			"existing": fiber.Map{
+				"id":         existing.ID,
+				"relawan_id": existing.RelawanID,
+				"status":     existing.Status,
+			},
+		})
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// wargaBelumDitugaskan returns affected warga without an active or finished task
+// that have not been evacuated yet, highest priority first (lihat urutkanMenurutKeselamatan)
+func wargaBelumDitugaskan(tx *gorm.DB, bencana models.KejadianBencana) ([]models.WargaRentan, error) {
+	sudahDitangani := tx.Model(&models.TugasEvakuasi{}).
+		Select("warga_id").
+		Where("bencana_id = ? AND status IN ?", bencana.ID, []string{"Ditugaskan", "Diterima", "Selesai"})
+	sudahDievakuasi := tx.Model(&models.LogEvakuasi{}).
+		Select("warga_id").
+		Where("bencana_id = ? AND status_terkini IN ?", bencana.ID, []string{services.StatusTerevakuasi, services.StatusDiTitikKumpul})
+
+	var warga []models.WargaRentan
+	err := preloadKeselamatan(preloadPaparan(queryWargaTerdampak(bencana), bencana.JenisBencana), bencana.ID).
+		Where("id NOT IN (?)", sudahDitangani).
+		Where("id NOT IN (?)", sudahDievakuasi).
+		Order("skor_prioritas DESC, nama ASC").
+		Find(&warga).Error
+	urutkanMenurutKeselamatan(warga)
+	return warga, err
+}
+
+// kandidatRelawan returns the relawan that may receive new tasks: akun aktif, sedang
+// dalam shift (sama seperti GetRelawanTersedia), dan belum mencapai maxBeban tugas
+func kandidatRelawan(tx *gorm.DB, maxBeban int) ([]services.KandidatRelawan, error) {
+	var profil []models.ProfilRelawan
+	if err := filterRelawanAktif(tx.Model(&models.ProfilRelawan{})).
+		Where("sedang_bertugas = ?", true).
+		Find(&profil).Error; err != nil {
+		return nil, err
+	}
+
+	bebanPerRelawan, err := bebanRelawan(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	kandidat := make([]services.KandidatRelawan, 0, len(profil))
+	for _, p := range profil {
+		if bebanPerRelawan[p.UserID] >= maxBeban {
+			continue
+		}
+		lat, lng, ok := posisiRelawan(tx, p.UserID)
+		kandidat = append(kandidat, services.KandidatRelawan{
+			ID:        p.UserID,
+			Latitude:  lat,
+			Longitude: lng,
+			AdaLokasi: ok,
+			Beban:     bebanPerRelawan[p.UserID],
+		})
+	}
+	return kandidat, nil
+}
+
+// posisiRelawan estimates where a relawan is: the last location reported in their
+// profile, or the location of the warga of their most recent task when that is newer.
+// ok is false when nothing is known yet.
+func posisiRelawan(tx *gorm.DB, relawanID uint) (lat, lng float64, ok bool) {
+	var profil models.ProfilRelawan
+	adaProfil := tx.Where("user_id = ?", relawanID).First(&profil).Error == nil &&
+		profil.WaktuLokasi != nil && services.AdaKoordinat(profil.Latitude, profil.Longitude)
+
+	var tugas models.TugasEvakuasi
+	if err := tx.Preload("Warga").
+		Where("relawan_id = ?", relawanID).
+		Order("waktu_ditugaskan DESC").
+		First(&tugas).Error; err != nil || !services.AdaKoordinat(tugas.Warga.Latitude, tugas.Warga.Longitude) {
+		if adaProfil {
+			return profil.Latitude, profil.Longitude, true
+		}
+		return 0, 0, false
+	}
+	if adaProfil && profil.WaktuLokasi.After(tugas.WaktuDitugaskan) {
+		return profil.Latitude, profil.Longitude, true
+	}
+	return tugas.Warga.Latitude, tugas.Warga.Longitude, true
+}
+
+// tugasMilikRelawan loads a task and makes sure it belongs to the relawan
+func tugasMilikRelawan(tx *gorm.DB, id, relawanID uint) (*models.TugasEvakuasi, error) {
+	var tugas models.TugasEvakuasi
+	if err := tx.First(&tugas, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newResponseError(fiber.StatusNotFound, "Task not found", nil)
+		}
+		return nil, err
+	}
+	if tugas.RelawanID != relawanID {
+		return nil, newResponseError(fiber.StatusForbidden, "This task is assigned to another relawan", nil)
+	}
+	return &tugas, nil
+}
+
+// envInt reads an integer setting from the environment, falling back to def
+func envInt(key string, def int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return def
+}
+
+// envIntNol is envInt for settings where 0 means "disabled"
+func envIntNol(key string, def int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value >= 0 {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,72 @@
+// handlers/event_kota.go
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+// StartEventKotaConsumer listens for events sent by Kota to this kecamatan.
+// Dijalankan sebagai goroutine dari main API Kecamatan.
+func StartEventKotaConsumer(brokerUrl string) {
+	messaging.StartKecamatanConsumer(brokerUrl, messaging.TopicKota, kecamatanID(), prosesEventKota)
+}
+
+// prosesEventKota routes one event from Kota to its handler.
+// Error dikembalikan hanya untuk kegagalan sementara (mis. DB) agar event dicoba lagi;
+// payload tidak valid dan action tak dikenal cukup dicatat di log.
+func prosesEventKota(event messaging.Event) error {
+	switch event.Action {
+	case "STATUS_PERMINTAAN_SUMBER_DAYA":
+		var data models.StatusPermintaanEvent
+		if decodeEventKota(event, &data) {
+			return terimaStatusPermintaan(data)
+		}
+
+	case "PENUGASAN_PASOKAN":
+		var data models.PenugasanPasokanEvent
+		if decodeEventKota(event, &data) {
+			return terimaPenugasanPasokan(data)
+		}
+
+	case "STATUS_PASOKAN":
+		var data models.StatusPermintaanEvent
+		if decodeEventKota(event, &data) {
+			return terimaStatusPasokan(data)
+		}
+
+	case "ARAHAN_KOTA":
+		var data models.ArahanKotaEvent
+		if decodeEventKota(event, &data) {
+			return terimaArahanKota(data)
+		}
+
+	case "PERINGATAN_DINI":
+		var data models.PeringatanDiniEvent
+		if decodeEventKota(event, &data) {
+			return terimaPeringatanDini(data)
+		}
+
+	case "JENIS_BENCANA":
+		var data models.JenisBencanaEvent
+		if decodeEventKota(event, &data) {
+			return terimaJenisBencana(data)
+		}
+
+	default:
+		log.Printf("⚠️ Event Kota tidak dikenal: %s", event.Action)
+	}
+	return nil
+}
+
+// decodeEventKota unmarshals the payload of an event, logging invalid ones
+func decodeEventKota(event messaging.Event, dest interface{}) bool {
+	if err := json.Unmarshal(event.Payload, dest); err != nil {
+		log.Printf("❌ Payload event Kota %s tidak valid: %v", event.Action, err)
+		return false
+	}
+	return true
+}
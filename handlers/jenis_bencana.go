@@ -0,0 +1,433 @@
+// handlers/jenis_bencana.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Taksonomi jenis bencana dikelola API Kota (lihat jenis_bencana_kota.go) dan
+// disalin utuh ke setiap kecamatan lewat event JENIS_BENCANA. Kedua API
+// memakai GetAllJenisBencana / GetJenisBencanaByKode terhadap DB masing-masing.
+
+// GetAllJenisBencana returns the disaster type taxonomy with playbooks.
+// Query: kategori_nasional, semua=true (termasuk yang nonaktif)
+func GetAllJenisBencana(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.JenisBencana{})
+	if kategori := c.Query("kategori_nasional"); kategori != "" {
+		query = query.Where("kategori_nasional = ?", kategori)
+	}
+	if !c.QueryBool("semua") {
+		query = query.Where("aktif = ?", true)
+	}
+
+	var jenis []models.JenisBencana
+	if err := query.Order("kategori_nasional, nama").Find(&jenis).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch jenis bencana",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  jenis,
+		"total": len(jenis),
+	})
+}
+
+// GetJenisBencanaByKode returns one disaster type; kode juga boleh berupa nama / alias
+func GetJenisBencanaByKode(c *fiber.Ctx) error {
+	jenis := cariJenisBencana(c.Params("kode"))
+	if jenis == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Jenis bencana not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  jenis,
+	})
+}
+
+// cariJenisBencana resolves free text (kode, nama baku atau alias) to an active disaster type
+func cariJenisBencana(teks string) *models.JenisBencana {
+	var daftar []models.JenisBencana
+	database.DB.Where("aktif = ?", true).Find(&daftar)
+	return services.CocokkanJenisBencana(daftar, teks)
+}
+
+// bakukanJenisBencana replaces the free-text type of a bencana with its canonical name and kode.
+// Jenis yang tidak dikenal dibiarkan apa adanya (mis. sensor lama).
+func bakukanJenisBencana(bencana *models.KejadianBencana) {
+	if bencana.KodeJenis != "" {
+		return
+	}
+	if jenis := cariJenisBencana(bencana.JenisBencana); jenis != nil {
+		bencana.JenisBencana = jenis.Nama
+		bencana.KodeJenis = jenis.Kode
+	}
+}
+
+// namaJenisBencanaAktif lists the canonical names, untuk pesan kesalahan
+func namaJenisBencanaAktif() []string {
+	var nama []string
+	database.DB.Model(&models.JenisBencana{}).Where("aktif = ?", true).Order("nama").Pluck("nama", &nama)
+	return nama
+}
+
+// jenisBahayaUntuk returns the hazard zone types of a disaster or warning type:
+// dari taksonomi bila dikenal, selain itu dari pemetaan kata kunci
+func jenisBahayaUntuk(jenis string) []string {
+	if j := cariJenisBencana(jenis); j != nil && len(j.JenisBahaya) > 0 {
+		return j.JenisBahaya
+	}
+	return services.JenisBahayaTerkait(jenis)
+}
+
+// terimaJenisBencana replaces the local copy of the taxonomy with the one from Kota
+func terimaJenisBencana(data models.JenisBencanaEvent) error {
+	for _, jenis := range data.Jenis {
+		jenis.ID = 0
+		err := database.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "kode"}},
+			DoUpdates: clause.AssignmentColumns([]string{"nama", "kategori_nasional", "alias", "jenis_bahaya", "playbook", "aktif", "updated_at"}),
+		}).Create(&jenis).Error
+		if err != nil {
+			return fmt.Errorf("simpan jenis bencana %s: %w", jenis.Kode, err)
+		}
+	}
+	log.Printf("📚 Taksonomi jenis bencana diperbarui dari Kota (%d jenis)", len(data.Jenis))
+	return nil
+}
+
+// playbookBencana returns the disaster type of a bencana, or nil for unrecognised legacy data
+func playbookBencana(tx *gorm.DB, bencana models.KejadianBencana) *models.JenisBencana {
+	if bencana.KodeJenis == "" {
+		return nil
+	}
+	var jenis models.JenisBencana
+	if err := tx.Where("kode = ?", bencana.KodeJenis).First(&jenis).Error; err != nil {
+		return nil
+	}
+	return &jenis
+}
+
+// buatTugasPlaybook instantiates the playbook checklist as tasks of an active bencana.
+// Tidak melakukan apa-apa bila tugas playbook sudah pernah dibuat.
+func buatTugasPlaybook(tx *gorm.DB, bencana models.KejadianBencana) error {
+	jenis := playbookBencana(tx, bencana)
+	if jenis == nil || len(jenis.Playbook.Checklist) == 0 {
+		return nil
+	}
+
+	var sudahAda int64
+	if err := tx.Model(&models.TugasBencana{}).
+		Where("bencana_id = ? AND sumber = ?", bencana.ID, "Playbook").
+		Count(&sudahAda).Error; err != nil {
+		return err
+	}
+	if sudahAda > 0 {
+		return nil
+	}
+
+	tugas := services.TugasDariPlaybook(bencana, jenis.Playbook)
+	return tx.Create(&tugas).Error
+}
+
+// GetPlaybookBencana returns the playbook of a bencana together with its progress:
+// tugas checklist, rekomendasi titik kumpul dan warga yang belum menuntaskan status wajib
+func GetPlaybookBencana(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var bencana models.KejadianBencana
+	if err := database.DB.First(&bencana, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana not found",
+		})
+	}
+
+	jenis := playbookBencana(database.DB, bencana)
+	if jenis == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana has no recognised jenis bencana, playbook unavailable",
+		})
+	}
+
+	var tugas []models.TugasBencana
+	database.DB.Where("bencana_id = ?", bencana.ID).Order("urutan, id").Find(&tugas)
+
+	tuntas, belum := progresStatusWajib(bencana.ID, jenis.Playbook.StatusWajib)
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"bencana":  bencana,
+			"jenis":    jenis,
+			"tugas":    tugas,
+			"shelter":  rekomendasiShelter(*jenis),
+			"evakuasi": fiber.Map{"status_wajib": jenis.Playbook.StatusWajib, "tuntas": tuntas, "belum_tuntas": belum},
+		},
+	})
+}
+
+// progresStatusWajib counts evacuation logs of a bencana that did / did not reach the last required status
+func progresStatusWajib(bencanaID uint, statusWajib []string) (tuntas, belum int64) {
+	var logs []models.LogEvakuasi
+	database.DB.Select("id", "status_terkini").Where("bencana_id = ?", bencanaID).Find(&logs)
+	for _, l := range logs {
+		if services.StatusEvakuasiTuntas(l.StatusTerkini, statusWajib) {
+			tuntas++
+		} else {
+			belum++
+		}
+	}
+	return tuntas, belum
+}
+
+// rekomendasiShelter lists active shelters matching the playbook, paling lega lebih dulu.
+// Titik kumpul di zona bahaya Sedang/Tinggi jenis terkait dilewati bila diminta playbook.
+func rekomendasiShelter(jenis models.JenisBencana) []fiber.Map {
+	query := database.DB.Where("aktif = ?", true)
+	if len(jenis.Playbook.JenisShelter) > 0 {
+		query = query.Where("jenis IN ?", jenis.Playbook.JenisShelter)
+	}
+	var titik []models.TitikKumpul
+	query.Find(&titik)
+
+	var zona []services.Poligon
+	if jenis.Playbook.HindariZonaBahaya && len(jenis.JenisBahaya) > 0 {
+		var zonaBahaya []models.ZonaBahaya
+		database.DB.Where("jenis_bahaya IN ? AND kelas_risiko IN ?", jenis.JenisBahaya, []string{"Sedang", "Tinggi"}).Find(&zonaBahaya)
+		for _, z := range zonaBahaya {
+			if p, err := services.ParseGeoJSONPoligon(z.Geometri); err == nil {
+				zona = append(zona, p...)
+			}
+		}
+	}
+
+	hasil := make([]fiber.Map, 0, len(titik))
+	for _, t := range titik {
+		if len(zona) > 0 && services.AdaKoordinat(t.Latitude, t.Longitude) &&
+			services.DalamSalahSatuPoligon(zona, services.Titik{Lat: t.Latitude, Lng: t.Longitude}) {
+			continue
+		}
+		hasil = append(hasil, fiber.Map{
+			"titik_kumpul":   t,
+			"sisa_kapasitas": t.SisaKapasitas(),
+		})
+	}
+	sort.SliceStable(hasil, func(i, j int) bool {
+		return hasil[i]["sisa_kapasitas"].(int) > hasil[j]["sisa_kapasitas"].(int)
+	})
+	return hasil
+}
+
+// GetTugasPlaybook returns the checklist tasks of a bencana. Query: status, peran
+func GetTugasPlaybook(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	query := database.DB.Where("bencana_id = ?", id)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if peran := c.Query("peran"); peran != "" {
+		query = query.Where("peran = ?", peran)
+	}
+
+	var tugas []models.TugasBencana
+	if err := query.Order("urutan, id").Find(&tugas).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch tugas bencana",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  tugas,
+		"total": len(tugas),
+	})
+}
+
+// CreateTugasPlaybook adds a manual task to the checklist of an active bencana
+func CreateTugasPlaybook(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.CreateTugasBencanaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	if strings.TrimSpace(req.Judul) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "judul is required",
+		})
+	}
+	if req.Peran != "" && !services.PeranTugasValid(req.Peran) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "peran must be RT, RW, Relawan or Admin_Kecamatan",
+		})
+	}
+
+	var tugas models.TugasBencana
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		bencana, err := cekBencanaAktif(tx, uint(id))
+		if err != nil {
+			return err
+		}
+
+		var urutan int
+		if err := tx.Model(&models.TugasBencana{}).Where("bencana_id = ?", bencana.ID).
+			Select("COALESCE(MAX(urutan), 0)").Scan(&urutan).Error; err != nil {
+			return err
+		}
+
+		tugas = models.TugasBencana{
+			BencanaID: bencana.ID,
+			Urutan:    urutan + 1,
+			Judul:     strings.TrimSpace(req.Judul),
+			Deskripsi: req.Deskripsi,
+			Peran:     req.Peran,
+			Sumber:    "Manual",
+			Status:    "Belum",
+		}
+		if req.BatasMenit > 0 {
+			batas := time.Now().Add(time.Duration(req.BatasMenit) * time.Minute)
+			tugas.BatasWaktu = &batas
+		}
+		return tx.Create(&tugas).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to create tugas bencana")
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, fmt.Sprintf("Menambahkan tugas bencana #%d: %s", id, tugas.Judul))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Tugas bencana created successfully",
+		"data":    tugas,
+	})
+}
+
+// UpdateTugasPlaybook marks a checklist task as done, skipped or open again
+func UpdateTugasPlaybook(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+	tugasID, err := strconv.Atoi(c.Params("tugas_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid tugas ID",
+		})
+	}
+
+	var req models.UpdateTugasBencanaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	if req.Status != "Belum" && req.Status != "Selesai" && req.Status != "Dilewati" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "status must be Belum, Selesai or Dilewati",
+		})
+	}
+	if req.Status == "Dilewati" && strings.TrimSpace(req.Catatan) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "catatan is required when skipping a task",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	var tugas models.TugasBencana
+	if err := database.DB.Where("bencana_id = ?", id).First(&tugas, tugasID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   true,
+				"message": "Tugas bencana not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch tugas bencana",
+		})
+	}
+
+	tugas.Status = req.Status
+	if req.Catatan != "" {
+		tugas.Catatan = req.Catatan
+	}
+	if req.Status == "Belum" {
+		tugas.DiselesaikanOleh = nil
+		tugas.WaktuSelesai = nil
+	} else {
+		now := time.Now()
+		tugas.DiselesaikanOleh = &userID
+		tugas.WaktuSelesai = &now
+	}
+
+	if err := database.DB.Save(&tugas).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update tugas bencana",
+		})
+	}
+
+	logActivity(userID, fmt.Sprintf("Tugas bencana #%d \"%s\" menjadi %s", id, tugas.Judul, tugas.Status))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Tugas bencana updated successfully",
+		"data":    tugas,
+	})
+}
@@ -0,0 +1,181 @@
+// handlers/jenis_bencana_kota.go
+package handlers
+
+import (
+	"strings"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// FUNGSI DI FILE INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA.
+
+// CreateJenisBencana adds a disaster type to the taxonomy and pushes it to every kecamatan
+func CreateJenisBencana(c *fiber.Ctx) error {
+	var req models.JenisBencanaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	jenis := jenisDariRequest(req)
+	if err := services.ValidasiJenisBencana(jenis); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+	if err := cekEjaanJenisBencana(jenis, 0); err != nil {
+		return writeError(c, err, "Failed to create jenis bencana")
+	}
+
+	if err := database.DB.Create(&jenis).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create jenis bencana",
+		})
+	}
+
+	go kirimJenisBencana()
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Jenis bencana created successfully",
+		"data":    jenis,
+	})
+}
+
+// UpdateJenisBencana replaces a disaster type and its playbook. Kode tidak dapat diubah
+// karena sudah tercatat di bencana kecamatan.
+func UpdateJenisBencana(c *fiber.Ctx) error {
+	var jenis models.JenisBencana
+	if err := database.DB.Where("kode = ?", c.Params("kode")).First(&jenis).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Jenis bencana not found",
+		})
+	}
+
+	var req models.JenisBencanaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	req.Kode = jenis.Kode
+	if req.Aktif == nil {
+		req.Aktif = &jenis.Aktif
+	}
+
+	baru := jenisDariRequest(req)
+	baru.ID = jenis.ID
+	baru.CreatedAt = jenis.CreatedAt
+	if err := services.ValidasiJenisBencana(baru); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+	if err := cekEjaanJenisBencana(baru, jenis.ID); err != nil {
+		return writeError(c, err, "Failed to update jenis bencana")
+	}
+
+	if err := database.DB.Save(&baru).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update jenis bencana",
+		})
+	}
+
+	go kirimJenisBencana()
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Jenis bencana updated successfully",
+		"data":    baru,
+	})
+}
+
+// DeleteJenisBencana deactivates a disaster type; bencana lama tetap menyimpan kodenya
+func DeleteJenisBencana(c *fiber.Ctx) error {
+	result := database.DB.Model(&models.JenisBencana{}).Where("kode = ?", c.Params("kode")).Update("aktif", false)
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to deactivate jenis bencana",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Jenis bencana not found",
+		})
+	}
+
+	go kirimJenisBencana()
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Jenis bencana deactivated",
+	})
+}
+
+// SinkronJenisBencana re-sends the whole taxonomy, mis. untuk kecamatan yang baru bergabung
+func SinkronJenisBencana(c *fiber.Ctx) error {
+	jumlah := kirimJenisBencana()
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Jenis bencana sent to every kecamatan",
+		"data":    fiber.Map{"jumlah": jumlah},
+	})
+}
+
+// kirimJenisBencana publishes the full taxonomy to every kecamatan
+func kirimJenisBencana() int {
+	var jenis []models.JenisBencana
+	database.DB.Order("kode").Find(&jenis)
+	messaging.PublishToKecamatan("JENIS_BENCANA", 0, models.JenisBencanaEvent{Jenis: jenis})
+	return len(jenis)
+}
+
+func jenisDariRequest(req models.JenisBencanaRequest) models.JenisBencana {
+	jenis := models.JenisBencana{
+		Kode:             strings.ToUpper(strings.TrimSpace(req.Kode)),
+		Nama:             strings.TrimSpace(req.Nama),
+		KategoriNasional: req.KategoriNasional,
+		Alias:            req.Alias,
+		JenisBahaya:      req.JenisBahaya,
+		Playbook:         req.Playbook,
+		Aktif:            true,
+	}
+	if req.Aktif != nil {
+		jenis.Aktif = *req.Aktif
+	}
+	return jenis
+}
+
+// cekEjaanJenisBencana makes sure the kode, nama and aliases do not already point to another type
+func cekEjaanJenisBencana(jenis models.JenisBencana, kecuali uint) error {
+	var daftar []models.JenisBencana
+	if err := database.DB.Where("id <> ?", kecuali).Find(&daftar).Error; err != nil {
+		return err
+	}
+	for i := range daftar {
+		daftar[i].Aktif = true // Ejaan jenis nonaktif juga tidak boleh dipakai ulang
+	}
+
+	ejaan := append([]string{jenis.Kode, jenis.Nama}, jenis.Alias...)
+	for _, e := range ejaan {
+		if lain := services.CocokkanJenisBencana(daftar, e); lain != nil {
+			return newResponseError(fiber.StatusConflict, "Ejaan \""+e+"\" already used by jenis "+lain.Kode, fiber.Map{"kode": lain.Kode})
+		}
+	}
+	return nil
+}
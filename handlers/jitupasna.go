@@ -0,0 +1,587 @@
+// handlers/jitupasna.go
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Pengkajian kebutuhan pascabencana (Jitupasna): petugas RT mendata kerusakan rumah,
+// fasilitas umum dan usaha warga per bencana; asesmen yang sudah diverifikasi
+// Admin_Kecamatan direkap per sektor dan dikirim ke Kota (REKAP_JITUPASNA).
+
+// asesmenListSpec defines sorting and search for assessments
+var asesmenListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":                "id",
+		"created_at":        "created_at",
+		"tingkat_kerusakan": "tingkat_kerusakan",
+		"nilai_kerusakan":   "nilai_kerusakan",
+		"sektor":            "sektor",
+		"status":            "status",
+	},
+	DefaultSort: "-created_at",
+	Search:      []string{"nama_objek LIKE ?", "alamat LIKE ?"},
+}
+
+// GetAllAsesmen returns assessments, paginated (see list_query.go).
+// Query: bencana_id, objek, sektor, status, rt, rw, kartu_keluarga_id
+func GetAllAsesmen(c *fiber.Ctx) error {
+	query := database.DB
+	for _, f := range []string{"objek", "sektor", "status", "rt", "rw"} {
+		if v := c.Query(f); v != "" {
+			query = query.Where(f+" = ?", v)
+		}
+	}
+	if id := c.QueryInt("bencana_id", 0); id > 0 {
+		query = query.Where("bencana_id = ?", id)
+	}
+	if id := c.QueryInt("kartu_keluarga_id", 0); id > 0 {
+		query = query.Where("kartu_keluarga_id = ?", id)
+	}
+
+	return listPage[models.AsesmenKerusakan](c, query, asesmenListSpec, "Failed to fetch asesmen", "KartuKeluarga", "Penghidupan", "Kebutuhan")
+}
+
+// GetReferensiJitupasna returns the sectors, subsectors and facility types for assessment forms
+func GetReferensiJitupasna(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"sektor":            services.UrutanSektorJitupasna,
+			"subsektor":         services.SubsektorJitupasna,
+			"jenis_fasilitas":   services.FasilitasJitupasna,
+			"tingkat_kerusakan": []string{"Tidak Rusak", "Ringan", "Sedang", "Berat"},
+		},
+	})
+}
+
+// GetAsesmenByID returns one assessment with its household, livelihoods and needs
+func GetAsesmenByID(c *fiber.Ctx) error {
+	var asesmen models.AsesmenKerusakan
+	if err := database.DB.Preload("Bencana").Preload("KartuKeluarga").Preload("Penghidupan").
+		Preload("Kebutuhan").Preload("Penilai").First(&asesmen, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Asesmen not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  asesmen,
+	})
+}
+
+// CreateAsesmen records a damage and needs assessment for a bencana
+func CreateAsesmen(c *fiber.Ctx) error {
+	var req models.AsesmenKerusakanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	asesmen := models.AsesmenKerusakan{DinilaiOleh: userID, Status: "Diajukan"}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var bencana models.KejadianBencana
+		if err := tx.First(&bencana, req.BencanaID).Error; err != nil {
+			return newResponseError(fiber.StatusNotFound, "Bencana not found", nil)
+		}
+		if bencana.Status == "Draft" {
+			return newResponseError(fiber.StatusConflict, "Bencana is still a draft", nil)
+		}
+		if err := isiAsesmen(tx, &asesmen, req); err != nil {
+			return err
+		}
+		return tx.Create(&asesmen).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to create asesmen")
+	}
+
+	logActivity(userID, fmt.Sprintf("Mengisi asesmen %s bencana #%d: %s", asesmen.Objek, asesmen.BencanaID, asesmen.NamaObjek))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Asesmen created successfully",
+		"data":    asesmen,
+	})
+}
+
+// UpdateAsesmen replaces an assessment that has not been verified yet; asesmen
+// yang ditolak kembali berstatus Diajukan setelah diperbaiki
+func UpdateAsesmen(c *fiber.Ctx) error {
+	var req models.AsesmenKerusakanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	var asesmen models.AsesmenKerusakan
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&asesmen, c.Params("id")).Error; err != nil {
+			return newResponseError(fiber.StatusNotFound, "Asesmen not found", nil)
+		}
+		if asesmen.Status == "Diverifikasi" {
+			return newResponseError(fiber.StatusConflict, "Asesmen is already verified; ask Admin_Kecamatan to reject it first", nil)
+		}
+
+		req.BencanaID = asesmen.BencanaID // Asesmen tidak dapat dipindah ke bencana lain
+		if err := isiAsesmen(tx, &asesmen, req); err != nil {
+			return err
+		}
+		asesmen.Status = "Diajukan"
+		asesmen.CatatanVerifikasi = ""
+
+		// Rincian diganti seluruhnya
+		if err := tx.Where("asesmen_id = ?", asesmen.ID).Delete(&models.KerugianPenghidupan{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("asesmen_id = ?", asesmen.ID).Delete(&models.KebutuhanPascabencana{}).Error; err != nil {
+			return err
+		}
+		for i := range asesmen.Penghidupan {
+			asesmen.Penghidupan[i].ID = 0
+		}
+		for i := range asesmen.Kebutuhan {
+			asesmen.Kebutuhan[i].ID = 0
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&asesmen).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to update asesmen")
+	}
+
+	logActivity(userID, fmt.Sprintf("Memperbarui asesmen #%d", asesmen.ID))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Asesmen updated successfully",
+		"data":    asesmen,
+	})
+}
+
+// VerifikasiAsesmen verifies or rejects an assessment and re-sends the recap of its bencana to the kota
+func VerifikasiAsesmen(c *fiber.Ctx) error {
+	var req models.VerifikasiAsesmenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	if req.Status != "Diverifikasi" && req.Status != "Ditolak" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "status must be Diverifikasi or Ditolak",
+		})
+	}
+	if req.Status == "Ditolak" && strings.TrimSpace(req.Catatan) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "catatan is required when rejecting",
+		})
+	}
+
+	var asesmen models.AsesmenKerusakan
+	if err := database.DB.First(&asesmen, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Asesmen not found",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+	statusLama := asesmen.Status
+	asesmen.Status = req.Status
+	asesmen.CatatanVerifikasi = req.Catatan
+	asesmen.DiverifikasiOleh = &userID
+	asesmen.WaktuVerifikasi = &now
+	if err := database.DB.Save(&asesmen).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to verify asesmen",
+		})
+	}
+
+	// Rekap Kota hanya berisi asesmen terverifikasi
+	if statusLama == "Diverifikasi" || req.Status == "Diverifikasi" {
+		go publikasiRekapJitupasna(asesmen.BencanaID)
+	}
+
+	logActivity(userID, fmt.Sprintf("Asesmen #%d %s", asesmen.ID, strings.ToLower(req.Status)))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Asesmen " + strings.ToLower(req.Status),
+		"data":    asesmen,
+	})
+}
+
+// DeleteAsesmen removes an assessment that has not been verified
+func DeleteAsesmen(c *fiber.Ctx) error {
+	var asesmen models.AsesmenKerusakan
+	if err := database.DB.First(&asesmen, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Asesmen not found",
+		})
+	}
+	if asesmen.Status == "Diverifikasi" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Verified asesmen cannot be deleted; reject it first",
+		})
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("asesmen_id = ?", asesmen.ID).Delete(&models.KerugianPenghidupan{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("asesmen_id = ?", asesmen.ID).Delete(&models.KebutuhanPascabencana{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&asesmen).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete asesmen",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, fmt.Sprintf("Menghapus asesmen #%d", asesmen.ID))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Asesmen deleted successfully",
+	})
+}
+
+// GetRekapJitupasna returns the standard recap tables of one bencana.
+// Query: tabel=sektor (default) | rumah | kebutuhan, format=json | csv,
+// semua=true untuk ikut menghitung asesmen yang belum diverifikasi
+func GetRekapJitupasna(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid bencana ID",
+		})
+	}
+
+	var bencana models.KejadianBencana
+	if err := database.DB.First(&bencana, bencanaID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana not found",
+		})
+	}
+
+	status := []string{"Diverifikasi"}
+	if c.QueryBool("semua") {
+		status = append(status, "Diajukan")
+	}
+	var asesmen []models.AsesmenKerusakan
+	if err := database.DB.Where("bencana_id = ? AND status IN ?", bencana.ID, status).
+		Preload("Penghidupan").Preload("Kebutuhan").Find(&asesmen).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch asesmen",
+		})
+	}
+
+	var judul string
+	var tabel [][]string
+	var data interface{}
+	switch c.Query("tabel", "sektor") {
+	case "sektor":
+		rekap := services.RekapJitupasna(asesmen)
+		judul, data = "sektor", fiber.Map{"rincian": rekap, "total": services.TotalSektorJitupasna(rekap)}
+		tabel = tabelSektorJitupasna(rekap)
+	case "rumah":
+		rumah := rekapRumahPerWilayah(asesmen)
+		judul, data = "rumah", rumah
+		tabel = [][]string{{"RW", "RT", "Rumah Didata", "Tidak Rusak", "Rusak Ringan", "Rusak Sedang", "Rusak Berat", "Nilai Kerusakan (Rp)"}}
+		for _, r := range rumah {
+			tabel = append(tabel, []string{r.RW, r.RT, strconv.Itoa(r.Jumlah), strconv.Itoa(r.TidakRusak),
+				strconv.Itoa(r.RusakRingan), strconv.Itoa(r.RusakSedang), strconv.Itoa(r.RusakBerat), rupiah(r.NilaiKerusakan)})
+		}
+	case "kebutuhan":
+		kebutuhan := rekapKebutuhanPascabencana(asesmen)
+		judul, data = "kebutuhan", kebutuhan
+		tabel = [][]string{{"Sektor", "Subsektor", "Uraian", "Jumlah", "Satuan", "Jumlah Asesmen", "Prioritas Tinggi", "Estimasi Biaya (Rp)"}}
+		for _, k := range kebutuhan {
+			tabel = append(tabel, []string{k.Sektor, k.Subsektor, k.Uraian, strconv.FormatFloat(k.Jumlah, 'f', -1, 64), k.Satuan,
+				strconv.Itoa(k.JumlahAsesmen), strconv.Itoa(k.PrioritasTinggi), rupiah(k.EstimasiBiaya)})
+		}
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "tabel must be sektor, rumah or kebutuhan",
+		})
+	}
+
+	if c.Query("format") == "csv" {
+		return kirimCSV(c, fmt.Sprintf("jitupasna-%s-bencana-%d.csv", judul, bencana.ID), tabel)
+	}
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"bencana": bencana,
+		"jumlah":  len(asesmen),
+		"data":    data,
+	})
+}
+
+// rekapRumahJitupasna is one row of the damaged houses table
+type rekapRumahJitupasna struct {
+	RT             string  `json:"rt"`
+	RW             string  `json:"rw"`
+	Jumlah         int     `json:"jumlah"`
+	TidakRusak     int     `json:"tidak_rusak"`
+	RusakRingan    int     `json:"rusak_ringan"`
+	RusakSedang    int     `json:"rusak_sedang"`
+	RusakBerat     int     `json:"rusak_berat"`
+	NilaiKerusakan float64 `json:"nilai_kerusakan"`
+}
+
+// rekapRumahPerWilayah counts assessed houses per RT/RW and damage level
+func rekapRumahPerWilayah(asesmen []models.AsesmenKerusakan) []rekapRumahJitupasna {
+	indeks := map[[2]string]*rekapRumahJitupasna{}
+	for _, a := range asesmen {
+		if a.Objek != "Rumah" {
+			continue
+		}
+		kunci := [2]string{a.RW, a.RT}
+		r, ok := indeks[kunci]
+		if !ok {
+			r = &rekapRumahJitupasna{RT: a.RT, RW: a.RW}
+			indeks[kunci] = r
+		}
+		r.Jumlah++
+		r.NilaiKerusakan += a.NilaiKerusakan
+		switch a.TingkatKerusakan {
+		case "Ringan":
+			r.RusakRingan++
+		case "Sedang":
+			r.RusakSedang++
+		case "Berat":
+			r.RusakBerat++
+		default:
+			r.TidakRusak++
+		}
+	}
+
+	hasil := make([]rekapRumahJitupasna, 0, len(indeks))
+	for _, r := range indeks {
+		hasil = append(hasil, *r)
+	}
+	sort.Slice(hasil, func(i, j int) bool {
+		if hasil[i].RW != hasil[j].RW {
+			return hasil[i].RW < hasil[j].RW
+		}
+		return hasil[i].RT < hasil[j].RT
+	})
+	return hasil
+}
+
+// rekapKebutuhanJitupasna is one row of the recovery needs table
+type rekapKebutuhanJitupasna struct {
+	Sektor          string  `json:"sektor"`
+	Subsektor       string  `json:"subsektor"`
+	Uraian          string  `json:"uraian"`
+	Satuan          string  `json:"satuan"`
+	Jumlah          float64 `json:"jumlah"`
+	JumlahAsesmen   int     `json:"jumlah_asesmen"`
+	PrioritasTinggi int     `json:"prioritas_tinggi"`
+	EstimasiBiaya   float64 `json:"estimasi_biaya"`
+}
+
+// rekapKebutuhanPascabencana groups recovery needs per sector, subsector, uraian and satuan
+func rekapKebutuhanPascabencana(asesmen []models.AsesmenKerusakan) []rekapKebutuhanJitupasna {
+	indeks := map[[4]string]*rekapKebutuhanJitupasna{}
+	for _, a := range asesmen {
+		for _, k := range a.Kebutuhan {
+			uraian := strings.TrimSpace(k.Uraian)
+			kunci := [4]string{k.Sektor, k.Subsektor, strings.ToLower(uraian), strings.ToLower(k.Satuan)}
+			r, ok := indeks[kunci]
+			if !ok {
+				r = &rekapKebutuhanJitupasna{Sektor: k.Sektor, Subsektor: k.Subsektor, Uraian: uraian, Satuan: k.Satuan}
+				indeks[kunci] = r
+			}
+			r.Jumlah += k.Jumlah
+			r.JumlahAsesmen++
+			r.EstimasiBiaya += k.EstimasiBiaya
+			if k.Prioritas == "Tinggi" {
+				r.PrioritasTinggi++
+			}
+		}
+	}
+
+	hasil := make([]rekapKebutuhanJitupasna, 0, len(indeks))
+	for _, r := range indeks {
+		hasil = append(hasil, *r)
+	}
+	sort.Slice(hasil, func(i, j int) bool {
+		if hasil[i].Sektor != hasil[j].Sektor {
+			return urutanSektor(hasil[i].Sektor) < urutanSektor(hasil[j].Sektor)
+		}
+		if hasil[i].Subsektor != hasil[j].Subsektor {
+			return hasil[i].Subsektor < hasil[j].Subsektor
+		}
+		return hasil[i].EstimasiBiaya > hasil[j].EstimasiBiaya
+	})
+	return hasil
+}
+
+// publikasiRekapJitupasna sends the verified recap of a bencana to the kota.
+// Rekap dikirim utuh sehingga Kota cukup mengganti baris lamanya.
+func publikasiRekapJitupasna(bencanaID uint) {
+	var bencana models.KejadianBencana
+	if err := database.DB.First(&bencana, bencanaID).Error; err != nil {
+		return
+	}
+	var asesmen []models.AsesmenKerusakan
+	database.DB.Where("bencana_id = ? AND status = ?", bencanaID, "Diverifikasi").
+		Preload("Penghidupan").Preload("Kebutuhan").Find(&asesmen)
+
+	messaging.PublishEvent("REKAP_JITUPASNA", kecamatanID(), models.RekapJitupasnaEvent{
+		BencanaID:    bencana.ID,
+		JenisBencana: bencana.JenisBencana,
+		KodeJenis:    bencana.KodeJenis,
+		WaktuMulai:   bencana.WaktuMulai,
+		Rekap:        services.RekapJitupasna(asesmen),
+		Waktu:        time.Now(),
+	})
+}
+
+// isiAsesmen copies the request into asesmen, mengisi data rumah dari KK, lalu memvalidasi
+func isiAsesmen(tx *gorm.DB, asesmen *models.AsesmenKerusakan, req models.AsesmenKerusakanRequest) error {
+	asesmen.BencanaID = req.BencanaID
+	asesmen.Objek = req.Objek
+	asesmen.KartuKeluargaID = req.KartuKeluargaID
+	asesmen.JenisFasilitas = req.JenisFasilitas
+	asesmen.NamaObjek = strings.TrimSpace(req.NamaObjek)
+	asesmen.Alamat = req.Alamat
+	asesmen.RT = strings.TrimSpace(req.RT)
+	asesmen.RW = strings.TrimSpace(req.RW)
+	asesmen.Latitude = req.Latitude
+	asesmen.Longitude = req.Longitude
+	asesmen.TingkatKerusakan = req.TingkatKerusakan
+	asesmen.NilaiKerusakan = req.NilaiKerusakan
+	asesmen.NilaiKerugian = req.NilaiKerugian
+	asesmen.Catatan = req.Catatan
+	asesmen.Penghidupan = req.Penghidupan
+	asesmen.Kebutuhan = req.Kebutuhan
+	if asesmen.TingkatKerusakan == "" {
+		asesmen.TingkatKerusakan = "Tidak Rusak"
+	}
+	if asesmen.Objek != "Fasilitas Umum" {
+		asesmen.JenisFasilitas = ""
+	}
+
+	if err := services.KlasifikasiAsesmen(asesmen); err != nil {
+		return newResponseError(fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	if asesmen.KartuKeluargaID != nil {
+		var kk models.KartuKeluarga
+		if err := tx.First(&kk, *asesmen.KartuKeluargaID).Error; err != nil {
+			return newResponseError(fiber.StatusNotFound, "Kartu keluarga not found", fiber.Map{"field": "kartu_keluarga_id"})
+		}
+		if asesmen.NamaObjek == "" {
+			asesmen.NamaObjek = kk.KepalaKeluarga
+		}
+		if asesmen.Alamat == "" {
+			asesmen.Alamat = kk.Alamat
+		}
+		if asesmen.RT == "" && asesmen.RW == "" {
+			asesmen.RT, asesmen.RW = kk.RT, kk.RW
+		}
+		if !services.AdaKoordinat(asesmen.Latitude, asesmen.Longitude) {
+			asesmen.Latitude, asesmen.Longitude = kk.Latitude, kk.Longitude
+		}
+	}
+
+	// Satu rumah tangga hanya dinilai sekali per bencana (asesmen ditolak tidak dihitung)
+	if asesmen.Objek == "Rumah" {
+		var lain models.AsesmenKerusakan
+		err := tx.Where("bencana_id = ? AND objek = ? AND kartu_keluarga_id = ? AND status <> ? AND id <> ?",
+			asesmen.BencanaID, "Rumah", *asesmen.KartuKeluargaID, "Ditolak", asesmen.ID).First(&lain).Error
+		if err == nil {
+			return newResponseError(fiber.StatusConflict, "This household already has a house assessment for the bencana", fiber.Map{"asesmen_id": lain.ID})
+		}
+	}
+	return nil
+}
+
+// tabelSektorJitupasna lays out the per-sector recap with subtotal rows per sector
+func tabelSektorJitupasna(rekap []models.RekapJitupasnaItem) [][]string {
+	tabel := [][]string{{"Sektor", "Subsektor", "Jumlah Objek", "Jumlah KK", "Rusak Ringan", "Rusak Sedang", "Rusak Berat",
+		"Pekerja Terdampak", "Kerusakan (Rp)", "Kerugian (Rp)", "Kebutuhan (Rp)"}}
+	baris := func(sektor, subsektor string, r models.RekapJitupasnaItem) []string {
+		return []string{sektor, subsektor, strconv.Itoa(r.JumlahObjek), strconv.Itoa(r.JumlahKK), strconv.Itoa(r.RusakRingan),
+			strconv.Itoa(r.RusakSedang), strconv.Itoa(r.RusakBerat), strconv.Itoa(r.Pekerja),
+			rupiah(r.NilaiKerusakan), rupiah(r.NilaiKerugian), rupiah(r.NilaiKebutuhan)}
+	}
+
+	total := services.TotalSektorJitupasna(rekap)
+	for _, t := range total[:len(total)-1] {
+		for _, r := range rekap {
+			if r.Sektor == t.Sektor {
+				tabel = append(tabel, baris(r.Sektor, r.Subsektor, r))
+			}
+		}
+		tabel = append(tabel, baris(t.Sektor, "Subtotal", t))
+	}
+	return append(tabel, baris("Total", "", total[len(total)-1]))
+}
+
+// kirimCSV writes rows as a downloadable CSV file
+func kirimCSV(c *fiber.Ctx, namaBerkas string, baris [][]string) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(baris); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to write CSV",
+		})
+	}
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+namaBerkas+`"`)
+	return c.Send(buf.Bytes())
+}
+
+func rupiah(nilai float64) string {
+	return strconv.FormatFloat(nilai, 'f', 0, 64)
+}
+
+func urutanSektor(sektor string) int {
+	for i, s := range services.UrutanSektorJitupasna {
+		if s == sektor {
+			return i
+		}
+	}
+	return len(services.UrutanSektorJitupasna)
+}
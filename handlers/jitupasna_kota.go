@@ -0,0 +1,148 @@
+// handlers/jitupasna_kota.go
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// rekapJitupasnaWilayah is one row of the per-kecamatan or per-bencana recap
+type rekapJitupasnaWilayah struct {
+	KecamatanID   uint   `json:"kecamatan_id"`
+	NamaKecamatan string `json:"nama_kecamatan"`
+	BencanaID     uint   `json:"bencana_id,omitempty"`
+	JenisBencana  string `json:"jenis_bencana,omitempty"`
+	WaktuMulai    string `json:"waktu_mulai,omitempty"`
+	models.RekapJitupasnaItem
+}
+
+// GetRekapJitupasnaKota returns the city-wide damage and needs recap for regional government.
+// Query: tabel=sektor (default) | kecamatan | bencana, format=json | csv,
+// kecamatan_id, bencana_id (bersama kecamatan_id), kode_jenis, tahun
+// FUNGSI INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA (diisi Sync Worker)
+func GetRekapJitupasnaKota(c *fiber.Ctx) error {
+	query := database.DB.Preload("Kecamatan")
+	if kecamatanID := c.QueryInt("kecamatan_id", 0); kecamatanID > 0 {
+		query = query.Where("kecamatan_id = ?", kecamatanID)
+		if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+			query = query.Where("bencana_id = ?", bencanaID)
+		}
+	}
+	if kode := c.Query("kode_jenis"); kode != "" {
+		query = query.Where("kode_jenis = ?", kode)
+	}
+	if tahun := c.QueryInt("tahun", 0); tahun > 0 {
+		query = query.Where("YEAR(waktu_mulai) = ?", tahun)
+	}
+
+	var baris []models.RekapJitupasnaKota
+	if err := query.Order("kecamatan_id, bencana_id").Find(&baris).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch rekap jitupasna",
+		})
+	}
+
+	tabel := c.Query("tabel", "sektor")
+	var data interface{}
+	var csvBaris [][]string
+	switch tabel {
+	case "sektor":
+		indeks := map[[2]string]*models.RekapJitupasnaItem{}
+		for _, r := range baris {
+			kunci := [2]string{r.Sektor, r.Subsektor}
+			item, ok := indeks[kunci]
+			if !ok {
+				item = &models.RekapJitupasnaItem{Sektor: r.Sektor, Subsektor: r.Subsektor}
+				indeks[kunci] = item
+			}
+			tambahRekapJitupasna(item, r)
+		}
+		rekap := make([]models.RekapJitupasnaItem, 0, len(indeks))
+		for _, item := range indeks {
+			rekap = append(rekap, *item)
+		}
+		services.UrutkanRekapJitupasna(rekap)
+		data = fiber.Map{"rincian": rekap, "total": services.TotalSektorJitupasna(rekap)}
+		csvBaris = tabelSektorJitupasna(rekap)
+	case "kecamatan", "bencana":
+		perBencana := tabel == "bencana"
+		indeks := map[[2]uint]*rekapJitupasnaWilayah{}
+		for _, r := range baris {
+			kunci := [2]uint{r.KecamatanID, 0}
+			if perBencana {
+				kunci[1] = r.BencanaID
+			}
+			item, ok := indeks[kunci]
+			if !ok {
+				item = &rekapJitupasnaWilayah{KecamatanID: r.KecamatanID, NamaKecamatan: r.Kecamatan.Nama}
+				if perBencana {
+					item.BencanaID = r.BencanaID
+					item.JenisBencana = r.JenisBencana
+					item.WaktuMulai = r.WaktuMulai.Format("2006-01-02")
+				}
+				indeks[kunci] = item
+			}
+			kk := item.JumlahKK
+			tambahRekapJitupasna(&item.RekapJitupasnaItem, r)
+			// KK dihitung dari rumah saja agar KK yang juga punya usaha tidak terhitung dua kali
+			if r.Subsektor != "Perumahan" {
+				item.JumlahKK = kk
+			}
+		}
+		rekap := make([]rekapJitupasnaWilayah, 0, len(indeks))
+		for _, item := range indeks {
+			rekap = append(rekap, *item)
+		}
+		sort.Slice(rekap, func(i, j int) bool {
+			if rekap[i].NamaKecamatan != rekap[j].NamaKecamatan {
+				return rekap[i].NamaKecamatan < rekap[j].NamaKecamatan
+			}
+			return rekap[i].WaktuMulai < rekap[j].WaktuMulai
+		})
+		data = rekap
+
+		csvBaris = [][]string{{"Kecamatan", "Bencana", "Jenis Bencana", "Tanggal", "Jumlah Objek", "KK Rumah", "Rusak Ringan", "Rusak Sedang",
+			"Rusak Berat", "Pekerja Terdampak", "Kerusakan (Rp)", "Kerugian (Rp)", "Kebutuhan (Rp)"}}
+		for _, r := range rekap {
+			bencana := ""
+			if r.BencanaID > 0 {
+				bencana = strconv.FormatUint(uint64(r.BencanaID), 10)
+			}
+			csvBaris = append(csvBaris, []string{r.NamaKecamatan, bencana, r.JenisBencana, r.WaktuMulai,
+				strconv.Itoa(r.JumlahObjek), strconv.Itoa(r.JumlahKK), strconv.Itoa(r.RusakRingan), strconv.Itoa(r.RusakSedang), strconv.Itoa(r.RusakBerat),
+				strconv.Itoa(r.Pekerja), rupiah(r.NilaiKerusakan), rupiah(r.NilaiKerugian), rupiah(r.NilaiKebutuhan)})
+		}
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "tabel must be sektor, kecamatan or bencana",
+		})
+	}
+
+	if c.Query("format") == "csv" {
+		return kirimCSV(c, fmt.Sprintf("jitupasna-kota-%s.csv", tabel), csvBaris)
+	}
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  data,
+	})
+}
+
+func tambahRekapJitupasna(item *models.RekapJitupasnaItem, r models.RekapJitupasnaKota) {
+	item.JumlahObjek += r.JumlahObjek
+	item.JumlahKK += r.JumlahKK
+	item.RusakRingan += r.RusakRingan
+	item.RusakSedang += r.RusakSedang
+	item.RusakBerat += r.RusakBerat
+	item.Pekerja += r.Pekerja
+	item.NilaiKerusakan += r.NilaiKerusakan
+	item.NilaiKerugian += r.NilaiKerugian
+	item.NilaiKebutuhan += r.NilaiKebutuhan
+}
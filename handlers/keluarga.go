@@ -0,0 +1,578 @@
+// handlers/keluarga.go
+package handlers
+
+import (
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// keluargaListSpec defines sorting and free-text search for the household list
+var keluargaListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":              "id",
+		"kepala_keluarga": "kepala_keluarga",
+		"no_kk":           "no_kk",
+		"rt":              "rt",
+		"rw":              "rw",
+		"created_at":      "created_at",
+	},
+	DefaultSort: "rw,rt,kepala_keluarga",
+	Search:      []string{"kepala_keluarga LIKE ?", "alamat LIKE ?", "no_kk LIKE ?"},
+}
+
+// GetAllKeluarga returns households with optional RT/RW filters, paginated (see list_query.go)
+func GetAllKeluarga(c *fiber.Ctx) error {
+	query := database.DB
+
+	if rt := c.Query("rt"); rt != "" {
+		query = query.Where("rt = ?", rt)
+	}
+	if rw := c.Query("rw"); rw != "" {
+		query = query.Where("rw = ?", rw)
+	}
+
+	return listPage[models.KartuKeluarga](c, query, keluargaListSpec, "Failed to fetch keluarga data", "Anggota")
+}
+
+// GetKeluargaByID returns a household with its members
+func GetKeluargaByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var keluarga models.KartuKeluarga
+	if err := database.DB.Preload("Anggota").First(&keluarga, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Keluarga not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  keluarga,
+	})
+}
+
+// CreateKeluarga creates a new household
+func CreateKeluarga(c *fiber.Ctx) error {
+	var req models.CreateKeluargaRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.KepalaKeluarga == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Missing required fields",
+		})
+	}
+
+	if err := services.ValidateNoKK(req.NoKK, os.Getenv("KODE_WILAYAH")); err != nil {
+		log.Printf("⚠️ Nomor KK ditolak: %v", err)
+		message := "Nomor KK must be 16 digits"
+		if errors.Is(err, services.ErrNIKWilayah) {
+			message = "Nomor KK region code does not belong to this kecamatan"
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+			"field":   "no_kk",
+		})
+	}
+
+	var existing models.KartuKeluarga
+	if err := database.DB.Unscoped().Where("no_kk = ?", req.NoKK).First(&existing).Error; err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Nomor KK already registered",
+			"existing": fiber.Map{
+				"id":              existing.ID,
+				"kepala_keluarga": existing.KepalaKeluarga,
+				"alamat":          existing.Alamat,
+				"deleted":         existing.DeletedAt.Valid,
+			},
+		})
+	}
+
+	keluarga := models.KartuKeluarga{
+		NoKK:           req.NoKK,
+		KepalaKeluarga: req.KepalaKeluarga,
+		Alamat:         req.Alamat,
+		RT:             req.RT,
+		RW:             req.RW,
+		Latitude:       req.Latitude,
+		Longitude:      req.Longitude,
+	}
+
+	if err := database.DB.Create(&keluarga).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create keluarga",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Menambahkan kartu keluarga: "+keluarga.NoKK)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Keluarga created successfully",
+		"data":    keluarga,
+	})
+}
+
+// UpdateKeluarga updates a household and moves its members to the new location
+func UpdateKeluarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var keluarga models.KartuKeluarga
+	if err := database.DB.First(&keluarga, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Keluarga not found",
+		})
+	}
+
+	var req models.CreateKeluargaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.NoKK != keluarga.NoKK {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Nomor KK cannot be changed",
+			"field":   "no_kk",
+		})
+	}
+
+	keluarga.KepalaKeluarga = req.KepalaKeluarga
+	keluarga.Alamat = req.Alamat
+	keluarga.RT = req.RT
+	keluarga.RW = req.RW
+	keluarga.Latitude = req.Latitude
+	keluarga.Longitude = req.Longitude
+
+	userID := c.Locals("userID").(uint)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&keluarga).Error; err != nil {
+			return err
+		}
+		// Anggota selalu mengikuti lokasi rumahnya
+		return updateAnggotaKeluarga(tx, keluarga.ID, lokasiKeluarga(keluarga), userID)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update keluarga",
+		})
+	}
+
+	logActivity(userID, "Mengupdate kartu keluarga: "+keluarga.NoKK)
+
+	database.DB.Preload("Anggota").First(&keluarga, keluarga.ID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Keluarga updated successfully",
+		"data":    keluarga,
+	})
+}
+
+// DeleteKeluarga soft deletes a household; its members are kept but unlinked
+func DeleteKeluarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var keluarga models.KartuKeluarga
+	if err := database.DB.First(&keluarga, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Keluarga not found",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := updateAnggotaKeluarga(tx, keluarga.ID, map[string]interface{}{"kartu_keluarga_id": nil}, userID); err != nil {
+			return err
+		}
+		return tx.Delete(&keluarga).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete keluarga",
+		})
+	}
+
+	logActivity(userID, "Menghapus kartu keluarga: "+keluarga.NoKK)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Keluarga deleted successfully",
+	})
+}
+
+// AddAnggotaKeluarga links an existing warga to a household
+func AddAnggotaKeluarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req struct {
+		WargaID uint `json:"warga_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	var keluarga models.KartuKeluarga
+	if err := database.DB.First(&keluarga, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Keluarga not found",
+		})
+	}
+
+	var warga models.WargaRentan
+	if err := database.DB.First(&warga, req.WargaID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warga not found",
+		})
+	}
+
+	if warga.KartuKeluargaID != nil && *warga.KartuKeluargaID != keluarga.ID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warga already belongs to another keluarga",
+			"existing": fiber.Map{
+				"kartu_keluarga_id": *warga.KartuKeluargaID,
+			},
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	updates := lokasiKeluarga(keluarga)
+	updates["kartu_keluarga_id"] = keluarga.ID
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return updateWargaTercatat(tx, warga.ID, updates, userID)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to add anggota keluarga",
+		})
+	}
+
+	logActivity(userID, "Menambahkan "+warga.Nama+" ke kartu keluarga: "+keluarga.NoKK)
+
+	database.DB.Preload("Anggota").First(&keluarga, keluarga.ID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Anggota added successfully",
+		"data":    keluarga,
+	})
+}
+
+// RemoveAnggotaKeluarga unlinks a warga from a household
+func RemoveAnggotaKeluarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+	wargaID, err := strconv.Atoi(c.Params("warga_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid warga ID",
+		})
+	}
+
+	var warga models.WargaRentan
+	if err := database.DB.Where("kartu_keluarga_id = ?", id).First(&warga, wargaID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Anggota not found in this keluarga",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return updateWargaTercatat(tx, warga.ID, map[string]interface{}{"kartu_keluarga_id": nil}, userID)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to remove anggota keluarga",
+		})
+	}
+
+	logActivity(userID, "Mengeluarkan "+warga.Nama+" dari kartu keluarga")
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Anggota removed successfully",
+	})
+}
+
+// GetPrioritasKeluarga returns the evacuation priority list grouped per household
+func GetPrioritasKeluarga(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid bencana ID",
+		})
+	}
+
+	var bencana models.KejadianBencana
+	if err := database.DB.First(&bencana, bencanaID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana not found",
+		})
+	}
+
+	var warga []models.WargaRentan
+	if err := preloadKeselamatan(preloadPaparan(queryWargaTerdampak(bencana), bencana.JenisBencana), bencana.ID).
+		Order("skor_prioritas DESC, nama ASC").
+		Find(&warga).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch evacuation priority",
+		})
+	}
+
+	// Kelompokkan warga rentan per rumah tangga
+	type prioritasKeluarga struct {
+		Keluarga      models.KartuKeluarga `json:"keluarga"`
+		AnggotaRentan []models.WargaRentan `json:"anggota_rentan"`
+		SkorPrioritas int                  `json:"skor_prioritas"`
+		// Kelas risiko zona bahaya tertinggi di antara anggota rentan (3 = Tinggi, 0 = di luar zona)
+		PeringkatPaparan int `json:"peringkat_paparan"`
+		// Peringkat keselamatan tertinggi anggota rentan (2 = meminta bantuan, 1 = tidak membalas peringatan)
+		PeringkatKeselamatan int `json:"peringkat_keselamatan"`
+	}
+
+	perKeluarga := make(map[uint]*prioritasKeluarga)
+	var tanpaKK []models.WargaRentan
+	var keluargaIDs []uint
+	for _, w := range warga {
+		if w.KartuKeluargaID == nil {
+			tanpaKK = append(tanpaKK, w)
+			continue
+		}
+		p, ok := perKeluarga[*w.KartuKeluargaID]
+		if !ok {
+			p = &prioritasKeluarga{}
+			perKeluarga[*w.KartuKeluargaID] = p
+			keluargaIDs = append(keluargaIDs, *w.KartuKeluargaID)
+		}
+		p.AnggotaRentan = append(p.AnggotaRentan, w)
+	}
+
+	var keluarga []models.KartuKeluarga
+	if len(keluargaIDs) > 0 {
+		database.DB.Preload("Anggota").Find(&keluarga, keluargaIDs)
+	}
+
+	hasil := make([]prioritasKeluarga, 0, len(keluarga))
+	for _, k := range keluarga {
+		p := perKeluarga[k.ID]
+		skor := make([]int, 0, len(p.AnggotaRentan))
+		for _, w := range p.AnggotaRentan {
+			skor = append(skor, w.SkorPrioritas)
+		}
+		p.Keluarga = k
+		p.SkorPrioritas = services.SkorPrioritasKeluarga(skor)
+		for _, w := range p.AnggotaRentan {
+			if r := peringkatPaparan(w); r > p.PeringkatPaparan {
+				p.PeringkatPaparan = r
+			}
+			if r := peringkatKeselamatanWarga(w); r > p.PeringkatKeselamatan {
+				p.PeringkatKeselamatan = r
+			}
+		}
+		hasil = append(hasil, *p)
+	}
+	sort.SliceStable(hasil, func(i, j int) bool {
+		if hasil[i].PeringkatKeselamatan != hasil[j].PeringkatKeselamatan {
+			return hasil[i].PeringkatKeselamatan > hasil[j].PeringkatKeselamatan
+		}
+		if hasil[i].PeringkatPaparan != hasil[j].PeringkatPaparan {
+			return hasil[i].PeringkatPaparan > hasil[j].PeringkatPaparan
+		}
+		return hasil[i].SkorPrioritas > hasil[j].SkorPrioritas
+	})
+	urutkanMenurutPaparan(tanpaKK)
+	urutkanMenurutKeselamatan(tanpaKK)
+
+	return c.JSON(fiber.Map{
+		"error":        false,
+		"bencana":      bencana,
+		"prioritas":    hasil,
+		"tanpa_kk":     tanpaKK,
+		"perlengkapan": rekapPerlengkapan(warga),
+		"total":        len(hasil) + len(tanpaKK),
+	})
+}
+
+// CreateLogEvakuasiKeluarga records one relawan visit that evacuates a whole household.
+// Every member's log is created (or moved to the new status) in one transaction,
+// so either the whole house is updated or nothing is.
+func CreateLogEvakuasiKeluarga(c *fiber.Ctx) error {
+	var req models.LogEvakuasiKeluargaRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	var keluarga models.KartuKeluarga
+	if err := database.DB.Preload("Anggota").First(&keluarga, req.KartuKeluargaID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Keluarga not found",
+		})
+	}
+
+	if len(keluarga.Anggota) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Keluarga has no anggota",
+		})
+	}
+
+	relawanID := c.Locals("userID").(uint)
+
+	logs := make([]models.LogEvakuasi, 0, len(keluarga.Anggota))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := cekBencanaAktif(tx, req.BencanaID); err != nil {
+			return err
+		}
+
+		for _, anggota := range keluarga.Anggota {
+			log, err := lockLogEvakuasi(tx, "bencana_id = ? AND warga_id = ?", req.BencanaID, anggota.ID)
+			switch {
+			case err == nil:
+				err = ubahStatusEvakuasi(tx, log, req.StatusTerkini, relawanID, req.Catatan)
+				if err == nil && req.StatusTerkini == services.StatusDiTitikKumpul {
+					err = tempatkanDiTitikKumpul(tx, log, req.TitikKumpulID, relawanID, c.QueryBool("force"))
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				log = &models.LogEvakuasi{
+					BencanaID:       req.BencanaID,
+					WargaID:         anggota.ID,
+					KartuKeluargaID: &keluarga.ID,
+					RelawanID:       relawanID,
+					StatusTerkini:   req.StatusTerkini,
+				}
+				err = buatLogEvakuasi(tx, log, relawanID, req.Catatan)
+			}
+			if err != nil {
+				var respErr *responseError
+				if errors.As(err, &respErr) {
+					respErr.body["warga_id"] = anggota.ID
+					respErr.body["nama"] = anggota.Nama
+				}
+				return err
+			}
+			logs = append(logs, *log)
+		}
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to create evacuation log")
+	}
+
+	logActivity(relawanID, "Update status evakuasi keluarga: "+keluarga.NoKK)
+
+	if req.StatusTerkini == services.StatusDiTitikKumpul && req.TitikKumpulID != nil {
+		go publishOkupansiTitikKumpul(*req.TitikKumpulID)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Evacuation log created successfully",
+		"data":    logs,
+		"total":   len(logs),
+	})
+}
+
+// updateAnggotaKeluarga applies the same update to every member of a household,
+// recording each change in the member's history
+func updateAnggotaKeluarga(tx *gorm.DB, keluargaID uint, updates map[string]interface{}, userID uint) error {
+	var anggotaIDs []uint
+	if err := tx.Model(&models.WargaRentan{}).Where("kartu_keluarga_id = ?", keluargaID).Pluck("id", &anggotaIDs).Error; err != nil {
+		return err
+	}
+
+	for _, wargaID := range anggotaIDs {
+		if err := updateWargaTercatat(tx, wargaID, updates, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lokasiKeluarga returns the location columns a member inherits from its household
+func lokasiKeluarga(k models.KartuKeluarga) map[string]interface{} {
+	return map[string]interface{}{
+		"alamat":    k.Alamat,
+		"rt":        k.RT,
+		"rw":        k.RW,
+		"latitude":  k.Latitude,
+		"longitude": k.Longitude,
+	}
+}
@@ -0,0 +1,502 @@
+// handlers/keselamatan.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Roster keselamatan: setiap penerima notifikasi darurat mendapat tautan bertoken dan
+// dapat membalas AMAN / TOLONG / OK lewat SMS atau WhatsApp. Warga rentan yang belum
+// merespons dalam KESELAMATAN_BATAS_MENIT (default 30) ditandai dan didahulukan di daftar
+// prioritas evakuasi; warga yang meminta bantuan selalu paling atas.
+
+// urutanStatusKeselamatan orders the roster: yang paling perlu ditindaklanjuti dulu
+var urutanStatusKeselamatan = map[string]int{
+	services.KeselamatanButuhBantuan: 0,
+	services.KeselamatanBelum:        1,
+	services.KeselamatanDiterima:     2,
+	services.KeselamatanAman:         3,
+}
+
+// tautanKeselamatan returns the link put in the alert (TAUTAN_KESELAMATAN_URL + token)
+func tautanKeselamatan(token string) string {
+	dasar := os.Getenv("TAUTAN_KESELAMATAN_URL")
+	if dasar == "" {
+		dasar = "http://localhost:3001/api/v1/keselamatan/"
+	}
+	return strings.TrimRight(dasar, "/") + "/" + token
+}
+
+// kirimPeringatanKeselamatan sends the alert to every warga with its own reply link and
+// adds them to the roster of the bencana. Returns the number of messages sent.
+func kirimPeringatanKeselamatan(bencana models.KejadianBencana, notifikasi models.NotifikasiBencana, warga []models.WargaRentan) int {
+	now := time.Now()
+	terkirim := 0
+	for _, w := range warga {
+		hp := services.NormalisasiNoHP(w.NoHP)
+		if hp == "" {
+			continue
+		}
+		token, hash, err := services.BuatTokenKeselamatan()
+		if err != nil {
+			log.Printf("❌ Gagal membuat token keselamatan: %v", err)
+			continue
+		}
+
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&models.PenerimaNotifikasi{
+				NotifikasiID: notifikasi.ID,
+				BencanaID:    bencana.ID,
+				WargaID:      w.ID,
+				NoHP:         hp,
+				TokenHash:    hash,
+			}).Error; err != nil {
+				return err
+			}
+			// Warga yang sudah ada di roster tetap dengan status & waktu peringatan pertamanya
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.StatusKeselamatan{
+				BencanaID:       bencana.ID,
+				WargaID:         w.ID,
+				Status:          services.KeselamatanBelum,
+				WaktuNotifikasi: now,
+			}).Error
+		})
+		if err != nil {
+			log.Printf("❌ Gagal mencatat penerima notifikasi warga #%d: %v", w.ID, err)
+			continue
+		}
+
+		kirimPesan("WhatsApp", hp, fmt.Sprintf("%s\n\nBalas AMAN bila Anda aman atau TOLONG bila butuh bantuan, atau buka %s",
+			notifikasi.Pesan, tautanKeselamatan(token)))
+		terkirim++
+	}
+	return terkirim
+}
+
+// GetTautanKeselamatan shows the alert behind a reply link (publik, tanpa mengubah apa pun:
+// pratinjau tautan WhatsApp tidak boleh terhitung sebagai konfirmasi)
+func GetTautanKeselamatan(c *fiber.Ctx) error {
+	penerima, err := cariPenerimaToken(c.Params("token"))
+	if err != nil {
+		return writeError(c, err, "Failed to fetch link")
+	}
+
+	var notifikasi models.NotifikasiBencana
+	var bencana models.KejadianBencana
+	var warga models.WargaRentan
+	var roster models.StatusKeselamatan
+	database.DB.First(&notifikasi, penerima.NotifikasiID)
+	database.DB.First(&bencana, penerima.BencanaID)
+	database.DB.Unscoped().Select("id", "nama").First(&warga, penerima.WargaID)
+	database.DB.Where("bencana_id = ? AND warga_id = ?", penerima.BencanaID, penerima.WargaID).First(&roster)
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"jenis_bencana":  bencana.JenisBencana,
+			"status_bencana": bencana.Status,
+			"pesan":          notifikasi.Pesan,
+			"waktu":          notifikasi.Waktu,
+			"nama":           warga.Nama,
+			"status":         roster.Status,
+			"diterima_pada":  penerima.DiterimaPada,
+			"pilihan":        []string{services.KeselamatanDiterima, services.KeselamatanAman, services.KeselamatanButuhBantuan},
+		},
+	})
+}
+
+// ResponTautanKeselamatan records a resident's answer through the reply link (publik)
+func ResponTautanKeselamatan(c *fiber.Ctx) error {
+	var req models.ResponKeselamatanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	status := services.NormalisasiStatusKeselamatan(req.Status)
+	if status == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "status must be Diterima, Aman or Butuh Bantuan",
+			"field":   "status",
+		})
+	}
+
+	penerima, err := cariPenerimaToken(c.Params("token"))
+	if err != nil {
+		return writeError(c, err, "Failed to record response")
+	}
+	roster, err := catatResponKeselamatan([]models.PenerimaNotifikasi{*penerima}, status, "Tautan", strings.TrimSpace(req.Catatan))
+	if err != nil {
+		return writeError(c, err, "Failed to record response")
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Response recorded",
+		"data":    fiber.Map{"status": roster[0].Status},
+	})
+}
+
+// pesanKeselamatan answers AMAN / TOLONG / OK sent by SMS or WhatsApp. Balasan berlaku untuk
+// notifikasi darurat terakhir ke nomor pengirim (semua warga yang memakai nomor itu).
+func pesanKeselamatan(status string) penangananPesan {
+	return func(pesan pesanMasuk, argumen string) string {
+		// Nomor pengirim webhook tanpa autentikasi bisa dipalsukan
+		if !pesan.Terverifikasi {
+			return "Balasan ini tidak dapat diverifikasi. Gunakan tautan pada pesan peringatan untuk mengabarkan kondisi Anda."
+		}
+
+		aktif := database.DB.Model(&models.KejadianBencana{}).Select("id").Where("status = ?", "Aktif")
+		var terakhir models.PenerimaNotifikasi
+		if err := database.DB.Where("no_hp = ? AND bencana_id IN (?)", pesan.Dari, aktif).
+			Order("created_at DESC, id DESC").
+			First(&terakhir).Error; err != nil {
+			return "Tidak ada peringatan aktif untuk nomor ini. Untuk melaporkan bahaya, kirim LAPOR <jenis> <keterangan>."
+		}
+		var penerima []models.PenerimaNotifikasi
+		database.DB.Where("notifikasi_id = ? AND no_hp = ?", terakhir.NotifikasiID, pesan.Dari).Find(&penerima)
+
+		catatan := argumen
+		if services.AdaKoordinat(pesan.Latitude, pesan.Longitude) {
+			catatan = strings.TrimSpace(fmt.Sprintf("%s (lokasi %.6f,%.6f)", catatan, pesan.Latitude, pesan.Longitude))
+		}
+		roster, err := catatResponKeselamatan(penerima, status, pesan.Kanal, catatan)
+		if err != nil {
+			log.Printf("❌ Gagal mencatat respons keselamatan dari %s: %v", pesan.Dari, err)
+			return "Maaf, balasan Anda gagal dicatat. Silakan coba lagi."
+		}
+		for _, r := range roster {
+			if r.Status == services.KeselamatanButuhBantuan && status != services.KeselamatanButuhBantuan {
+				return "Permintaan bantuan Anda masih tercatat. Petugas akan memastikan kondisi Anda sebelum statusnya diubah."
+			}
+		}
+
+		switch status {
+		case services.KeselamatanAman:
+			return "Terima kasih, Anda tercatat AMAN. Tetap waspada dan ikuti arahan petugas."
+		case services.KeselamatanButuhBantuan:
+			return "Permintaan bantuan Anda sudah diteruskan ke petugas. Bila bisa, kirim lokasi Anda dan tetap di tempat yang aman."
+		}
+		return "Terima kasih, peringatan sudah Anda terima. Balas AMAN bila Anda aman atau TOLONG bila butuh bantuan."
+	}
+}
+
+// GetKeselamatanBencana returns the safety roster of a bencana, yang perlu ditindaklanjuti dulu
+func GetKeselamatanBencana(c *fiber.Ctx) error {
+	var bencana models.KejadianBencana
+	if err := database.DB.First(&bencana, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana not found",
+		})
+	}
+
+	query := database.DB.Where("bencana_id = ?", bencana.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if c.QueryBool("diprioritaskan") {
+		query = query.Where("diprioritaskan = ?", true)
+	}
+	if rt := c.Query("rt"); rt != "" {
+		query = query.Where("warga_id IN (?)", database.DB.Model(&models.WargaRentan{}).Select("id").Where("rt = ?", rt))
+	}
+	if rw := c.Query("rw"); rw != "" {
+		query = query.Where("warga_id IN (?)", database.DB.Model(&models.WargaRentan{}).Select("id").Where("rw = ?", rw))
+	}
+
+	var roster []models.StatusKeselamatan
+	if err := query.Preload("Warga").Find(&roster).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch safety roster",
+		})
+	}
+	sort.SliceStable(roster, func(i, j int) bool {
+		ri := services.PeringkatKeselamatan(roster[i].Status, roster[i].Diprioritaskan)
+		rj := services.PeringkatKeselamatan(roster[j].Status, roster[j].Diprioritaskan)
+		if ri != rj {
+			return ri > rj
+		}
+		if urutanStatusKeselamatan[roster[i].Status] != urutanStatusKeselamatan[roster[j].Status] {
+			return urutanStatusKeselamatan[roster[i].Status] < urutanStatusKeselamatan[roster[j].Status]
+		}
+		if roster[i].Warga != nil && roster[j].Warga != nil {
+			return roster[i].Warga.SkorPrioritas > roster[j].Warga.SkorPrioritas
+		}
+		return roster[i].WargaID < roster[j].WargaID
+	})
+
+	rekap := models.RekapKeselamatan{Total: len(roster), PerStatus: map[string]int{}}
+	for status := range urutanStatusKeselamatan {
+		rekap.PerStatus[status] = 0
+	}
+	for _, r := range roster {
+		rekap.PerStatus[r.Status]++
+		if r.Diprioritaskan {
+			rekap.Diprioritaskan++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        roster,
+		"rekap":       rekap,
+		"batas_menit": envInt("KESELAMATAN_BATAS_MENIT", 30),
+	})
+}
+
+// UpdateKeselamatanWarga lets an officer record a resident's condition (mis. setelah
+// ditelepon atau dikunjungi). Status petugas menimpa status sebelumnya.
+func UpdateKeselamatanWarga(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+	wargaID, err := strconv.Atoi(c.Params("warga_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid warga ID",
+		})
+	}
+
+	var req models.ResponKeselamatanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	status := services.NormalisasiStatusKeselamatan(req.Status)
+	if status == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "status must be Diterima, Aman or Butuh Bantuan",
+			"field":   "status",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var roster models.StatusKeselamatan
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := cekBencanaAktif(tx, uint(bencanaID)); err != nil {
+			return err
+		}
+		if _, err := cekWargaAda(tx, uint(wargaID)); err != nil {
+			return err
+		}
+		if err := kunciRosterKeselamatan(tx, &roster, uint(bencanaID), uint(wargaID), now); err != nil {
+			return err
+		}
+		roster.Status = status
+		roster.Kanal = "Petugas"
+		roster.Catatan = strings.TrimSpace(req.Catatan)
+		roster.WaktuRespons = &now
+		roster.Diprioritaskan = false
+		roster.DicatatOleh = &userID
+		return tx.Save(&roster).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to update safety status")
+	}
+
+	siarkanKeselamatan([]models.StatusKeselamatan{roster})
+	logActivity(userID, fmt.Sprintf("Mencatat status keselamatan warga #%d bencana #%d: %s", roster.WargaID, roster.BencanaID, roster.Status))
+
+	database.DB.Preload("Warga").First(&roster, roster.ID)
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Safety status updated",
+		"data":    roster,
+	})
+}
+
+// StartKeselamatanWorker periodically prioritises vulnerable warga who did not answer an alert.
+// Dijalankan sebagai goroutine dari main API Kecamatan.
+func StartKeselamatanWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := cekKeselamatan(time.Now()); err != nil {
+			log.Printf("❌ Gagal cek roster keselamatan: %v", err)
+		}
+	}
+}
+
+// cekKeselamatan flags the roster rows of vulnerable warga that passed the response deadline
+func cekKeselamatan(now time.Time) error {
+	batas := now.Add(-time.Duration(envInt("KESELAMATAN_BATAS_MENIT", 30)) * time.Minute)
+	aktif := database.DB.Model(&models.KejadianBencana{}).Select("id").Where("status = ?", "Aktif")
+	rentan := database.DB.Model(&models.WargaRentan{}).Select("id").Where("kategori_rentan != ?", "Non-Rentan")
+
+	var terlambat []models.StatusKeselamatan
+	if err := database.DB.Where("status = ? AND diprioritaskan = ? AND waktu_notifikasi < ?", services.KeselamatanBelum, false, batas).
+		Where("bencana_id IN (?)", aktif).
+		Where("warga_id IN (?)", rentan).
+		Find(&terlambat).Error; err != nil {
+		return err
+	}
+	if len(terlambat) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(terlambat))
+	perBencana := make(map[uint][]uint)
+	for _, r := range terlambat {
+		ids = append(ids, r.ID)
+		perBencana[r.BencanaID] = append(perBencana[r.BencanaID], r.WargaID)
+	}
+	// Status dicek lagi: warga bisa saja membalas sejak query di atas
+	if err := database.DB.Model(&models.StatusKeselamatan{}).
+		Where("id IN ? AND status = ?", ids, services.KeselamatanBelum).
+		Updates(map[string]interface{}{"diprioritaskan": true, "waktu_diprioritaskan": now}).Error; err != nil {
+		return err
+	}
+
+	for bencanaID, warga := range perBencana {
+		log.Printf("⚠️ %d warga rentan belum merespons peringatan bencana #%d, didahulukan di prioritas evakuasi", len(warga), bencanaID)
+		message, _ := json.Marshal(fiber.Map{
+			"tipe":       "keselamatan_tidak_merespons",
+			"bencana_id": bencanaID,
+			"warga_id":   warga,
+		})
+		broadcastToClients(string(message))
+	}
+	return nil
+}
+
+// catatResponKeselamatan records a resident's answer for every given recipient row
+func catatResponKeselamatan(penerima []models.PenerimaNotifikasi, status, kanal, catatan string) ([]models.StatusKeselamatan, error) {
+	now := time.Now()
+	roster := make([]models.StatusKeselamatan, 0, len(penerima))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, p := range penerima {
+			if _, err := cekBencanaAktif(tx, p.BencanaID); err != nil {
+				return newResponseError(fiber.StatusConflict, "Bencana is no longer active", nil)
+			}
+			if p.DiterimaPada == nil {
+				if err := tx.Model(&models.PenerimaNotifikasi{}).Where("id = ?", p.ID).
+					Updates(map[string]interface{}{"diterima_pada": now, "kanal": kanal}).Error; err != nil {
+					return err
+				}
+			}
+
+			var r models.StatusKeselamatan
+			if err := kunciRosterKeselamatan(tx, &r, p.BencanaID, p.WargaID, p.CreatedAt); err != nil {
+				return err
+			}
+			statusLama := r.Status
+			r.Status = services.GabungStatusKeselamatan(r.Status, status)
+			r.Kanal = kanal
+			if catatan != "" {
+				r.Catatan = catatan
+			}
+			r.WaktuRespons = &now
+			// Konfirmasi "Diterima" saja belum menjawab kondisi warga, jadi prioritasnya tetap
+			if r.Status != services.KeselamatanDiterima {
+				r.Diprioritaskan = false
+			}
+			if r.Status != statusLama {
+				r.DicatatOleh = nil
+			}
+			if err := tx.Save(&r).Error; err != nil {
+				return err
+			}
+			roster = append(roster, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	siarkanKeselamatan(roster)
+	return roster, nil
+}
+
+// kunciRosterKeselamatan locks the roster row of a warga, creating it when the warga is not on it yet
+func kunciRosterKeselamatan(tx *gorm.DB, roster *models.StatusKeselamatan, bencanaID, wargaID uint, waktuNotifikasi time.Time) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("bencana_id = ? AND warga_id = ?", bencanaID, wargaID).
+		First(roster).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		*roster = models.StatusKeselamatan{
+			BencanaID:       bencanaID,
+			WargaID:         wargaID,
+			Status:          services.KeselamatanBelum,
+			WaktuNotifikasi: waktuNotifikasi,
+		}
+		return tx.Create(roster).Error
+	}
+	return err
+}
+
+// cariPenerimaToken finds the recipient row of a reply link
+func cariPenerimaToken(token string) (*models.PenerimaNotifikasi, error) {
+	var penerima models.PenerimaNotifikasi
+	if token == "" || database.DB.Where("token_hash = ?", services.HashTokenKeselamatan(token)).First(&penerima).Error != nil {
+		return nil, newResponseError(fiber.StatusNotFound, "Link not found or no longer valid", nil)
+	}
+	return &penerima, nil
+}
+
+// siarkanKeselamatan tells coordinators about roster changes; permintaan bantuan juga dicatat di log
+func siarkanKeselamatan(roster []models.StatusKeselamatan) {
+	for _, r := range roster {
+		if r.Status == services.KeselamatanButuhBantuan {
+			log.Printf("🆘 Warga #%d meminta bantuan (bencana #%d) lewat %s", r.WargaID, r.BencanaID, r.Kanal)
+		}
+		message, _ := json.Marshal(fiber.Map{
+			"tipe":       "keselamatan_warga",
+			"bencana_id": r.BencanaID,
+			"warga_id":   r.WargaID,
+			"status":     r.Status,
+		})
+		broadcastToClients(string(message))
+	}
+}
+
+// preloadKeselamatan loads the roster row of each warga for one bencana
+func preloadKeselamatan(query *gorm.DB, bencanaID uint) *gorm.DB {
+	return query.Preload("Keselamatan", "bencana_id = ?", bencanaID)
+}
+
+// peringkatKeselamatanWarga returns the roster rank of a warga (lihat services.PeringkatKeselamatan)
+func peringkatKeselamatanWarga(w models.WargaRentan) int {
+	if len(w.Keselamatan) == 0 {
+		return 0
+	}
+	return services.PeringkatKeselamatan(w.Keselamatan[0].Status, w.Keselamatan[0].Diprioritaskan)
+}
+
+// urutkanMenurutKeselamatan moves warga asking for help and overdue non-responders to the
+// front; urutan lain di dalam peringkat yang sama dipertahankan
+func urutkanMenurutKeselamatan(warga []models.WargaRentan) {
+	sort.SliceStable(warga, func(i, j int) bool {
+		return peringkatKeselamatanWarga(warga[i]) > peringkatKeselamatanWarga(warga[j])
+	})
+}
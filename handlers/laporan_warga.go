@@ -0,0 +1,653 @@
+// handlers/laporan_warga.go
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Laporan warga: masyarakat tanpa akun melaporkan bahaya lewat web publik, SMS, atau
+// WhatsApp (lihat pesan_masuk.go). Laporan masuk antrean verifikasi; RT/RW/Admin_Kecamatan
+// mengonfirmasinya menjadi bencana, menolaknya, atau menggabungkannya ke bencana yang ada.
+// Pelapor dikabari setiap keputusan lewat kanal laporannya.
+
+// laporanWargaListSpec defines sorting and free-text search for the verification queue
+var laporanWargaListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":            "id",
+		"created_at":    "created_at",
+		"status":        "status",
+		"jenis_bencana": "jenis_bencana",
+	},
+	DefaultSort: "-created_at",
+	Search:      []string{"kode LIKE ?", "deskripsi LIKE ?", "lokasi LIKE ?", "nama_pelapor LIKE ?"},
+}
+
+// ekstensiFoto is the file extension stored for each detected image type
+var ekstensiFoto = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// CreateLaporanWarga receives a public hazard report (tanpa login, dibatasi per IP & nomor HP).
+// JSON atau multipart/form-data dengan foto pada field "foto".
+func CreateLaporanWarga(c *fiber.Ctx) error {
+	var req models.CreateLaporanWargaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	noHP := services.NormalisasiNoHP(req.NoHP)
+	if noHP == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "no_hp must be a valid Indonesian phone number",
+			"field":   "no_hp",
+		})
+	}
+	if req.Latitude < -90 || req.Latitude > 90 || req.Longitude < -180 || req.Longitude > 180 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid latitude/longitude",
+		})
+	}
+
+	var foto *berkasUnggahan
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		if fh, err := c.FormFile("foto"); err == nil {
+			if fh.Size > int64(envInt("MAKS_LAMPIRAN_MB", 10))<<20 {
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+					"error":   true,
+					"message": fmt.Sprintf("foto is larger than %d MB", envInt("MAKS_LAMPIRAN_MB", 10)),
+				})
+			}
+			b, err := bacaBerkas(fh)
+			if err == nil && b.jenis != "Foto" {
+				err = newResponseError(fiber.StatusUnsupportedMediaType, "foto must be an image", fiber.Map{"field": "foto"})
+			}
+			if err != nil {
+				return writeError(c, err, "Failed to read foto")
+			}
+			foto = &b
+		}
+	}
+
+	laporan := models.LaporanWarga{
+		Sumber:       "Web",
+		NamaPelapor:  strings.TrimSpace(req.NamaPelapor),
+		NoHP:         noHP,
+		JenisBencana: req.JenisBencana,
+		Deskripsi:    strings.TrimSpace(req.Deskripsi),
+		RT:           strings.TrimSpace(req.RT),
+		RW:           strings.TrimSpace(req.RW),
+		Lokasi:       strings.TrimSpace(req.Lokasi),
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		IP:           c.IP(),
+	}
+	if err := buatLaporanWarga(&laporan, foto); err != nil {
+		return writeError(c, err, "Failed to save laporan")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Report received, use the kode to check its status",
+		"data":    laporan.StatusPublik(),
+	})
+}
+
+// GetStatusLaporanWarga lets a reporter follow their report with its kode (publik)
+func GetStatusLaporanWarga(c *fiber.Ctx) error {
+	var laporan models.LaporanWarga
+	if err := database.DB.Where("kode = ?", strings.ToUpper(strings.TrimSpace(c.Params("kode")))).
+		First(&laporan).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Laporan not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  laporan.StatusPublik(),
+	})
+}
+
+// GetAllLaporanWarga returns the verification queue, paginated (see list_query.go)
+func GetAllLaporanWarga(c *fiber.Ctx) error {
+	query := database.DB
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if sumber := c.Query("sumber"); sumber != "" {
+		query = query.Where("sumber = ?", sumber)
+	}
+	if rt := c.Query("rt"); rt != "" {
+		query = query.Where("rt = ?", rt)
+	}
+	if rw := c.Query("rw"); rw != "" {
+		query = query.Where("rw = ?", rw)
+	}
+	if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+
+	return listPage[models.LaporanWarga](c, query, laporanWargaListSpec, "Failed to fetch laporan warga", "Verifikator")
+}
+
+// GetLaporanWargaByID returns one report with the open bencana it probably duplicates
+func GetLaporanWargaByID(c *fiber.Ctx) error {
+	var laporan models.LaporanWarga
+	if err := database.DB.Preload("Verifikator").First(&laporan, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Laporan not found",
+		})
+	}
+
+	kandidat := []services.KandidatDuplikat{}
+	if laporan.Status == "Menunggu" {
+		if k := cariDuplikatBencana(database.DB, bencanaDariLaporan(laporan, "Lokal_RT")); k != nil {
+			kandidat = k
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"error":    false,
+		"data":     laporan,
+		"duplikat": kandidat,
+	})
+}
+
+// GetFotoLaporanWarga streams the photo sent with a report
+func GetFotoLaporanWarga(c *fiber.Ctx) error {
+	var laporan models.LaporanWarga
+	if err := database.DB.First(&laporan, c.Params("id")).Error; err != nil || laporan.FotoKunci == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Foto not found",
+		})
+	}
+
+	store, err := penyimpanan(laporan.FotoPenyimpanan)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   true,
+			"message": "File storage is not available",
+		})
+	}
+	isi, err := store.Ambil(laporan.FotoKunci)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   true,
+				"message": "Foto file is missing from storage",
+			})
+		}
+		log.Printf("❌ Gagal mengambil foto laporan warga #%d: %v", laporan.ID, err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch foto",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, laporan.FotoTipe)
+	return c.SendStream(isi, int(laporan.FotoUkuran))
+}
+
+// KonfirmasiLaporanWarga turns a pending report into a new active bencana. Bila ada bencana
+// terbuka yang mirip, respons 409 berisi kandidatnya (gabungkan laporan, atau buat_baru=true).
+func KonfirmasiLaporanWarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.KonfirmasiLaporanWargaRequest
+	if err := c.BodyParser(&req); err != nil && len(c.Body()) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	if req.Level == "" {
+		req.Level = "Lokal_RT"
+	}
+	if req.Level != "Lokal_RT" && req.Level != "Kecamatan" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "level must be Lokal_RT or Kecamatan",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var laporan models.LaporanWarga
+	var bencana models.KejadianBencana
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kunciLaporanMenunggu(tx, &laporan, id); err != nil {
+			return err
+		}
+		if req.JenisBencana != "" {
+			jenis := cariJenisBencana(req.JenisBencana)
+			if jenis == nil {
+				return newResponseError(fiber.StatusBadRequest, "Unknown jenis_bencana", fiber.Map{
+					"field":       "jenis_bencana",
+					"jenis_valid": namaJenisBencanaAktif(),
+				})
+			}
+			laporan.JenisBencana, laporan.KodeJenis = jenis.Nama, jenis.Kode
+		}
+
+		bencana = bencanaDariLaporan(laporan, req.Level)
+		bencana.UserPelaporID = userID
+		if req.Deskripsi != "" {
+			bencana.Deskripsi = req.Deskripsi
+		}
+		if !req.BuatBaru {
+			if kandidat := cariDuplikatBencana(tx, bencana); len(kandidat) > 0 {
+				return newResponseError(fiber.StatusConflict,
+					"Similar bencana already open; merge the laporan into it or resend with buat_baru=true", fiber.Map{
+						"duplikat": kandidat,
+					})
+			}
+		}
+
+		if err := tx.Create(&bencana).Error; err != nil {
+			return err
+		}
+		if err := catatTimeline(tx, bencana.ID, fmt.Sprintf("Bencana dikonfirmasi dari laporan warga %s: %s", laporan.Kode, bencana.Deskripsi), &userID); err != nil {
+			return err
+		}
+		if err := buatTugasPlaybook(tx, bencana); err != nil {
+			return err
+		}
+		if err := catatLaporanWarga(tx, laporan, bencana.ID, userID); err != nil {
+			return err
+		}
+
+		laporan.Status = "Dikonfirmasi"
+		laporan.BencanaID = &bencana.ID
+		laporan.CatatanVerifikasi = req.Catatan
+		laporan.DiverifikasiOleh = &userID
+		laporan.WaktuVerifikasi = &now
+		return tx.Save(&laporan).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to confirm laporan")
+	}
+
+	database.DB.Preload("UserPelapor").First(&bencana, bencana.ID)
+	go messaging.PublishEvent("CREATE_BENCANA", kecamatanID(), bencana)
+	go triggerBencanaNotification(bencana)
+	go kabariPelapor(laporan)
+	siarkanLaporanWarga(laporan)
+	logActivity(userID, fmt.Sprintf("Konfirmasi laporan warga %s menjadi bencana #%d", laporan.Kode, bencana.ID))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Laporan confirmed, bencana is now active",
+		"data":    bencana,
+	})
+}
+
+// TolakLaporanWarga rejects a report (mis. laporan palsu atau bukan bencana)
+func TolakLaporanWarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.KeputusanLaporanWargaRequest
+	if err := c.BodyParser(&req); err != nil && len(c.Body()) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var laporan models.LaporanWarga
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kunciLaporanMenunggu(tx, &laporan, id); err != nil {
+			return err
+		}
+		laporan.Status = "Ditolak"
+		laporan.CatatanVerifikasi = req.Catatan
+		laporan.DiverifikasiOleh = &userID
+		laporan.WaktuVerifikasi = &now
+		return tx.Save(&laporan).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to reject laporan")
+	}
+
+	go kabariPelapor(laporan)
+	siarkanLaporanWarga(laporan)
+	logActivity(userID, "Menolak laporan warga "+laporan.Kode)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Laporan rejected",
+		"data":    laporan,
+	})
+}
+
+// GabungLaporanWarga adds a report to an existing active bencana as a timeline entry
+// (beserta fotonya). Query force=true untuk bencana berbeda jenis.
+func GabungLaporanWarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.KeputusanLaporanWargaRequest
+	if err := c.BodyParser(&req); err != nil || req.BencanaID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "bencana_id is required",
+			"field":   "bencana_id",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var laporan models.LaporanWarga
+	var bencana models.KejadianBencana
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kunciLaporanMenunggu(tx, &laporan, id); err != nil {
+			return err
+		}
+		if err := tx.First(&bencana, req.BencanaID).Error; err != nil {
+			return newResponseError(fiber.StatusNotFound, "Bencana not found", fiber.Map{"field": "bencana_id"})
+		}
+		if err := cekBencanaTerbuka(bencana); err != nil {
+			return err
+		}
+		if bencana.Status == "Draft" {
+			return newResponseError(fiber.StatusConflict, "Bencana is still a draft, confirm it first", nil)
+		}
+		if !c.QueryBool("force") && laporan.KodeJenis != "" && bencana.KodeJenis != "" && laporan.KodeJenis != bencana.KodeJenis {
+			return newResponseError(fiber.StatusConflict, "Bencana has a different jenis_bencana, use force=true to merge anyway", fiber.Map{
+				"jenis_bencana": bencana.JenisBencana,
+			})
+		}
+
+		if err := catatLaporanWarga(tx, laporan, bencana.ID, userID); err != nil {
+			return err
+		}
+		laporan.Status = "Digabung"
+		laporan.BencanaID = &bencana.ID
+		laporan.CatatanVerifikasi = req.Catatan
+		laporan.DiverifikasiOleh = &userID
+		laporan.WaktuVerifikasi = &now
+		return tx.Save(&laporan).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to merge laporan")
+	}
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":       "timeline_bencana",
+		"bencana_id": bencana.ID,
+		"isi":        "Laporan warga " + laporan.Kode + " ditambahkan",
+	})
+	broadcastToClients(string(message))
+	go kabariPelapor(laporan)
+	siarkanLaporanWarga(laporan)
+	logActivity(userID, fmt.Sprintf("Menggabungkan laporan warga %s ke bencana #%d", laporan.Kode, bencana.ID))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Laporan merged into bencana",
+		"data":    laporan,
+	})
+}
+
+// buatLaporanWarga validates and stores a new report from any channel
+func buatLaporanWarga(laporan *models.LaporanWarga, foto *berkasUnggahan) error {
+	jenis := cariJenisBencana(laporan.JenisBencana)
+	if jenis == nil {
+		return newResponseError(fiber.StatusBadRequest, "Unknown jenis_bencana", fiber.Map{
+			"field":       "jenis_bencana",
+			"jenis_valid": namaJenisBencanaAktif(),
+		})
+	}
+	laporan.JenisBencana, laporan.KodeJenis = jenis.Nama, jenis.Kode
+
+	// Satu nomor tidak boleh membanjiri antrean verifikasi
+	var jumlah int64
+	database.DB.Model(&models.LaporanWarga{}).
+		Where("no_hp = ? AND created_at > ?", laporan.NoHP, time.Now().Add(-time.Hour)).
+		Count(&jumlah)
+	if maks := envInt("LAPORAN_WARGA_MAKS_PER_JAM", 5); jumlah >= int64(maks) {
+		return newResponseError(fiber.StatusTooManyRequests,
+			fmt.Sprintf("At most %d reports per hour from one phone number", maks), nil)
+	}
+
+	kode, err := services.BuatKodeLaporan()
+	if err != nil {
+		return err
+	}
+	laporan.Kode = kode
+	laporan.Status = "Menunggu"
+
+	if foto != nil {
+		if err := simpanFotoLaporan(laporan, *foto); err != nil {
+			return err
+		}
+	}
+	if err := database.DB.Create(laporan).Error; err != nil {
+		hapusFotoLaporan(*laporan)
+		return err
+	}
+	laporan.AfterFind(database.DB)
+
+	// Stream SSE bersifat publik: nomor HP & nama pelapor tidak ikut disiarkan
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":          "laporan_warga_baru",
+		"id":            laporan.ID,
+		"kode":          laporan.Kode,
+		"sumber":        laporan.Sumber,
+		"jenis_bencana": laporan.JenisBencana,
+		"rt":            laporan.RT,
+		"rw":            laporan.RW,
+		"latitude":      laporan.Latitude,
+		"longitude":     laporan.Longitude,
+	})
+	broadcastToClients(string(message))
+	return nil
+}
+
+// berkasFoto wraps photo bytes received through a message gateway
+func berkasFoto(data []byte) (berkasUnggahan, error) {
+	tipe := http.DetectContentType(data)
+	if !strings.HasPrefix(tipe, "image/") {
+		return berkasUnggahan{}, newResponseError(fiber.StatusUnsupportedMediaType, "Media is not a photo", nil)
+	}
+	if int64(len(data)) > int64(envInt("MAKS_LAMPIRAN_MB", 10))<<20 {
+		return berkasUnggahan{}, newResponseError(fiber.StatusRequestEntityTooLarge, "Photo is too large", nil)
+	}
+	return berkasUnggahan{nama: "foto" + ekstensiFoto[tipe], tipe: tipe, jenis: "Foto", ext: ekstensiFoto[tipe], data: data}, nil
+}
+
+// simpanFotoLaporan puts the photo in the active blob store and records it on the report
+func simpanFotoLaporan(laporan *models.LaporanWarga, foto berkasUnggahan) error {
+	nama := namaPenyimpananAktif()
+	store, err := penyimpanan(nama)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return newResponseError(fiber.StatusServiceUnavailable, "File storage is not available", nil)
+	}
+	hash := sha256.Sum256(foto.data)
+	laporan.FotoSHA256 = hex.EncodeToString(hash[:])
+	laporan.FotoKunci = fmt.Sprintf("laporan-warga/%s-%s%s", time.Now().Format("20060102T150405"), laporan.FotoSHA256[:16], foto.ext)
+	laporan.FotoPenyimpanan = nama
+	laporan.FotoTipe = foto.tipe
+	laporan.FotoUkuran = int64(len(foto.data))
+	if err := store.Simpan(laporan.FotoKunci, laporan.FotoTipe, foto.data); err != nil {
+		log.Printf("❌ Gagal menyimpan foto laporan warga: %v", err)
+		laporan.FotoKunci = ""
+		return newResponseError(fiber.StatusBadGateway, "Failed to store foto", nil)
+	}
+	return nil
+}
+
+// hapusFotoLaporan removes the stored photo of a report that could not be saved
+func hapusFotoLaporan(laporan models.LaporanWarga) {
+	if laporan.FotoKunci == "" {
+		return
+	}
+	hapusBerkasLampiran([]models.LampiranBencana{{Penyimpanan: laporan.FotoPenyimpanan, Kunci: laporan.FotoKunci}})
+}
+
+// bencanaDariLaporan builds the bencana a report would become (juga untuk deteksi laporan ganda)
+func bencanaDariLaporan(laporan models.LaporanWarga, level string) models.KejadianBencana {
+	deskripsi := laporan.Deskripsi
+	if laporan.Lokasi != "" {
+		deskripsi = strings.TrimSpace(deskripsi + " (" + laporan.Lokasi + ")")
+	}
+	return models.KejadianBencana{
+		JenisBencana: laporan.JenisBencana,
+		KodeJenis:    laporan.KodeJenis,
+		Level:        level,
+		WaktuMulai:   laporan.CreatedAt,
+		Status:       "Aktif",
+		Deskripsi:    deskripsi,
+		RT:           laporan.RT,
+		RW:           laporan.RW,
+		Latitude:     laporan.Latitude,
+		Longitude:    laporan.Longitude,
+	}
+}
+
+// catatLaporanWarga records the report (dan fotonya) as a timeline entry of the bencana inside tx
+func catatLaporanWarga(tx *gorm.DB, laporan models.LaporanWarga, bencanaID, userID uint) error {
+	isi := fmt.Sprintf("Laporan warga %s lewat %s", laporan.Kode, laporan.Sumber)
+	if laporan.NamaPelapor != "" {
+		isi += " dari " + laporan.NamaPelapor
+	}
+	if laporan.Deskripsi != "" {
+		isi += ": " + laporan.Deskripsi
+	}
+	entri := models.TimelineBencana{
+		BencanaID:   bencanaID,
+		Tipe:        "Update",
+		Isi:         isi,
+		RT:          laporan.RT,
+		RW:          laporan.RW,
+		Lokasi:      laporan.Lokasi,
+		Latitude:    laporan.Latitude,
+		Longitude:   laporan.Longitude,
+		Waktu:       laporan.CreatedAt,
+		DicatatOleh: &userID,
+	}
+	if err := tx.Create(&entri).Error; err != nil {
+		return err
+	}
+	if laporan.FotoKunci == "" {
+		return nil
+	}
+	// Berkas yang sama dipakai ulang; tidak disalin
+	return tx.Create(&models.LampiranBencana{
+		BencanaID:    bencanaID,
+		TimelineID:   entri.ID,
+		Jenis:        "Foto",
+		NamaBerkas:   laporan.Kode + ekstensiFoto[laporan.FotoTipe],
+		TipeKonten:   laporan.FotoTipe,
+		Ukuran:       laporan.FotoUkuran,
+		SHA256:       laporan.FotoSHA256,
+		Penyimpanan:  laporan.FotoPenyimpanan,
+		Kunci:        laporan.FotoKunci,
+		DiunggahOleh: userID,
+	}).Error
+}
+
+// kunciLaporanMenunggu locks a report that has not been decided yet
+func kunciLaporanMenunggu(tx *gorm.DB, laporan *models.LaporanWarga, id int) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(laporan, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return newResponseError(fiber.StatusNotFound, "Laporan not found", nil)
+		}
+		return err
+	}
+	if laporan.Status != "Menunggu" {
+		return newResponseError(fiber.StatusConflict, "Laporan already "+strings.ToLower(laporan.Status), fiber.Map{"status": laporan.Status})
+	}
+	return nil
+}
+
+// kabariPelapor tells the reporter the decision on the channel they reported through
+func kabariPelapor(laporan models.LaporanWarga) {
+	if laporan.NoHP == "" {
+		return
+	}
+	var teks string
+	switch laporan.Status {
+	case "Dikonfirmasi":
+		teks = fmt.Sprintf("Laporan %s (%s) telah diverifikasi petugas dan sedang ditangani. Terima kasih.", laporan.Kode, laporan.JenisBencana)
+	case "Digabung":
+		teks = fmt.Sprintf("Laporan %s (%s) telah diverifikasi: kejadian ini sudah ditangani petugas. Terima kasih.", laporan.Kode, laporan.JenisBencana)
+	case "Ditolak":
+		teks = fmt.Sprintf("Laporan %s tidak dapat ditindaklanjuti.", laporan.Kode)
+		if laporan.CatatanVerifikasi != "" {
+			teks += " Catatan petugas: " + laporan.CatatanVerifikasi
+		}
+	default:
+		return
+	}
+	kanal := "WhatsApp"
+	if laporan.Sumber == "SMS" {
+		kanal = "SMS"
+	}
+	kirimPesan(kanal, laporan.NoHP, teks)
+}
+
+// siarkanLaporanWarga tells dashboards that a report left the queue
+func siarkanLaporanWarga(laporan models.LaporanWarga) {
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":       "laporan_warga",
+		"id":         laporan.ID,
+		"kode":       laporan.Kode,
+		"status":     laporan.Status,
+		"bencana_id": laporan.BencanaID,
+	})
+	broadcastToClients(string(message))
+}
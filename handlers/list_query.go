@@ -0,0 +1,377 @@
+// handlers/list_query.go
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Query language untuk semua endpoint list:
+//
+//	?limit=50                     jumlah data per halaman (maks maxPageSize)
+//	?cursor=<next_cursor>         halaman berikutnya (dari meta.next_cursor)
+//	?offset=100                   alternatif cursor untuk klien lama (diabaikan bila ada cursor)
+//	?sort=-skor_prioritas,nama    urutan; awalan "-" berarti DESC
+//	?fields=id,nama,rt            hanya kembalikan field tertentu
+//	?q=siti jalan merdeka         pencarian bebas (semua kata harus cocok)
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// listSpec describes what a list endpoint allows
+type listSpec struct {
+	// Sortable maps the sort parameter (also the JSON field name) to its column
+	Sortable map[string]string
+	// DefaultSort is used when ?sort is empty, e.g. "-waktu_mulai"
+	DefaultSort string
+	// Search holds conditions with one "?" placeholder, e.g. "nama LIKE ?"
+	Search []string
+}
+
+type sortField struct {
+	Param  string
+	Column string
+	Desc   bool
+}
+
+type listQuery struct {
+	Limit  int
+	Offset int
+	Sort   []sortField
+	Cursor []interface{}
+	Fields []string
+	Search string
+}
+
+// listPage runs a paginated list query and writes the standard response:
+//
+//	{"error": false, "data": [...], "total": N, "meta": {...}}
+//
+// query must only contain filters; preloads are applied to the page query only.
+func listPage[T any](c *fiber.Ctx, query *gorm.DB, spec listSpec, errMessage string, preloads ...string) error {
+	lq, err := parseListQuery[T](c, spec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	query = applySearch(query, spec, lq.Search)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Model(new(T)).Count(&total).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": errMessage,
+		})
+	}
+
+	page := query.Session(&gorm.Session{})
+	for _, preload := range preloads {
+		page = page.Preload(preload)
+	}
+	if lq.Cursor != nil {
+		page = applyCursor(page, lq.Sort, lq.Cursor)
+	} else if lq.Offset > 0 {
+		page = page.Offset(lq.Offset)
+	}
+	for _, s := range lq.Sort {
+		if s.Desc {
+			page = page.Order(s.Column + " DESC")
+		} else {
+			page = page.Order(s.Column + " ASC")
+		}
+	}
+
+	// Ambil satu data lebih untuk mengetahui apakah masih ada halaman berikutnya
+	var items []T
+	if err := page.Limit(lq.Limit + 1).Find(&items).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": errMessage,
+		})
+	}
+
+	hasMore := len(items) > lq.Limit
+	if hasMore {
+		items = items[:lq.Limit]
+	}
+
+	var nextCursor string
+	if hasMore {
+		if nextCursor, err = encodeCursor(items[len(items)-1], lq.Sort); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": errMessage,
+			})
+		}
+	}
+
+	var data interface{} = items
+	if len(lq.Fields) > 0 {
+		if data, err = selectFields(items, lq.Fields); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": errMessage,
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  data,
+		"total": total,
+		"meta": fiber.Map{
+			"count":       len(items),
+			"total":       total,
+			"limit":       lq.Limit,
+			"offset":      lq.Offset,
+			"sort":        formatSort(lq.Sort),
+			"q":           lq.Search,
+			"has_more":    hasMore,
+			"next_cursor": nextCursor,
+		},
+	})
+}
+
+// parseListQuery reads and validates the list query parameters
+func parseListQuery[T any](c *fiber.Ctx, spec listSpec) (*listQuery, error) {
+	lq := &listQuery{
+		Limit:  c.QueryInt("limit", defaultPageSize),
+		Offset: c.QueryInt("offset", 0),
+		Search: strings.TrimSpace(c.Query("q")),
+	}
+	if lq.Limit < 1 {
+		return nil, errors.New("limit must be a positive number")
+	}
+	if lq.Limit > maxPageSize {
+		lq.Limit = maxPageSize
+	}
+	if lq.Offset < 0 {
+		return nil, errors.New("offset must not be negative")
+	}
+
+	sortParam := c.Query("sort", spec.DefaultSort)
+	dipakai := map[string]bool{}
+	for _, part := range strings.Split(sortParam, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		desc := strings.HasPrefix(part, "-")
+		param := strings.TrimPrefix(part, "-")
+		column, ok := spec.Sortable[param]
+		if !ok {
+			return nil, fmt.Errorf("cannot sort by %q (allowed: %s)", param, strings.Join(sortableParams(spec), ", "))
+		}
+		if dipakai[param] {
+			continue
+		}
+		dipakai[param] = true
+		lq.Sort = append(lq.Sort, sortField{Param: param, Column: column, Desc: desc})
+	}
+	// id jadi penentu terakhir agar urutan (dan cursor) stabil, kecuali sudah diminta
+	if !dipakai["id"] {
+		lq.Sort = append(lq.Sort, sortField{Param: "id", Column: "id"})
+	}
+
+	if fields := c.Query("fields"); fields != "" {
+		known := jsonFieldNames(reflect.TypeOf(new(T)).Elem())
+		for _, field := range strings.Split(fields, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if !known[field] {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+			lq.Fields = append(lq.Fields, field)
+		}
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		values, err := decodeCursor(cursor, len(lq.Sort))
+		if err != nil {
+			return nil, err
+		}
+		lq.Cursor = values
+		lq.Offset = 0
+	}
+
+	return lq, nil
+}
+
+// applySearch requires every word of q to match at least one search condition
+func applySearch(query *gorm.DB, spec listSpec, q string) *gorm.DB {
+	if q == "" || len(spec.Search) == 0 {
+		return query
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	for _, word := range strings.Fields(q) {
+		pattern := "%" + escaper.Replace(word) + "%"
+
+		conditions := make([]string, 0, len(spec.Search))
+		args := make([]interface{}, 0, len(spec.Search))
+		for _, cond := range spec.Search {
+			conditions = append(conditions, cond)
+			args = append(args, pattern)
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	return query
+}
+
+// applyCursor adds the keyset condition that continues after the cursor row:
+// (a > va) OR (a = va AND b > vb) OR ... with > / < depending on direction
+func applyCursor(query *gorm.DB, sort []sortField, cursor []interface{}) *gorm.DB {
+	var clauses []string
+	var args []interface{}
+
+	for i := range sort {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, sort[j].Column+" = ?")
+			args = append(args, cursor[j])
+		}
+		op := ">"
+		if sort[i].Desc {
+			op = "<"
+		}
+		parts = append(parts, sort[i].Column+" "+op+" ?")
+		args = append(args, cursor[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return query.Where("("+strings.Join(clauses, " OR ")+")", args...)
+}
+
+// encodeCursor stores the sort values of the last row as base64url JSON
+func encodeCursor(item interface{}, sort []sortField) (string, error) {
+	row, err := toJSONMap(item)
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]interface{}, 0, len(sort))
+	for _, s := range sort {
+		values = append(values, row[s.Param])
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor reverses encodeCursor; timestamps are turned back into time.Time
+func decodeCursor(cursor string, expected int) ([]interface{}, error) {
+	errInvalid := errors.New("invalid cursor (the sort order must not change between pages)")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	var values []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil || len(values) != expected {
+		return nil, errInvalid
+	}
+
+	for i, v := range values {
+		switch val := v.(type) {
+		case json.Number:
+			values[i] = val.String()
+		case string:
+			if t, err := time.Parse(time.RFC3339Nano, val); err == nil {
+				values[i] = t
+			}
+		case nil:
+			return nil, errInvalid
+		}
+	}
+	return values, nil
+}
+
+// selectFields keeps only the requested JSON fields of every item
+func selectFields[T any](items []T, fields []string) ([]map[string]interface{}, error) {
+	result := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		row, err := toJSONMap(item)
+		if err != nil {
+			return nil, err
+		}
+		selected := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if value, ok := row[field]; ok {
+				selected[field] = value
+			}
+		}
+		result = append(result, selected)
+	}
+	return result, nil
+}
+
+func toJSONMap(item interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var row map[string]interface{}
+	err = json.Unmarshal(raw, &row)
+	return row, err
+}
+
+// jsonFieldNames returns the JSON names of the exported fields of a struct type
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+func sortableParams(spec listSpec) []string {
+	params := make([]string, 0, len(spec.Sortable))
+	for param := range spec.Sortable {
+		params = append(params, param)
+	}
+	return params
+}
+
+func formatSort(sort []sortField) string {
+	parts := make([]string, 0, len(sort))
+	for _, s := range sort {
+		if s.Desc {
+			parts = append(parts, "-"+s.Param)
+		} else {
+			parts = append(parts, s.Param)
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type barisUji struct {
+	ID      uint      `json:"id"`
+	Nama    string    `json:"nama"`
+	Skor    int       `json:"skor_prioritas"`
+	Waktu   time.Time `json:"waktu"`
+	Rahasia string    `json:"-"`
+	Catatan string
+}
+
+var specUji = listSpec{
+	Sortable: map[string]string{
+		"id":             "id",
+		"nama":           "nama",
+		"skor_prioritas": "skor_prioritas",
+		"waktu":          "waktu",
+	},
+	DefaultSort: "-skor_prioritas",
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	waktu := time.Date(2024, 3, 5, 7, 30, 15, 123000000, time.UTC)
+	baris := barisUji{ID: 42, Nama: "Siti", Skor: 95, Waktu: waktu}
+
+	tests := []struct {
+		nama string
+		sort []sortField
+		want []interface{}
+	}{
+		{
+			"angka lalu id",
+			[]sortField{{Param: "skor_prioritas", Desc: true}, {Param: "id"}},
+			[]interface{}{"95", "42"},
+		},
+		{
+			"teks lalu id",
+			[]sortField{{Param: "nama"}, {Param: "id"}},
+			[]interface{}{"Siti", "42"},
+		},
+		{
+			"waktu kembali jadi time.Time",
+			[]sortField{{Param: "waktu", Desc: true}, {Param: "id"}},
+			[]interface{}{waktu, "42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			cursor, err := encodeCursor(baris, tt.sort)
+			if err != nil {
+				t.Fatalf("encodeCursor: %v", err)
+			}
+			got, err := decodeCursor(cursor, len(tt.sort))
+			if err != nil {
+				t.Fatalf("decodeCursor(%q): %v", cursor, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("decodeCursor = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if w, ok := tt.want[i].(time.Time); ok {
+					if g, ok := got[i].(time.Time); !ok || !g.Equal(w) {
+						t.Errorf("value %d = %#v, want %v", i, got[i], w)
+					}
+					continue
+				}
+				if got[i] != tt.want[i] {
+					t.Errorf("value %d = %#v, want %#v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		nama   string
+		cursor string
+		jumlah int
+	}{
+		{"bukan base64", "!!!", 2},
+		{"bukan JSON", enc("bukan json"), 2},
+		{"bukan array", enc(`{"id":1}`), 1},
+		{"jumlah nilai berbeda (urutan berubah)", enc(`[95,42]`), 3},
+		{"nilai null", enc(`[null,42]`), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if _, err := decodeCursor(tt.cursor, tt.jumlah); err == nil {
+				t.Fatalf("decodeCursor(%q) succeeded, want error", tt.cursor)
+			}
+		})
+	}
+}
+
+func TestParseListQuery(t *testing.T) {
+	tests := []struct {
+		nama   string
+		query  string
+		galat  bool
+		limit  int
+		offset int
+		sort   string
+		fields []string
+	}{
+		{"default", "", false, defaultPageSize, 0, "-skor_prioritas,id", nil},
+		{"limit dibatasi", "?limit=1000", false, maxPageSize, 0, "-skor_prioritas,id", nil},
+		{"sort beberapa kolom", "?sort=nama,-waktu", false, defaultPageSize, 0, "nama,-waktu,id", nil},
+		{"id menurun dipertahankan", "?sort=-id", false, defaultPageSize, 0, "-id", nil},
+		{"id di tengah tidak diulang", "?sort=nama,id,-waktu", false, defaultPageSize, 0, "nama,id,-waktu", nil},
+		{"kolom ganda diambil sekali", "?sort=-nama,nama", false, defaultPageSize, 0, "-nama,id", nil},
+		{"fields", "?fields=id,nama", false, defaultPageSize, 0, "-skor_prioritas,id", []string{"id", "nama"}},
+		{"field tanpa tag json", "?fields=Catatan", false, defaultPageSize, 0, "-skor_prioritas,id", []string{"Catatan"}},
+		{"offset", "?offset=100", false, defaultPageSize, 100, "-skor_prioritas,id", nil},
+		{"limit nol", "?limit=0", true, 0, 0, "", nil},
+		{"offset negatif", "?offset=-1", true, 0, 0, "", nil},
+		{"kolom sort tidak dikenal", "?sort=nik", true, 0, 0, "", nil},
+		{"field tersembunyi", "?fields=rahasia", true, 0, 0, "", nil},
+		{"cursor rusak", "?cursor=abc", true, 0, 0, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			app := fiber.New()
+			var lq *listQuery
+			var galat error
+			app.Get("/", func(c *fiber.Ctx) error {
+				lq, galat = parseListQuery[barisUji](c, specUji)
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil)); err != nil {
+				t.Fatal(err)
+			}
+
+			if (galat != nil) != tt.galat {
+				t.Fatalf("parseListQuery(%q) error = %v, want error %v", tt.query, galat, tt.galat)
+			}
+			if tt.galat {
+				return
+			}
+			if lq.Limit != tt.limit || lq.Offset != tt.offset {
+				t.Errorf("limit/offset = %d/%d, want %d/%d", lq.Limit, lq.Offset, tt.limit, tt.offset)
+			}
+			if got := formatSort(lq.Sort); got != tt.sort {
+				t.Errorf("sort = %q, want %q", got, tt.sort)
+			}
+			if !reflect.DeepEqual(lq.Fields, tt.fields) {
+				t.Errorf("fields = %v, want %v", lq.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestParseListQueryCursorMengabaikanOffset(t *testing.T) {
+	cursor, err := encodeCursor(barisUji{ID: 7, Skor: 80}, []sortField{{Param: "skor_prioritas", Desc: true}, {Param: "id"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		lq, err := parseListQuery[barisUji](c, specUji)
+		if err != nil {
+			return err
+		}
+		return c.JSON(fiber.Map{"offset": lq.Offset, "cursor": lq.Cursor})
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", "/?offset=100&cursor="+cursor, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	var hasil struct {
+		Offset int           `json:"offset"`
+		Cursor []interface{} `json:"cursor"`
+	}
+	if err := json.Unmarshal(body, &hasil); err != nil {
+		t.Fatalf("%v: %s", err, body)
+	}
+	if hasil.Offset != 0 || !reflect.DeepEqual(hasil.Cursor, []interface{}{"80", "7"}) {
+		t.Errorf("offset/cursor = %d/%v, want 0/[80 7]", hasil.Offset, hasil.Cursor)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	items := []barisUji{{ID: 1, Nama: "Siti", Skor: 90, Rahasia: "x"}}
+	got, err := selectFields(items, []string{"id", "nama", "tidak_ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{{"id": float64(1), "nama": "Siti"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectFields = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,960 @@
+// handlers/logistik.go
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Jenis mutasi stok
+const (
+	mutasiMasuk          = "Masuk"
+	mutasiKeluar         = "Keluar"
+	mutasiTransferMasuk  = "Transfer Masuk"
+	mutasiTransferKeluar = "Transfer Keluar"
+	mutasiDistribusi     = "Distribusi"
+	mutasiPenyesuaian    = "Penyesuaian"
+)
+
+// Kategori barang yang dikenal (lihat enum BarangLogistik.Kategori)
+var kategoriBarangValid = map[string]bool{
+	"Pangan": true, "Air": true, "Sandang": true, "Kesehatan": true,
+	"Kebersihan": true, "Hunian": true, "Lainnya": true,
+}
+
+// gudangListSpec defines sorting and search for the warehouse list
+var gudangListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":    "id",
+		"nama":  "nama",
+		"jenis": "jenis",
+	},
+	DefaultSort: "nama",
+	Search:      []string{"nama LIKE ?", "alamat LIKE ?"},
+}
+
+// barangListSpec defines sorting and search for the item catalogue
+var barangListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":       "id",
+		"kode":     "kode",
+		"nama":     "nama",
+		"kategori": "kategori",
+	},
+	DefaultSort: "nama",
+	Search:      []string{"kode LIKE ?", "nama LIKE ?"},
+}
+
+// mutasiListSpec defines sorting for the stock card
+var mutasiListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":         "id",
+		"jenis":      "jenis",
+		"created_at": "created_at",
+	},
+	DefaultSort: "-id",
+	Search:      []string{"sumber LIKE ?", "catatan LIKE ?"},
+}
+
+// distribusiListSpec defines sorting and search for the distribution list
+var distribusiListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+	},
+	DefaultSort: "-id",
+	Search:      []string{"penerima_nama LIKE ?", "catatan LIKE ?"},
+}
+
+// GetAllGudangLogistik returns warehouses and posts, paginated (see list_query.go)
+func GetAllGudangLogistik(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.GudangLogistik{})
+
+	if aktif := c.Query("aktif"); aktif != "" {
+		query = query.Where("aktif = ?", c.QueryBool("aktif"))
+	}
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+
+	return listPage[models.GudangLogistik](c, query, gudangListSpec, "Failed to fetch gudang")
+}
+
+// GetGudangLogistikByID returns a warehouse with its current stock
+func GetGudangLogistikByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var gudang models.GudangLogistik
+	if err := database.DB.First(&gudang, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Gudang not found",
+		})
+	}
+
+	var stok []models.StokLogistik
+	database.DB.Joins("Barang").Where("stok_logistiks.gudang_id = ?", gudang.ID).Order("Barang.nama").Find(&stok)
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"gudang": gudang,
+			"stok":   stok,
+		},
+	})
+}
+
+// CreateGudangLogistik registers a warehouse or supply post
+func CreateGudangLogistik(c *fiber.Ctx) error {
+	var req models.GudangLogistikRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	gudang := models.GudangLogistik{Aktif: true}
+	if err := applyGudangLogistik(&gudang, req); err != nil {
+		return writeError(c, err, "Failed to create gudang")
+	}
+
+	if err := database.DB.Create(&gudang).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create gudang",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Menambah gudang logistik: "+gudang.Nama)
+
+	go publishStokLogistik(gudang.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Gudang created successfully",
+		"data":    gudang,
+	})
+}
+
+// UpdateGudangLogistik updates a warehouse's data; stock is only changed through mutations
+func UpdateGudangLogistik(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var gudang models.GudangLogistik
+	if err := database.DB.First(&gudang, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Gudang not found",
+		})
+	}
+
+	var req models.GudangLogistikRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := applyGudangLogistik(&gudang, req); err != nil {
+		return writeError(c, err, "Failed to update gudang")
+	}
+
+	if err := database.DB.Save(&gudang).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update gudang",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Mengubah gudang logistik: "+gudang.Nama)
+
+	go publishStokLogistik(gudang.ID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Gudang updated successfully",
+		"data":    gudang,
+	})
+}
+
+// DeleteGudangLogistik removes an empty warehouse
+func DeleteGudangLogistik(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var gudang models.GudangLogistik
+	if err := database.DB.First(&gudang, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Gudang not found",
+		})
+	}
+
+	var sisa int64
+	database.DB.Model(&models.StokLogistik{}).Where("gudang_id = ? AND jumlah > 0", gudang.ID).Count(&sisa)
+	if sisa > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":        true,
+			"message":      "Gudang still holds stock, transfer it first or deactivate the gudang",
+			"jenis_barang": sisa,
+		})
+	}
+
+	if err := database.DB.Delete(&gudang).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete gudang",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Menghapus gudang logistik: "+gudang.Nama)
+
+	go publishStokLogistik(gudang.ID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Gudang deleted successfully",
+	})
+}
+
+// GetAllBarangLogistik returns the item catalogue, paginated (see list_query.go)
+func GetAllBarangLogistik(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.BarangLogistik{})
+
+	if kategori := c.Query("kategori"); kategori != "" {
+		query = query.Where("kategori = ?", kategori)
+	}
+
+	return listPage[models.BarangLogistik](c, query, barangListSpec, "Failed to fetch barang")
+}
+
+// CreateBarangLogistik adds an item to the catalogue
+func CreateBarangLogistik(c *fiber.Ctx) error {
+	var req models.BarangLogistikRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	var barang models.BarangLogistik
+	if err := applyBarangLogistik(&barang, req); err != nil {
+		return writeError(c, err, "Failed to create barang")
+	}
+
+	var exists int64
+	database.DB.Model(&models.BarangLogistik{}).Where("kode = ?", barang.Kode).Count(&exists)
+	if exists > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Kode barang already exists",
+			"field":   "kode",
+		})
+	}
+
+	if err := database.DB.Create(&barang).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create barang",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Menambah barang logistik: "+barang.Nama)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Barang created successfully",
+		"data":    barang,
+	})
+}
+
+// UpdateBarangLogistik updates a catalogue item and resends the stock of every warehouse holding it
+func UpdateBarangLogistik(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var barang models.BarangLogistik
+	if err := database.DB.First(&barang, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Barang not found",
+		})
+	}
+
+	var req models.BarangLogistikRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Kode != "" && req.Kode != barang.Kode {
+		// Kode menjadi kunci stok di DB Kota, jadi tidak boleh diganti
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   true,
+			"message": "kode cannot be changed",
+			"field":   "kode",
+		})
+	}
+	req.Kode = barang.Kode
+
+	if err := applyBarangLogistik(&barang, req); err != nil {
+		return writeError(c, err, "Failed to update barang")
+	}
+
+	if err := database.DB.Save(&barang).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update barang",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Mengubah barang logistik: "+barang.Nama)
+
+	var gudangIDs []uint
+	database.DB.Model(&models.StokLogistik{}).Where("barang_id = ?", barang.ID).Pluck("gudang_id", &gudangIDs)
+	go func() {
+		for _, gudangID := range gudangIDs {
+			publishStokLogistik(gudangID)
+		}
+	}()
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Barang updated successfully",
+		"data":    barang,
+	})
+}
+
+// GetStokLogistik returns stock levels across warehouses.
+// Query: gudang_id, barang_id, kategori, kurang=true (di bawah stok minimum)
+func GetStokLogistik(c *fiber.Ctx) error {
+	query := database.DB.Joins("Barang").InnerJoins("Gudang")
+
+	if gudangID := c.QueryInt("gudang_id", 0); gudangID > 0 {
+		query = query.Where("stok_logistiks.gudang_id = ?", gudangID)
+	}
+	if barangID := c.QueryInt("barang_id", 0); barangID > 0 {
+		query = query.Where("stok_logistiks.barang_id = ?", barangID)
+	}
+	if kategori := c.Query("kategori"); kategori != "" {
+		query = query.Where("Barang.kategori = ?", kategori)
+	}
+	if c.QueryBool("kurang") {
+		query = query.Where("stok_logistiks.jumlah < Barang.stok_minimum")
+	}
+
+	var stok []models.StokLogistik
+	if err := query.Order("Barang.nama, Gudang.nama").Find(&stok).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch stok",
+		})
+	}
+
+	// Total per barang untuk seluruh kecamatan
+	total := make(map[string]float64)
+	for _, s := range stok {
+		if s.Barang != nil {
+			total[s.Barang.Kode] += s.Jumlah
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"error":      false,
+		"data":       stok,
+		"total":      len(stok),
+		"per_barang": total,
+	})
+}
+
+// GetMutasiStok returns the stock card, paginated (see list_query.go).
+// Query: gudang_id, barang_id, jenis, bencana_id
+func GetMutasiStok(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.MutasiStok{})
+
+	if gudangID := c.QueryInt("gudang_id", 0); gudangID > 0 {
+		query = query.Where("gudang_id = ?", gudangID)
+	}
+	if barangID := c.QueryInt("barang_id", 0); barangID > 0 {
+		query = query.Where("barang_id = ?", barangID)
+	}
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+
+	return listPage[models.MutasiStok](c, query, mutasiListSpec, "Failed to fetch mutasi stok", "Barang")
+}
+
+// CreateMutasiStok records goods coming in, going out, moving to another warehouse,
+// or a stock-take correction for one warehouse
+func CreateMutasiStok(c *fiber.Ctx) error {
+	gudangID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid gudang ID",
+		})
+	}
+
+	var req models.MutasiStokRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Jumlah < 0 || (req.Jumlah == 0 && req.Jenis != mutasiPenyesuaian) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "jumlah must be a positive number",
+			"field":   "jumlah",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	var mutasi []models.MutasiStok
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := cekGudangAktif(tx, uint(gudangID)); err != nil {
+			return err
+		}
+		if err := cekBarangAda(tx, req.BarangID); err != nil {
+			return err
+		}
+
+		dasar := models.MutasiStok{
+			GudangID:  uint(gudangID),
+			BarangID:  req.BarangID,
+			BencanaID: req.BencanaID,
+			Sumber:    req.Sumber,
+			Catatan:   req.Catatan,
+			PetugasID: userID,
+		}
+
+		switch req.Jenis {
+		case mutasiMasuk:
+			m := dasar
+			m.Jenis = mutasiMasuk
+			if err := ubahStok(tx, &m, req.Jumlah); err != nil {
+				return err
+			}
+			mutasi = append(mutasi, m)
+
+		case mutasiKeluar:
+			m := dasar
+			m.Jenis = mutasiKeluar
+			if err := ubahStok(tx, &m, -req.Jumlah); err != nil {
+				return err
+			}
+			mutasi = append(mutasi, m)
+
+		case "Transfer":
+			if req.GudangTujuan == nil || *req.GudangTujuan == uint(gudangID) {
+				return newResponseError(fiber.StatusBadRequest, "gudang_tujuan_id must be another gudang", fiber.Map{"field": "gudang_tujuan_id"})
+			}
+			if _, err := cekGudangAktif(tx, *req.GudangTujuan); err != nil {
+				return err
+			}
+
+			keluar := dasar
+			keluar.Jenis = mutasiTransferKeluar
+			keluar.GudangLainID = req.GudangTujuan
+			masuk := dasar
+			masuk.GudangID = *req.GudangTujuan
+			masuk.Jenis = mutasiTransferMasuk
+			masuk.GudangLainID = &dasar.GudangID
+
+			// Kunci stok dalam urutan gudang yang tetap agar dua transfer berlawanan tidak deadlock
+			if masuk.GudangID < keluar.GudangID {
+				if _, err := kunciStok(tx, masuk.GudangID, masuk.BarangID); err != nil {
+					return err
+				}
+			}
+			if err := ubahStok(tx, &keluar, -req.Jumlah); err != nil {
+				return err
+			}
+			if err := ubahStok(tx, &masuk, req.Jumlah); err != nil {
+				return err
+			}
+			mutasi = append(mutasi, keluar, masuk)
+
+		case mutasiPenyesuaian:
+			stok, err := kunciStok(tx, dasar.GudangID, dasar.BarangID)
+			if err != nil {
+				return err
+			}
+			m := dasar
+			m.Jenis = mutasiPenyesuaian
+			if err := ubahStok(tx, &m, req.Jumlah-stok.Jumlah); err != nil {
+				return err
+			}
+			mutasi = append(mutasi, m)
+
+		default:
+			return newResponseError(fiber.StatusBadRequest, "jenis must be Masuk, Keluar, Transfer or Penyesuaian", fiber.Map{"field": "jenis"})
+		}
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to record mutasi stok")
+	}
+
+	logActivity(userID, "Mencatat mutasi stok "+req.Jenis+" gudang ID: "+strconv.Itoa(gudangID))
+
+	go func() {
+		for _, m := range mutasi {
+			publishStokLogistik(m.GudangID)
+		}
+	}()
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Mutasi stok recorded successfully",
+		"data":    mutasi,
+	})
+}
+
+// GetAllDistribusiBantuan returns aid distributions, paginated (see list_query.go).
+// Query: gudang_id, bencana_id, titik_kumpul_id, kartu_keluarga_id
+func GetAllDistribusiBantuan(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.DistribusiBantuan{})
+
+	if gudangID := c.QueryInt("gudang_id", 0); gudangID > 0 {
+		query = query.Where("gudang_id = ?", gudangID)
+	}
+	if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+	if titikKumpulID := c.QueryInt("titik_kumpul_id", 0); titikKumpulID > 0 {
+		query = query.Where("titik_kumpul_id = ?", titikKumpulID)
+	}
+	if keluargaID := c.QueryInt("kartu_keluarga_id", 0); keluargaID > 0 {
+		query = query.Where("kartu_keluarga_id = ?", keluargaID)
+	}
+
+	return listPage[models.DistribusiBantuan](c, query, distribusiListSpec, "Failed to fetch distribusi", "Item", "Item.Barang")
+}
+
+// CreateDistribusiBantuan hands out goods from a warehouse to a shelter or a household.
+// A household recipient must prove membership of the KK with a warga QR or NIK;
+// a second distribution to the same household within DISTRIBUSI_JEDA_JAM needs ?force=true.
+func CreateDistribusiBantuan(c *fiber.Ctx) error {
+	gudangID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid gudang ID",
+		})
+	}
+
+	var req models.DistribusiBantuanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if len(req.Item) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "item is required",
+			"field":   "item",
+		})
+	}
+	if (req.TitikKumpulID == nil) == (req.KartuKeluargaID == nil) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Provide either titik_kumpul_id or kartu_keluarga_id",
+			"field":   "titik_kumpul_id",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	distribusi := models.DistribusiBantuan{
+		GudangID:        uint(gudangID),
+		BencanaID:       req.BencanaID,
+		TitikKumpulID:   req.TitikKumpulID,
+		KartuKeluargaID: req.KartuKeluargaID,
+		Catatan:         req.Catatan,
+		PetugasID:       userID,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := cekGudangAktif(tx, distribusi.GudangID); err != nil {
+			return err
+		}
+		if req.BencanaID != nil {
+			if _, err := cekBencanaAktif(tx, *req.BencanaID); err != nil {
+				return err
+			}
+		}
+
+		if req.TitikKumpulID != nil {
+			distribusi.TujuanJenis = "Titik Kumpul"
+			if err := tx.First(&models.TitikKumpul{}, *req.TitikKumpulID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return newResponseError(fiber.StatusBadRequest, "Titik kumpul not found", fiber.Map{"field": "titik_kumpul_id"})
+				}
+				return err
+			}
+		} else {
+			distribusi.TujuanJenis = "Keluarga"
+			var keluarga models.KartuKeluarga
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&keluarga, *req.KartuKeluargaID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return newResponseError(fiber.StatusBadRequest, "Kartu keluarga not found", fiber.Map{"field": "kartu_keluarga_id"})
+				}
+				return err
+			}
+			if !c.QueryBool("force") {
+				if err := cekDistribusiGanda(tx, keluarga.ID, req.BencanaID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := verifikasiPenerima(tx, &distribusi, req); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&distribusi).Error; err != nil {
+			return err
+		}
+
+		for _, item := range req.Item {
+			if item.Jumlah <= 0 {
+				return newResponseError(fiber.StatusBadRequest, "jumlah must be a positive number", fiber.Map{"field": "item", "barang_id": item.BarangID})
+			}
+			if err := cekBarangAda(tx, item.BarangID); err != nil {
+				return err
+			}
+
+			m := models.MutasiStok{
+				GudangID:     distribusi.GudangID,
+				BarangID:     item.BarangID,
+				Jenis:        mutasiDistribusi,
+				BencanaID:    distribusi.BencanaID,
+				DistribusiID: &distribusi.ID,
+				Catatan:      "Distribusi ke " + distribusi.TujuanJenis + ": " + distribusi.PenerimaNama,
+				PetugasID:    userID,
+			}
+			if err := ubahStok(tx, &m, -item.Jumlah); err != nil {
+				return err
+			}
+
+			baris := models.DistribusiItem{DistribusiID: distribusi.ID, BarangID: item.BarangID, Jumlah: item.Jumlah}
+			if err := tx.Create(&baris).Error; err != nil {
+				return err
+			}
+			distribusi.Item = append(distribusi.Item, baris)
+		}
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to record distribusi")
+	}
+
+	logActivity(userID, "Mendistribusikan bantuan ke "+distribusi.TujuanJenis+": "+distribusi.PenerimaNama)
+
+	go publishStokLogistik(distribusi.GudangID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Distribusi recorded successfully",
+		"data":    distribusi,
+	})
+}
+
+// verifikasiPenerima checks who receives the goods. Household deliveries must be
+// taken by a member of the KK (QR or NIK); shelter deliveries accept a QR/NIK or a
+// name recorded by the officer.
+func verifikasiPenerima(tx *gorm.DB, distribusi *models.DistribusiBantuan, req models.DistribusiBantuanRequest) error {
+	var warga *models.WargaRentan
+	switch {
+	case req.PenerimaQR != "":
+		rahasia, err := rahasiaQR()
+		if err != nil {
+			return err
+		}
+		wargaID, err := services.ParseTokenQRWarga(req.PenerimaQR, rahasia)
+		if err != nil {
+			return newResponseError(fiber.StatusBadRequest, err.Error(), fiber.Map{"field": "penerima_qr"})
+		}
+		if warga, err = cekWargaAda(tx, wargaID); err != nil {
+			return err
+		}
+		distribusi.MetodeVerifikasi = "QR"
+	case req.PenerimaNIK != "":
+		var w models.WargaRentan
+		err := tx.Where("nik = ?", req.PenerimaNIK).First(&w).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			warga = &w
+		}
+		distribusi.MetodeVerifikasi = "NIK"
+	}
+
+	if distribusi.KartuKeluargaID != nil {
+		if warga == nil {
+			return newResponseError(fiber.StatusUnprocessableEntity, "Recipient must be verified with penerima_qr or the NIK of a family member", fiber.Map{"field": "penerima_qr"})
+		}
+		if warga.KartuKeluargaID == nil || *warga.KartuKeluargaID != *distribusi.KartuKeluargaID {
+			return newResponseError(fiber.StatusUnprocessableEntity, "Recipient is not a member of this kartu keluarga", fiber.Map{"field": "penerima_qr", "warga_id": warga.ID})
+		}
+	}
+
+	if warga != nil {
+		distribusi.PenerimaWargaID = &warga.ID
+		distribusi.PenerimaNama = warga.Nama
+		return nil
+	}
+
+	distribusi.PenerimaNama = strings.TrimSpace(req.PenerimaNama)
+	if distribusi.PenerimaNama == "" {
+		return newResponseError(fiber.StatusBadRequest, "penerima_nama is required", fiber.Map{"field": "penerima_nama"})
+	}
+	if distribusi.MetodeVerifikasi == "" {
+		distribusi.MetodeVerifikasi = "Petugas"
+	}
+	return nil
+}
+
+// cekDistribusiGanda rejects a household distribution when the same KK already
+// received aid for the same bencana within DISTRIBUSI_JEDA_JAM hours (0 = tidak dicek)
+func cekDistribusiGanda(tx *gorm.DB, kartuKeluargaID uint, bencanaID *uint) error {
+	jeda := time.Duration(envIntNol("DISTRIBUSI_JEDA_JAM", 24)) * time.Hour
+	if jeda <= 0 {
+		return nil
+	}
+
+	query := tx.Where("kartu_keluarga_id = ? AND created_at > ?", kartuKeluargaID, time.Now().Add(-jeda))
+	if bencanaID != nil {
+		query = query.Where("bencana_id = ?", *bencanaID)
+	}
+
+	var terakhir models.DistribusiBantuan
+	err := query.Order("created_at DESC").First(&terakhir).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return newResponseError(fiber.StatusConflict, "This household already received aid recently, retry with ?force=true to distribute again", fiber.Map{
+		"field":               "kartu_keluarga_id",
+		"distribusi_terakhir": terakhir.ID,
+		"waktu":               terakhir.CreatedAt,
+	})
+}
+
+// ubahStok applies delta to the stock row of m's gudang/barang and records m as
+// the stock card entry. Stock never goes below zero.
+func ubahStok(tx *gorm.DB, m *models.MutasiStok, delta float64) error {
+	stok, err := kunciStok(tx, m.GudangID, m.BarangID)
+	if err != nil {
+		return err
+	}
+
+	saldo := stok.Jumlah + delta
+	if saldo < 0 {
+		return newResponseError(fiber.StatusConflict, "Insufficient stock", fiber.Map{
+			"gudang_id": m.GudangID,
+			"barang_id": m.BarangID,
+			"tersedia":  stok.Jumlah,
+			"diminta":   -delta,
+		})
+	}
+
+	if err := tx.Model(stok).Update("jumlah", saldo).Error; err != nil {
+		return err
+	}
+
+	m.Jumlah = delta
+	m.SaldoSetelah = saldo
+	return tx.Create(m).Error
+}
+
+// kunciStok loads the stock row of a gudang/barang with a row lock, creating it at zero first
+func kunciStok(tx *gorm.DB, gudangID, barangID uint) (*models.StokLogistik, error) {
+	baru := models.StokLogistik{GudangID: gudangID, BarangID: barangID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&baru).Error; err != nil {
+		return nil, err
+	}
+
+	var stok models.StokLogistik
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("gudang_id = ? AND barang_id = ?", gudangID, barangID).
+		First(&stok).Error
+	return &stok, err
+}
+
+// cekGudangAktif loads an active warehouse
+func cekGudangAktif(tx *gorm.DB, id uint) (*models.GudangLogistik, error) {
+	var gudang models.GudangLogistik
+	if err := tx.First(&gudang, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newResponseError(fiber.StatusNotFound, "Gudang not found", nil)
+		}
+		return nil, err
+	}
+	if !gudang.Aktif {
+		return nil, newResponseError(fiber.StatusUnprocessableEntity, "Gudang is not active", fiber.Map{"gudang_id": gudang.ID})
+	}
+	return &gudang, nil
+}
+
+// cekBarangAda checks that an item exists in the catalogue
+func cekBarangAda(tx *gorm.DB, id uint) error {
+	if err := tx.First(&models.BarangLogistik{}, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return newResponseError(fiber.StatusBadRequest, "Barang not found", fiber.Map{"field": "barang_id", "barang_id": id})
+		}
+		return err
+	}
+	return nil
+}
+
+// applyGudangLogistik validates a request and copies it into the model
+func applyGudangLogistik(gudang *models.GudangLogistik, req models.GudangLogistikRequest) error {
+	if strings.TrimSpace(req.Nama) == "" {
+		return newResponseError(fiber.StatusBadRequest, "nama is required", fiber.Map{"field": "nama"})
+	}
+	if req.Jenis == "" {
+		req.Jenis = "Posko"
+	}
+	if req.Jenis != "Gudang" && req.Jenis != "Posko" {
+		return newResponseError(fiber.StatusBadRequest, "jenis must be Gudang or Posko", fiber.Map{"field": "jenis"})
+	}
+	if req.TitikKumpulID != nil {
+		if err := database.DB.First(&models.TitikKumpul{}, *req.TitikKumpulID).Error; err != nil {
+			return newResponseError(fiber.StatusBadRequest, "Titik kumpul not found", fiber.Map{"field": "titik_kumpul_id"})
+		}
+	}
+
+	gudang.Nama = strings.TrimSpace(req.Nama)
+	gudang.Jenis = req.Jenis
+	gudang.Alamat = req.Alamat
+	gudang.Latitude = req.Latitude
+	gudang.Longitude = req.Longitude
+	gudang.TitikKumpulID = req.TitikKumpulID
+	gudang.PenanggungJawab = req.PenanggungJawab
+	gudang.NoHP = req.NoHP
+	if req.Aktif != nil {
+		gudang.Aktif = *req.Aktif
+	}
+	return nil
+}
+
+// applyBarangLogistik validates a request and copies it into the model
+func applyBarangLogistik(barang *models.BarangLogistik, req models.BarangLogistikRequest) error {
+	req.Kode = strings.ToUpper(strings.TrimSpace(req.Kode))
+	if req.Kode == "" {
+		return newResponseError(fiber.StatusBadRequest, "kode is required", fiber.Map{"field": "kode"})
+	}
+	if strings.TrimSpace(req.Nama) == "" {
+		return newResponseError(fiber.StatusBadRequest, "nama is required", fiber.Map{"field": "nama"})
+	}
+	if strings.TrimSpace(req.Satuan) == "" {
+		return newResponseError(fiber.StatusBadRequest, "satuan is required", fiber.Map{"field": "satuan"})
+	}
+	if req.Kategori == "" {
+		req.Kategori = "Lainnya"
+	}
+	if !kategoriBarangValid[req.Kategori] {
+		return newResponseError(fiber.StatusBadRequest, "Unknown kategori: "+req.Kategori, fiber.Map{"field": "kategori"})
+	}
+	if req.StokMinimum < 0 {
+		return newResponseError(fiber.StatusBadRequest, "stok_minimum cannot be negative", fiber.Map{"field": "stok_minimum"})
+	}
+
+	barang.Kode = req.Kode
+	barang.Nama = strings.TrimSpace(req.Nama)
+	barang.Kategori = req.Kategori
+	barang.Satuan = strings.TrimSpace(req.Satuan)
+	barang.StokMinimum = req.StokMinimum
+	return nil
+}
+
+// publishStokLogistik sends the full stock of a warehouse to the sync pipeline.
+// Dipanggil sebagai goroutine setelah transaksi selesai.
+func publishStokLogistik(gudangID uint) {
+	var gudang models.GudangLogistik
+	if err := database.DB.Unscoped().First(&gudang, gudangID).Error; err != nil {
+		return
+	}
+
+	event := models.StokLogistikEvent{GudangID: gudang.ID, GudangNama: gudang.Nama, Item: []models.StokBarangEvent{}}
+	if gudang.DeletedAt.Valid || !gudang.Aktif {
+		// Gudang yang dihapus/nonaktif tidak lagi dihitung sebagai stok yang bisa dipindahkan
+		messaging.PublishEvent("UPDATE_STOK_LOGISTIK", kecamatanID(), event)
+		return
+	}
+
+	var stok []models.StokLogistik
+	database.DB.Joins("Barang").Where("stok_logistiks.gudang_id = ?", gudang.ID).Find(&stok)
+	for _, s := range stok {
+		if s.Barang == nil {
+			continue
+		}
+		event.Item = append(event.Item, models.StokBarangEvent{
+			BarangKode:  s.Barang.Kode,
+			BarangNama:  s.Barang.Nama,
+			Kategori:    s.Barang.Kategori,
+			Satuan:      s.Barang.Satuan,
+			Jumlah:      s.Jumlah,
+			StokMinimum: s.Barang.StokMinimum,
+		})
+	}
+
+	messaging.PublishEvent("UPDATE_STOK_LOGISTIK", kecamatanID(), event)
+}
@@ -0,0 +1,410 @@
+// handlers/lokasi_relawan.go
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Lokasi relawan hanya dibagikan selama shift aktif. Shift otomatis diakhiri saat
+// bencananya selesai atau melewati SHIFT_MAKS_JAM; ping lebih tua dari
+// LOKASI_RETENSI_HARI dihapus oleh StartPelacakanWorker.
+
+// Batas jumlah titik jejak dalam satu respons
+const maksTitikJejak = 5000
+
+// PingLokasi stores a GPS ping of the logged in relawan and pushes it to the live map
+func PingLokasi(c *fiber.Ctx) error {
+	var req models.PingLokasiRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	if !services.AdaKoordinat(req.Latitude, req.Longitude) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "latitude and longitude are required",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+	waktu := now
+	if req.Waktu != nil && req.Waktu.Before(now) {
+		waktu = *req.Waktu // Ping tertunda (sinyal hilang) tetap memakai waktu perangkat
+	}
+
+	var ping models.LokasiRelawan
+	var dimatikan *models.ShiftRelawan
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		profil, err := kunciProfilRelawan(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !profil.SedangBertugas || profil.ShiftAktifID == nil {
+			return newResponseError(fiber.StatusConflict, "Location sharing is off, check in to a shift first", fiber.Map{"berbagi_lokasi": false})
+		}
+
+		var shift models.ShiftRelawan
+		if err := tx.Preload("Bencana").First(&shift, *profil.ShiftAktifID).Error; err != nil {
+			return err
+		}
+		if alasan := alasanShiftBerakhir(shift, now); alasan != "" {
+			dimatikan, err = akhiriShift(tx, profil, now, alasan)
+			return err
+		}
+
+		ping = models.LokasiRelawan{
+			RelawanID: userID,
+			ShiftID:   shift.ID,
+			BencanaID: shift.BencanaID,
+			Latitude:  req.Latitude,
+			Longitude: req.Longitude,
+			AkurasiM:  req.AkurasiM,
+			Waktu:     waktu,
+		}
+		if req.TugasID != nil {
+			tugas, err := tugasMilikRelawan(tx, *req.TugasID, userID)
+			if err != nil {
+				return err
+			}
+			ping.TugasID = &tugas.ID
+		} else {
+			ping.TugasID = tugasBerjalan(tx, userID, shift.BencanaID)
+		}
+		if err := tx.Create(&ping).Error; err != nil {
+			return err
+		}
+
+		// Ping tertunda tidak boleh menimpa lokasi yang lebih baru
+		if profil.WaktuLokasi == nil || waktu.After(*profil.WaktuLokasi) {
+			return tx.Model(profil).Updates(map[string]interface{}{
+				"latitude":     req.Latitude,
+				"longitude":    req.Longitude,
+				"waktu_lokasi": waktu,
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to store lokasi")
+	}
+
+	if dimatikan != nil {
+		broadcastLokasiBerhenti(*dimatikan)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":          true,
+			"message":        "Shift ended: " + dimatikan.AlasanSelesai + ", location sharing is off",
+			"berbagi_lokasi": false,
+		})
+	}
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":       "lokasi_relawan",
+		"relawan_id": ping.RelawanID,
+		"bencana_id": ping.BencanaID,
+		"tugas_id":   ping.TugasID,
+		"latitude":   ping.Latitude,
+		"longitude":  ping.Longitude,
+		"akurasi_m":  ping.AkurasiM,
+		"waktu":      ping.Waktu.Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":          false,
+		"data":           ping,
+		"berbagi_lokasi": true,
+	})
+}
+
+// GetPetaRelawan returns the last position of every relawan on shift for a bencana.
+// Pembaruan berikutnya datang lewat SSE /notifikasi/stream ("tipe":"lokasi_relawan").
+func GetPetaRelawan(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid bencana ID",
+		})
+	}
+
+	var shift []models.ShiftRelawan
+	if err := database.DB.Preload("Relawan").
+		Where("bencana_id = ? AND waktu_selesai IS NULL", bencanaID).
+		Find(&shift).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch peta relawan",
+		})
+	}
+
+	relawanIDs := make([]uint, 0, len(shift))
+	for _, s := range shift {
+		relawanIDs = append(relawanIDs, s.RelawanID)
+	}
+	var profil []models.ProfilRelawan
+	database.DB.Where("user_id IN ?", relawanIDs).Find(&profil)
+	profilPerRelawan := make(map[uint]models.ProfilRelawan, len(profil))
+	for _, p := range profil {
+		profilPerRelawan[p.UserID] = p
+	}
+	beban, _ := bebanRelawan(database.DB)
+
+	batasBasi := time.Now().Add(-time.Duration(envInt("LOKASI_BASI_MENIT", 10)) * time.Minute)
+	peta := make([]fiber.Map, 0, len(shift))
+	for _, s := range shift {
+		p := profilPerRelawan[s.RelawanID]
+		var lat, lng interface{}
+		if p.WaktuLokasi != nil {
+			lat, lng = p.Latitude, p.Longitude
+		}
+		peta = append(peta, fiber.Map{
+			"relawan_id":   s.RelawanID,
+			"nama":         s.Relawan.NamaLengkap,
+			"no_hp":        s.Relawan.NoHP,
+			"shift_id":     s.ID,
+			"mulai_shift":  s.WaktuMulai,
+			"latitude":     lat,
+			"longitude":    lng,
+			"waktu_lokasi": p.WaktuLokasi,
+			"basi":         p.WaktuLokasi == nil || p.WaktuLokasi.Before(batasBasi),
+			"tugas_aktif":  beban[s.RelawanID],
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  peta,
+		"total": len(peta),
+	})
+}
+
+// GetJejakTugas returns the GPS trail recorded while a relawan worked on an evacuation task.
+// Query: format=json|geojson
+func GetJejakTugas(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var tugas models.TugasEvakuasi
+	if err := database.DB.First(&tugas, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Task not found",
+		})
+	}
+
+	return kirimJejak(c, tugas.RelawanID, database.DB.Where("tugas_id = ?", tugas.ID))
+}
+
+// GetJejakRelawan returns the GPS trail of a relawan (:id = user ID relawan).
+// Query: bencana_id, shift_id, dari, sampai (RFC3339), format=json|geojson
+func GetJejakRelawan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	query := database.DB.Where("relawan_id = ?", id)
+	if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+	if shiftID := c.QueryInt("shift_id", 0); shiftID > 0 {
+		query = query.Where("shift_id = ?", shiftID)
+	}
+	for param, op := range map[string]string{"dari": ">=", "sampai": "<="} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": param + " must be an RFC3339 timestamp",
+				"field":   param,
+			})
+		}
+		query = query.Where("waktu "+op+" ?", t)
+	}
+
+	return kirimJejak(c, uint(id), query)
+}
+
+// kirimJejak loads the pings of query in time order and writes them as JSON or GeoJSON
+func kirimJejak(c *fiber.Ctx, relawanID uint, query *gorm.DB) error {
+	format := c.Query("format", "json")
+	if format != "json" && format != "geojson" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "format must be json or geojson",
+		})
+	}
+
+	var titik []models.LokasiRelawan
+	if err := query.Order("waktu ASC").Limit(maksTitikJejak + 1).Find(&titik).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch jejak",
+		})
+	}
+	terpotong := len(titik) > maksTitikJejak
+	if terpotong {
+		titik = titik[:maksTitikJejak]
+	}
+
+	if format == "geojson" {
+		body, err := json.Marshal(services.JejakGeoJSON(relawanID, titik))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to export jejak",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "application/geo+json")
+		return c.Send(body)
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  titik,
+		"meta": fiber.Map{
+			"total":          len(titik),
+			"total_jarak_km": services.PanjangJejakKm(titik),
+			"terpotong":      terpotong,
+		},
+	})
+}
+
+// StartPelacakanWorker periodically ends expired shifts and purges old location pings.
+// Dijalankan sebagai goroutine dari main API Kecamatan.
+func StartPelacakanWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		akhiriShiftKedaluwarsa(now)
+		hapusLokasiLama(now)
+	}
+}
+
+// akhiriShiftBencana ends every shift tied to a bencana that has just finished
+func akhiriShiftBencana(bencanaID uint) {
+	var relawanIDs []uint
+	database.DB.Model(&models.ShiftRelawan{}).
+		Where("bencana_id = ? AND waktu_selesai IS NULL", bencanaID).
+		Pluck("relawan_id", &relawanIDs)
+
+	for _, id := range relawanIDs {
+		matikanBerbagiLokasi(id, "Bencana Selesai")
+	}
+}
+
+// akhiriShiftKedaluwarsa ends shifts whose bencana is over or that exceeded SHIFT_MAKS_JAM
+func akhiriShiftKedaluwarsa(now time.Time) {
+	var shift []models.ShiftRelawan
+	if err := database.DB.Preload("Bencana").Where("waktu_selesai IS NULL").Find(&shift).Error; err != nil {
+		log.Printf("❌ Gagal memeriksa shift relawan: %v", err)
+		return
+	}
+	for _, s := range shift {
+		if alasan := alasanShiftBerakhir(s, now); alasan != "" {
+			matikanBerbagiLokasi(s.RelawanID, alasan)
+		}
+	}
+}
+
+// alasanShiftBerakhir tells why a shift must end now, or "" when it may continue.
+// Bencana harus sudah di-preload.
+func alasanShiftBerakhir(shift models.ShiftRelawan, now time.Time) string {
+	if shift.Bencana != nil && shift.Bencana.Status != "Aktif" {
+		return "Bencana Selesai"
+	}
+	if now.Sub(shift.WaktuMulai) > time.Duration(envInt("SHIFT_MAKS_JAM", 12))*time.Hour {
+		return "Melewati Batas Shift"
+	}
+	return ""
+}
+
+// matikanBerbagiLokasi ends the active shift of a relawan and tells the live map
+func matikanBerbagiLokasi(relawanID uint, alasan string) {
+	var shift *models.ShiftRelawan
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		profil, err := kunciProfilRelawan(tx, relawanID)
+		if err != nil {
+			return err
+		}
+		if !profil.SedangBertugas {
+			return nil // Sudah check-out
+		}
+		shift, err = akhiriShift(tx, profil, time.Now(), alasan)
+		return err
+	})
+	if err != nil {
+		log.Printf("❌ Gagal mengakhiri shift relawan %d: %v", relawanID, err)
+		return
+	}
+	if shift != nil {
+		broadcastLokasiBerhenti(*shift)
+	}
+}
+
+// hapusLokasiLama deletes pings older than LOKASI_RETENSI_HARI in small batches
+func hapusLokasiLama(now time.Time) {
+	batas := now.AddDate(0, 0, -envInt("LOKASI_RETENSI_HARI", 30))
+	for {
+		result := database.DB.Exec("DELETE FROM lokasi_relawans WHERE waktu < ? LIMIT 5000", batas)
+		if result.Error != nil {
+			log.Printf("❌ Gagal menghapus lokasi relawan lama: %v", result.Error)
+			return
+		}
+		if result.RowsAffected < 5000 {
+			return
+		}
+	}
+}
+
+// tugasBerjalan returns the task a relawan is currently working on, if any
+func tugasBerjalan(tx *gorm.DB, relawanID uint, bencanaID *uint) *uint {
+	query := tx.Model(&models.TugasEvakuasi{}).Where("relawan_id = ? AND status = ?", relawanID, "Diterima")
+	if bencanaID != nil {
+		query = query.Where("bencana_id = ?", *bencanaID)
+	}
+	var tugas models.TugasEvakuasi
+	if err := query.Order("waktu_direspon DESC").First(&tugas).Error; err != nil {
+		return nil
+	}
+	return &tugas.ID
+}
+
+// broadcastLokasiBerhenti removes a relawan from the live map of SSE clients
+func broadcastLokasiBerhenti(shift models.ShiftRelawan) {
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":       "lokasi_relawan_berhenti",
+		"relawan_id": shift.RelawanID,
+		"bencana_id": shift.BencanaID,
+		"shift_id":   shift.ID,
+		"alasan":     shift.AlasanSelesai,
+		"waktu":      time.Now().Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+	log.Printf("📍 Berbagi lokasi relawan %d dimatikan: %s", shift.RelawanID, shift.AlasanSelesai)
+}
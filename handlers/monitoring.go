@@ -10,6 +10,7 @@ import (
 
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -155,6 +156,13 @@ func SendDaruratNotification(c *fiber.Ctx) error {
 			"message": "Bencana not found",
 		})
 	}
+	if bencana.Status == "Digabung" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":          true,
+			"message":        "Bencana has been merged; notify through the bencana it was merged into",
+			"digabung_ke_id": bencana.DigabungKeID,
+		})
+	}
 
 	// Format broadcast message
 	message := fmt.Sprintf(
@@ -168,11 +176,25 @@ func SendDaruratNotification(c *fiber.Ctx) error {
 	// Broadcast to all connected SSE clients
 	broadcastToClients(message)
 
+	// Notifikasi dicatat per bencana agar ikut terbawa saat bencana digabung
+	userID := c.Locals("userID").(uint)
+	notifikasi := models.NotifikasiBencana{
+		BencanaID:   bencana.ID,
+		Jenis:       "Darurat",
+		Level:       req.Level,
+		Pesan:       req.Message,
+		DikirimOleh: &userID,
+		Waktu:       time.Now(),
+	}
+	database.DB.Create(&notifikasi)
+
 	// Send WhatsApp notifications (integrate with WA API)
-	go sendWhatsAppNotifications(bencana, req.Message)
+	go func() {
+		penerima := sendWhatsAppNotifications(bencana, notifikasi)
+		database.DB.Model(&notifikasi).Update("jumlah_penerima", penerima)
+	}()
 
 	// Log activity
-	userID := c.Locals("userID").(uint)
 	logActivity(userID, "Mengirim notifikasi darurat: "+bencana.JenisBencana)
 
 	return c.JSON(fiber.Map{
@@ -185,6 +207,47 @@ func SendDaruratNotification(c *fiber.Ctx) error {
 	})
 }
 
+// GetNotifikasiBencana returns the notifications sent for a bencana, newest first
+func GetNotifikasiBencana(c *fiber.Ctx) error {
+	var notifikasi []models.NotifikasiBencana
+	if err := database.DB.Where("bencana_id = ?", c.Params("id")).Order("waktu DESC").Find(&notifikasi).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch notifikasi",
+		})
+	}
+
+	// Jumlah warga yang sudah membalas tiap notifikasi
+	if len(notifikasi) > 0 {
+		ids := make([]uint, len(notifikasi))
+		for i, n := range notifikasi {
+			ids[i] = n.ID
+		}
+		var respons []struct {
+			NotifikasiID uint
+			Jumlah       int
+		}
+		database.DB.Model(&models.PenerimaNotifikasi{}).
+			Select("notifikasi_id, COUNT(*) AS jumlah").
+			Where("notifikasi_id IN ? AND diterima_pada IS NOT NULL", ids).
+			Group("notifikasi_id").
+			Scan(&respons)
+		jumlah := make(map[uint]int, len(respons))
+		for _, r := range respons {
+			jumlah[r.NotifikasiID] = r.Jumlah
+		}
+		for i := range notifikasi {
+			notifikasi[i].JumlahRespons = jumlah[notifikasi[i].ID]
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  notifikasi,
+		"total": len(notifikasi),
+	})
+}
+
 // BroadcastStream handles SSE connections
 // FUNGSI INI HANYA DIJALANKAN DI API KECAMATAN
 func BroadcastStream(c *fiber.Ctx) error {
@@ -245,49 +308,27 @@ func BroadcastStream(c *fiber.Ctx) error {
 	return nil
 }
 
-// GetSystemLogs returns system activity logs
-func GetSystemLogs(c *fiber.Ctx) error {
-	var logs []models.SystemLog
+// systemLogListSpec defines sorting and free-text search for system logs
+var systemLogListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":        "id",
+		"timestamp": "timestamp",
+		"user_id":   "user_id",
+	},
+	DefaultSort: "-timestamp",
+	Search:      []string{"aktivitas LIKE ?"},
+}
 
-	query := database.DB.Preload("User")
+// GetSystemLogs returns system activity logs, paginated (see list_query.go)
+func GetSystemLogs(c *fiber.Ctx) error {
+	query := database.DB
 
 	// Filter by user
 	if userID := c.Query("user_id"); userID != "" {
 		query = query.Where("user_id = ?", userID)
 	}
 
-	// Pagination
-	limit := 50
-	if l := c.Query("limit"); l != "" {
-		if parsedLimit, err := strconv.Atoi(l); err == nil {
-			limit = parsedLimit
-		}
-	}
-
-	offset := 0
-	if o := c.Query("offset"); o != "" {
-		if parsedOffset, err := strconv.Atoi(o); err == nil {
-			offset = parsedOffset
-		}
-	}
-
-	var total int64
-	query.Model(&models.SystemLog{}).Count(&total)
-
-	if err := query.Order("timestamp DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to fetch system logs",
-		})
-	}
-
-	return c.JSON(fiber.Map{
-		"error":  false,
-		"data":   logs,
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
-	})
+	return listPage[models.SystemLog](c, query, systemLogListSpec, "Failed to fetch system logs", "User")
 }
 
 // Helper function to broadcast to all SSE clients
@@ -304,11 +345,9 @@ func broadcastToClients(message string) {
 	}
 }
 
-// Helper function to send WhatsApp notifications
-func sendWhatsAppNotifications(bencana models.KejadianBencana, message string) {
-	// TODO: Integrate with WhatsApp Business API or third-party service
-	// This is a placeholder for the actual implementation
-
+// Helper function to send WhatsApp notifications. Setiap penerima mendapat tautan balasan
+// dan masuk roster keselamatan bencana (lihat keselamatan.go).
+func sendWhatsAppNotifications(bencana models.KejadianBencana, notifikasi models.NotifikasiBencana) int {
 	// Get target phone numbers based on bencana level
 	var warga []models.WargaRentan
 	query := database.DB.Where("no_hp IS NOT NULL AND no_hp != ''")
@@ -320,9 +359,234 @@ func sendWhatsAppNotifications(bencana models.KejadianBencana, message string) {
 
 	query.Find(&warga)
 
-	// Send notifications (implement actual WA API integration)
-	for _, w := range warga {
-		// sendWAMessage(w.NoHP, message)
-		fmt.Printf("Sending WA to %s: %s\n", w.NoHP, message)
+	return kirimPeringatanKeselamatan(bencana, notifikasi, warga)
+}
+
+// kirimWhatsApp sends one message to every number through the gateway in PESAN_GATEWAY (lihat pesan.go)
+func kirimWhatsApp(nomor []string, message string) {
+	for _, n := range nomor {
+		if hp := services.NormalisasiNoHP(n); hp != "" {
+			kirimPesan("WhatsApp", hp, message)
+		}
+	}
+}
+
+// GetOkupansiTitikKumpulKota returns the shelter occupancy of every kecamatan
+// FUNGSI INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA (diisi Sync Worker)
+func GetOkupansiTitikKumpulKota(c *fiber.Ctx) error {
+	query := database.DB.Preload("Kecamatan")
+	if kecamatanID := c.QueryInt("kecamatan_id", 0); kecamatanID > 0 {
+		query = query.Where("kecamatan_id = ?", kecamatanID)
+	}
+
+	var titik []models.OkupansiTitikKumpulKota
+	if err := query.Order("kecamatan_id, nama").Find(&titik).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch titik kumpul",
+		})
+	}
+
+	// Rekap per kecamatan (hanya titik kumpul aktif)
+	type rekapOkupansi struct {
+		KecamatanID uint   `json:"kecamatan_id"`
+		Nama        string `json:"nama"`
+		TitikKumpul int    `json:"titik_kumpul"`
+		Kapasitas   int    `json:"kapasitas"`
+		Terisi      int    `json:"terisi"`
+	}
+	rekap := make([]*rekapOkupansi, 0)
+	perKecamatan := make(map[uint]*rekapOkupansi)
+	var totalKapasitas, totalTerisi int
+	for _, t := range titik {
+		if !t.Aktif {
+			continue
+		}
+		r, ok := perKecamatan[t.KecamatanID]
+		if !ok {
+			r = &rekapOkupansi{KecamatanID: t.KecamatanID, Nama: t.Kecamatan.Nama}
+			perKecamatan[t.KecamatanID] = r
+			rekap = append(rekap, r)
+		}
+		r.TitikKumpul++
+		r.Kapasitas += t.Kapasitas
+		r.Terisi += t.Terisi
+		totalKapasitas += t.Kapasitas
+		totalTerisi += t.Terisi
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"titik_kumpul":    titik,
+			"rekap_kecamatan": rekap,
+			"total_kapasitas": totalKapasitas,
+			"total_terisi":    totalTerisi,
+		},
+	})
+}
+
+// GetRekapOrangHilangKota returns the missing person counts of every kecamatan
+// FUNGSI INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA (diisi Sync Worker)
+func GetRekapOrangHilangKota(c *fiber.Ctx) error {
+	query := database.DB.Preload("Kecamatan")
+	if kecamatanID := c.QueryInt("kecamatan_id", 0); kecamatanID > 0 {
+		query = query.Where("kecamatan_id = ?", kecamatanID)
+	}
+
+	var rekap []models.RekapOrangHilangKota
+	if err := query.Order("jumlah_hilang DESC").Find(&rekap).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch rekap orang hilang",
+		})
+	}
+
+	var totalHilang, totalDitemukan int
+	for _, r := range rekap {
+		totalHilang += r.JumlahHilang
+		totalDitemukan += r.JumlahDitemukan
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"rekap":           rekap,
+			"total_hilang":    totalHilang,
+			"total_ditemukan": totalDitemukan,
+		},
+	})
+}
+
+// GetRekapPaparanKota returns how many warga of every kecamatan live in hazard zones.
+// Query: kecamatan_id, jenis_bahaya
+// FUNGSI INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA (diisi Sync Worker)
+func GetRekapPaparanKota(c *fiber.Ctx) error {
+	query := database.DB.Preload("Kecamatan")
+	if kecamatanID := c.QueryInt("kecamatan_id", 0); kecamatanID > 0 {
+		query = query.Where("kecamatan_id = ?", kecamatanID)
+	}
+	if jenis := c.Query("jenis_bahaya"); jenis != "" {
+		query = query.Where("jenis_bahaya = ?", jenis)
+	}
+
+	var rekap []models.RekapPaparanKota
+	if err := query.Order("kecamatan_id, jenis_bahaya, jumlah_warga DESC").Find(&rekap).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch rekap paparan",
+		})
 	}
+
+	// Total per jenis bahaya untuk seluruh kota
+	type totalJenis struct {
+		JumlahWarga  int            `json:"jumlah_warga"`
+		JumlahRentan int            `json:"jumlah_rentan"`
+		PerKelas     map[string]int `json:"per_kelas"`
+	}
+	total := make(map[string]*totalJenis)
+	for _, r := range rekap {
+		t, ok := total[r.JenisBahaya]
+		if !ok {
+			t = &totalJenis{PerKelas: make(map[string]int)}
+			total[r.JenisBahaya] = t
+		}
+		t.JumlahWarga += r.JumlahWarga
+		t.JumlahRentan += r.JumlahRentan
+		t.PerKelas[r.KelasRisiko] += r.JumlahWarga
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"rekap": rekap,
+			"total": total,
+		},
+	})
+}
+
+// GetPenggabunganBencanaKota returns the duplicate bencana merged in every kecamatan.
+// Query: kecamatan_id
+// FUNGSI INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA (diisi Sync Worker)
+func GetPenggabunganBencanaKota(c *fiber.Ctx) error {
+	query := database.DB.Preload("Kecamatan")
+	if kecamatanID := c.QueryInt("kecamatan_id", 0); kecamatanID > 0 {
+		query = query.Where("kecamatan_id = ?", kecamatanID)
+	}
+
+	var riwayat []models.PenggabunganBencanaKota
+	if err := query.Order("waktu DESC").Limit(200).Find(&riwayat).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch penggabungan bencana",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  riwayat,
+	})
+}
+
+// GetStokLogistikKota returns relief stock of every kecamatan with rebalancing suggestions.
+// Query: kecamatan_id, barang_kode, kategori
+// FUNGSI INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA (diisi Sync Worker)
+func GetStokLogistikKota(c *fiber.Ctx) error {
+	query := database.DB.Preload("Kecamatan")
+	if kecamatanID := c.QueryInt("kecamatan_id", 0); kecamatanID > 0 {
+		query = query.Where("kecamatan_id = ?", kecamatanID)
+	}
+	if kode := c.Query("barang_kode"); kode != "" {
+		query = query.Where("barang_kode = ?", kode)
+	}
+	if kategori := c.Query("kategori"); kategori != "" {
+		query = query.Where("kategori = ?", kategori)
+	}
+
+	var stok []models.StokLogistikKota
+	if err := query.Order("barang_kode, kecamatan_id, gudang_nama").Find(&stok).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch stok logistik",
+		})
+	}
+
+	// Total per kecamatan + barang; minimum kecamatan = jumlah minimum semua gudangnya
+	type kunci struct {
+		kecamatanID uint
+		kode        string
+	}
+	indeks := make(map[kunci]int)
+	var saldo []services.SaldoKecamatan
+	for _, s := range stok {
+		k := kunci{s.KecamatanID, s.BarangKode}
+		i, ok := indeks[k]
+		if !ok {
+			i = len(saldo)
+			indeks[k] = i
+			saldo = append(saldo, services.SaldoKecamatan{KecamatanID: s.KecamatanID, BarangKode: s.BarangKode})
+		}
+		saldo[i].Jumlah += s.Jumlah
+		saldo[i].StokMinimum += s.StokMinimum
+	}
+
+	perKecamatan := make([]fiber.Map, 0, len(saldo))
+	for _, s := range saldo {
+		perKecamatan = append(perKecamatan, fiber.Map{
+			"kecamatan_id": s.KecamatanID,
+			"barang_kode":  s.BarangKode,
+			"jumlah":       s.Jumlah,
+			"stok_minimum": s.StokMinimum,
+			"kurang":       s.Jumlah < s.StokMinimum,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"stok":             stok,
+			"per_kecamatan":    perKecamatan,
+			"saran_pemindahan": services.SaranRebalancing(saldo),
+		},
+	})
 }
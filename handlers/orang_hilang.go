@@ -0,0 +1,463 @@
+// handlers/orang_hilang.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Status keberadaan warga pada rekonsiliasi
+const (
+	keberadaanDiTitikKumpul  = "Di Titik Kumpul"
+	keberadaanTerevakuasi    = "Terevakuasi"
+	keberadaanDalamEvakuasi  = "Dalam Evakuasi"
+	keberadaanKeluarShelter  = "Keluar Titik Kumpul"
+	keberadaanBelumDiketahui = "Belum Diketahui"
+)
+
+// laporanHilangListSpec defines sorting and search for missing person reports
+var laporanHilangListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":         "id",
+		"nama":       "nama",
+		"status":     "status",
+		"created_at": "created_at",
+	},
+	DefaultSort: "-created_at",
+	Search:      []string{"nama LIKE ?", "nik LIKE ?", "pelapor_nama LIKE ?"},
+}
+
+// GetRekonsiliasiWarga compares every warga in the affected area with the evacuation
+// logs and shelter registry, listing who is still unaccounted for first
+func GetRekonsiliasiWarga(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid bencana ID",
+		})
+	}
+
+	var bencana models.KejadianBencana
+	if err := database.DB.First(&bencana, bencanaID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana not found",
+		})
+	}
+
+	query := queryWargaWilayahBencana(bencana)
+	if c.QueryBool("rentan") {
+		query = query.Where("kategori_rentan != ?", "Non-Rentan")
+	}
+	if rt := c.Query("rt"); rt != "" {
+		query = query.Where("rt = ?", rt)
+	}
+
+	var warga []models.WargaRentan
+	if err := query.Find(&warga).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch warga",
+		})
+	}
+
+	// Registrasi terbaru per warga (urut naik, yang terakhir menimpa)
+	var registrasi []models.RegistrasiPengungsi
+	database.DB.Where("bencana_id = ? AND warga_id IS NOT NULL", bencana.ID).Order("waktu_masuk, id").Find(&registrasi)
+	regPerWarga := make(map[uint]models.RegistrasiPengungsi, len(registrasi))
+	for _, r := range registrasi {
+		regPerWarga[*r.WargaID] = r
+	}
+
+	var logs []models.LogEvakuasi
+	database.DB.Where("bencana_id = ?", bencana.ID).Find(&logs)
+	logPerWarga := make(map[uint]models.LogEvakuasi, len(logs))
+	for _, l := range logs {
+		logPerWarga[l.WargaID] = l
+	}
+
+	var laporan []models.LaporanOrangHilang
+	database.DB.Where("bencana_id = ? AND status = ? AND warga_id IS NOT NULL", bencana.ID, "Hilang").Find(&laporan)
+	laporanPerWarga := make(map[uint]uint, len(laporan))
+	for _, l := range laporan {
+		laporanPerWarga[*l.WargaID] = l.ID
+	}
+
+	filter := c.Query("keberadaan")
+	ringkasan := make(map[string]int)
+	hasil := make([]fiber.Map, 0, len(warga))
+	for _, w := range warga {
+		keberadaan, titikKumpulID := keberadaanWarga(regPerWarga[w.ID], logPerWarga[w.ID])
+		ringkasan[keberadaan]++
+
+		if filter != "" && filter != keberadaan {
+			continue
+		}
+
+		var laporanID *uint
+		if id, ok := laporanPerWarga[w.ID]; ok {
+			laporanID = &id
+		}
+
+		hasil = append(hasil, fiber.Map{
+			"warga_id":          w.ID,
+			"nama":              w.Nama,
+			"rt":                w.RT,
+			"rw":                w.RW,
+			"alamat":            w.Alamat,
+			"kategori_rentan":   w.KategoriRentan,
+			"kartu_keluarga_id": w.KartuKeluargaID,
+			"keberadaan":        keberadaan,
+			"titik_kumpul_id":   titikKumpulID,
+			"laporan_hilang_id": laporanID,
+		})
+	}
+
+	// Yang belum diketahui keberadaannya di atas
+	sort.SliceStable(hasil, func(i, j int) bool {
+		a, b := hasil[i]["keberadaan"] == keberadaanBelumDiketahui, hasil[j]["keberadaan"] == keberadaanBelumDiketahui
+		if a != b {
+			return a
+		}
+		if hasil[i]["rt"] != hasil[j]["rt"] {
+			return hasil[i]["rt"].(string) < hasil[j]["rt"].(string)
+		}
+		return hasil[i]["nama"].(string) < hasil[j]["nama"].(string)
+	})
+
+	var tidakTerdata int64
+	database.DB.Model(&models.RegistrasiPengungsi{}).
+		Where("bencana_id = ? AND warga_id IS NULL AND status = ?", bencana.ID, "Di Lokasi").
+		Count(&tidakTerdata)
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  hasil,
+		"total": len(hasil),
+		"ringkasan": fiber.Map{
+			"total_warga":             len(warga),
+			"per_keberadaan":          ringkasan,
+			"belum_diketahui":         ringkasan[keberadaanBelumDiketahui],
+			"laporan_hilang_aktif":    len(laporan),
+			"pengungsi_tidak_terdata": tidakTerdata,
+		},
+	})
+}
+
+// GetAllLaporanOrangHilang returns missing person reports, paginated (see list_query.go)
+func GetAllLaporanOrangHilang(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.LaporanOrangHilang{})
+
+	if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	return listPage[models.LaporanOrangHilang](c, query, laporanHilangListSpec, "Failed to fetch reports")
+}
+
+// GetLaporanOrangHilangByID returns a report with candidate matches from the shelter registry
+func GetLaporanOrangHilangByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var laporan models.LaporanOrangHilang
+	if err := database.DB.Preload("Warga").Preload("Registrasi").Preload("Registrasi.TitikKumpul").First(&laporan, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Laporan not found",
+		})
+	}
+
+	kecocokan := []fiber.Map{}
+	if laporan.Status == "Hilang" {
+		kecocokan = cariKecocokanRegistrasi(laporan)
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"laporan":   laporan,
+			"kecocokan": kecocokan,
+		},
+	})
+}
+
+// CreateLaporanOrangHilang files a missing person report
+func CreateLaporanOrangHilang(c *fiber.Ctx) error {
+	var req models.CreateLaporanOrangHilangRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if strings.TrimSpace(req.PelaporNama) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "pelapor_nama is required",
+			"field":   "pelapor_nama",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	laporan := models.LaporanOrangHilang{
+		BencanaID:             req.BencanaID,
+		Nama:                  strings.TrimSpace(req.Nama),
+		NIK:                   req.NIK,
+		JenisKelamin:          req.JenisKelamin,
+		Umur:                  req.Umur,
+		CiriCiri:              req.CiriCiri,
+		TerakhirTerlihatDi:    req.TerakhirTerlihatDi,
+		TerakhirTerlihatWaktu: req.TerakhirTerlihatWaktu,
+		TerakhirLatitude:      req.TerakhirLatitude,
+		TerakhirLongitude:     req.TerakhirLongitude,
+		PelaporNama:           strings.TrimSpace(req.PelaporNama),
+		PelaporNoHP:           req.PelaporNoHP,
+		PelaporHubungan:       req.PelaporHubungan,
+		DicatatOleh:           userID,
+		Status:                "Hilang",
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := cekBencanaAktif(tx, req.BencanaID); err != nil {
+			return err
+		}
+
+		if req.WargaID != nil {
+			warga, err := cekWargaAda(tx, *req.WargaID)
+			if err != nil {
+				return err
+			}
+
+			var existing models.LaporanOrangHilang
+			err = tx.Where("bencana_id = ? AND warga_id = ? AND status = ?", req.BencanaID, warga.ID, "Hilang").First(&existing).Error
+			if err == nil {
+				return newResponseError(fiber.StatusConflict, "This warga has already been reported missing", fiber.Map{
+					"existing": fiber.Map{"id": existing.ID, "pelapor_nama": existing.PelaporNama},
+				})
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			// Identitas diambil dari data warga agar pencocokan akurat
+			laporan.WargaID = &warga.ID
+			laporan.Nama = warga.Nama
+			laporan.JenisKelamin = warga.JenisKelamin
+			if warga.NIK != nil {
+				laporan.NIK = *warga.NIK
+			}
+			if warga.TanggalLahir != nil {
+				umur := umurPada(*warga.TanggalLahir, time.Now())
+				laporan.Umur = &umur
+			}
+		}
+
+		if laporan.Nama == "" {
+			return newResponseError(fiber.StatusBadRequest, "nama or warga_id is required", fiber.Map{"field": "nama"})
+		}
+		return tx.Create(&laporan).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to create report")
+	}
+
+	logActivity(userID, "Laporan orang hilang: "+laporan.Nama)
+
+	go publishOrangHilang(laporan.BencanaID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Report created successfully",
+		"data":    laporan,
+		// Mungkin orangnya sudah tercatat di titik kumpul
+		"kecocokan": cariKecocokanRegistrasi(laporan),
+	})
+}
+
+// TandaiDitemukan marks a missing person as found, optionally at a shelter registration
+func TandaiDitemukan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.TandaiDitemukanRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	var laporan models.LaporanOrangHilang
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&laporan, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newResponseError(fiber.StatusNotFound, "Laporan not found", nil)
+			}
+			return err
+		}
+		if laporan.Status != "Hilang" {
+			return newResponseError(fiber.StatusUnprocessableEntity, "Person has already been marked found", nil)
+		}
+
+		if req.RegistrasiID != nil {
+			var reg models.RegistrasiPengungsi
+			if err := tx.First(&reg, *req.RegistrasiID).Error; err != nil {
+				return newResponseError(fiber.StatusBadRequest, "Registrasi not found", fiber.Map{"field": "registrasi_id"})
+			}
+			if reg.BencanaID != laporan.BencanaID {
+				return newResponseError(fiber.StatusBadRequest, "Registrasi belongs to another bencana", fiber.Map{"field": "registrasi_id"})
+			}
+			laporan.RegistrasiID = &reg.ID
+			// Orang tak terdata yang ternyata warga: hubungkan laporannya
+			if laporan.WargaID == nil {
+				laporan.WargaID = reg.WargaID
+			}
+		}
+
+		now := time.Now()
+		laporan.Status = "Ditemukan"
+		laporan.WaktuDitemukan = &now
+		laporan.DitemukanOleh = &userID
+		laporan.KeteranganDitemukan = req.Keterangan
+		return tx.Save(&laporan).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to update report")
+	}
+
+	logActivity(userID, "Orang hilang ditemukan: "+laporan.Nama)
+
+	go publishOrangHilang(laporan.BencanaID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Marked as found",
+		"data":    laporan,
+	})
+}
+
+// keberadaanWarga decides where a warga is from their latest registration and evacuation log
+func keberadaanWarga(reg models.RegistrasiPengungsi, log models.LogEvakuasi) (string, *uint) {
+	switch {
+	case reg.ID != 0 && reg.Status == "Di Lokasi":
+		return keberadaanDiTitikKumpul, &reg.TitikKumpulID
+	case reg.ID != 0:
+		return keberadaanKeluarShelter, nil
+	}
+
+	switch log.StatusTerkini {
+	case services.StatusDiTitikKumpul:
+		return keberadaanDiTitikKumpul, log.TitikKumpulID
+	case services.StatusTerevakuasi:
+		return keberadaanTerevakuasi, nil
+	case services.StatusMenunggu, services.StatusDalamProses:
+		return keberadaanDalamEvakuasi, nil
+	}
+	return keberadaanBelumDiketahui, nil
+}
+
+// cariKecocokanRegistrasi compares a report with the shelter registrations of its bencana
+func cariKecocokanRegistrasi(laporan models.LaporanOrangHilang) []fiber.Map {
+	var registrasi []models.RegistrasiPengungsi
+	database.DB.Preload("TitikKumpul").
+		Where("bencana_id = ? AND status = ?", laporan.BencanaID, "Di Lokasi").
+		Find(&registrasi)
+
+	orang := dataOrangLaporan(laporan)
+	kecocokan := []fiber.Map{}
+	for _, r := range registrasi {
+		skor := services.SkorKecocokan(orang, dataOrangRegistrasi(r))
+		if skor < services.BatasKecocokanOrang {
+			continue
+		}
+		kecocokan = append(kecocokan, fiber.Map{
+			"skor":       skor,
+			"registrasi": r,
+		})
+	}
+
+	sort.SliceStable(kecocokan, func(i, j int) bool {
+		return kecocokan[i]["skor"].(float64) > kecocokan[j]["skor"].(float64)
+	})
+	return kecocokan
+}
+
+// cocokkanLaporanHilang alerts officers over SSE when a new check-in matches an open report.
+// Dipanggil sebagai goroutine setelah check-in.
+func cocokkanLaporanHilang(reg models.RegistrasiPengungsi) {
+	var laporan []models.LaporanOrangHilang
+	database.DB.Where("bencana_id = ? AND status = ?", reg.BencanaID, "Hilang").Find(&laporan)
+
+	orang := dataOrangRegistrasi(reg)
+	for _, l := range laporan {
+		skor := services.SkorKecocokan(dataOrangLaporan(l), orang)
+		if skor < services.BatasKecocokanOrang {
+			continue
+		}
+
+		message, _ := json.Marshal(fiber.Map{
+			"tipe":            "kecocokan_orang_hilang",
+			"laporan_id":      l.ID,
+			"registrasi_id":   reg.ID,
+			"titik_kumpul_id": reg.TitikKumpulID,
+			"nama_laporan":    l.Nama,
+			"nama_registrasi": reg.Nama,
+			"skor":            skor,
+		})
+		broadcastToClients(string(message))
+	}
+}
+
+// publishOrangHilang sends the missing person count of a bencana to the sync pipeline
+func publishOrangHilang(bencanaID uint) {
+	var hilang, ditemukan int64
+	database.DB.Model(&models.LaporanOrangHilang{}).Where("bencana_id = ? AND status = ?", bencanaID, "Hilang").Count(&hilang)
+	database.DB.Model(&models.LaporanOrangHilang{}).Where("bencana_id = ? AND status = ?", bencanaID, "Ditemukan").Count(&ditemukan)
+
+	messaging.PublishEvent("UPDATE_ORANG_HILANG", kecamatanID(), models.OrangHilangEvent{
+		BencanaID:       bencanaID,
+		JumlahHilang:    int(hilang),
+		JumlahDitemukan: int(ditemukan),
+	})
+}
+
+func dataOrangLaporan(l models.LaporanOrangHilang) services.DataOrang {
+	return services.DataOrang{WargaID: l.WargaID, Nama: l.Nama, NIK: l.NIK, JenisKelamin: l.JenisKelamin, Umur: l.Umur}
+}
+
+func dataOrangRegistrasi(r models.RegistrasiPengungsi) services.DataOrang {
+	return services.DataOrang{WargaID: r.WargaID, Nama: r.Nama, NIK: r.NIK, JenisKelamin: r.JenisKelamin, Umur: r.Umur}
+}
@@ -0,0 +1,518 @@
+// handlers/penggabungan.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Laporan ganda: saat banjir, beberapa RT melaporkan kejadian yang sama. Laporan baru
+// dicocokkan dengan bencana Aktif/Draft berjenis sama yang berdekatan (DUPLIKAT_RADIUS_M,
+// default 2000) dan dimulai berdekatan (DUPLIKAT_JENDELA_JAM, default 24). Pelapor dapat
+// bergabung ke bencana yang ada, dan Admin_Kecamatan dapat menggabungkan dua bencana.
+
+// kriteriaDuplikat reads the duplicate detection thresholds
+func kriteriaDuplikat() services.KriteriaDuplikat {
+	return services.KriteriaDuplikat{
+		RadiusKm: float64(envInt("DUPLIKAT_RADIUS_M", 2000)) / 1000,
+		Jendela:  time.Duration(envInt("DUPLIKAT_JENDELA_JAM", 24)) * time.Hour,
+	}
+}
+
+// cariDuplikatBencana returns the open bencana that probably describe the same incident as bencana
+func cariDuplikatBencana(tx *gorm.DB, bencana models.KejadianBencana) []services.KandidatDuplikat {
+	k := kriteriaDuplikat()
+	var terbuka []models.KejadianBencana
+	tx.Where("status IN ?", []string{"Aktif", "Draft"}).
+		Where("waktu_mulai BETWEEN ? AND ?", bencana.WaktuMulai.Add(-k.Jendela), bencana.WaktuMulai.Add(k.Jendela)).
+		Preload("UserPelapor").
+		Find(&terbuka)
+	return services.CariDuplikatBencana(bencana, terbuka, k)
+}
+
+// GetDuplikatBencana returns open bencana that look like the same incident as this one
+func GetDuplikatBencana(c *fiber.Ctx) error {
+	var bencana models.KejadianBencana
+	if err := database.DB.First(&bencana, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana not found",
+		})
+	}
+
+	kandidat := cariDuplikatBencana(database.DB, bencana)
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  kandidat,
+		"total": len(kandidat),
+	})
+}
+
+// gabungLaporanBencana adds a new report to an existing bencana instead of creating a new one.
+// Laporan dicatat sebagai entri timeline bencana tujuan.
+func gabungLaporanBencana(c *fiber.Ctx, req models.CreateBencanaRequest, jenis *models.JenisBencana, userID uint) error {
+	var bencana models.KejadianBencana
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&bencana, *req.GabungKe).Error; err != nil {
+			return newResponseError(fiber.StatusNotFound, "Bencana to join not found", fiber.Map{"field": "gabung_ke"})
+		}
+		if err := cekBencanaTerbuka(bencana); err != nil {
+			return err
+		}
+		if bencana.KodeJenis != "" && bencana.KodeJenis != jenis.Kode {
+			return newResponseError(fiber.StatusConflict, "Bencana to join has a different jenis_bencana", fiber.Map{
+				"field":         "gabung_ke",
+				"jenis_bencana": bencana.JenisBencana,
+			})
+		}
+
+		var pelapor models.User
+		tx.First(&pelapor, userID)
+		isi := "Laporan tambahan dari " + pelapor.NamaLengkap
+		if req.Deskripsi != "" {
+			isi += ": " + req.Deskripsi
+		}
+		return tx.Create(&models.TimelineBencana{
+			BencanaID:   bencana.ID,
+			Tipe:        "Update",
+			Isi:         isi,
+			RT:          req.RT,
+			RW:          req.RW,
+			Latitude:    req.Latitude,
+			Longitude:   req.Longitude,
+			Waktu:       time.Now(),
+			DicatatOleh: &userID,
+		}).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to join bencana")
+	}
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":       "timeline_bencana",
+		"bencana_id": bencana.ID,
+		"isi":        "Laporan tambahan diterima",
+	})
+	broadcastToClients(string(message))
+	logActivity(userID, fmt.Sprintf("Bergabung ke laporan bencana #%d: %s", bencana.ID, bencana.JenisBencana))
+
+	database.DB.Preload("UserPelapor").First(&bencana, bencana.ID)
+	return c.JSON(fiber.Map{
+		"error":     false,
+		"message":   "Report added to existing bencana",
+		"bergabung": true,
+		"data":      bencana,
+	})
+}
+
+// GabungBencana merges bencana :id into another bencana. Semua data bencana :id (log
+// evakuasi, tugas, pengungsi, orang hilang, timeline, lampiran, notifikasi, asesmen, dst.)
+// dipindahkan, bencana :id berstatus Digabung, dan penggabungan dicatat untuk audit.
+// Query force=true untuk menggabungkan bencana berbeda jenis.
+func GabungBencana(c *fiber.Ctx) error {
+	sumberID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.GabungBencanaRequest
+	if err := c.BodyParser(&req); err != nil || req.Ke == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "ke (ID bencana tujuan) is required",
+		})
+	}
+	if req.Ke == uint(sumberID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Cannot merge a bencana into itself",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	var sumber, tujuan models.KejadianBencana
+	var audit models.PenggabunganBencana
+	var kurangiTotal bool
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci keduanya dengan urutan ID tetap agar dua penggabungan bersamaan tidak deadlock
+		var kedua []models.KejadianBencana
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{uint(sumberID), req.Ke}).Order("id").Find(&kedua).Error; err != nil {
+			return err
+		}
+		for _, b := range kedua {
+			if b.ID == uint(sumberID) {
+				sumber = b
+			} else {
+				tujuan = b
+			}
+		}
+		if sumber.ID == 0 {
+			return newResponseError(fiber.StatusNotFound, "Bencana not found", nil)
+		}
+		if tujuan.ID == 0 {
+			return newResponseError(fiber.StatusNotFound, "Target bencana not found", fiber.Map{"field": "ke"})
+		}
+		if err := cekBencanaTerbuka(sumber); err != nil {
+			return err
+		}
+		if err := cekBencanaTerbuka(tujuan); err != nil {
+			return err
+		}
+		if !c.QueryBool("force") && sumber.JenisBencana != tujuan.JenisBencana {
+			return newResponseError(fiber.StatusConflict, "Bencana have different jenis_bencana; use ?force=true to merge anyway", fiber.Map{
+				"jenis_bencana": sumber.JenisBencana,
+				"jenis_tujuan":  tujuan.JenisBencana,
+			})
+		}
+
+		rincian, err := pindahkanDataBencana(tx, sumber, tujuan, userID)
+		if err != nil {
+			return err
+		}
+
+		// Bencana yang dipertahankan mencakup rentang dan cakupan keduanya
+		now := time.Now()
+		if sumber.WaktuMulai.Before(tujuan.WaktuMulai) {
+			tujuan.WaktuMulai = sumber.WaktuMulai
+		}
+		if sumber.Level == "Kecamatan" {
+			tujuan.Level = "Kecamatan"
+		}
+		// Kota hanya mengenal bencana Aktif; bila keduanya sudah terhitung, jumlahnya dikurangi satu
+		kurangiTotal = sumber.Status == "Aktif" && tujuan.Status == "Aktif"
+		if tujuan.Status == "Draft" && sumber.Status == "Aktif" {
+			tujuan.Status = "Aktif"
+		}
+		if err := tx.Save(&tujuan).Error; err != nil {
+			return err
+		}
+
+		statusSumber := sumber.Status
+		sumber.Status = services.BencanaDigabung
+		sumber.DigabungKeID = &tujuan.ID
+		sumber.WaktuSelesai = &now
+		if err := tx.Save(&sumber).Error; err != nil {
+			return err
+		}
+
+		isi := fmt.Sprintf("Bencana #%d (%s, dilaporkan %s) digabungkan ke bencana ini", sumber.ID, statusSumber,
+			sumber.WaktuMulai.Format("02-01-2006 15:04"))
+		if req.Alasan != "" {
+			isi += ": " + req.Alasan
+		}
+		if err := catatTimeline(tx, tujuan.ID, isi, &userID); err != nil {
+			return err
+		}
+
+		audit = models.PenggabunganBencana{
+			BencanaID:       sumber.ID,
+			DigabungKeID:    tujuan.ID,
+			JenisBencana:    sumber.JenisBencana,
+			WaktuMulai:      sumber.WaktuMulai,
+			Deskripsi:       sumber.Deskripsi,
+			Alasan:          req.Alasan,
+			Rincian:         rincian,
+			DigabungkanOleh: userID,
+			Waktu:           now,
+		}
+		return tx.Create(&audit).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to merge bencana")
+	}
+
+	go sinkronPenggabungan(audit, kurangiTotal)
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":           "bencana_digabung",
+		"bencana_id":     sumber.ID,
+		"digabung_ke_id": tujuan.ID,
+	})
+	broadcastToClients(string(message))
+	logActivity(userID, fmt.Sprintf("Menggabungkan bencana #%d ke bencana #%d", sumber.ID, tujuan.ID))
+
+	database.DB.Preload("UserPelapor").First(&tujuan, tujuan.ID)
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Bencana merged successfully",
+		"data": fiber.Map{
+			"bencana":      tujuan,
+			"penggabungan": audit,
+		},
+	})
+}
+
+// GetPenggabunganBencana returns the merge audit trail of a bencana (as source or target)
+func GetPenggabunganBencana(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var riwayat []models.PenggabunganBencana
+	if err := database.DB.Where("bencana_id = ? OR digabung_ke_id = ?", id, id).
+		Preload("Pengguna").Order("waktu DESC").Find(&riwayat).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch merge history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  riwayat,
+	})
+}
+
+// cekBencanaTerbuka rejects bencana that are finished or already merged
+func cekBencanaTerbuka(bencana models.KejadianBencana) error {
+	switch bencana.Status {
+	case "Digabung":
+		return newResponseError(fiber.StatusConflict, "Bencana has been merged into another bencana", fiber.Map{
+			"bencana_id":     bencana.ID,
+			"digabung_ke_id": bencana.DigabungKeID,
+		})
+	case "Selesai":
+		return newResponseError(fiber.StatusConflict, "Bencana already finished", fiber.Map{"bencana_id": bencana.ID})
+	}
+	return nil
+}
+
+// pindahkanDataBencana moves every record of sumber to tujuan and returns how many rows
+// per jenis data were moved. Data yang bentrok (warga yang sama dicatat di kedua bencana)
+// diselesaikan dulu agar tidak ada log evakuasi, tugas atau registrasi ganda.
+func pindahkanDataBencana(tx *gorm.DB, sumber, tujuan models.KejadianBencana, userID uint) (map[string]int, error) {
+	rincian := map[string]int{}
+	now := time.Now()
+
+	// Log evakuasi: satu warga satu log per bencana; status yang lebih jauh dipertahankan
+	var logSumber, logTujuan []models.LogEvakuasi
+	if err := tx.Where("bencana_id = ?", sumber.ID).Find(&logSumber).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("bencana_id = ?", tujuan.ID).Find(&logTujuan).Error; err != nil {
+		return nil, err
+	}
+	perWarga := make(map[uint]models.LogEvakuasi, len(logTujuan))
+	for _, l := range logTujuan {
+		perWarga[l.WargaID] = l
+	}
+	for _, ls := range logSumber {
+		lt, ada := perWarga[ls.WargaID]
+		if !ada {
+			continue
+		}
+		simpan, buang := lt, ls
+		us, ut := services.UrutanStatusEvakuasi(ls.StatusTerkini), services.UrutanStatusEvakuasi(lt.StatusTerkini)
+		if us > ut || (us == ut && ls.WaktuUpdate.After(lt.WaktuUpdate)) {
+			simpan, buang = ls, lt
+		}
+		if err := tx.Model(&models.RiwayatStatusEvakuasi{}).Where("log_evakuasi_id = ?", buang.ID).
+			Update("log_evakuasi_id", simpan.ID).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Delete(&models.LogEvakuasi{}, buang.ID).Error; err != nil {
+			return nil, err
+		}
+		rincian["log_evakuasi_ganda"]++
+	}
+
+	// Tugas evakuasi aktif ganda untuk warga yang sama: tugas bencana sumber dibatalkan.
+	// (MySQL tidak mengizinkan subquery ke tabel yang sama pada UPDATE, jadi ID diambil dulu)
+	aktif := []string{"Ditugaskan", "Diterima"}
+	var wargaDitugaskan []uint
+	if err := tx.Model(&models.TugasEvakuasi{}).Where("bencana_id = ? AND status IN ?", tujuan.ID, aktif).
+		Pluck("warga_id", &wargaDitugaskan).Error; err != nil {
+		return nil, err
+	}
+	if len(wargaDitugaskan) > 0 {
+		res := tx.Model(&models.TugasEvakuasi{}).
+			Where("bencana_id = ? AND status IN ? AND warga_id IN ?", sumber.ID, aktif, wargaDitugaskan).
+			Updates(map[string]interface{}{"status": "Dibatalkan", "alasan_tolak": "Bencana digabungkan", "waktu_selesai": now})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		rincian["tugas_evakuasi_dibatalkan"] = int(res.RowsAffected)
+	}
+
+	// Pengungsi yang terdaftar di kedua bencana: registrasi bencana sumber ditutup
+	var wargaMengungsi []uint
+	if err := tx.Model(&models.RegistrasiPengungsi{}).
+		Where("bencana_id = ? AND status = ? AND warga_id IS NOT NULL", tujuan.ID, "Di Lokasi").
+		Pluck("warga_id", &wargaMengungsi).Error; err != nil {
+		return nil, err
+	}
+	if len(wargaMengungsi) > 0 {
+		res := tx.Model(&models.RegistrasiPengungsi{}).
+			Where("bencana_id = ? AND status = ? AND warga_id IN ?", sumber.ID, "Di Lokasi", wargaMengungsi).
+			Updates(map[string]interface{}{
+				"status":            "Keluar",
+				"waktu_keluar":      now,
+				"tujuan_keluar":     fmt.Sprintf("Registrasi ganda, digabung ke bencana #%d", tujuan.ID),
+				"petugas_keluar_id": userID,
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		rincian["pengungsi_ganda"] = int(res.RowsAffected)
+	}
+
+	// Asesmen rumah ganda untuk KK yang sama: asesmen bencana sumber ditolak
+	var kkDinilai []uint
+	if err := tx.Model(&models.AsesmenKerusakan{}).
+		Where("bencana_id = ? AND objek = ? AND status <> ?", tujuan.ID, "Rumah", "Ditolak").
+		Pluck("kartu_keluarga_id", &kkDinilai).Error; err != nil {
+		return nil, err
+	}
+	if len(kkDinilai) > 0 {
+		res := tx.Model(&models.AsesmenKerusakan{}).
+			Where("bencana_id = ? AND objek = ? AND status <> ? AND kartu_keluarga_id IN ?", sumber.ID, "Rumah", "Ditolak", kkDinilai).
+			Updates(map[string]interface{}{
+				"status":             "Ditolak",
+				"catatan_verifikasi": fmt.Sprintf("Asesmen ganda setelah bencana digabung ke #%d", tujuan.ID),
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		rincian["asesmen_ganda"] = int(res.RowsAffected)
+	}
+
+	// Checklist playbook: tugas berjudul sama cukup satu, progres bencana sumber dibawa
+	var tugasSumber, tugasTujuan []models.TugasBencana
+	if err := tx.Where("bencana_id = ?", sumber.ID).Find(&tugasSumber).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("bencana_id = ?", tujuan.ID).Find(&tugasTujuan).Error; err != nil {
+		return nil, err
+	}
+	perJudul := make(map[string]models.TugasBencana, len(tugasTujuan))
+	for _, t := range tugasTujuan {
+		perJudul[strings.ToLower(t.Judul)] = t
+	}
+	for _, ts := range tugasSumber {
+		tt, ada := perJudul[strings.ToLower(ts.Judul)]
+		if !ada {
+			continue
+		}
+		if tt.Status == "Belum" && ts.Status != "Belum" {
+			tt.Status, tt.Catatan = ts.Status, ts.Catatan
+			tt.DiselesaikanOleh, tt.WaktuSelesai = ts.DiselesaikanOleh, ts.WaktuSelesai
+			if err := tx.Save(&tt).Error; err != nil {
+				return nil, err
+			}
+		}
+		if err := tx.Delete(&models.TugasBencana{}, ts.ID).Error; err != nil {
+			return nil, err
+		}
+		rincian["tugas_playbook_ganda"]++
+	}
+
+	// Roster keselamatan: satu warga satu baris per bencana; respons terbaru dipertahankan
+	var rosterSumber, rosterTujuan []models.StatusKeselamatan
+	if err := tx.Where("bencana_id = ?", sumber.ID).Find(&rosterSumber).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("bencana_id = ?", tujuan.ID).Find(&rosterTujuan).Error; err != nil {
+		return nil, err
+	}
+	rosterPerWarga := make(map[uint]models.StatusKeselamatan, len(rosterTujuan))
+	for _, r := range rosterTujuan {
+		rosterPerWarga[r.WargaID] = r
+	}
+	for _, rs := range rosterSumber {
+		rt, ada := rosterPerWarga[rs.WargaID]
+		if !ada {
+			continue
+		}
+		buang := rs.ID
+		if rs.WaktuRespons != nil && (rt.WaktuRespons == nil || rs.WaktuRespons.After(*rt.WaktuRespons)) {
+			buang = rt.ID
+		}
+		if err := tx.Delete(&models.StatusKeselamatan{}, buang).Error; err != nil {
+			return nil, err
+		}
+		rincian["keselamatan_ganda"]++
+	}
+
+	// Sisanya cukup dipindahkan
+	tabel := []struct {
+		nama  string
+		model interface{}
+	}{
+		{"log_evakuasi", &models.LogEvakuasi{}},
+		{"riwayat_status_evakuasi", &models.RiwayatStatusEvakuasi{}},
+		{"tugas_evakuasi", &models.TugasEvakuasi{}},
+		{"eskalasi_evakuasi", &models.EskalasiEvakuasi{}},
+		{"tugas_playbook", &models.TugasBencana{}},
+		{"timeline", &models.TimelineBencana{}},
+		{"lampiran", &models.LampiranBencana{}},
+		{"notifikasi", &models.NotifikasiBencana{}},
+		{"pengungsi", &models.RegistrasiPengungsi{}},
+		{"orang_hilang", &models.LaporanOrangHilang{}},
+		{"asesmen", &models.AsesmenKerusakan{}},
+		{"mutasi_stok", &models.MutasiStok{}},
+		{"distribusi_bantuan", &models.DistribusiBantuan{}},
+		{"permintaan_sumber_daya", &models.PermintaanSumberDaya{}},
+		{"shift_relawan", &models.ShiftRelawan{}},
+		{"lokasi_relawan", &models.LokasiRelawan{}},
+		{"peringatan_sensor", &models.PeringatanSensor{}},
+		{"laporan_warga", &models.LaporanWarga{}},
+		{"penerima_notifikasi", &models.PenerimaNotifikasi{}},
+		{"keselamatan", &models.StatusKeselamatan{}},
+	}
+	for _, t := range tabel {
+		res := tx.Model(t.model).Where("bencana_id = ?", sumber.ID).Update("bencana_id", tujuan.ID)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected > 0 {
+			rincian[t.nama] = int(res.RowsAffected)
+		}
+	}
+	for k, v := range rincian {
+		if v == 0 {
+			delete(rincian, k)
+		}
+	}
+	return rincian, nil
+}
+
+// sinkronPenggabungan tells the kota about a merge and re-sends the recaps of the kept bencana
+func sinkronPenggabungan(audit models.PenggabunganBencana, kurangiTotal bool) {
+	messaging.PublishEvent("MERGE_BENCANA", kecamatanID(), models.BencanaDigabungEvent{
+		BencanaID:    audit.BencanaID,
+		DigabungKeID: audit.DigabungKeID,
+		JenisBencana: audit.JenisBencana,
+		Alasan:       audit.Alasan,
+		KurangiTotal: kurangiTotal,
+		Waktu:        audit.Waktu,
+	})
+	if audit.Rincian["orang_hilang"] > 0 {
+		publishOrangHilang(audit.DigabungKeID)
+	}
+	if audit.Rincian["asesmen"] > 0 {
+		publikasiRekapJitupasna(audit.DigabungKeID)
+	}
+	// Penghuni titik kumpul dihitung dari bencana aktif; registrasi ganda sudah ditutup
+	if audit.Rincian["pengungsi"] > 0 {
+		hitungUlangSemuaOkupansi()
+	}
+}
@@ -0,0 +1,567 @@
+// handlers/pengungsi.go
+package handlers
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	qrcode "github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pengungsiListSpec defines sorting and search for the shelter registry
+var pengungsiListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":              "id",
+		"nama":            "nama",
+		"waktu_masuk":     "waktu_masuk",
+		"titik_kumpul_id": "titik_kumpul_id",
+		"status":          "status",
+	},
+	DefaultSort: "-waktu_masuk",
+	Search: []string{
+		"nama LIKE ?",
+		"nik LIKE ?",
+		"kartu_keluarga_id IN (SELECT id FROM kartu_keluargas WHERE no_kk LIKE ? AND deleted_at IS NULL)",
+	},
+}
+
+// GetWargaQR returns the check-in QR code of a warga as PNG (?format=text for the raw content)
+func GetWargaQR(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var warga models.WargaRentan
+	if err := database.DB.First(&warga, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warga not found",
+		})
+	}
+
+	rahasia, err := rahasiaQR()
+	if err != nil {
+		return writeError(c, err, "Failed to generate QR code")
+	}
+	token := services.TokenQRWarga(warga.ID, rahasia)
+	if c.Query("format") == "text" {
+		return c.JSON(fiber.Map{
+			"error": false,
+			"data": fiber.Map{
+				"warga_id": warga.ID,
+				"nama":     warga.Nama,
+				"qr":       token,
+			},
+		})
+	}
+
+	size := c.QueryInt("size", 256)
+	if size < 64 || size > 1024 {
+		size = 256
+	}
+	png, err := qrcode.Encode(token, qrcode.Medium, size)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate QR code",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="qr-warga-`+strconv.Itoa(int(warga.ID))+`.png"`)
+	return c.Send(png)
+}
+
+// CheckInPengungsi registers an arrival at a shelter: by scanned QR, by warga_id,
+// or with personal data for people who are not registered as warga
+func CheckInPengungsi(c *fiber.Ctx) error {
+	titikKumpulID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid titik kumpul ID",
+		})
+	}
+
+	var req models.CheckInPengungsiRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	var reg *models.RegistrasiPengungsi
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := cekBencanaAktif(tx, req.BencanaID); err != nil {
+			return err
+		}
+
+		warga, err := wargaCheckIn(tx, req)
+		if err != nil {
+			return err
+		}
+
+		if warga == nil {
+			// Pengungsi tidak terdata
+			if strings.TrimSpace(req.Nama) == "" {
+				return newResponseError(fiber.StatusBadRequest, "Provide qr, warga_id or nama", fiber.Map{"field": "nama"})
+			}
+			reg = &models.RegistrasiPengungsi{
+				TitikKumpulID:  uint(titikKumpulID),
+				BencanaID:      req.BencanaID,
+				Nama:           strings.TrimSpace(req.Nama),
+				NIK:            req.NIK,
+				JenisKelamin:   req.JenisKelamin,
+				Umur:           req.Umur,
+				Alamat:         req.Alamat,
+				NoHP:           req.NoHP,
+				Catatan:        req.Catatan,
+				PetugasMasukID: userID,
+			}
+			return daftarkanPengungsi(tx, reg, c.QueryBool("force"))
+		}
+
+		reg = registrasiWarga(*warga, req.BencanaID, uint(titikKumpulID), userID)
+		reg.NoHP = req.NoHP
+		reg.Catatan = req.Catatan
+		if err := daftarkanPengungsi(tx, reg, c.QueryBool("force")); err != nil {
+			return err
+		}
+		return sinkronLogEvakuasi(tx, reg, userID)
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to check in")
+	}
+
+	logActivity(userID, "Check-in pengungsi: "+reg.Nama)
+
+	go publishOkupansiTitikKumpul(reg.TitikKumpulID)
+	go cocokkanLaporanHilang(*reg)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Checked in successfully",
+		"data":    reg,
+	})
+}
+
+// PindahPengungsi moves a checked-in person to another shelter
+func PindahPengungsi(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.PindahPengungsiRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	var lama *models.RegistrasiPengungsi
+	var baru models.RegistrasiPengungsi
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if lama, err = lockRegistrasiAktif(tx, uint(id)); err != nil {
+			return err
+		}
+		if lama.TitikKumpulID == req.TitikKumpulID {
+			return newResponseError(fiber.StatusBadRequest, "Already at this titik kumpul", fiber.Map{"field": "titik_kumpul_id"})
+		}
+
+		// Tutup registrasi lama dulu agar tidak dianggap check-in ganda
+		now := time.Now()
+		lama.Status = "Pindah"
+		lama.WaktuKeluar = &now
+		lama.PetugasKeluarID = &userID
+		if err := tx.Save(lama).Error; err != nil {
+			return err
+		}
+
+		baru = *lama
+		baru.ID = 0
+		baru.CreatedAt = time.Time{}
+		baru.UpdatedAt = time.Time{}
+		baru.TitikKumpul = nil
+		baru.Warga = nil
+		baru.TitikKumpulID = req.TitikKumpulID
+		baru.PindahDariID = &lama.ID
+		baru.PindahKeID = nil
+		baru.WaktuKeluar = nil
+		baru.PetugasKeluarID = nil
+		baru.PetugasMasukID = userID
+		baru.Catatan = req.Catatan
+		if err := daftarkanPengungsi(tx, &baru, c.QueryBool("force")); err != nil {
+			return err
+		}
+
+		lama.PindahKeID = &baru.ID
+		if err := tx.Model(lama).Update("pindah_ke_id", baru.ID).Error; err != nil {
+			return err
+		}
+		if err := hitungOkupansi(tx, lama.TitikKumpulID); err != nil {
+			return err
+		}
+		return sinkronLogEvakuasi(tx, &baru, userID)
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to move evacuee")
+	}
+
+	logActivity(userID, "Memindahkan pengungsi: "+baru.Nama)
+
+	go publishOkupansiTitikKumpul(lama.TitikKumpulID)
+	go publishOkupansiTitikKumpul(baru.TitikKumpulID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Evacuee moved successfully",
+		"data":    baru,
+	})
+}
+
+// CheckOutPengungsi records that a person has left the shelter
+func CheckOutPengungsi(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.CheckOutPengungsiRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	var reg *models.RegistrasiPengungsi
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if reg, err = lockRegistrasiAktif(tx, uint(id)); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		reg.Status = "Keluar"
+		reg.WaktuKeluar = &now
+		reg.PetugasKeluarID = &userID
+		reg.TujuanKeluar = req.TujuanKeluar
+		if req.Catatan != "" {
+			reg.Catatan = req.Catatan
+		}
+		if err := tx.Save(reg).Error; err != nil {
+			return err
+		}
+		return hitungOkupansi(tx, reg.TitikKumpulID)
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to check out")
+	}
+
+	logActivity(userID, "Check-out pengungsi: "+reg.Nama)
+
+	go publishOkupansiTitikKumpul(reg.TitikKumpulID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Checked out successfully",
+		"data":    reg,
+	})
+}
+
+// GetPengungsiTitikKumpul lists the registry of one shelter (default: people still there)
+func GetPengungsiTitikKumpul(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid titik kumpul ID",
+		})
+	}
+
+	query := database.DB.Where("titik_kumpul_id = ?", id)
+
+	// ?status=semua untuk seluruh buku tamu
+	if status := c.Query("status", "Di Lokasi"); status != "semua" {
+		query = query.Where("status = ?", status)
+	}
+
+	if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+
+	return listPage[models.RegistrasiPengungsi](c, query, pengungsiListSpec, "Failed to fetch evacuees")
+}
+
+// CariPengungsi is the "where is my family member" lookup across all shelters.
+// Pencarian (q) mencocokkan nama, NIK atau nomor KK; hasil terbaru lebih dulu.
+func CariPengungsi(c *fiber.Ctx) error {
+	if strings.TrimSpace(c.Query("q")) == "" && c.Query("warga_id") == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "q or warga_id is required",
+		})
+	}
+
+	query := database.DB.Model(&models.RegistrasiPengungsi{})
+
+	if wargaID := c.QueryInt("warga_id", 0); wargaID > 0 {
+		query = query.Where("warga_id = ?", wargaID)
+	}
+	if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	return listPage[models.RegistrasiPengungsi](c, query, pengungsiListSpec, "Failed to search evacuees", "TitikKumpul")
+}
+
+// GetPengungsiKeluarga shows where every member of a household is: their latest
+// shelter registration and evacuation status for the bencana
+func GetPengungsiKeluarga(c *fiber.Ctx) error {
+	keluargaID, err := strconv.Atoi(c.Params("kartu_keluarga_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid kartu keluarga ID",
+		})
+	}
+
+	var keluarga models.KartuKeluarga
+	if err := database.DB.Preload("Anggota").First(&keluarga, keluargaID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Keluarga not found",
+		})
+	}
+
+	bencanaID := c.QueryInt("bencana_id", 0)
+
+	anggota := make([]fiber.Map, 0, len(keluarga.Anggota))
+	for _, w := range keluarga.Anggota {
+		regQuery := database.DB.Preload("TitikKumpul").Where("warga_id = ?", w.ID)
+		logQuery := database.DB.Where("warga_id = ?", w.ID)
+		if bencanaID > 0 {
+			regQuery = regQuery.Where("bencana_id = ?", bencanaID)
+			logQuery = logQuery.Where("bencana_id = ?", bencanaID)
+		}
+
+		var registrasi *models.RegistrasiPengungsi
+		var reg models.RegistrasiPengungsi
+		if err := regQuery.Order("waktu_masuk DESC, id DESC").First(&reg).Error; err == nil {
+			registrasi = &reg
+		}
+
+		var statusEvakuasi *string
+		var log models.LogEvakuasi
+		if err := logQuery.Order("waktu_update DESC").First(&log).Error; err == nil {
+			statusEvakuasi = &log.StatusTerkini
+		}
+
+		anggota = append(anggota, fiber.Map{
+			"warga_id":        w.ID,
+			"nama":            w.Nama,
+			"status_evakuasi": statusEvakuasi,
+			"registrasi":      registrasi,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"kartu_keluarga": fiber.Map{
+				"id":              keluarga.ID,
+				"no_kk":           keluarga.NoKK,
+				"kepala_keluarga": keluarga.KepalaKeluarga,
+			},
+			"anggota": anggota,
+		},
+	})
+}
+
+// daftarkanPengungsi checks a person in to a shelter and recounts its occupancy.
+// A registered warga can only be at one shelter per bencana; checking in again at
+// the same shelter returns the existing registration.
+func daftarkanPengungsi(tx *gorm.DB, reg *models.RegistrasiPengungsi, force bool) error {
+	if reg.WargaID != nil {
+		var existing models.RegistrasiPengungsi
+		err := tx.Where("bencana_id = ? AND warga_id = ? AND status = ?", reg.BencanaID, *reg.WargaID, "Di Lokasi").First(&existing).Error
+		if err == nil {
+			if existing.TitikKumpulID == reg.TitikKumpulID {
+				*reg = existing
+				return nil
+			}
+			return newResponseError(fiber.StatusConflict, "Warga is already checked in at another titik kumpul, move them instead", fiber.Map{
+				"existing": fiber.Map{
+					"id":              existing.ID,
+					"titik_kumpul_id": existing.TitikKumpulID,
+					"waktu_masuk":     existing.WaktuMasuk,
+				},
+			})
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	if _, err := kunciTitikKumpul(tx, reg.TitikKumpulID, force); err != nil {
+		return err
+	}
+
+	reg.Status = "Di Lokasi"
+	reg.WaktuMasuk = time.Now()
+	if err := tx.Create(reg).Error; err != nil {
+		return err
+	}
+	return hitungOkupansi(tx, reg.TitikKumpulID)
+}
+
+// sinkronLogEvakuasi keeps the evacuation log in step with the registry: an
+// evacuated warga who checks in moves to "Di Titik Kumpul" at that shelter
+func sinkronLogEvakuasi(tx *gorm.DB, reg *models.RegistrasiPengungsi, userID uint) error {
+	if reg.WargaID == nil {
+		return nil
+	}
+
+	log, err := lockLogEvakuasi(tx, "bencana_id = ? AND warga_id = ?", reg.BencanaID, *reg.WargaID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch log.StatusTerkini {
+	case services.StatusTerevakuasi:
+		if err := ubahStatusEvakuasi(tx, log, services.StatusDiTitikKumpul, userID, "Check-in di titik kumpul"); err != nil {
+			return err
+		}
+	case services.StatusDiTitikKumpul:
+	default:
+		// Belum dievakuasi menurut log; status tidak dilompati
+		return nil
+	}
+
+	log.TitikKumpulID = &reg.TitikKumpulID
+	return tx.Model(log).Update("titik_kumpul_id", reg.TitikKumpulID).Error
+}
+
+// rahasiaQR returns QR_SECRET, the key that signs warga QR cards. Sengaja terpisah dari
+// JWT_SECRET agar rotasi kunci login tidak membatalkan kartu QR yang sudah dicetak.
+func rahasiaQR() (string, error) {
+	if rahasia := os.Getenv("QR_SECRET"); rahasia != "" {
+		return rahasia, nil
+	}
+	return "", newResponseError(fiber.StatusServiceUnavailable, "QR_SECRET is not configured", nil)
+}
+
+// wargaCheckIn resolves the warga behind a check-in request (nil for unregistered people)
+func wargaCheckIn(tx *gorm.DB, req models.CheckInPengungsiRequest) (*models.WargaRentan, error) {
+	switch {
+	case req.QR != "":
+		rahasia, err := rahasiaQR()
+		if err != nil {
+			return nil, err
+		}
+		wargaID, err := services.ParseTokenQRWarga(req.QR, rahasia)
+		if err != nil {
+			return nil, newResponseError(fiber.StatusBadRequest, err.Error(), fiber.Map{"field": "qr"})
+		}
+		return cekWargaAda(tx, wargaID)
+	case req.WargaID != nil:
+		return cekWargaAda(tx, *req.WargaID)
+	case req.NIK != "":
+		if _, err := services.ParseNIK(req.NIK); err != nil {
+			return nil, newResponseError(fiber.StatusBadRequest, pesanNIK(err), fiber.Map{"field": "nik"})
+		}
+		// Pengungsi yang ternyata sudah terdata dihubungkan ke data warganya
+		var warga models.WargaRentan
+		err := tx.Where("nik = ?", req.NIK).First(&warga).Error
+		if err == nil {
+			return &warga, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// registrasiWarga fills a registration from the warga's data
+func registrasiWarga(warga models.WargaRentan, bencanaID, titikKumpulID, userID uint) *models.RegistrasiPengungsi {
+	reg := &models.RegistrasiPengungsi{
+		TitikKumpulID:   titikKumpulID,
+		BencanaID:       bencanaID,
+		WargaID:         &warga.ID,
+		KartuKeluargaID: warga.KartuKeluargaID,
+		Nama:            warga.Nama,
+		JenisKelamin:    warga.JenisKelamin,
+		Alamat:          warga.Alamat,
+		PetugasMasukID:  userID,
+	}
+	if warga.NIK != nil {
+		reg.NIK = *warga.NIK
+	}
+	if warga.TanggalLahir != nil {
+		umur := umurPada(*warga.TanggalLahir, time.Now())
+		reg.Umur = &umur
+	}
+	return reg
+}
+
+// lockRegistrasiAktif loads a registration that is still "Di Lokasi" with a row lock
+func lockRegistrasiAktif(tx *gorm.DB, id uint) (*models.RegistrasiPengungsi, error) {
+	var reg models.RegistrasiPengungsi
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reg, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newResponseError(fiber.StatusNotFound, "Registrasi not found", nil)
+		}
+		return nil, err
+	}
+	if reg.Status != "Di Lokasi" {
+		return nil, newResponseError(fiber.StatusUnprocessableEntity, "Evacuee is no longer at this titik kumpul", fiber.Map{"status": reg.Status})
+	}
+	return &reg, nil
+}
+
+// umurPada returns the age in whole years on the given day
+func umurPada(lahir, pada time.Time) int {
+	umur := pada.Year() - lahir.Year()
+	if pada.Month() < lahir.Month() || (pada.Month() == lahir.Month() && pada.Day() < lahir.Day()) {
+		umur--
+	}
+	return umur
+}
@@ -0,0 +1,205 @@
+// handlers/penyimpanan.go
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Blob store untuk lampiran (foto & dokumen). Backend dipilih lewat
+// PENYIMPANAN_BERKAS (lokal | s3); lampiran mencatat nama backend saat diunggah
+// sehingga berkas lama tetap bisa dibaca setelah backend diganti.
+
+// penyimpananBerkas stores opaque blobs under a key such as "bencana/12/abc.jpg"
+type penyimpananBerkas interface {
+	Simpan(kunci, tipeKonten string, data []byte) error
+	Ambil(kunci string) (io.ReadCloser, error)
+	Hapus(kunci string) error
+}
+
+// pembuatPenyimpanan maps a backend name to its constructor.
+// Tambahkan backend lain di sini (mis. GCS).
+var pembuatPenyimpanan = map[string]func() (penyimpananBerkas, error){
+	"lokal": buatPenyimpananLokal,
+	"s3":    buatPenyimpananS3,
+}
+
+var (
+	penyimpananMu     sync.Mutex
+	daftarPenyimpanan = map[string]penyimpananBerkas{}
+)
+
+// namaPenyimpananAktif returns the backend new uploads go to
+func namaPenyimpananAktif() string {
+	if nama := strings.ToLower(strings.TrimSpace(os.Getenv("PENYIMPANAN_BERKAS"))); nama != "" {
+		return nama
+	}
+	return "lokal"
+}
+
+// penyimpanan returns the (cached) backend with the given name
+func penyimpanan(nama string) (penyimpananBerkas, error) {
+	penyimpananMu.Lock()
+	defer penyimpananMu.Unlock()
+
+	if p, ok := daftarPenyimpanan[nama]; ok {
+		return p, nil
+	}
+	buat, ok := pembuatPenyimpanan[nama]
+	if !ok {
+		return nil, fmt.Errorf("penyimpanan berkas %q tidak dikenal", nama)
+	}
+	p, err := buat()
+	if err != nil {
+		return nil, fmt.Errorf("penyimpanan berkas %s: %w", nama, err)
+	}
+	daftarPenyimpanan[nama] = p
+	return p, nil
+}
+
+// ---------------------------------------------------------------
+// Lokal
+// ---------------------------------------------------------------
+
+// penyimpananLokal keeps blobs under a directory on disk (PENYIMPANAN_LOKAL_DIR)
+type penyimpananLokal struct {
+	dir string
+}
+
+func buatPenyimpananLokal() (penyimpananBerkas, error) {
+	dir := os.Getenv("PENYIMPANAN_LOKAL_DIR")
+	if dir == "" {
+		dir = "./data/lampiran"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &penyimpananLokal{dir: dir}, nil
+}
+
+func (p *penyimpananLokal) path(kunci string) (string, error) {
+	bersih := filepath.Clean(filepath.FromSlash(kunci))
+	if filepath.IsAbs(bersih) || bersih == ".." || strings.HasPrefix(bersih, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("kunci berkas tidak valid: %s", kunci)
+	}
+	return filepath.Join(p.dir, bersih), nil
+}
+
+func (p *penyimpananLokal) Simpan(kunci, tipeKonten string, data []byte) error {
+	path, err := p.path(kunci)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Tulis ke file sementara lalu rename agar tidak ada berkas setengah jadi
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (p *penyimpananLokal) Ambil(kunci string) (io.ReadCloser, error) {
+	path, err := p.path(kunci)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (p *penyimpananLokal) Hapus(kunci string) error {
+	path, err := p.path(kunci)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------
+// S3-compatible (AWS S3, MinIO, ...)
+// ---------------------------------------------------------------
+
+// penyimpananS3 talks to an S3-compatible endpoint using path-style URLs
+// (S3_ENDPOINT/S3_BUCKET/kunci) lewat klien minio-go
+type penyimpananS3 struct {
+	client *minio.Client
+	bucket string
+}
+
+func buatPenyimpananS3() (penyimpananBerkas, error) {
+	endpoint, err := url.Parse(os.Getenv("S3_ENDPOINT"))
+	if err != nil || endpoint.Host == "" {
+		return nil, errors.New("S3_ENDPOINT harus berupa URL, mis. http://localhost:9000")
+	}
+	bucket := os.Getenv("S3_BUCKET")
+	region := os.Getenv("S3_REGION")
+	accessKey := os.Getenv("S3_ACCESS_KEY")
+	secretKey := os.Getenv("S3_SECRET_KEY")
+	if region == "" {
+		region = "us-east-1"
+	}
+	if bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, errors.New("S3_BUCKET, S3_ACCESS_KEY dan S3_SECRET_KEY wajib diisi")
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &penyimpananS3{client: client, bucket: bucket}, nil
+}
+
+func (p *penyimpananS3) Simpan(kunci, tipeKonten string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	_, err := p.client.PutObject(ctx, p.bucket, kunci, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: tipeKonten})
+	return err
+}
+
+func (p *penyimpananS3) Ambil(kunci string) (io.ReadCloser, error) {
+	obj, err := p.client.GetObject(context.Background(), p.bucket, kunci, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject baru menghubungi server saat dibaca; Stat memastikan objeknya ada
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, os.ErrNotExist
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (p *penyimpananS3) Hapus(kunci string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// RemoveObject tidak mengembalikan error untuk objek yang sudah tidak ada
+	return p.client.RemoveObject(ctx, p.bucket, kunci, minio.RemoveObjectOptions{})
+}
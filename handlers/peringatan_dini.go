@@ -0,0 +1,158 @@
+// handlers/peringatan_dini.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm/clause"
+)
+
+// Peringatan resmi (BMKG) diimpor oleh API Kota lalu diteruskan ke kecamatan
+// terdampak lewat topic kota-events (lihat peringatan_dini_kota.go).
+
+// peringatanDiniKecamatanListSpec defines sorting and search for received warnings
+var peringatanDiniKecamatanListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":             "id",
+		"waktu_kirim":    "waktu_kirim",
+		"waktu_diterima": "waktu_diterima",
+		"tingkat":        "tingkat",
+		"jenis":          "jenis",
+	},
+	DefaultSort: "-waktu_kirim",
+	Search:      []string{"judul LIKE ?", "jenis LIKE ?", "wilayah LIKE ?"},
+}
+
+// GetAllPeringatanDini returns official warnings forwarded by Kota, paginated (see list_query.go).
+// Query: jenis, tingkat, status, aktif=true
+func GetAllPeringatanDini(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.PeringatanDiniKecamatan{})
+
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	if tingkat := c.Query("tingkat"); tingkat != "" {
+		query = query.Where("tingkat = ?", tingkat)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if c.QueryBool("aktif") {
+		query = query.Where("status = ?", "Aktif").
+			Where("berlaku_sampai IS NULL OR berlaku_sampai > ?", time.Now())
+	}
+
+	return listPage[models.PeringatanDiniKecamatan](c, query, peringatanDiniKecamatanListSpec, "Failed to fetch peringatan dini")
+}
+
+// terimaPeringatanDini stores a warning from Kota and alerts officers (dan warga bila Siaga/Awas)
+func terimaPeringatanDini(data models.PeringatanDiniEvent) error {
+	now := time.Now()
+
+	if data.Status == "Dibatalkan" {
+		if len(data.Menggantikan) == 0 {
+			return nil
+		}
+		var dibatalkan []models.PeringatanDiniKecamatan
+		if err := database.DB.Where("peringatan_kota_id IN ? AND status <> ?", data.Menggantikan, "Dibatalkan").Find(&dibatalkan).Error; err != nil {
+			return err
+		}
+		if len(dibatalkan) == 0 {
+			return nil
+		}
+		if err := database.DB.Model(&models.PeringatanDiniKecamatan{}).
+			Where("peringatan_kota_id IN ?", data.Menggantikan).
+			Update("status", "Dibatalkan").Error; err != nil {
+			return err
+		}
+
+		for _, p := range dibatalkan {
+			message, _ := json.Marshal(fiber.Map{
+				"tipe":          "peringatan_dini_batal",
+				"peringatan_id": p.ID,
+				"judul":         p.Judul,
+				"waktu":         now.Format(time.RFC3339),
+			})
+			broadcastToClients(string(message))
+		}
+
+		var nomor []string
+		database.DB.Model(&models.User{}).Where("no_hp IS NOT NULL AND no_hp != ''").Pluck("no_hp", &nomor)
+		kirimWhatsApp(nomor, fmt.Sprintf("[BMKG] Peringatan dibatalkan: %s", dibatalkan[0].Judul))
+		return nil
+	}
+
+	peringatan := models.PeringatanDiniKecamatan{
+		PeringatanKotaID: data.PeringatanKotaID,
+		Jenis:            data.Jenis,
+		Judul:            data.Judul,
+		Deskripsi:        data.Deskripsi,
+		Instruksi:        data.Instruksi,
+		Keparahan:        data.Keparahan,
+		Tingkat:          data.Tingkat,
+		Magnitudo:        data.Magnitudo,
+		Latitude:         data.Latitude,
+		Longitude:        data.Longitude,
+		Wilayah:          strings.Join(data.Wilayah, "; "),
+		BerlakuMulai:     data.BerlakuMulai,
+		BerlakuSampai:    data.BerlakuSampai,
+		Status:           "Aktif",
+		WaktuKirim:       data.WaktuKirim,
+		WaktuDiterima:    now,
+	}
+	// Peringatan yang digantikan ditandai lebih dulu: bila penyimpanan gagal dan event
+	// dicoba lagi, langkah ini aman diulang, sedangkan event ganda berhenti di bawah
+	if len(data.Menggantikan) > 0 {
+		if err := database.DB.Model(&models.PeringatanDiniKecamatan{}).
+			Where("peringatan_kota_id IN ? AND status = ?", data.Menggantikan, "Aktif").
+			Update("status", "Diperbarui").Error; err != nil {
+			return err
+		}
+	}
+
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&peringatan)
+	if result.Error != nil {
+		return fmt.Errorf("simpan peringatan dini: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil // Event ganda
+	}
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":           "peringatan_dini",
+		"peringatan_id":  peringatan.ID,
+		"jenis":          peringatan.Jenis,
+		"judul":          peringatan.Judul,
+		"tingkat":        peringatan.Tingkat,
+		"magnitudo":      peringatan.Magnitudo,
+		"wilayah":        data.Wilayah,
+		"berlaku_sampai": peringatan.BerlakuSampai,
+		"waktu":          now.Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+
+	teks := fmt.Sprintf("[BMKG] %s (%s): %s", peringatan.Judul, peringatan.Tingkat, peringatan.Instruksi)
+	if peringatan.Instruksi == "" {
+		teks = fmt.Sprintf("[BMKG] %s (%s). Wilayah: %s", peringatan.Judul, peringatan.Tingkat, peringatan.Wilayah)
+	}
+
+	// Sama seperti arahan Kota: petugas selalu, seluruh warga untuk Siaga/Awas,
+	// dan untuk Waspada hanya warga di zona bahaya yang terkait jenis peringatan
+	var nomor []string
+	database.DB.Model(&models.User{}).Where("no_hp IS NOT NULL AND no_hp != ''").Pluck("no_hp", &nomor)
+	if peringatan.Tingkat == "Siaga" || peringatan.Tingkat == "Awas" {
+		var nomorWarga []string
+		database.DB.Model(&models.WargaRentan{}).Where("no_hp IS NOT NULL AND no_hp != ''").Pluck("no_hp", &nomorWarga)
+		nomor = append(nomor, nomorWarga...)
+	} else {
+		nomor = append(nomor, nomorWargaTerpapar(jenisBahayaUntuk(peringatan.Jenis))...)
+	}
+	kirimWhatsApp(nomor, teks)
+	return nil
+}
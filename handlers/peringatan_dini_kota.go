@@ -0,0 +1,661 @@
+// handlers/peringatan_dini_kota.go
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// FUNGSI DI FILE INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA.
+//
+// Peringatan resmi (CAP/XML atau JSON BMKG) ditarik berkala dari feed di
+// FEED_PERINGATAN atau didorong ke /peringatan-dini/push, dicocokkan dengan
+// wilayah MasterKecamatan, lalu diteruskan ke kecamatan terdampak (PERINGATAN_DINI).
+
+const (
+	maksUkuranFeed  = 5 << 20 // 5 MB per dokumen
+	maksTautanIndex = 50      // Dokumen CAP yang diambil dari satu indeks RSS/Atom
+)
+
+// sumberFeed fetches raw documents from one warning feed. Implementasi dipilih
+// dari skema lokasi feed (lihat pembuatSumberFeed).
+type sumberFeed interface {
+	// Ambil returns the document(s) currently published by the feed
+	Ambil() ([][]byte, error)
+	// AmbilTautan fetches a document linked from an RSS/Atom index
+	AmbilTautan(tautan string) ([]byte, error)
+}
+
+// pembuatSumberFeed maps a location scheme to its feed implementation.
+// Tambahkan skema baru di sini untuk sumber lain (mis. SFTP).
+var pembuatSumberFeed = map[string]func(lokasi string) (sumberFeed, error){
+	"http":  buatSumberHTTP,
+	"https": buatSumberHTTP,
+	"file":  buatSumberFile,
+}
+
+// konfigFeed is one entry of FEED_PERINGATAN ("nama|format|lokasi")
+type konfigFeed struct {
+	Nama   string `json:"nama"`
+	Format string `json:"format"`
+	Lokasi string `json:"lokasi"`
+	sumber sumberFeed
+}
+
+// imporMu serialises imports so polling and push never store the same warning twice
+var imporMu sync.Mutex
+
+// ringkasanImpor summarises one import run
+type ringkasanImpor struct {
+	Sumber     string   `json:"sumber"`
+	Baru       int      `json:"baru"`
+	Duplikat   int      `json:"duplikat"`
+	Diteruskan int      `json:"diteruskan"`
+	Gagal      []string `json:"gagal,omitempty"`
+}
+
+// peringatanDiniListSpec defines sorting and search for imported warnings
+var peringatanDiniListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":          "id",
+		"waktu_kirim": "waktu_kirim",
+		"jenis":       "jenis",
+		"tingkat":     "tingkat",
+		"status":      "status",
+	},
+	DefaultSort: "-waktu_kirim",
+	Search:      []string{"judul LIKE ?", "jenis LIKE ?", "deskripsi LIKE ?"},
+}
+
+// ---------------------------------------------------------------
+// Sumber feed
+// ---------------------------------------------------------------
+
+// sumberHTTP polls an HTTP(S) URL
+type sumberHTTP struct {
+	url    *url.URL
+	client *http.Client
+}
+
+func buatSumberHTTP(lokasi string) (sumberFeed, error) {
+	u, err := url.Parse(lokasi)
+	if err != nil {
+		return nil, err
+	}
+	return &sumberHTTP{url: u, client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+func (s *sumberHTTP) Ambil() ([][]byte, error) {
+	data, err := s.get(s.url.String())
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{data}, nil
+}
+
+func (s *sumberHTTP) AmbilTautan(tautan string) ([]byte, error) {
+	u, err := s.url.Parse(tautan) // Tautan relatif diselesaikan terhadap URL feed
+	if err != nil {
+		return nil, err
+	}
+	return s.get(u.String())
+}
+
+func (s *sumberHTTP) get(alamat string) ([]byte, error) {
+	resp, err := s.client.Get(alamat)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: HTTP %d", alamat, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maksUkuranFeed))
+}
+
+// sumberFile reads a local file, or every .xml/.json file in a directory.
+// Dipakai sebagai feed tiruan untuk uji coba offline.
+type sumberFile struct {
+	path string
+}
+
+func buatSumberFile(lokasi string) (sumberFeed, error) {
+	path := strings.TrimPrefix(lokasi, "file://")
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return &sumberFile{path: path}, nil
+}
+
+func (s *sumberFile) Ambil() ([][]byte, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(s.path)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{data}, nil
+	}
+
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var dokumen [][]byte
+	for _, e := range entries { // ReadDir sudah terurut nama file
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".xml" && ext != ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.path, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		dokumen = append(dokumen, data)
+	}
+	return dokumen, nil
+}
+
+func (s *sumberFile) AmbilTautan(tautan string) ([]byte, error) {
+	tautan = strings.TrimPrefix(tautan, "file://")
+	if !filepath.IsAbs(tautan) {
+		dir := s.path
+		if info, err := os.Stat(s.path); err == nil && !info.IsDir() {
+			dir = filepath.Dir(s.path)
+		}
+		tautan = filepath.Join(dir, tautan)
+	}
+	return os.ReadFile(tautan)
+}
+
+// daftarFeedPeringatan parses FEED_PERINGATAN, e.g.
+// "bmkg-gempa|json|https://data.bmkg.go.id/DataMKG/TEWS/autogempa.json;lokal|auto|./contoh_feed"
+func daftarFeedPeringatan() ([]konfigFeed, error) {
+	var feeds []konfigFeed
+	for _, entri := range strings.Split(os.Getenv("FEED_PERINGATAN"), ";") {
+		entri = strings.TrimSpace(entri)
+		if entri == "" {
+			continue
+		}
+		bagian := strings.SplitN(entri, "|", 3)
+		if len(bagian) != 3 {
+			return nil, fmt.Errorf("entri FEED_PERINGATAN %q harus nama|format|lokasi", entri)
+		}
+		feed := konfigFeed{
+			Nama:   strings.TrimSpace(bagian[0]),
+			Format: strings.TrimSpace(bagian[1]),
+			Lokasi: strings.TrimSpace(bagian[2]),
+		}
+
+		skema := "file"
+		if i := strings.Index(feed.Lokasi, "://"); i > 0 {
+			skema = strings.ToLower(feed.Lokasi[:i])
+		}
+		buat, ok := pembuatSumberFeed[skema]
+		if !ok {
+			return nil, fmt.Errorf("feed %s: skema %q tidak didukung", feed.Nama, skema)
+		}
+		sumber, err := buat(feed.Lokasi)
+		if err != nil {
+			return nil, fmt.Errorf("feed %s: %w", feed.Nama, err)
+		}
+		feed.sumber = sumber
+		feeds = append(feeds, feed)
+	}
+	return feeds, nil
+}
+
+// ---------------------------------------------------------------
+// Poll & impor
+// ---------------------------------------------------------------
+
+// StartFeedPeringatanWorker polls every feed in FEED_PERINGATAN each
+// FEED_PERINGATAN_INTERVAL_MENIT minutes (default 5). Dijalankan sebagai goroutine.
+func StartFeedPeringatanWorker() {
+	feeds, err := daftarFeedPeringatan()
+	if err != nil {
+		log.Printf("❌ Konfigurasi feed peringatan tidak valid: %v", err)
+		return
+	}
+	if len(feeds) == 0 {
+		log.Println("ℹ️ FEED_PERINGATAN kosong, impor peringatan hanya lewat push")
+		return
+	}
+
+	interval := time.Duration(envInt("FEED_PERINGATAN_INTERVAL_MENIT", 5)) * time.Minute
+	for {
+		for _, r := range tarikFeed(feeds) {
+			if r.Baru > 0 || len(r.Gagal) > 0 {
+				log.Printf("📥 Feed %s: %d baru, %d diteruskan, %d gagal", r.Sumber, r.Baru, r.Diteruskan, len(r.Gagal))
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// tarikFeed fetches and imports every feed once
+func tarikFeed(feeds []konfigFeed) []ringkasanImpor {
+	hasil := make([]ringkasanImpor, 0, len(feeds))
+	for _, feed := range feeds {
+		ringkasan := ringkasanImpor{Sumber: feed.Nama}
+
+		dokumen, err := feed.sumber.Ambil()
+		if err != nil {
+			ringkasan.Gagal = append(ringkasan.Gagal, err.Error())
+			hasil = append(hasil, ringkasan)
+			continue
+		}
+
+		var peringatan []models.PeringatanDini
+		for _, data := range dokumen {
+			parsed, err := services.ParseFeedPeringatan(data, feed.Format)
+			if err != nil {
+				ringkasan.Gagal = append(ringkasan.Gagal, err.Error())
+				continue
+			}
+			peringatan = append(peringatan, parsed.Peringatan...)
+
+			// Indeks RSS/Atom: ambil dokumen CAP yang ditautkan
+			for i, tautan := range parsed.Tautan {
+				if i == maksTautanIndex {
+					break
+				}
+				data, err := feed.sumber.AmbilTautan(tautan)
+				if err != nil {
+					ringkasan.Gagal = append(ringkasan.Gagal, err.Error())
+					continue
+				}
+				dok, err := services.ParseFeedPeringatan(data, services.FormatFeedCAP)
+				if err != nil {
+					ringkasan.Gagal = append(ringkasan.Gagal, fmt.Sprintf("%s: %v", tautan, err))
+					continue
+				}
+				peringatan = append(peringatan, dok.Peringatan...)
+			}
+		}
+
+		imporPeringatan(feed.Nama, peringatan, &ringkasan)
+		hasil = append(hasil, ringkasan)
+	}
+	return hasil
+}
+
+// imporPeringatan stores new warnings, matches them to kecamatan and forwards the relevant ones
+func imporPeringatan(sumber string, peringatan []models.PeringatanDini, ringkasan *ringkasanImpor) {
+	if len(peringatan) == 0 {
+		return
+	}
+
+	imporMu.Lock()
+	defer imporMu.Unlock()
+
+	var kecamatan []models.MasterKecamatan
+	if err := database.DB.Find(&kecamatan).Error; err != nil {
+		ringkasan.Gagal = append(ringkasan.Gagal, "gagal membaca MasterKecamatan: "+err.Error())
+		return
+	}
+	wilayah, batasGagal := services.SiapkanWilayah(kecamatan)
+	for id, err := range batasGagal {
+		log.Printf("⚠️ Batas kecamatan #%d tidak valid, dicocokkan lewat kode/nama saja: %v", id, err)
+	}
+	perID := make(map[uint]models.MasterKecamatan, len(kecamatan))
+	for _, k := range kecamatan {
+		perID[k.ID] = k
+	}
+
+	// Urut waktu kirim agar Update/Cancel diproses setelah peringatan yang dirujuk
+	sort.SliceStable(peringatan, func(i, j int) bool {
+		return peringatan[i].WaktuKirim.Before(peringatan[j].WaktuKirim)
+	})
+
+	for _, p := range peringatan {
+		p.Sumber = sumber
+		p.Status = "Aktif"
+
+		var ada int64
+		database.DB.Model(&models.PeringatanDini{}).
+			Where("sumber = ? AND identifier = ?", p.Sumber, p.Identifier).
+			Count(&ada)
+		if ada > 0 {
+			ringkasan.Duplikat++
+			continue
+		}
+
+		terdampak := services.KecamatanTerdampak(p.Area, wilayah)
+
+		// Peringatan yang diperbarui / dibatalkan oleh pesan ini
+		var dirujuk []models.PeringatanDini
+		if refs := services.IdentifierReferensi(p.Referensi); len(refs) > 0 && p.JenisPesan != "Alert" {
+			database.DB.Preload("Target").Where("identifier IN ?", refs).Find(&dirujuk)
+		}
+		if p.JenisPesan == "Cancel" {
+			// Pembatalan dikirim ke kecamatan yang dulu menerima peringatan aslinya
+			for _, d := range dirujuk {
+				for _, k := range d.Target {
+					if _, ok := terdampak[k.ID]; !ok {
+						terdampak[k.ID] = []string{}
+					}
+				}
+			}
+		}
+
+		for id := range terdampak {
+			p.Target = append(p.Target, perID[id])
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit("Target.*").Create(&p).Error; err != nil {
+				return err
+			}
+			if len(dirujuk) == 0 {
+				return nil
+			}
+			status := "Diperbarui"
+			if p.JenisPesan == "Cancel" {
+				status = "Dibatalkan"
+			}
+			ids := make([]uint, len(dirujuk))
+			for i, d := range dirujuk {
+				ids[i] = d.ID
+			}
+			return tx.Model(&models.PeringatanDini{}).Where("id IN ?", ids).Update("status", status).Error
+		})
+		if err != nil {
+			ringkasan.Gagal = append(ringkasan.Gagal, fmt.Sprintf("%s: %v", p.Identifier, err))
+			continue
+		}
+		ringkasan.Baru++
+
+		if teruskanPeringatan(p, terdampak, dirujuk) {
+			ringkasan.Diteruskan++
+		}
+	}
+}
+
+// teruskanPeringatan publishes a stored warning to every affected kecamatan.
+// Hanya pesan Actual yang belum kedaluwarsa yang diteruskan.
+func teruskanPeringatan(p models.PeringatanDini, terdampak map[uint][]string, dirujuk []models.PeringatanDini) bool {
+	if p.StatusCAP != "Actual" || len(terdampak) == 0 {
+		return false
+	}
+	if p.BerlakuSampai != nil && p.BerlakuSampai.Before(time.Now()) {
+		return false
+	}
+
+	event := models.PeringatanDiniEvent{
+		PeringatanKotaID: p.ID,
+		Jenis:            p.Jenis,
+		Judul:            p.Judul,
+		Deskripsi:        p.Deskripsi,
+		Instruksi:        p.Instruksi,
+		Keparahan:        p.Keparahan,
+		Tingkat:          p.Tingkat,
+		Magnitudo:        p.Magnitudo,
+		Latitude:         p.Latitude,
+		Longitude:        p.Longitude,
+		BerlakuMulai:     p.BerlakuMulai,
+		BerlakuSampai:    p.BerlakuSampai,
+		Status:           "Aktif",
+		WaktuKirim:       p.WaktuKirim,
+	}
+	if p.JenisPesan == "Cancel" {
+		event.Status = "Dibatalkan"
+	}
+	for _, d := range dirujuk {
+		event.Menggantikan = append(event.Menggantikan, d.ID)
+	}
+
+	for id, wilayah := range terdampak {
+		event.Wilayah = wilayah
+		messaging.PublishToKecamatan("PERINGATAN_DINI", id, event)
+	}
+
+	now := time.Now()
+	database.DB.Model(&models.PeringatanDini{}).Where("id = ?", p.ID).Update("waktu_diteruskan", now)
+	return true
+}
+
+// ---------------------------------------------------------------
+// Endpoint
+// ---------------------------------------------------------------
+
+// GetAllPeringatanDiniKota returns imported warnings, paginated (see list_query.go).
+// Query: status, jenis, sumber, kecamatan_id, aktif=true
+func GetAllPeringatanDiniKota(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.PeringatanDini{})
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	if sumber := c.Query("sumber"); sumber != "" {
+		query = query.Where("sumber = ?", sumber)
+	}
+	if kecamatanID := c.QueryInt("kecamatan_id", 0); kecamatanID > 0 {
+		query = query.Where("id IN (SELECT peringatan_dini_id FROM peringatan_dini_kecamatan WHERE master_kecamatan_id = ?)", kecamatanID)
+	}
+	if c.QueryBool("aktif") {
+		query = query.Where("status = ? AND jenis_pesan <> ?", "Aktif", "Cancel").
+			Where("berlaku_sampai IS NULL OR berlaku_sampai > ?", time.Now())
+	}
+
+	return listPage[models.PeringatanDini](c, query, peringatanDiniListSpec, "Failed to fetch peringatan dini", "Target")
+}
+
+// GetPeringatanDiniKotaByID returns one imported warning with its areas and targets
+func GetPeringatanDiniKotaByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var peringatan models.PeringatanDini
+	if err := database.DB.Preload("Target").First(&peringatan, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Peringatan not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  peringatan,
+	})
+}
+
+// PushPeringatanDini accepts a pushed CAP/XML or JSON document. Endpoint publik,
+// diautentikasi dengan header X-Feed-Token (FEED_PUSH_TOKEN). Query: sumber, format.
+func PushPeringatanDini(c *fiber.Ctx) error {
+	token := os.Getenv("FEED_PUSH_TOKEN")
+	if token == "" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   true,
+			"message": "Feed push is disabled (FEED_PUSH_TOKEN not set)",
+		})
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Get("X-Feed-Token")), []byte(token)) != 1 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid feed token",
+		})
+	}
+
+	hasil, err := services.ParseFeedPeringatan(c.Body(), c.Query("format", services.FormatFeedOtomatis))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+	if len(hasil.Peringatan) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Document contains no warnings (push CAP alerts or JSON, not an RSS index)",
+		})
+	}
+
+	ringkasan := ringkasanImpor{Sumber: c.Query("sumber", "push")}
+	imporPeringatan(ringkasan.Sumber, hasil.Peringatan, &ringkasan)
+
+	status := fiber.StatusCreated
+	if ringkasan.Baru == 0 {
+		status = fiber.StatusOK
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   false,
+		"message": "Peringatan imported",
+		"data":    ringkasan,
+	})
+}
+
+// TarikFeedPeringatan polls every configured feed immediately
+func TarikFeedPeringatan(c *fiber.Ctx) error {
+	feeds, err := daftarFeedPeringatan()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+	if len(feeds) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No feeds configured in FEED_PERINGATAN",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Feeds polled",
+		"data":    tarikFeed(feeds),
+	})
+}
+
+// GetWilayahKecamatan returns the area code and boundary of a kecamatan
+func GetWilayahKecamatan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var kecamatan models.MasterKecamatan
+	if err := database.DB.First(&kecamatan, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Kecamatan not found",
+		})
+	}
+
+	var batas json.RawMessage
+	if kecamatan.Batas != "" {
+		batas = json.RawMessage(kecamatan.Batas)
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"id":           kecamatan.ID,
+			"nama":         kecamatan.Nama,
+			"kode_wilayah": kecamatan.KodeWilayah,
+			"batas":        batas,
+		},
+	})
+}
+
+// UpdateWilayahKecamatan sets the area code and GeoJSON boundary used to match warnings
+func UpdateWilayahKecamatan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.UpdateWilayahKecamatanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	var kecamatan models.MasterKecamatan
+	if err := database.DB.First(&kecamatan, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   true,
+				"message": "Kecamatan not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch kecamatan",
+		})
+	}
+
+	update := map[string]interface{}{}
+	if req.KodeWilayah != "" {
+		update["kode_wilayah"] = strings.TrimSpace(req.KodeWilayah)
+	}
+	if len(req.Batas) > 0 && string(req.Batas) != "null" {
+		if _, err := services.ParseGeoJSONPoligon(req.Batas); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": err.Error(),
+				"field":   "batas",
+			})
+		}
+		update["batas"] = string(req.Batas)
+	}
+	if len(update) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "kode_wilayah or batas is required",
+		})
+	}
+
+	if err := database.DB.Model(&kecamatan).Updates(update).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update wilayah kecamatan",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Wilayah kecamatan updated",
+		"data":    kecamatan,
+	})
+}
@@ -0,0 +1,382 @@
+// handlers/pesan.go
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Gateway SMS/WhatsApp untuk pesan masuk (webhook) dan keluar. Backend dipilih lewat
+// PESAN_GATEWAY (lokal | twilio). "lokal" adalah pengganti untuk pengembangan: pesan
+// masuk dikirim sebagai JSON dan pesan keluar ditampung di kotak keluar dalam memori.
+
+// pesanMasuk is one inbound SMS/WhatsApp message after the gateway decoded it
+type pesanMasuk struct {
+	Kanal     string // SMS / WhatsApp
+	Dari      string // Nomor pengirim, format 62xxx
+	Teks      string
+	Latitude  float64 // Lokasi yang dibagikan (WhatsApp), 0 bila tidak ada
+	Longitude float64
+	Media     []byte // Foto pertama, bila ada
+	// Terverifikasi: pengirim dibuktikan oleh gateway (tanda tangan Twilio atau
+	// X-Webhook-Token), bukan webhook pengembangan tanpa token
+	Terverifikasi bool
+}
+
+// gatewayPesan is an SMS/WhatsApp provider
+type gatewayPesan interface {
+	// Baca authenticates and decodes an inbound webhook request
+	Baca(c *fiber.Ctx, kanal string) (pesanMasuk, error)
+	// Balas answers the webhook request itself
+	Balas(c *fiber.Ctx, teks string) error
+	// Kirim sends a message outside a webhook request (kabar status, notifikasi)
+	Kirim(kanal, nomor, teks string) error
+}
+
+// pembuatGateway maps a gateway name to its constructor.
+// Tambahkan penyedia lain di sini.
+var pembuatGateway = map[string]func() (gatewayPesan, error){
+	"lokal":  buatGatewayLokal,
+	"twilio": buatGatewayTwilio,
+}
+
+var (
+	gatewayMu       sync.Mutex
+	gatewayTerpakai gatewayPesan
+)
+
+// gatewayAktif returns the (cached) gateway configured in PESAN_GATEWAY
+func gatewayAktif() (gatewayPesan, error) {
+	gatewayMu.Lock()
+	defer gatewayMu.Unlock()
+
+	if gatewayTerpakai != nil {
+		return gatewayTerpakai, nil
+	}
+	nama := strings.ToLower(strings.TrimSpace(os.Getenv("PESAN_GATEWAY")))
+	if nama == "" {
+		nama = "lokal"
+	}
+	buat, ok := pembuatGateway[nama]
+	if !ok {
+		return nil, fmt.Errorf("gateway pesan %q tidak dikenal", nama)
+	}
+	g, err := buat()
+	if err != nil {
+		return nil, fmt.Errorf("gateway pesan %s: %w", nama, err)
+	}
+	gatewayTerpakai = g
+	return g, nil
+}
+
+// kirimPesan sends one SMS/WhatsApp message; kegagalan hanya dicatat di log
+func kirimPesan(kanal, nomor, teks string) {
+	g, err := gatewayAktif()
+	if err == nil {
+		err = g.Kirim(kanal, nomor, teks)
+	}
+	if err != nil {
+		log.Printf("❌ Gagal mengirim %s ke %s: %v", kanal, nomor, err)
+	}
+}
+
+// kanalPesan maps the :kanal route parameter to the Kanal value
+func kanalPesan(s string) string {
+	switch strings.ToLower(s) {
+	case "sms":
+		return "SMS"
+	case "whatsapp", "wa":
+		return "WhatsApp"
+	}
+	return ""
+}
+
+// ---------------------------------------------------------------
+// Lokal
+// ---------------------------------------------------------------
+
+// maksKotakKeluar is the number of sent messages the local gateway keeps
+const maksKotakKeluar = 500
+
+// pesanKeluar is a message sent through the local gateway
+type pesanKeluar struct {
+	Kanal string    `json:"kanal"`
+	Nomor string    `json:"nomor"`
+	Teks  string    `json:"teks"`
+	Waktu time.Time `json:"waktu"`
+}
+
+// gatewayLokal reads JSON webhooks and keeps outgoing messages in memory
+type gatewayLokal struct {
+	token      string // PESAN_WEBHOOK_TOKEN, wajib kecuali tanpaToken
+	tanpaToken bool   // PESAN_WEBHOOK_TANPA_TOKEN=true, hanya untuk pengembangan
+
+	mu     sync.Mutex
+	keluar []pesanKeluar
+}
+
+func buatGatewayLokal() (gatewayPesan, error) {
+	return &gatewayLokal{
+		token:      os.Getenv("PESAN_WEBHOOK_TOKEN"),
+		tanpaToken: os.Getenv("PESAN_WEBHOOK_TANPA_TOKEN") == "true",
+	}, nil
+}
+
+// Baca expects {"dari","pesan","latitude","longitude","media_base64"} with header X-Webhook-Token
+func (g *gatewayLokal) Baca(c *fiber.Ctx, kanal string) (pesanMasuk, error) {
+	switch {
+	case g.token != "":
+		if subtle.ConstantTimeCompare([]byte(c.Get("X-Webhook-Token")), []byte(g.token)) != 1 {
+			return pesanMasuk{}, newResponseError(fiber.StatusUnauthorized, "Invalid X-Webhook-Token", nil)
+		}
+	case !g.tanpaToken:
+		// Tanpa token siapa pun bisa mengirim laporan atas nama nomor mana pun
+		return pesanMasuk{}, newResponseError(fiber.StatusServiceUnavailable, "PESAN_WEBHOOK_TOKEN is not configured", nil)
+	}
+
+	var body struct {
+		Dari        string  `json:"dari"`
+		Pesan       string  `json:"pesan"`
+		Latitude    float64 `json:"latitude"`
+		Longitude   float64 `json:"longitude"`
+		MediaBase64 string  `json:"media_base64"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return pesanMasuk{}, newResponseError(fiber.StatusBadRequest, "Invalid request body", nil)
+	}
+	pesan := pesanMasuk{
+		Kanal:         kanal,
+		Dari:          services.NormalisasiNoHP(body.Dari),
+		Teks:          body.Pesan,
+		Latitude:      body.Latitude,
+		Longitude:     body.Longitude,
+		Terverifikasi: g.token != "",
+	}
+	if body.MediaBase64 != "" {
+		media, err := base64.StdEncoding.DecodeString(body.MediaBase64)
+		if err != nil {
+			return pesanMasuk{}, newResponseError(fiber.StatusBadRequest, "media_base64 is not valid base64", nil)
+		}
+		pesan.Media = media
+	}
+	return pesan, nil
+}
+
+func (g *gatewayLokal) Balas(c *fiber.Ctx, teks string) error {
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"balasan": teks,
+	})
+}
+
+func (g *gatewayLokal) Kirim(kanal, nomor, teks string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.keluar = append(g.keluar, pesanKeluar{Kanal: kanal, Nomor: nomor, Teks: teks, Waktu: time.Now()})
+	if len(g.keluar) > maksKotakKeluar {
+		g.keluar = g.keluar[len(g.keluar)-maksKotakKeluar:]
+	}
+	return nil
+}
+
+// daftarKeluar returns the kept messages, newest first, optionally for one number
+func (g *gatewayLokal) daftarKeluar(nomor string) []pesanKeluar {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	hasil := make([]pesanKeluar, 0, len(g.keluar))
+	for i := len(g.keluar) - 1; i >= 0; i-- {
+		if nomor == "" || g.keluar[i].Nomor == nomor {
+			hasil = append(hasil, g.keluar[i])
+		}
+	}
+	return hasil
+}
+
+// GetPesanKeluarLokal shows the outbox of the local gateway (pengganti SMS/WhatsApp saat pengembangan)
+func GetPesanKeluarLokal(c *fiber.Ctx) error {
+	g, err := gatewayAktif()
+	lokal, ok := g.(*gatewayLokal)
+	if err != nil || !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Outbox is only available with PESAN_GATEWAY=lokal",
+		})
+	}
+
+	nomor := ""
+	if q := c.Query("nomor"); q != "" {
+		nomor = services.NormalisasiNoHP(q)
+	}
+	keluar := lokal.daftarKeluar(nomor)
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  keluar,
+		"total": len(keluar),
+	})
+}
+
+// ---------------------------------------------------------------
+// Twilio (SMS & WhatsApp)
+// ---------------------------------------------------------------
+
+// gatewayTwilio talks to the Twilio Messaging API
+type gatewayTwilio struct {
+	sid, token string
+	nomorSMS   string // TWILIO_NOMOR_SMS, mis. +6221xxx
+	nomorWA    string // TWILIO_NOMOR_WHATSAPP
+	urlPublik  string // PESAN_WEBHOOK_URL: awalan URL webhook seperti yang dilihat Twilio
+	client     *http.Client
+}
+
+func buatGatewayTwilio() (gatewayPesan, error) {
+	g := &gatewayTwilio{
+		sid:       os.Getenv("TWILIO_ACCOUNT_SID"),
+		token:     os.Getenv("TWILIO_AUTH_TOKEN"),
+		nomorSMS:  os.Getenv("TWILIO_NOMOR_SMS"),
+		nomorWA:   os.Getenv("TWILIO_NOMOR_WHATSAPP"),
+		urlPublik: strings.TrimRight(os.Getenv("PESAN_WEBHOOK_URL"), "/"),
+		client:    &http.Client{Timeout: 20 * time.Second},
+	}
+	if g.sid == "" || g.token == "" {
+		return nil, errors.New("TWILIO_ACCOUNT_SID dan TWILIO_AUTH_TOKEN wajib diisi")
+	}
+	return g, nil
+}
+
+// Baca verifies X-Twilio-Signature and reads the form fields Twilio posts
+func (g *gatewayTwilio) Baca(c *fiber.Ctx, kanal string) (pesanMasuk, error) {
+	form := map[string]string{}
+	c.Request().PostArgs().VisitAll(func(k, v []byte) {
+		form[string(k)] = string(v)
+	})
+
+	alamat := g.urlPublik + c.OriginalURL()
+	if g.urlPublik == "" {
+		alamat = c.BaseURL() + c.OriginalURL()
+	}
+	if !hmac.Equal([]byte(c.Get("X-Twilio-Signature")), []byte(tandaTanganTwilio(g.token, alamat, form))) {
+		return pesanMasuk{}, newResponseError(fiber.StatusUnauthorized, "Invalid X-Twilio-Signature", nil)
+	}
+
+	pesan := pesanMasuk{
+		Kanal:         kanal,
+		Dari:          services.NormalisasiNoHP(form["From"]),
+		Teks:          form["Body"],
+		Terverifikasi: true,
+	}
+	pesan.Latitude, _ = strconv.ParseFloat(form["Latitude"], 64)
+	pesan.Longitude, _ = strconv.ParseFloat(form["Longitude"], 64)
+	if n, _ := strconv.Atoi(form["NumMedia"]); n > 0 && strings.HasPrefix(form["MediaContentType0"], "image/") {
+		media, err := g.unduhMedia(form["MediaUrl0"])
+		if err != nil {
+			// Pesan tetap diproses tanpa foto
+			log.Printf("⚠️ Gagal mengunduh media Twilio: %v", err)
+		}
+		pesan.Media = media
+	}
+	return pesan, nil
+}
+
+// tandaTanganTwilio computes base64(HMAC-SHA1(token, url + sorted key/value pairs))
+func tandaTanganTwilio(token, alamat string, form map[string]string) string {
+	kunci := make([]string, 0, len(form))
+	for k := range form {
+		kunci = append(kunci, k)
+	}
+	sort.Strings(kunci)
+
+	var b strings.Builder
+	b.WriteString(alamat)
+	for _, k := range kunci {
+		b.WriteString(k)
+		b.WriteString(form[k])
+	}
+	mac := hmac.New(sha1.New, []byte(token))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (g *gatewayTwilio) unduhMedia(alamat string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, alamat, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(g.sid, g.token)
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %s", resp.Status)
+	}
+	maks := int64(envInt("MAKS_LAMPIRAN_MB", 10)) << 20
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maks+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maks {
+		return nil, fmt.Errorf("media lebih besar dari %d MB", maks>>20)
+	}
+	return data, nil
+}
+
+// Balas answers with TwiML so Twilio delivers the reply on the same channel
+func (g *gatewayTwilio) Balas(c *fiber.Ctx, teks string) error {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><Response><Message>`)
+	xml.EscapeText(&b, []byte(teks))
+	b.WriteString(`</Message></Response>`)
+	c.Set(fiber.HeaderContentType, fiber.MIMETextXMLCharsetUTF8)
+	return c.SendString(b.String())
+}
+
+func (g *gatewayTwilio) Kirim(kanal, nomor, teks string) error {
+	dari, ke := g.nomorSMS, "+"+nomor
+	if kanal == "WhatsApp" {
+		dari, ke = "whatsapp:"+g.nomorWA, "whatsapp:+"+nomor
+	}
+	if strings.TrimPrefix(dari, "whatsapp:") == "" {
+		return fmt.Errorf("nomor pengirim %s Twilio belum diatur", kanal)
+	}
+
+	form := url.Values{"From": {dari}, "To": {ke}, "Body": {teks}}
+	req, err := http.NewRequest(http.MethodPost,
+		"https://api.twilio.com/2010-04-01/Accounts/"+g.sid+"/Messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(g.sid, g.token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		isi, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("twilio %s: %s", resp.Status, isi)
+	}
+	return nil
+}
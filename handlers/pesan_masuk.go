@@ -0,0 +1,203 @@
+// handlers/pesan_masuk.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Pesan masuk SMS/WhatsApp dikenali dari kata kunci pertamanya (lihat
+// services.UraiPerintahPesan). Pesan tanpa kata kunci yang hanya berisi foto atau
+// lokasi melengkapi laporan terakhir pengirim yang masih menunggu verifikasi.
+
+// penangananPesan handles one keyword and returns the reply for the sender
+type penangananPesan func(pesan pesanMasuk, argumen string) string
+
+// perintahPesan maps a keyword to its handler. Tambahkan perintah lain di sini.
+var perintahPesan = map[string]penangananPesan{
+	"LAPOR":  pesanLapor,
+	"CEK":    pesanCekLaporan,
+	"AMAN":   pesanKeselamatan(services.KeselamatanAman),
+	"TOLONG": pesanKeselamatan(services.KeselamatanButuhBantuan),
+	"TERIMA": pesanKeselamatan(services.KeselamatanDiterima),
+}
+
+// bantuanPesan is the help text, one line per keyword
+var bantuanPesan = []string{
+	"LAPOR <jenis> <keterangan> - laporkan bahaya, mis. LAPOR BANJIR air 1 meter di RT 3 RW 5. Sertakan foto/lokasi bila ada.",
+	"CEK <kode> - lihat status laporan.",
+	"AMAN / TOLONG / OK - balas peringatan darurat: Anda aman, butuh bantuan, atau sudah menerima peringatan.",
+}
+
+// jendelaLengkapiLaporan is how long after a report a photo/location alone is added to it
+const jendelaLengkapiLaporan = 30 * time.Minute
+
+// TerimaPesanMasuk is the webhook of the SMS/WhatsApp gateway (:kanal = sms | whatsapp).
+// Balasan dikirim sebagai respons webhook sesuai format gateway.
+func TerimaPesanMasuk(c *fiber.Ctx) error {
+	kanal := kanalPesan(c.Params("kanal"))
+	if kanal == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unknown kanal, use sms or whatsapp",
+		})
+	}
+
+	g, err := gatewayAktif()
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   true,
+			"message": "Message gateway is not available",
+		})
+	}
+	pesan, err := g.Baca(c, kanal)
+	if err != nil {
+		return writeError(c, err, "Failed to read message")
+	}
+	if pesan.Dari == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Sender is not a valid phone number",
+		})
+	}
+
+	perintah, argumen := services.UraiPerintahPesan(pesan.Teks)
+	var balasan string
+	if tangani, ok := perintahPesan[perintah]; ok {
+		balasan = tangani(pesan, argumen)
+	} else if perintah == "" && (len(pesan.Media) > 0 || services.AdaKoordinat(pesan.Latitude, pesan.Longitude)) {
+		balasan = lengkapiLaporanWarga(pesan)
+	} else {
+		balasan = "Perintah yang tersedia:\n" + strings.Join(bantuanPesan, "\n")
+	}
+	return g.Balas(c, balasan)
+}
+
+// pesanLapor creates a report from "LAPOR <jenis> <keterangan>"
+func pesanLapor(pesan pesanMasuk, argumen string) string {
+	var daftar []models.JenisBencana
+	database.DB.Where("aktif = ?", true).Find(&daftar)
+	jenis, keterangan := services.PecahJenisLaporan(argumen, func(s string) bool {
+		return services.CocokkanJenisBencana(daftar, s) != nil
+	})
+	if jenis == "" {
+		return "Jenis bencana tidak dikenal. Gunakan salah satu: " + strings.Join(namaJenisBencanaAktif(), ", ") +
+			". Contoh: LAPOR BANJIR air 1 meter di RT 3 RW 5"
+	}
+
+	rt, rw := services.CariRTRW(keterangan)
+	laporan := models.LaporanWarga{
+		Sumber:       pesan.Kanal,
+		NoHP:         pesan.Dari,
+		JenisBencana: jenis,
+		Deskripsi:    keterangan,
+		RT:           rt,
+		RW:           rw,
+		Latitude:     pesan.Latitude,
+		Longitude:    pesan.Longitude,
+	}
+	var foto *berkasUnggahan
+	if len(pesan.Media) > 0 {
+		// Foto yang tidak valid diabaikan; laporannya tetap diterima
+		if b, err := berkasFoto(pesan.Media); err == nil {
+			foto = &b
+		}
+	}
+
+	if err := buatLaporanWarga(&laporan, foto); err != nil {
+		var respErr *responseError
+		if errors.As(err, &respErr) && respErr.status == fiber.StatusTooManyRequests {
+			return "Anda sudah mengirim terlalu banyak laporan dalam satu jam terakhir. Petugas sedang memeriksa laporan Anda."
+		}
+		log.Printf("❌ Gagal menyimpan laporan warga dari %s: %v", pesan.Dari, err)
+		return "Maaf, laporan gagal disimpan. Silakan coba lagi."
+	}
+
+	balasan := fmt.Sprintf("Laporan %s diterima dengan kode %s dan menunggu verifikasi petugas. Kirim CEK %s untuk melihat status.",
+		laporan.JenisBencana, laporan.Kode, laporan.Kode)
+	if !services.AdaKoordinat(laporan.Latitude, laporan.Longitude) && laporan.RW == "" {
+		balasan += " Kirim lokasi Anda atau sebutkan RT/RW agar petugas dapat menemukan lokasinya."
+	}
+	return balasan
+}
+
+// pesanCekLaporan answers "CEK <kode>"; tanpa kode, laporan terakhir pengirim yang dipakai
+func pesanCekLaporan(pesan pesanMasuk, argumen string) string {
+	var laporan models.LaporanWarga
+	query := database.DB
+	if kode := strings.Fields(argumen); len(kode) > 0 {
+		query = query.Where("kode = ?", strings.ToUpper(kode[0]))
+	} else {
+		query = query.Where("no_hp = ?", pesan.Dari).Order("created_at DESC")
+	}
+	if err := query.First(&laporan).Error; err != nil {
+		return "Laporan tidak ditemukan. Periksa kembali kode laporan Anda."
+	}
+
+	status := map[string]string{
+		"Menunggu":     "menunggu verifikasi petugas",
+		"Dikonfirmasi": "sudah diverifikasi dan sedang ditangani",
+		"Digabung":     "sudah diverifikasi, kejadian ini sudah ditangani petugas",
+		"Ditolak":      "tidak dapat ditindaklanjuti",
+	}[laporan.Status]
+	balasan := fmt.Sprintf("Laporan %s (%s): %s.", laporan.Kode, laporan.JenisBencana, status)
+	if laporan.CatatanVerifikasi != "" {
+		balasan += " Catatan petugas: " + laporan.CatatanVerifikasi
+	}
+	return balasan
+}
+
+// lengkapiLaporanWarga adds a photo or shared location to the sender's latest pending report.
+// Di WhatsApp, lokasi dan foto biasanya terkirim sebagai pesan terpisah.
+func lengkapiLaporanWarga(pesan pesanMasuk) string {
+	var laporan models.LaporanWarga
+	if err := database.DB.Where("no_hp = ? AND status = ? AND created_at > ?", pesan.Dari, "Menunggu", time.Now().Add(-jendelaLengkapiLaporan)).
+		Order("created_at DESC").
+		First(&laporan).Error; err != nil {
+		return "Untuk melaporkan bahaya, kirim LAPOR <jenis> <keterangan> lalu kirim foto/lokasi Anda."
+	}
+
+	var ditambahkan []string
+	fotoBaru := false
+	var update models.LaporanWarga
+	if services.AdaKoordinat(pesan.Latitude, pesan.Longitude) {
+		update.Latitude, update.Longitude = pesan.Latitude, pesan.Longitude
+		ditambahkan = append(ditambahkan, "lokasi")
+	}
+	if len(pesan.Media) > 0 && laporan.FotoKunci == "" {
+		foto, err := berkasFoto(pesan.Media)
+		if err == nil {
+			err = simpanFotoLaporan(&laporan, foto)
+		}
+		if err != nil {
+			log.Printf("⚠️ Foto laporan warga %s tidak disimpan: %v", laporan.Kode, err)
+		} else {
+			update.FotoPenyimpanan, update.FotoKunci = laporan.FotoPenyimpanan, laporan.FotoKunci
+			update.FotoTipe, update.FotoUkuran, update.FotoSHA256 = laporan.FotoTipe, laporan.FotoUkuran, laporan.FotoSHA256
+			ditambahkan = append(ditambahkan, "foto")
+			fotoBaru = true
+		}
+	}
+	if len(ditambahkan) == 0 {
+		return fmt.Sprintf("Laporan %s sudah memiliki foto. Petugas sedang memeriksanya.", laporan.Kode)
+	}
+
+	// Hanya diperbarui selama laporan masih menunggu verifikasi
+	res := database.DB.Model(&models.LaporanWarga{}).Where("id = ? AND status = ?", laporan.ID, "Menunggu").Updates(update)
+	if res.Error != nil || res.RowsAffected == 0 {
+		if fotoBaru {
+			hapusFotoLaporan(laporan)
+		}
+		return fmt.Sprintf("Laporan %s sudah diverifikasi petugas. Kirim CEK %s untuk melihat status.", laporan.Kode, laporan.Kode)
+	}
+	return fmt.Sprintf("Terima kasih, %s ditambahkan ke laporan %s.", strings.Join(ditambahkan, " dan "), laporan.Kode)
+}
@@ -0,0 +1,594 @@
+// handlers/relawan.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// relawanListSpec defines sorting and search for relawan profiles
+var relawanListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":           "id",
+		"user_id":      "user_id",
+		"waktu_lokasi": "waktu_lokasi",
+		"updated_at":   "updated_at",
+	},
+	DefaultSort: "user_id",
+	Search:      []string{"user_id IN (SELECT id FROM users WHERE nama_lengkap LIKE ?)"},
+}
+
+// shiftListSpec defines sorting for shift history
+var shiftListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":          "id",
+		"waktu_mulai": "waktu_mulai",
+		"relawan_id":  "relawan_id",
+	},
+	DefaultSort: "-waktu_mulai",
+}
+
+// Preload profil relawan lengkap
+var preloadProfilRelawan = []string{"User", "Keahlian", "Peralatan", "Jadwal"}
+
+// GetProfilRelawanSaya returns the profile of the logged in relawan
+func GetProfilRelawanSaya(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	profil, err := profilRelawan(database.DB, userID)
+	if err != nil {
+		return writeError(c, err, "Failed to fetch profil relawan")
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  profil,
+	})
+}
+
+// UpdateProfilRelawanSaya lets a relawan fill in their skills, equipment and schedule
+func UpdateProfilRelawanSaya(c *fiber.Ctx) error {
+	return updateProfilRelawan(c, c.Locals("userID").(uint))
+}
+
+// UpdateProfilRelawan lets a coordinator edit the profile of a relawan (:id = user ID relawan)
+func UpdateProfilRelawan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+	return updateProfilRelawan(c, uint(id))
+}
+
+// GetAllRelawan returns relawan profiles, paginated (see list_query.go).
+// Query: keahlian (dipisah koma, harus punya semuanya), sedang_bertugas=true|false
+func GetAllRelawan(c *fiber.Ctx) error {
+	query := filterRelawanAktif(database.DB.Model(&models.ProfilRelawan{}))
+
+	if keahlian := daftarQuery(c.Query("keahlian")); len(keahlian) > 0 {
+		query = filterKeahlian(query, keahlian)
+	}
+	if bertugas := c.Query("sedang_bertugas"); bertugas != "" {
+		query = query.Where("sedang_bertugas = ?", bertugas == "true")
+	}
+
+	return listPage[models.ProfilRelawan](c, query, relawanListSpec, "Failed to fetch relawan", preloadProfilRelawan...)
+}
+
+// GetRelawanByID returns a relawan profile with the active shift and current load (:id = user ID relawan)
+func GetRelawanByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	profil, err := profilRelawan(database.DB, uint(id))
+	if err != nil {
+		return writeError(c, err, "Failed to fetch relawan")
+	}
+
+	var shift *models.ShiftRelawan
+	if profil.ShiftAktifID != nil {
+		var s models.ShiftRelawan
+		if database.DB.Preload("Bencana").First(&s, *profil.ShiftAktifID).Error == nil {
+			shift = &s
+		}
+	}
+
+	var beban int64
+	database.DB.Model(&models.TugasEvakuasi{}).Where("relawan_id = ? AND status IN ?", id, statusTugasAktif).Count(&beban)
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"profil":      profil,
+			"shift_aktif": shift,
+			"beban":       beban,
+			"terjadwal":   services.TersediaPadaJadwal(profil.Jadwal, time.Now()),
+		},
+	})
+}
+
+// GetShiftRelawan returns shift history, paginated (see list_query.go).
+// Query: relawan_id, bencana_id, aktif=true
+func GetShiftRelawan(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.ShiftRelawan{})
+
+	if relawanID := c.QueryInt("relawan_id", 0); relawanID > 0 {
+		query = query.Where("relawan_id = ?", relawanID)
+	}
+	if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+	if c.QueryBool("aktif") {
+		query = query.Where("waktu_selesai IS NULL")
+	}
+
+	return listPage[models.ShiftRelawan](c, query, shiftListSpec, "Failed to fetch shift", "Relawan", "Bencana")
+}
+
+// CheckInShift starts a duty shift for the logged in relawan, optionally for a bencana
+func CheckInShift(c *fiber.Ctx) error {
+	var req models.CheckInShiftRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var shift models.ShiftRelawan
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		profil, err := kunciProfilRelawan(tx, userID)
+		if err != nil {
+			return err
+		}
+		if profil.SedangBertugas {
+			return newResponseError(fiber.StatusConflict, "Relawan is already on duty", fiber.Map{"shift_aktif_id": profil.ShiftAktifID})
+		}
+		if req.BencanaID != nil {
+			if _, err := cekBencanaAktif(tx, *req.BencanaID); err != nil {
+				return err
+			}
+		}
+
+		shift = models.ShiftRelawan{
+			RelawanID:      userID,
+			BencanaID:      req.BencanaID,
+			WaktuMulai:     now,
+			LatitudeMulai:  req.Latitude,
+			LongitudeMulai: req.Longitude,
+			Catatan:        req.Catatan,
+		}
+		if err := tx.Create(&shift).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"sedang_bertugas": true, "shift_aktif_id": shift.ID}
+		if services.AdaKoordinat(req.Latitude, req.Longitude) {
+			updates["latitude"] = req.Latitude
+			updates["longitude"] = req.Longitude
+			updates["waktu_lokasi"] = now
+		}
+		return tx.Model(profil).Updates(updates).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to check in")
+	}
+
+	logActivity(userID, "Relawan check-in shift")
+	broadcastShiftRelawan(shift, "Check-in")
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Checked in",
+		"data":    shift,
+	})
+}
+
+// CheckOutShift ends the active shift of the logged in relawan
+func CheckOutShift(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var shift models.ShiftRelawan
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		profil, err := kunciProfilRelawan(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !profil.SedangBertugas || profil.ShiftAktifID == nil {
+			return newResponseError(fiber.StatusConflict, "Relawan is not on duty", nil)
+		}
+		selesai, err := akhiriShift(tx, profil, now, "Check-out")
+		if err != nil {
+			return err
+		}
+		shift = *selesai
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to check out")
+	}
+
+	logActivity(userID, "Relawan check-out shift")
+	broadcastShiftRelawan(shift, "Check-out")
+	broadcastLokasiBerhenti(shift)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Checked out",
+		"data": fiber.Map{
+			"shift":      shift,
+			"durasi_jam": now.Sub(shift.WaktuMulai).Hours(),
+		},
+	})
+}
+
+// UpdateLokasiRelawanSaya is the older location endpoint, kept for existing clients. Sama
+// dengan PingLokasi: hanya diterima selama shift aktif dan ikut tercatat di jejak relawan.
+func UpdateLokasiRelawanSaya(c *fiber.Ctx) error {
+	return PingLokasi(c)
+}
+
+// GetRelawanTersedia lists relawan who can be sent to a point: on duty (or scheduled
+// now when termasuk_terjadwal=true), below the task limit and having every requested skill.
+// Query: lat, lng, keahlian (dipisah koma), radius_km (default 10), termasuk_terjadwal, limit
+func GetRelawanTersedia(c *fiber.Ctx) error {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil || !services.AdaKoordinat(lat, lng) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "lat and lng are required",
+		})
+	}
+	radius, err := strconv.ParseFloat(c.Query("radius_km", "10"), 64)
+	if err != nil || radius <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "radius_km must be a positive number",
+		})
+	}
+	keahlian := daftarQuery(c.Query("keahlian"))
+	termasukTerjadwal := c.QueryBool("termasuk_terjadwal")
+
+	query := filterRelawanAktif(database.DB.Model(&models.ProfilRelawan{}))
+	if len(keahlian) > 0 {
+		query = filterKeahlian(query, keahlian)
+	}
+	if !termasukTerjadwal {
+		query = query.Where("sedang_bertugas = ?", true)
+	}
+	for _, p := range preloadProfilRelawan {
+		query = query.Preload(p)
+	}
+
+	var profil []models.ProfilRelawan
+	if err := query.Find(&profil).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch relawan",
+		})
+	}
+
+	beban, err := bebanRelawan(database.DB)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch relawan",
+		})
+	}
+	maxBeban := envInt("MAX_TUGAS_RELAWAN", 5)
+	now := time.Now()
+
+	tersedia := make([]services.RelawanTersedia, 0, len(profil))
+	for _, p := range profil {
+		terjadwal := !p.SedangBertugas && services.TersediaPadaJadwal(p.Jadwal, now)
+		if !p.SedangBertugas && !terjadwal {
+			continue
+		}
+		if beban[p.UserID] >= maxBeban {
+			continue
+		}
+
+		r := services.RelawanTersedia{ProfilRelawan: p, Terjadwal: terjadwal, Beban: beban[p.UserID]}
+		if pLat, pLng, ok := posisiRelawan(database.DB, p.UserID); ok {
+			jarak := services.JarakKm(pLat, pLng, lat, lng)
+			if jarak > radius {
+				continue
+			}
+			r.JarakKm = &jarak
+		}
+		tersedia = append(tersedia, r)
+	}
+
+	services.UrutkanRelawanTersedia(tersedia)
+	if limit := c.QueryInt("limit", 20); limit > 0 && len(tersedia) > limit {
+		tersedia = tersedia[:limit]
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  tersedia,
+		"total": len(tersedia),
+	})
+}
+
+// updateProfilRelawan replaces the skills, equipment and/or schedule sent in the body
+func updateProfilRelawan(c *fiber.Ctx, userID uint) error {
+	var req models.UpdateProfilRelawanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	var keahlian []string
+	if req.Keahlian != nil {
+		dikenal := map[string]bool{}
+		for _, k := range *req.Keahlian {
+			k = strings.TrimSpace(k)
+			if !services.KeahlianRelawanValid[k] {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "Unknown keahlian: " + k,
+					"field":   "keahlian",
+					"pilihan": daftarKeahlianRelawan(),
+				})
+			}
+			if !dikenal[k] {
+				dikenal[k] = true
+				keahlian = append(keahlian, k)
+			}
+		}
+	}
+	if req.Peralatan != nil {
+		for _, p := range *req.Peralatan {
+			if strings.TrimSpace(p.Nama) == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "Nama peralatan is required",
+					"field":   "peralatan",
+				})
+			}
+		}
+	}
+	if req.Jadwal != nil {
+		for _, j := range *req.Jadwal {
+			if err := services.ValidateJadwal(j); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": err.Error(),
+					"field":   "jadwal",
+				})
+			}
+		}
+	}
+
+	var profil *models.ProfilRelawan
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		profil, err = kunciProfilRelawan(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if req.Keahlian != nil {
+			if err := tx.Where("profil_id = ?", profil.ID).Delete(&models.KeahlianRelawan{}).Error; err != nil {
+				return err
+			}
+			for _, k := range keahlian {
+				if err := tx.Create(&models.KeahlianRelawan{ProfilID: profil.ID, Keahlian: k}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if req.Peralatan != nil {
+			if err := tx.Where("profil_id = ?", profil.ID).Delete(&models.PeralatanRelawan{}).Error; err != nil {
+				return err
+			}
+			for _, p := range *req.Peralatan {
+				jumlah := p.Jumlah
+				if jumlah <= 0 {
+					jumlah = 1
+				}
+				if err := tx.Create(&models.PeralatanRelawan{ProfilID: profil.ID, Nama: strings.TrimSpace(p.Nama), Jumlah: jumlah}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if req.Jadwal != nil {
+			if err := tx.Where("profil_id = ?", profil.ID).Delete(&models.JadwalRelawan{}).Error; err != nil {
+				return err
+			}
+			for _, j := range *req.Jadwal {
+				if err := tx.Create(&models.JadwalRelawan{ProfilID: profil.ID, Hari: j.Hari, JamMulai: j.JamMulai, JamSelesai: j.JamSelesai}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if req.Catatan != nil {
+			profil.Catatan = *req.Catatan
+		}
+		return tx.Omit(clause.Associations).Save(profil).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to update profil relawan")
+	}
+
+	logActivity(c.Locals("userID").(uint), "Update profil relawan #"+strconv.Itoa(int(userID)))
+
+	profil, _ = profilRelawan(database.DB, userID)
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Profil relawan updated",
+		"data":    profil,
+	})
+}
+
+// akhiriShift closes the active shift of a locked profile and turns location sharing off
+func akhiriShift(tx *gorm.DB, profil *models.ProfilRelawan, now time.Time, alasan string) (*models.ShiftRelawan, error) {
+	var shift models.ShiftRelawan
+	if profil.ShiftAktifID != nil {
+		if err := tx.First(&shift, *profil.ShiftAktifID).Error; err != nil {
+			return nil, err
+		}
+		shift.WaktuSelesai = &now
+		shift.AlasanSelesai = alasan
+		if err := tx.Save(&shift).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Model(profil).Updates(map[string]interface{}{"sedang_bertugas": false, "shift_aktif_id": nil}).Error; err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// profilRelawan loads the full profile of a relawan, creating it on first access
+func profilRelawan(tx *gorm.DB, userID uint) (*models.ProfilRelawan, error) {
+	if _, err := cekUserRelawan(tx, userID); err != nil {
+		return nil, err
+	}
+
+	var profil models.ProfilRelawan
+	q := tx
+	for _, p := range preloadProfilRelawan {
+		q = q.Preload(p)
+	}
+	if err := q.Where(models.ProfilRelawan{UserID: userID}).FirstOrCreate(&profil).Error; err != nil {
+		return nil, err
+	}
+	return &profil, nil
+}
+
+// kunciProfilRelawan loads (or creates) a relawan profile with a row lock
+func kunciProfilRelawan(tx *gorm.DB, userID uint) (*models.ProfilRelawan, error) {
+	if _, err := cekUserRelawan(tx, userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ProfilRelawan{UserID: userID}).Error; err != nil {
+		return nil, err
+	}
+
+	var profil models.ProfilRelawan
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&profil).Error; err != nil {
+		return nil, err
+	}
+	return &profil, nil
+}
+
+// cekUserRelawan makes sure the user exists and has role Relawan
+func cekUserRelawan(tx *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	if err := tx.Where("role = ?", "Relawan").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newResponseError(fiber.StatusNotFound, "Relawan not found", nil)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// filterRelawanAktif drops profiles whose user is deleted or no longer a relawan
+func filterRelawanAktif(query *gorm.DB) *gorm.DB {
+	return query.Where("user_id IN (SELECT id FROM users WHERE role = ? AND deleted_at IS NULL)", "Relawan")
+}
+
+// filterKeahlian keeps profiles that have every one of the given skills
+func filterKeahlian(query *gorm.DB, keahlian []string) *gorm.DB {
+	// Keahlian ganda (P3K,p3k) dihitung sekali, sama seperti COUNT(DISTINCT) di MySQL
+	unik := make([]string, 0, len(keahlian))
+	sudah := make(map[string]bool, len(keahlian))
+	for _, k := range keahlian {
+		if kunci := strings.ToLower(k); !sudah[kunci] {
+			sudah[kunci] = true
+			unik = append(unik, k)
+		}
+	}
+	keahlian = unik
+
+	return query.Where(
+		"id IN (SELECT profil_id FROM keahlian_relawans WHERE keahlian IN ? GROUP BY profil_id HAVING COUNT(DISTINCT keahlian) = ?)",
+		keahlian, len(keahlian),
+	)
+}
+
+// bebanRelawan counts the active evacuation tasks of every relawan
+func bebanRelawan(tx *gorm.DB) (map[uint]int, error) {
+	type beban struct {
+		RelawanID uint
+		Total     int
+	}
+	var rows []beban
+	if err := tx.Model(&models.TugasEvakuasi{}).
+		Select("relawan_id, COUNT(*) AS total").
+		Where("status IN ?", statusTugasAktif).
+		Group("relawan_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	hasil := make(map[uint]int, len(rows))
+	for _, b := range rows {
+		hasil[b.RelawanID] = b.Total
+	}
+	return hasil, nil
+}
+
+// broadcastShiftRelawan tells SSE clients that a relawan started or ended a shift
+func broadcastShiftRelawan(shift models.ShiftRelawan, status string) {
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":       "shift_relawan",
+		"relawan_id": shift.RelawanID,
+		"shift_id":   shift.ID,
+		"bencana_id": shift.BencanaID,
+		"status":     status,
+		"waktu":      time.Now().Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+}
+
+// daftarQuery splits a comma separated query value, dropping empty items
+func daftarQuery(value string) []string {
+	var hasil []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			hasil = append(hasil, v)
+		}
+	}
+	return hasil
+}
+
+// daftarKeahlianRelawan returns the known skills in a stable order
+func daftarKeahlianRelawan() []string {
+	daftar := make([]string, 0, len(services.KeahlianRelawanValid))
+	for k := range services.KeahlianRelawanValid {
+		daftar = append(daftar, k)
+	}
+	sort.Strings(daftar)
+	return daftar
+}
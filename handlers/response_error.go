@@ -0,0 +1,45 @@
+// handlers/response_error.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// responseError is an error that already knows its HTTP response.
+// Helpers running inside a transaction return it so the handler can reply
+// with the right status instead of a generic 500.
+type responseError struct {
+	status int
+	body   fiber.Map
+}
+
+// newResponseError builds {"error": true, "message": message, ...extra}
+func newResponseError(status int, message string, extra fiber.Map) *responseError {
+	body := fiber.Map{
+		"error":   true,
+		"message": message,
+	}
+	for key, value := range extra {
+		body[key] = value
+	}
+	return &responseError{status: status, body: body}
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprint(e.body["message"])
+}
+
+// writeError replies with err's own response, or a 500 with fallback for other errors
+func writeError(c *fiber.Ctx, err error, fallback string) error {
+	var respErr *responseError
+	if errors.As(err, &respErr) {
+		return c.Status(respErr.status).JSON(respErr.body)
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   true,
+		"message": fallback,
+	})
+}
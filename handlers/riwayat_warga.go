@@ -0,0 +1,364 @@
+// handlers/riwayat_warga.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Field yang tidak dicatat di riwayat (metadata atau relasi yang dimuat ulang)
+var fieldRiwayatDiabaikan = map[string]bool{
+	"id":                               true,
+	"created_at":                       true,
+	"updated_at":                       true,
+	"kartu_keluarga":                   true,
+	"kebutuhan_perawatan.created_at":   true,
+	"kebutuhan_perawatan.updated_at":   true,
+	"kebutuhan_perawatan.perlengkapan": true,
+	"paparan":                          true, // Turunan dari koordinat, dihitung ulang otomatis
+}
+
+// GetRiwayatWarga returns the version timeline of a warga, including deleted ones
+func GetRiwayatWarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var warga models.WargaRentan
+	if err := database.DB.Unscoped().First(&warga, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warga not found",
+		})
+	}
+
+	var riwayat []models.RiwayatWarga
+	if err := database.DB.Where("warga_id = ?", id).
+		Preload("User").
+		Order("versi DESC").
+		Find(&riwayat).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch warga history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"data":    riwayat,
+		"deleted": warga.DeletedAt.Valid,
+		"total":   len(riwayat),
+	})
+}
+
+// RestoreWarga restores a soft-deleted warga
+func RestoreWarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	var warga models.WargaRentan
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kunciWarga(tx, &warga, uint(id), true); err != nil {
+			return err
+		}
+		if !warga.DeletedAt.Valid {
+			return newResponseError(fiber.StatusBadRequest, "Warga is not deleted", nil)
+		}
+		sebelum := warga
+
+		if err := tx.Unscoped().Model(&warga).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		warga.DeletedAt = gorm.DeletedAt{}
+		return catatRiwayatWarga(tx, "Restore", userID, &sebelum, warga)
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to restore warga")
+	}
+
+	logActivity(userID, "Memulihkan warga rentan: "+warga.Nama)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Warga restored successfully",
+		"data":    warga,
+	})
+}
+
+// RollbackWarga returns a warga to the state recorded in a previous version
+func RollbackWarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.RollbackWargaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	var versi models.RiwayatWarga
+	if err := database.DB.Where("warga_id = ? AND versi = ?", id, req.Versi).First(&versi).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Versi not found",
+		})
+	}
+
+	var target models.WargaRentan
+	if err := json.Unmarshal(versi.Snapshot, &target); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to read versi snapshot",
+		})
+	}
+
+	// NIK lama mungkin sudah dipakai warga lain
+	if target.NIK != nil {
+		var existing models.WargaRentan
+		if err := database.DB.Unscoped().Where("nik = ? AND id <> ?", *target.NIK, id).First(&existing).Error; err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":    true,
+				"message":  "NIK of this versi is now registered to another warga",
+				"existing": wargaRingkas(existing),
+			})
+		}
+	}
+
+	if target.KartuKeluargaID != nil {
+		if err := database.DB.First(&models.KartuKeluarga{}, *target.KartuKeluargaID).Error; err != nil {
+			target.KartuKeluargaID = nil
+		}
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var warga models.WargaRentan
+		if err := kunciWarga(tx, &warga, uint(id), false); err != nil {
+			var respErr *responseError
+			if errors.As(err, &respErr) && respErr.status == fiber.StatusNotFound {
+				return newResponseError(fiber.StatusNotFound, "Warga not found (restore it first if it was deleted)", nil)
+			}
+			return err
+		}
+		sebelum := warga
+
+		// Kembalikan semua field data; ID dan metadata tetap
+		target.ID = warga.ID
+		target.CreatedAt = warga.CreatedAt
+		target.DeletedAt = warga.DeletedAt
+		target.KartuKeluarga = nil
+
+		if err := tx.Where("warga_id = ?", warga.ID).Delete(&models.KategoriWarga{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("warga_id = ?", warga.ID).Delete(&models.KebutuhanPerawatan{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(&target).Error; err != nil {
+			return err
+		}
+		return catatRiwayatWarga(tx, "Rollback", userID, &sebelum, target)
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to rollback warga")
+	}
+
+	logActivity(userID, fmt.Sprintf("Mengembalikan warga rentan %s ke versi %d", target.Nama, req.Versi))
+
+	database.DB.Preload("Kategori").Preload("KebutuhanPerawatan").First(&target, target.ID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Warga rolled back successfully",
+		"data":    target,
+	})
+}
+
+// kunciWarga reads a warga with its categories and care needs while holding its row lock.
+// Semua perubahan yang dicatat di riwayat membaca "sebelum" lewat fungsi ini sehingga
+// edit bersamaan berjalan bergantian dan nomor versi tidak bentrok.
+func kunciWarga(tx *gorm.DB, warga *models.WargaRentan, id uint, termasukTerhapus bool) error {
+	query := tx
+	if termasukTerhapus {
+		query = query.Unscoped()
+	}
+	err := query.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Kategori").Preload("KebutuhanPerawatan").
+		First(warga, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return newResponseError(fiber.StatusNotFound, "Warga not found", nil)
+	}
+	return err
+}
+
+// catatRiwayatWarga stores a new version of a warga with its field-level changes.
+// sebelum is nil when the warga has just been created; selain itu pemanggil harus
+// sudah memegang kunci baris warga (kunciWarga) agar MAX(versi)+1 tidak bentrok.
+func catatRiwayatWarga(tx *gorm.DB, aksi string, userID uint, sebelum *models.WargaRentan, sesudah models.WargaRentan) error {
+	snapshot, err := json.Marshal(sesudah)
+	if err != nil {
+		return err
+	}
+
+	dari := map[string]interface{}{}
+	if sebelum != nil {
+		if dari, err = flattenWarga(*sebelum); err != nil {
+			return err
+		}
+	}
+	ke, err := flattenWarga(sesudah)
+	if err != nil {
+		return err
+	}
+
+	perubahan := diffWarga(dari, ke)
+	if aksi == "Update" && len(perubahan) == 0 {
+		// Tidak ada yang berubah, tidak perlu versi baru
+		return nil
+	}
+
+	// Paparan zona bahaya mengikuti koordinat rumah warga
+	if aksi != "Update" || adaPerubahan(perubahan, "latitude", "longitude") {
+		if err := perbaruiPaparanWarga(tx, sesudah, aksi == "Delete"); err != nil {
+			return err
+		}
+	}
+	jadwalkanRekapPaparan()
+
+	perubahanJSON, err := json.Marshal(perubahan)
+	if err != nil {
+		return err
+	}
+
+	var versiTerakhir int
+	if err := tx.Model(&models.RiwayatWarga{}).
+		Where("warga_id = ?", sesudah.ID).
+		Select("COALESCE(MAX(versi), 0)").
+		Scan(&versiTerakhir).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.RiwayatWarga{
+		WargaID:   sesudah.ID,
+		Versi:     versiTerakhir + 1,
+		Aksi:      aksi,
+		UserID:    userID,
+		Perubahan: perubahanJSON,
+		Snapshot:  snapshot,
+	}).Error
+}
+
+// flattenWarga turns a warga into field -> value, with nested objects as "parent.child"
+func flattenWarga(w models.WargaRentan) (map[string]interface{}, error) {
+	raw, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+
+	var nested map[string]interface{}
+	if err := json.Unmarshal(raw, &nested); err != nil {
+		return nil, err
+	}
+
+	flat := make(map[string]interface{})
+	for key, value := range nested {
+		if obj, ok := value.(map[string]interface{}); ok {
+			for childKey, childValue := range obj {
+				flat[key+"."+childKey] = childValue
+			}
+			continue
+		}
+		flat[key] = value
+	}
+
+	for key := range fieldRiwayatDiabaikan {
+		delete(flat, key)
+	}
+	return flat, nil
+}
+
+// diffWarga returns the changed fields between two flattened warga, sorted by name
+func diffWarga(dari, ke map[string]interface{}) []models.PerubahanField {
+	fields := make(map[string]bool)
+	for key := range dari {
+		fields[key] = true
+	}
+	for key := range ke {
+		fields[key] = true
+	}
+
+	perubahan := []models.PerubahanField{}
+	for field := range fields {
+		if !reflect.DeepEqual(dari[field], ke[field]) {
+			perubahan = append(perubahan, models.PerubahanField{
+				Field: field,
+				Dari:  dari[field],
+				Ke:    ke[field],
+			})
+		}
+	}
+
+	sort.Slice(perubahan, func(i, j int) bool {
+		return perubahan[i].Field < perubahan[j].Field
+	})
+	return perubahan
+}
+
+// adaPerubahan reports whether any of the fields is among the changes
+func adaPerubahan(perubahan []models.PerubahanField, fields ...string) bool {
+	for _, p := range perubahan {
+		for _, f := range fields {
+			if p.Field == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateWargaTercatat applies a partial update to one warga and records it in its history
+func updateWargaTercatat(tx *gorm.DB, wargaID uint, updates map[string]interface{}, userID uint) error {
+	var sebelum models.WargaRentan
+	if err := kunciWarga(tx, &sebelum, wargaID, false); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.WargaRentan{}).Where("id = ?", wargaID).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	var sesudah models.WargaRentan
+	if err := tx.Preload("Kategori").Preload("KebutuhanPerawatan").First(&sesudah, wargaID).Error; err != nil {
+		return err
+	}
+	return catatRiwayatWarga(tx, "Update", userID, &sebelum, sesudah)
+}
@@ -0,0 +1,117 @@
+// handlers/rute.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetRuteSaya plans the visit order of the logged in relawan's active tasks.
+// Query: lat, lng (posisi relawan saat ini), bencana_id, format=json|geojson|gpx
+func GetRuteSaya(c *fiber.Ctx) error {
+	return kirimRute(c, c.Locals("userID").(uint), c.QueryInt("bencana_id", 0))
+}
+
+// GetRuteRelawan plans the route of a relawan for the coordinator
+func GetRuteRelawan(c *fiber.Ctx) error {
+	bencanaID, err := strconv.Atoi(c.Params("bencana_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid bencana ID",
+		})
+	}
+	relawanID, err := strconv.Atoi(c.Params("relawan_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid relawan ID",
+		})
+	}
+
+	return kirimRute(c, uint(relawanID), bencanaID)
+}
+
+// kirimRute builds the route for a relawan and writes it in the requested format
+func kirimRute(c *fiber.Ctx, relawanID uint, bencanaID int) error {
+	format := c.Query("format", "json")
+	if format != "json" && format != "geojson" && format != "gpx" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "format must be json, geojson or gpx",
+		})
+	}
+
+	query := database.DB.Preload("Warga").
+		Where("relawan_id = ? AND status IN ?", relawanID, statusTugasAktif)
+	if bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+
+	var tugas []models.TugasEvakuasi
+	if err := query.Find(&tugas).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch tasks",
+		})
+	}
+
+	titik := make([]services.TitikRute, 0, len(tugas))
+	for _, t := range tugas {
+		titik = append(titik, services.TitikRute{
+			WargaID:       t.WargaID,
+			TugasID:       t.ID,
+			Nama:          t.Warga.Nama,
+			Alamat:        t.Warga.Alamat,
+			Latitude:      t.Warga.Latitude,
+			Longitude:     t.Warga.Longitude,
+			SkorPrioritas: t.SkorPrioritas,
+		})
+	}
+
+	// Titik awal dari posisi yang dikirim aplikasi relawan; tanpa itu rute
+	// dimulai dari warga paling prioritas
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	adaAwal := errLat == nil && errLng == nil && services.AdaKoordinat(lat, lng)
+
+	rute := services.RencanaRute(lat, lng, adaAwal, titik)
+
+	switch format {
+	case "geojson":
+		body, err := json.Marshal(services.RuteGeoJSON(rute))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to export route",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "application/geo+json")
+		return c.Send(body)
+	case "gpx":
+		nama := fmt.Sprintf("Rute evakuasi relawan %d", relawanID)
+		body, err := services.RuteGPX(nama, rute, time.Now())
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to export route",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "application/gpx+xml")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="rute-relawan-%d.gpx"`, relawanID))
+		return c.Send(body)
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  rute,
+		"total": len(rute.Langkah) + len(rute.TanpaLokasi),
+	})
+}
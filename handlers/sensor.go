@@ -0,0 +1,1018 @@
+// handlers/sensor.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sensor mengirim bacaan lewat HTTP (header X-Sensor-Token) atau MQTT
+// (topic sensor/<kode>/bacaan). Bacaan yang melewati aturan ambang membuat
+// PeringatanSensor dan draft KejadianBencana yang harus dikonfirmasi Admin_Kecamatan.
+
+// Batas jumlah bacaan dalam satu request batch
+const maksBacaanBatch = 500
+
+var jenisSensorValid = map[string]bool{
+	"Tinggi Muka Air":  true,
+	"Curah Hujan":      true,
+	"Kelembapan Tanah": true,
+	"Lainnya":          true,
+}
+
+var tingkatPeringatanValid = map[string]bool{"Waspada": true, "Siaga": true, "Awas": true}
+
+// sensorListSpec defines sorting and search for the sensor registry
+var sensorListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":                    "id",
+		"kode":                  "kode",
+		"nama":                  "nama",
+		"jenis":                 "jenis",
+		"waktu_bacaan_terakhir": "waktu_bacaan_terakhir",
+	},
+	DefaultSort: "kode",
+	Search:      []string{"kode LIKE ?", "nama LIKE ?", "lokasi LIKE ?"},
+}
+
+// bacaanListSpec defines sorting for sensor readings
+var bacaanListSpec = listSpec{
+	Sortable: map[string]string{
+		"waktu": "waktu",
+		"nilai": "nilai",
+	},
+	DefaultSort: "-waktu",
+}
+
+// peringatanSensorListSpec defines sorting for sensor alerts
+var peringatanSensorListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":      "id",
+		"waktu":   "waktu",
+		"tingkat": "tingkat",
+		"status":  "status",
+	},
+	DefaultSort: "-waktu",
+}
+
+// GetAllSensor returns registered sensors, paginated (see list_query.go).
+// Query: jenis, aktif=true|false
+func GetAllSensor(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.Sensor{})
+
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	if aktif := c.Query("aktif"); aktif != "" {
+		query = query.Where("aktif = ?", aktif == "true")
+	}
+
+	return listPage[models.Sensor](c, query, sensorListSpec, "Failed to fetch sensor", "Aturan")
+}
+
+// GetSensorByID returns one sensor with its threshold rules
+func GetSensorByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var sensor models.Sensor
+	if err := database.DB.Preload("Aturan").First(&sensor, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Sensor not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  sensor,
+	})
+}
+
+// CreateSensor registers a sensor. Token ingest hanya dikembalikan sekali di respons ini.
+func CreateSensor(c *fiber.Ctx) error {
+	var req models.CreateSensorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Kode = strings.TrimSpace(req.Kode)
+	if req.Kode == "" || req.Nama == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "kode and nama are required",
+		})
+	}
+	// Kode dipakai sebagai satu level topic MQTT
+	if strings.ContainsAny(req.Kode, "/+# ") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "kode must not contain '/', '+', '#' or spaces",
+		})
+	}
+	if !jenisSensorValid[req.Jenis] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid jenis sensor",
+		})
+	}
+
+	token, hash, err := services.BuatTokenSensor()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate sensor token",
+		})
+	}
+
+	sensor := models.Sensor{
+		Kode:         req.Kode,
+		Nama:         req.Nama,
+		Jenis:        req.Jenis,
+		Satuan:       req.Satuan,
+		Lokasi:       req.Lokasi,
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		JenisBencana: req.JenisBencana,
+		TokenHash:    hash,
+		Aktif:        true,
+	}
+	if sensor.JenisBencana == "" {
+		sensor.JenisBencana = "Banjir"
+	}
+
+	var existing int64
+	database.DB.Unscoped().Model(&models.Sensor{}).Where("kode = ?", sensor.Kode).Count(&existing)
+	if existing > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Sensor kode already registered",
+		})
+	}
+
+	if err := database.DB.Create(&sensor).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create sensor",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Mendaftarkan sensor: "+sensor.Kode)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Sensor registered successfully",
+		"data":    sensor,
+		"token":   token,
+	})
+}
+
+// UpdateSensor updates sensor metadata (kode tetap)
+func UpdateSensor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.UpdateSensorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	if req.Jenis != "" && !jenisSensorValid[req.Jenis] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid jenis sensor",
+		})
+	}
+
+	var sensor models.Sensor
+	if err := database.DB.First(&sensor, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Sensor not found",
+		})
+	}
+
+	if req.Nama != "" {
+		sensor.Nama = req.Nama
+	}
+	if req.Jenis != "" {
+		sensor.Jenis = req.Jenis
+	}
+	if req.Satuan != "" {
+		sensor.Satuan = req.Satuan
+	}
+	if req.Lokasi != "" {
+		sensor.Lokasi = req.Lokasi
+	}
+	if req.Latitude != 0 || req.Longitude != 0 {
+		sensor.Latitude = req.Latitude
+		sensor.Longitude = req.Longitude
+	}
+	if req.JenisBencana != "" {
+		sensor.JenisBencana = req.JenisBencana
+	}
+	if req.Aktif != nil {
+		sensor.Aktif = *req.Aktif
+	}
+
+	if err := database.DB.Save(&sensor).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update sensor",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Sensor updated successfully",
+		"data":    sensor,
+	})
+}
+
+// DeleteSensor soft-deletes a sensor; bacaan dan peringatan lama tetap disimpan
+func DeleteSensor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	result := database.DB.Delete(&models.Sensor{}, id)
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete sensor",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Sensor not found",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, fmt.Sprintf("Menghapus sensor #%d", id))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Sensor deleted successfully",
+	})
+}
+
+// RegenerateTokenSensor issues a new ingestion token; token lama langsung tidak berlaku
+func RegenerateTokenSensor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var sensor models.Sensor
+	if err := database.DB.First(&sensor, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Sensor not found",
+		})
+	}
+
+	token, hash, err := services.BuatTokenSensor()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate sensor token",
+		})
+	}
+	if err := database.DB.Model(&sensor).Update("token_hash", hash).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update sensor token",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Membuat ulang token sensor: "+sensor.Kode)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Sensor token regenerated",
+		"token":   token,
+	})
+}
+
+// CreateAturanSensor adds a threshold rule to a sensor
+func CreateAturanSensor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.AturanAmbangRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	var sensor models.Sensor
+	if err := database.DB.First(&sensor, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Sensor not found",
+		})
+	}
+
+	aturan := models.AturanAmbangSensor{SensorID: sensor.ID, Aktif: true}
+	if err := terapkanAturan(&aturan, req); err != nil {
+		return writeError(c, err, "Invalid aturan")
+	}
+
+	if err := database.DB.Create(&aturan).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create aturan",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Aturan created successfully",
+		"data":    aturan,
+	})
+}
+
+// UpdateAturanSensor replaces a threshold rule
+func UpdateAturanSensor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("aturan_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid aturan ID",
+		})
+	}
+
+	var req models.AturanAmbangRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	var aturan models.AturanAmbangSensor
+	if err := database.DB.Where("sensor_id = ?", c.Params("id")).First(&aturan, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Aturan not found",
+		})
+	}
+
+	if err := terapkanAturan(&aturan, req); err != nil {
+		return writeError(c, err, "Invalid aturan")
+	}
+
+	if err := database.DB.Save(&aturan).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update aturan",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Aturan updated successfully",
+		"data":    aturan,
+	})
+}
+
+// DeleteAturanSensor removes a threshold rule
+func DeleteAturanSensor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("aturan_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid aturan ID",
+		})
+	}
+
+	result := database.DB.Where("sensor_id = ?", c.Params("id")).Delete(&models.AturanAmbangSensor{}, id)
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete aturan",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Aturan not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Aturan deleted successfully",
+	})
+}
+
+// terapkanAturan validates req and copies it into aturan
+func terapkanAturan(aturan *models.AturanAmbangSensor, req models.AturanAmbangRequest) error {
+	if !services.OperatorAmbangValid[req.Operator] {
+		return newResponseError(fiber.StatusBadRequest, "operator must be one of >, >=, <, <=", nil)
+	}
+	if !tingkatPeringatanValid[req.Tingkat] {
+		return newResponseError(fiber.StatusBadRequest, "tingkat must be Waspada, Siaga or Awas", nil)
+	}
+	if req.JumlahBacaan < 0 || req.JedaMenit < 0 {
+		return newResponseError(fiber.StatusBadRequest, "jumlah_bacaan and jeda_menit must not be negative", nil)
+	}
+
+	aturan.Nama = req.Nama
+	aturan.Operator = req.Operator
+	aturan.Ambang = req.Ambang
+	aturan.Tingkat = req.Tingkat
+	aturan.JumlahBacaan = req.JumlahBacaan
+	if aturan.JumlahBacaan == 0 {
+		aturan.JumlahBacaan = 1
+	}
+	aturan.JedaMenit = req.JedaMenit
+	if aturan.JedaMenit == 0 {
+		aturan.JedaMenit = 60
+	}
+	if req.Aktif != nil {
+		aturan.Aktif = *req.Aktif
+	}
+	return nil
+}
+
+// GetBacaanSensor returns the readings of a sensor, paginated (see list_query.go).
+// Query: dari, sampai (RFC3339)
+func GetBacaanSensor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	query := database.DB.Model(&models.BacaanSensor{}).Where("sensor_id = ?", id)
+	if dari := c.Query("dari"); dari != "" {
+		t, err := time.Parse(time.RFC3339, dari)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "dari must be RFC3339",
+			})
+		}
+		query = query.Where("waktu >= ?", t)
+	}
+	if sampai := c.Query("sampai"); sampai != "" {
+		t, err := time.Parse(time.RFC3339, sampai)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "sampai must be RFC3339",
+			})
+		}
+		query = query.Where("waktu <= ?", t)
+	}
+
+	return listPage[models.BacaanSensor](c, query, bacaanListSpec, "Failed to fetch bacaan sensor")
+}
+
+// IngestBacaanSensor receives readings over HTTP. Endpoint publik; sensor
+// diautentikasi dengan header X-Sensor-Token.
+func IngestBacaanSensor(c *fiber.Ctx) error {
+	token := c.Get("X-Sensor-Token")
+	if token == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Missing X-Sensor-Token header",
+		})
+	}
+
+	var sensor models.Sensor
+	if err := database.DB.Where("token_hash = ?", services.HashTokenSensor(token)).First(&sensor).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid sensor token",
+		})
+	}
+	if !sensor.Aktif {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Sensor is not active",
+		})
+	}
+
+	var req models.IngestBacaanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	bacaan := req.Bacaan
+	if len(bacaan) == 0 {
+		bacaan = []models.BacaanSensorRequest{req.BacaanSensorRequest}
+	}
+	if len(bacaan) > maksBacaanBatch {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("At most %d bacaan per request", maksBacaanBatch),
+		})
+	}
+	for i, b := range bacaan {
+		if b.Nilai == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "nilai is required",
+				"index":   i,
+			})
+		}
+	}
+
+	var peringatan []models.PeringatanSensor
+	for _, b := range bacaan {
+		baru, err := terimaBacaan(sensor.ID, *b.Nilai, waktuBacaan(b.Waktu), "HTTP")
+		if err != nil {
+			return writeError(c, err, "Failed to store bacaan sensor")
+		}
+		peringatan = append(peringatan, baru...)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":      false,
+		"message":    "Bacaan stored",
+		"diterima":   len(bacaan),
+		"peringatan": peringatan,
+	})
+}
+
+// StartSensorMQTT subscribes to MQTT_TOPIC_SENSOR (default sensor/+/bacaan) on broker.
+// Kode sensor diambil dari level kedua topic; payload wajib berisi token sensor.
+func StartSensorMQTT(broker string) {
+	topic := os.Getenv("MQTT_TOPIC_SENSOR")
+	if topic == "" {
+		topic = "sensor/+/bacaan"
+	}
+
+	messaging.StartMQTTSubscriber(messaging.MQTTConfig{
+		Broker:   broker,
+		ClientID: fmt.Sprintf("api-kecamatan-%d", kecamatanID()),
+		Username: os.Getenv("MQTT_USERNAME"),
+		Password: os.Getenv("MQTT_PASSWORD"),
+	}, topic, prosesPesanMQTT)
+}
+
+// prosesPesanMQTT stores one reading published to sensor/<kode>/bacaan
+func prosesPesanMQTT(topic string, payload []byte) {
+	bagian := strings.Split(topic, "/")
+	if len(bagian) < 2 {
+		log.Printf("⚠️ Topic MQTT tidak dikenal: %s", topic)
+		return
+	}
+	kode := bagian[1]
+
+	var data models.MQTTBacaanPayload
+	if err := json.Unmarshal(payload, &data); err != nil || data.Nilai == nil {
+		log.Printf("⚠️ Payload MQTT sensor %s tidak valid", kode)
+		return
+	}
+
+	var sensor models.Sensor
+	if err := database.DB.Where("kode = ?", kode).First(&sensor).Error; err != nil {
+		log.Printf("⚠️ Sensor %s tidak terdaftar", kode)
+		return
+	}
+	if sensor.TokenHash != services.HashTokenSensor(data.Token) {
+		log.Printf("⚠️ Token MQTT sensor %s salah", kode)
+		return
+	}
+	if !sensor.Aktif {
+		return
+	}
+
+	if _, err := terimaBacaan(sensor.ID, *data.Nilai, waktuBacaan(data.Waktu), "MQTT"); err != nil {
+		log.Printf("❌ Gagal menyimpan bacaan sensor %s: %v", kode, err)
+	}
+}
+
+// waktuBacaan returns the device timestamp of a reading. Waktu di masa depan (jam perangkat
+// salah) diganti waktu server agar tidak membuat bacaan berikutnya dianggap susulan.
+func waktuBacaan(waktu *time.Time) time.Time {
+	now := time.Now()
+	if waktu == nil || waktu.After(now) {
+		return now
+	}
+	return *waktu
+}
+
+// terimaBacaan stores a reading, evaluates the sensor's threshold rules and
+// returns the alerts it raised. Dipakai bersama oleh HTTP dan MQTT.
+func terimaBacaan(sensorID uint, nilai float64, waktu time.Time, sumber string) ([]models.PeringatanSensor, error) {
+	var sensor models.Sensor
+	var peringatan []models.PeringatanSensor
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci baris sensor agar bacaan beruntun dievaluasi satu per satu
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sensor, sensorID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newResponseError(fiber.StatusNotFound, "Sensor not found", nil)
+			}
+			return err
+		}
+
+		bacaan := models.BacaanSensor{SensorID: sensor.ID, Nilai: nilai, Waktu: waktu, Sumber: sumber}
+		if err := tx.Create(&bacaan).Error; err != nil {
+			return err
+		}
+
+		// Bacaan susulan (lebih lama dari bacaan terakhir) disimpan tanpa memicu aturan.
+		// Waktu terakhir di masa depan (tersimpan sebelum waktu perangkat dibatasi) diabaikan.
+		terakhir := sensor.WaktuBacaanTerakhir
+		if terakhir != nil && !terakhir.After(time.Now()) && waktu.Before(*terakhir) {
+			return nil
+		}
+		if err := tx.Model(&sensor).Updates(map[string]interface{}{
+			"bacaan_terakhir":       nilai,
+			"waktu_bacaan_terakhir": waktu,
+		}).Error; err != nil {
+			return err
+		}
+
+		var aturan []models.AturanAmbangSensor
+		if err := tx.Where("sensor_id = ? AND aktif = ?", sensor.ID, true).Find(&aturan).Error; err != nil {
+			return err
+		}
+		if len(aturan) == 0 {
+			return nil
+		}
+
+		// Bacaan terbaru secukupnya untuk aturan dengan JumlahBacaan terbesar
+		maksJumlah := 1
+		for _, a := range aturan {
+			if a.JumlahBacaan > maksJumlah {
+				maksJumlah = a.JumlahBacaan
+			}
+		}
+		var terbaru []float64
+		if err := tx.Model(&models.BacaanSensor{}).
+			Where("sensor_id = ? AND waktu <= ?", sensor.ID, waktu).
+			Order("waktu DESC, id DESC").
+			Limit(maksJumlah).
+			Pluck("nilai", &terbaru).Error; err != nil {
+			return err
+		}
+
+		for _, a := range aturan {
+			if !services.AmbangTerlewati(a.Operator, a.Ambang, a.JumlahBacaan, terbaru) {
+				continue
+			}
+
+			// Jeda: aturan yang sama tidak memicu lagi dalam JedaMenit
+			var terakhir int64
+			tx.Model(&models.PeringatanSensor{}).
+				Where("aturan_id = ? AND waktu > ?", a.ID, waktu.Add(-time.Duration(a.JedaMenit)*time.Minute)).
+				Count(&terakhir)
+			if terakhir > 0 {
+				continue
+			}
+
+			bencanaID, err := draftBencanaSensor(tx, sensor, a, nilai, waktu)
+			if err != nil {
+				return err
+			}
+
+			p := models.PeringatanSensor{
+				SensorID:  sensor.ID,
+				AturanID:  a.ID,
+				BacaanID:  bacaan.ID,
+				Nilai:     nilai,
+				Tingkat:   a.Tingkat,
+				BencanaID: bencanaID,
+				Status:    "Menunggu",
+				Waktu:     waktu,
+			}
+			if err := tx.Create(&p).Error; err != nil {
+				return err
+			}
+			peringatan = append(peringatan, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range peringatan {
+		go beritahuPeringatanSensor(sensor, p)
+	}
+	return peringatan, nil
+}
+
+// draftBencanaSensor returns the pending draft bencana of the sensor, or creates one.
+// Draft butuh pelapor; dipakai Admin_Kecamatan pertama sampai dikonfirmasi.
+func draftBencanaSensor(tx *gorm.DB, sensor models.Sensor, aturan models.AturanAmbangSensor, nilai float64, waktu time.Time) (*uint, error) {
+	var existing models.PeringatanSensor
+	err := tx.Joins("JOIN kejadian_bencanas ON kejadian_bencanas.id = peringatan_sensors.bencana_id").
+		Where("peringatan_sensors.sensor_id = ? AND peringatan_sensors.status = ?", sensor.ID, "Menunggu").
+		Where("kejadian_bencanas.status = ? AND kejadian_bencanas.deleted_at IS NULL", "Draft").
+		Order("peringatan_sensors.id DESC").
+		First(&existing).Error
+	if err == nil {
+		return existing.BencanaID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var admin models.User
+	if err := tx.Where("role = ?", "Admin_Kecamatan").Order("id").First(&admin).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("⚠️ Tidak ada Admin_Kecamatan, peringatan sensor %s tanpa draft bencana", sensor.Kode)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	bencana := models.KejadianBencana{
+		JenisBencana:  sensor.JenisBencana,
+		Level:         "Kecamatan",
+		WaktuMulai:    waktu,
+		Status:        "Draft",
+		UserPelaporID: admin.ID,
+		Deskripsi: fmt.Sprintf("Otomatis dari sensor %s (%s): %s %s %.2f %s (ambang %.2f, status %s)",
+			sensor.Kode, sensor.Nama, sensor.Jenis, aturan.Operator, nilai, sensor.Satuan, aturan.Ambang, aturan.Tingkat),
+	}
+	bakukanJenisBencana(&bencana)
+	if err := tx.Create(&bencana).Error; err != nil {
+		return nil, err
+	}
+	return &bencana.ID, nil
+}
+
+// beritahuPeringatanSensor pushes a new alert to the dashboard and Admin_Kecamatan
+func beritahuPeringatanSensor(sensor models.Sensor, p models.PeringatanSensor) {
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":          "peringatan_sensor",
+		"peringatan_id": p.ID,
+		"sensor_id":     sensor.ID,
+		"kode":          sensor.Kode,
+		"nama":          sensor.Nama,
+		"nilai":         p.Nilai,
+		"satuan":        sensor.Satuan,
+		"tingkat":       p.Tingkat,
+		"bencana_id":    p.BencanaID,
+		"waktu":         p.Waktu.Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+
+	var nomor []string
+	database.DB.Model(&models.User{}).
+		Where("role = ? AND no_hp IS NOT NULL AND no_hp != ''", "Admin_Kecamatan").
+		Pluck("no_hp", &nomor)
+	kirimWhatsApp(nomor, fmt.Sprintf("[SENSOR] %s %s: %.2f %s (%s). Mohon konfirmasi peringatan #%d.",
+		sensor.Nama, sensor.Lokasi, p.Nilai, sensor.Satuan, p.Tingkat, p.ID))
+
+	// Siaga/Awas: warga di zona rawan bahaya yang dipantau sensor diminta bersiap lebih awal
+	if p.Tingkat == "Siaga" || p.Tingkat == "Awas" {
+		kirimWhatsApp(nomorWargaTerpapar(jenisBahayaUntuk(sensor.JenisBencana)), fmt.Sprintf(
+			"[%s] %s di %s meningkat (%s). Rumah Anda berada di zona rawan %s, siapkan diri untuk evakuasi.",
+			strings.ToUpper(p.Tingkat), sensor.Nama, sensor.Lokasi, p.Tingkat, strings.ToLower(sensor.JenisBencana)))
+	}
+}
+
+// GetAllPeringatanSensor returns sensor alerts, paginated (see list_query.go).
+// Query: status, sensor_id
+func GetAllPeringatanSensor(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.PeringatanSensor{})
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if sensorID := c.QueryInt("sensor_id", 0); sensorID > 0 {
+		query = query.Where("sensor_id = ?", sensorID)
+	}
+
+	return listPage[models.PeringatanSensor](c, query, peringatanSensorListSpec, "Failed to fetch peringatan sensor", "Sensor", "Aturan", "Bencana")
+}
+
+// KonfirmasiPeringatanSensor turns the alert's draft into an active bencana
+func KonfirmasiPeringatanSensor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.KeputusanPeringatanRequest
+	if err := c.BodyParser(&req); err != nil && len(c.Body()) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	if req.Level != "" && req.Level != "Lokal_RT" && req.Level != "Kecamatan" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "level must be Lokal_RT or Kecamatan",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var peringatan models.PeringatanSensor
+	var bencana models.KejadianBencana
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kunciPeringatanMenunggu(tx, &peringatan, id); err != nil {
+			return err
+		}
+
+		// Draft bisa belum ada (tidak ada admin saat itu) atau sudah ditolak lewat peringatan lain
+		if peringatan.BencanaID != nil {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bencana, *peringatan.BencanaID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		if bencana.ID == 0 {
+			var sensor models.Sensor
+			tx.Unscoped().First(&sensor, peringatan.SensorID)
+			bencana = models.KejadianBencana{
+				JenisBencana: sensor.JenisBencana,
+				Level:        "Kecamatan",
+				WaktuMulai:   peringatan.Waktu,
+				Deskripsi:    fmt.Sprintf("Dari sensor %s (%s): %.2f %s, status %s", sensor.Kode, sensor.Nama, peringatan.Nilai, sensor.Satuan, peringatan.Tingkat),
+			}
+		}
+		if bencana.Status == "Selesai" {
+			return newResponseError(fiber.StatusConflict, "Bencana already finished", fiber.Map{"bencana_id": bencana.ID})
+		}
+
+		if bencana.Status != "Aktif" {
+			bencana.Status = "Aktif"
+			bencana.UserPelaporID = userID
+			if req.Level != "" {
+				bencana.Level = req.Level
+			}
+			bakukanJenisBencana(&bencana)
+			if err := tx.Save(&bencana).Error; err != nil {
+				return err
+			}
+			if err := buatTugasPlaybook(tx, bencana); err != nil {
+				return err
+			}
+			if err := catatTimeline(tx, bencana.ID, "Peringatan sensor dikonfirmasi: "+bencana.Deskripsi, &userID); err != nil {
+				return err
+			}
+		}
+
+		// Semua peringatan yang menunggu pada draft yang sama ikut dikonfirmasi
+		update := map[string]interface{}{
+			"status":           "Dikonfirmasi",
+			"bencana_id":       bencana.ID,
+			"diputuskan_oleh":  userID,
+			"waktu_diputuskan": now,
+			"catatan":          req.Catatan,
+		}
+		query := tx.Model(&models.PeringatanSensor{}).Where("status = ?", "Menunggu")
+		if peringatan.BencanaID != nil {
+			query = query.Where("id = ? OR bencana_id = ?", peringatan.ID, *peringatan.BencanaID)
+		} else {
+			query = query.Where("id = ?", peringatan.ID)
+		}
+		return query.Updates(update).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to confirm peringatan")
+	}
+
+	database.DB.Preload("UserPelapor").First(&bencana, bencana.ID)
+	go messaging.PublishEvent("CREATE_BENCANA", kecamatanID(), bencana)
+	go triggerBencanaNotification(bencana)
+	logActivity(userID, fmt.Sprintf("Konfirmasi peringatan sensor #%d menjadi bencana #%d", peringatan.ID, bencana.ID))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Peringatan confirmed, bencana is now active",
+		"data":    bencana,
+	})
+}
+
+// TolakPeringatanSensor rejects an alert (mis. sensor rusak) and drops its draft
+// bencana when no other pending alert still refers to it
+func TolakPeringatanSensor(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.KeputusanPeringatanRequest
+	if err := c.BodyParser(&req); err != nil && len(c.Body()) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var peringatan models.PeringatanSensor
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kunciPeringatanMenunggu(tx, &peringatan, id); err != nil {
+			return err
+		}
+
+		peringatan.Status = "Ditolak"
+		peringatan.DiputuskanOleh = &userID
+		peringatan.WaktuDiputuskan = &now
+		peringatan.Catatan = req.Catatan
+		if err := tx.Save(&peringatan).Error; err != nil {
+			return err
+		}
+
+		if peringatan.BencanaID == nil {
+			return nil
+		}
+		var lain int64
+		tx.Model(&models.PeringatanSensor{}).
+			Where("bencana_id = ? AND status = ?", *peringatan.BencanaID, "Menunggu").
+			Count(&lain)
+		if lain > 0 {
+			return nil
+		}
+		return tx.Where("id = ? AND status = ?", *peringatan.BencanaID, "Draft").Delete(&models.KejadianBencana{}).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to reject peringatan")
+	}
+
+	logActivity(userID, fmt.Sprintf("Menolak peringatan sensor #%d", peringatan.ID))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Peringatan rejected",
+		"data":    peringatan,
+	})
+}
+
+// kunciPeringatanMenunggu locks an alert that has not been decided yet
+func kunciPeringatanMenunggu(tx *gorm.DB, peringatan *models.PeringatanSensor, id int) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(peringatan, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return newResponseError(fiber.StatusNotFound, "Peringatan not found", nil)
+		}
+		return err
+	}
+	if peringatan.Status != "Menunggu" {
+		return newResponseError(fiber.StatusConflict, "Peringatan already "+strings.ToLower(peringatan.Status), fiber.Map{"status": peringatan.Status})
+	}
+	return nil
+}
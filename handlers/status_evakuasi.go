@@ -0,0 +1,173 @@
+// handlers/status_evakuasi.go
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetRiwayatStatusWarga returns every evacuation status change of a warga
+func GetRiwayatStatusWarga(c *fiber.Ctx) error {
+	wargaID, err := strconv.Atoi(c.Params("warga_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid warga ID",
+		})
+	}
+
+	query := database.DB.Where("warga_id = ?", wargaID)
+
+	// Filter by bencana
+	if bencanaID := c.Query("bencana_id"); bencanaID != "" {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+
+	var riwayat []models.RiwayatStatusEvakuasi
+	if err := query.Preload("User").Order("waktu ASC, id ASC").Find(&riwayat).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch evacuation history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  riwayat,
+		"total": len(riwayat),
+	})
+}
+
+// cekBencanaAktif loads a bencana and makes sure evacuation is still running
+func cekBencanaAktif(tx *gorm.DB, bencanaID uint) (*models.KejadianBencana, error) {
+	var bencana models.KejadianBencana
+	if err := tx.First(&bencana, bencanaID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newResponseError(fiber.StatusBadRequest, "Bencana not found", fiber.Map{"field": "bencana_id"})
+		}
+		return nil, err
+	}
+	if bencana.Status != "Aktif" {
+		return nil, newResponseError(fiber.StatusBadRequest, "Bencana is not active", fiber.Map{"field": "bencana_id"})
+	}
+	return &bencana, nil
+}
+
+// cekWargaAda loads a warga that has not been deleted
+func cekWargaAda(tx *gorm.DB, wargaID uint) (*models.WargaRentan, error) {
+	var warga models.WargaRentan
+	if err := tx.First(&warga, wargaID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newResponseError(fiber.StatusBadRequest, "Warga not found", fiber.Map{"field": "warga_id"})
+		}
+		return nil, err
+	}
+	return &warga, nil
+}
+
+// buatLogEvakuasi creates the single evacuation log of a warga for a bencana,
+// together with the first entry of its status history
+func buatLogEvakuasi(tx *gorm.DB, log *models.LogEvakuasi, userID uint, catatan string) error {
+	if !services.IsStatusEvakuasiValid(log.StatusTerkini) {
+		return newResponseError(fiber.StatusBadRequest, "Unknown status_terkini: "+log.StatusTerkini, fiber.Map{"field": "status_terkini"})
+	}
+	if err := services.ValidateStatusAwalEvakuasi(log.StatusTerkini); err != nil {
+		return newResponseError(fiber.StatusUnprocessableEntity, err.Error(), fiber.Map{"field": "status_terkini"})
+	}
+
+	// Satu warga hanya punya satu log per bencana; perubahan berikutnya lewat transisi status
+	var existing models.LogEvakuasi
+	err := tx.Where("bencana_id = ? AND warga_id = ?", log.BencanaID, log.WargaID).First(&existing).Error
+	if err == nil {
+		return newResponseError(fiber.StatusConflict, "Evacuation log already exists for this warga, update its status instead", fiber.Map{
+			"existing": fiber.Map{
+				"id":             existing.ID,
+				"status_terkini": existing.StatusTerkini,
+			},
+		})
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	log.WaktuUpdate = time.Now()
+	log.Catatan = catatan
+	if err := tx.Create(log).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.RiwayatStatusEvakuasi{
+		LogEvakuasiID: log.ID,
+		BencanaID:     log.BencanaID,
+		WargaID:       log.WargaID,
+		UserID:        userID,
+		StatusKe:      log.StatusTerkini,
+		Catatan:       catatan,
+		Waktu:         log.WaktuUpdate,
+	}).Error
+}
+
+// ubahStatusEvakuasi moves a log to a new status if the state machine allows it
+// and records the transition in the status history
+func ubahStatusEvakuasi(tx *gorm.DB, log *models.LogEvakuasi, statusBaru string, userID uint, catatan string) error {
+	if !services.IsStatusEvakuasiValid(statusBaru) {
+		return newResponseError(fiber.StatusBadRequest, "Unknown status_terkini: "+statusBaru, fiber.Map{"field": "status_terkini"})
+	}
+	if _, err := cekBencanaAktif(tx, log.BencanaID); err != nil {
+		return err
+	}
+	if err := services.ValidateTransisiEvakuasi(log.StatusTerkini, statusBaru); err != nil {
+		return newResponseError(fiber.StatusUnprocessableEntity, err.Error(), fiber.Map{
+			"field":          "status_terkini",
+			"status_terkini": log.StatusTerkini,
+			"allowed":        services.StatusEvakuasiBerikutnya(log.StatusTerkini),
+		})
+	}
+
+	statusLama := log.StatusTerkini
+	log.StatusTerkini = statusBaru
+	log.WaktuUpdate = time.Now()
+	log.Catatan = catatan
+	if err := tx.Model(log).Updates(map[string]interface{}{
+		"status_terkini": log.StatusTerkini,
+		"waktu_update":   log.WaktuUpdate,
+		"catatan":        log.Catatan,
+	}).Error; err != nil {
+		return err
+	}
+
+	// Warga sudah dievakuasi: tugas relawan untuk warga ini selesai
+	if statusBaru == services.StatusTerevakuasi {
+		if err := selesaikanTugas(tx, log.BencanaID, log.WargaID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Create(&models.RiwayatStatusEvakuasi{
+		LogEvakuasiID: log.ID,
+		BencanaID:     log.BencanaID,
+		WargaID:       log.WargaID,
+		UserID:        userID,
+		StatusDari:    &statusLama,
+		StatusKe:      statusBaru,
+		Catatan:       catatan,
+		Waktu:         log.WaktuUpdate,
+	}).Error
+}
+
+// lockLogEvakuasi loads a log with a row lock so concurrent updates are serialised
+func lockLogEvakuasi(tx *gorm.DB, query interface{}, args ...interface{}) (*models.LogEvakuasi, error) {
+	var log models.LogEvakuasi
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(&log).Error; err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
@@ -0,0 +1,515 @@
+// handlers/sumber_daya.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Jenis sumber daya yang bisa diminta (lihat enum PermintaanSumberDaya.Jenis)
+var jenisSumberDayaValid = map[string]bool{
+	"Perahu": true, "Relawan": true, "Logistik": true, "Kendaraan": true,
+	"Medis": true, "Alat Berat": true, "Lainnya": true,
+}
+
+// permintaanListSpec defines sorting and search for resource requests
+var permintaanListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":         "id",
+		"status":     "status",
+		"urgensi":    "urgensi",
+		"created_at": "created_at",
+	},
+	DefaultSort: "-created_at",
+	Search:      []string{"deskripsi LIKE ?", "lokasi_tujuan LIKE ?"},
+}
+
+// GetAllPermintaanSumberDaya returns the requests raised by this kecamatan, paginated (see list_query.go)
+func GetAllPermintaanSumberDaya(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.PermintaanSumberDaya{})
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	if bencanaID := c.QueryInt("bencana_id", 0); bencanaID > 0 {
+		query = query.Where("bencana_id = ?", bencanaID)
+	}
+
+	return listPage[models.PermintaanSumberDaya](c, query, permintaanListSpec, "Failed to fetch permintaan")
+}
+
+// GetPermintaanSumberDayaByID returns one request raised by this kecamatan
+func GetPermintaanSumberDayaByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var permintaan models.PermintaanSumberDaya
+	if err := database.DB.First(&permintaan, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Permintaan not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  permintaan,
+	})
+}
+
+// CreatePermintaanSumberDaya raises a request for boats, relawan or supplies to the city
+func CreatePermintaanSumberDaya(c *fiber.Ctx) error {
+	var req models.CreatePermintaanSumberDayaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if !jenisSumberDayaValid[req.Jenis] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unknown jenis: " + req.Jenis,
+			"field":   "jenis",
+		})
+	}
+	if strings.TrimSpace(req.Deskripsi) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "deskripsi is required",
+			"field":   "deskripsi",
+		})
+	}
+	if req.Jumlah <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "jumlah must be a positive number",
+			"field":   "jumlah",
+		})
+	}
+	if req.Urgensi == "" {
+		req.Urgensi = "Normal"
+	}
+	if req.Urgensi != "Normal" && req.Urgensi != "Mendesak" && req.Urgensi != "Kritis" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "urgensi must be Normal, Mendesak or Kritis",
+			"field":   "urgensi",
+		})
+	}
+	if req.BencanaID != nil {
+		if err := database.DB.First(&models.KejadianBencana{}, *req.BencanaID).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Bencana not found",
+				"field":   "bencana_id",
+			})
+		}
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	permintaan := models.PermintaanSumberDaya{
+		BencanaID:    req.BencanaID,
+		Jenis:        req.Jenis,
+		Deskripsi:    strings.TrimSpace(req.Deskripsi),
+		BarangKode:   strings.ToUpper(strings.TrimSpace(req.BarangKode)),
+		Jumlah:       req.Jumlah,
+		Satuan:       req.Satuan,
+		Urgensi:      req.Urgensi,
+		LokasiTujuan: req.LokasiTujuan,
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		Status:       services.PermintaanDiajukan,
+		DiajukanOleh: userID,
+	}
+
+	if err := database.DB.Create(&permintaan).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create permintaan",
+		})
+	}
+
+	logActivity(userID, "Mengajukan permintaan sumber daya: "+permintaan.Jenis+" - "+permintaan.Deskripsi)
+
+	go messaging.PublishEvent("CREATE_PERMINTAAN_SUMBER_DAYA", kecamatanID(), models.PermintaanSumberDayaEvent{
+		PermintaanID:  permintaan.ID,
+		BencanaID:     permintaan.BencanaID,
+		Jenis:         permintaan.Jenis,
+		Deskripsi:     permintaan.Deskripsi,
+		BarangKode:    permintaan.BarangKode,
+		Jumlah:        permintaan.Jumlah,
+		Satuan:        permintaan.Satuan,
+		Urgensi:       permintaan.Urgensi,
+		LokasiTujuan:  permintaan.LokasiTujuan,
+		Latitude:      permintaan.Latitude,
+		Longitude:     permintaan.Longitude,
+		WaktuDiajukan: permintaan.CreatedAt,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Permintaan submitted to Kota",
+		"data":    permintaan,
+	})
+}
+
+// TerimaPermintaanSumberDaya confirms that the requested resources arrived
+func TerimaPermintaanSumberDaya(c *fiber.Ctx) error {
+	return ubahStatusPermintaanLokal(c, services.PermintaanDiterima)
+}
+
+// BatalkanPermintaanSumberDaya withdraws a request that has not been sent yet. Pembatalan
+// baru berlaku setelah Kota mengonfirmasi (pemasok bisa saja sudah mengirim).
+func BatalkanPermintaanSumberDaya(c *fiber.Ctx) error {
+	return ubahStatusPermintaanLokal(c, services.PermintaanDibatalkan)
+}
+
+// ubahStatusPermintaanLokal moves a request of this kecamatan to status and reports it to Kota.
+// Pembatalan hanya ditandai pembatalan_diminta; statusnya diubah oleh jawaban Kota.
+func ubahStatusPermintaanLokal(c *fiber.Ctx, status string) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var body struct {
+		Catatan string `json:"catatan"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var permintaan models.PermintaanSumberDaya
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&permintaan, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newResponseError(fiber.StatusNotFound, "Permintaan not found", nil)
+			}
+			return err
+		}
+		if err := services.ValidateTransisiPermintaan(permintaan.Status, status); err != nil {
+			return newResponseError(fiber.StatusUnprocessableEntity, err.Error(), fiber.Map{"status": permintaan.Status})
+		}
+
+		if status == services.PermintaanDibatalkan {
+			// Dikirim ulang bila diminta lagi, mis. event sebelumnya tidak sampai
+			permintaan.PembatalanDiminta = &now
+			return tx.Model(&permintaan).Update("pembatalan_diminta", now).Error
+		}
+		permintaan.Status = status
+		if status == services.PermintaanDiterima {
+			permintaan.WaktuDiterima = &now
+		}
+		return tx.Save(&permintaan).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to update permintaan")
+	}
+
+	go messaging.PublishEvent("UPDATE_STATUS_PERMINTAAN_SUMBER_DAYA", kecamatanID(), models.StatusPermintaanEvent{
+		PermintaanID: permintaan.ID,
+		Status:       status,
+		Catatan:      body.Catatan,
+		Waktu:        now,
+	})
+
+	if status == services.PermintaanDibatalkan {
+		logActivity(userID, "Meminta pembatalan permintaan sumber daya ID "+strconv.Itoa(id))
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"error":   false,
+			"message": "Cancellation sent to Kota; the request stays active until Kota confirms it",
+			"data":    permintaan,
+		})
+	}
+
+	logActivity(userID, "Mengubah status permintaan sumber daya ID "+strconv.Itoa(id)+" menjadi "+status)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Permintaan updated successfully",
+		"data":    permintaan,
+	})
+}
+
+// GetAllPasokanSumberDaya returns requests of other kecamatan this kecamatan was assigned to supply
+func GetAllPasokanSumberDaya(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.PasokanSumberDaya{})
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	return listPage[models.PasokanSumberDaya](c, query, permintaanListSpec, "Failed to fetch pasokan")
+}
+
+// KirimPasokanSumberDaya marks an assigned supply as sent. For logistics with a gudang_id
+// the goods are taken out of that warehouse's stock in the same transaction.
+func KirimPasokanSumberDaya(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.KirimPasokanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var pasokan models.PasokanSumberDaya
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pasokan, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newResponseError(fiber.StatusNotFound, "Pasokan not found", nil)
+			}
+			return err
+		}
+		if err := services.ValidateTransisiPermintaan(pasokan.Status, services.PermintaanDikirim); err != nil {
+			return newResponseError(fiber.StatusUnprocessableEntity, err.Error(), fiber.Map{"status": pasokan.Status})
+		}
+
+		if req.GudangID != nil {
+			if pasokan.BarangKode == "" {
+				return newResponseError(fiber.StatusUnprocessableEntity, "Permintaan has no barang_kode, send without gudang_id", fiber.Map{"field": "gudang_id"})
+			}
+			if _, err := cekGudangAktif(tx, *req.GudangID); err != nil {
+				return err
+			}
+			var barang models.BarangLogistik
+			if err := tx.Where("kode = ?", pasokan.BarangKode).First(&barang).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return newResponseError(fiber.StatusUnprocessableEntity, "Barang "+pasokan.BarangKode+" is not in the catalogue", fiber.Map{"field": "gudang_id"})
+				}
+				return err
+			}
+			m := models.MutasiStok{
+				GudangID:  *req.GudangID,
+				BarangID:  barang.ID,
+				Jenis:     mutasiKeluar,
+				Catatan:   "Pasokan ke kecamatan " + pasokan.KecamatanPemintaNama,
+				PetugasID: userID,
+			}
+			if err := ubahStok(tx, &m, -pasokan.Jumlah); err != nil {
+				return err
+			}
+			pasokan.GudangID = req.GudangID
+		}
+
+		pasokan.Status = services.PermintaanDikirim
+		pasokan.DikirimOleh = &userID
+		pasokan.CatatanPengiriman = req.Catatan
+		pasokan.WaktuDikirim = &now
+		return tx.Save(&pasokan).Error
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to update pasokan")
+	}
+
+	logActivity(userID, "Mengirim pasokan "+pasokan.Jenis+" ke kecamatan "+pasokan.KecamatanPemintaNama)
+
+	go messaging.PublishEvent("UPDATE_STATUS_PERMINTAAN_SUMBER_DAYA", kecamatanID(), models.StatusPermintaanEvent{
+		PermintaanKotaID: pasokan.PermintaanKotaID,
+		Status:           services.PermintaanDikirim,
+		Catatan:          req.Catatan,
+		Waktu:            now,
+	})
+	if pasokan.GudangID != nil {
+		go publishStokLogistik(*pasokan.GudangID)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Pasokan marked as sent",
+		"data":    pasokan,
+	})
+}
+
+// terimaStatusPermintaan applies a status decided at Kota to a request of this kecamatan.
+// Pembatalan yang menunggu selesai bila Kota menjawabnya atau statusnya tidak bisa dibatalkan lagi.
+func terimaStatusPermintaan(data models.StatusPermintaanEvent) error {
+	var permintaan models.PermintaanSumberDaya
+	if err := database.DB.First(&permintaan, data.PermintaanID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("⚠️ Permintaan sumber daya %d tidak ditemukan", data.PermintaanID)
+			return nil
+		}
+		return err
+	}
+	pembatalanSelesai := permintaan.PembatalanDiminta != nil && (data.JawabanPembatalan ||
+		data.Status == services.PermintaanDibatalkan ||
+		services.ValidateTransisiPermintaan(data.Status, services.PermintaanDibatalkan) != nil)
+
+	if permintaan.Status == data.Status {
+		// Event ganda, atau Kota menolak pembatalan tanpa perubahan status
+		if pembatalanSelesai {
+			if err := database.DB.Model(&permintaan).Update("pembatalan_diminta", nil).Error; err != nil {
+				return err
+			}
+			siarkanStatusPermintaan(permintaan.ID, data, true)
+		}
+		return nil
+	}
+	if err := services.ValidateTransisiPermintaan(permintaan.Status, data.Status); err != nil {
+		log.Printf("⚠️ Status permintaan %d diabaikan: %v", permintaan.ID, err)
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"status":             data.Status,
+		"permintaan_kota_id": data.PermintaanKotaID,
+	}
+	if pembatalanSelesai {
+		updates["pembatalan_diminta"] = nil
+	}
+	switch data.Status {
+	case services.PermintaanDisetujui, services.PermintaanDitolak:
+		updates["kecamatan_pemasok_id"] = data.KecamatanPemasokID
+		updates["kecamatan_pemasok_nama"] = data.KecamatanPemasokNama
+		updates["catatan_kota"] = data.Catatan
+		updates["waktu_diputuskan"] = data.Waktu
+	case services.PermintaanDikirim:
+		updates["waktu_dikirim"] = data.Waktu
+	case services.PermintaanDibatalkan:
+		updates["catatan_kota"] = data.Catatan
+	}
+	if err := database.DB.Model(&permintaan).Updates(updates).Error; err != nil {
+		return fmt.Errorf("update permintaan %d: %w", permintaan.ID, err)
+	}
+
+	siarkanStatusPermintaan(permintaan.ID, data, pembatalanSelesai && data.Status != services.PermintaanDibatalkan)
+	return nil
+}
+
+// siarkanStatusPermintaan tells SSE clients about a status received from Kota
+func siarkanStatusPermintaan(permintaanID uint, data models.StatusPermintaanEvent, pembatalanDitolak bool) {
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":               "permintaan_sumber_daya",
+		"permintaan_id":      permintaanID,
+		"status":             data.Status,
+		"kecamatan_pemasok":  data.KecamatanPemasokNama,
+		"catatan":            data.Catatan,
+		"pembatalan_ditolak": pembatalanDitolak,
+		"waktu":              data.Waktu.Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+}
+
+// terimaPenugasanPasokan records a request this kecamatan was assigned to supply
+func terimaPenugasanPasokan(data models.PenugasanPasokanEvent) error {
+	pasokan := models.PasokanSumberDaya{
+		PermintaanKotaID:     data.PermintaanKotaID,
+		KecamatanPemintaID:   data.KecamatanPemintaID,
+		KecamatanPemintaNama: data.KecamatanPemintaNama,
+		Jenis:                data.Jenis,
+		Deskripsi:            data.Deskripsi,
+		BarangKode:           data.BarangKode,
+		Jumlah:               data.Jumlah,
+		Satuan:               data.Satuan,
+		Urgensi:              data.Urgensi,
+		LokasiTujuan:         data.LokasiTujuan,
+		Latitude:             data.Latitude,
+		Longitude:            data.Longitude,
+		CatatanKota:          data.Catatan,
+		Status:               services.PermintaanDisetujui,
+	}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&pasokan)
+	if result.Error != nil {
+		return fmt.Errorf("simpan penugasan pasokan: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil // Event ganda
+	}
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":              "penugasan_pasokan",
+		"pasokan_id":        pasokan.ID,
+		"kecamatan_peminta": pasokan.KecamatanPemintaNama,
+		"jenis":             pasokan.Jenis,
+		"deskripsi":         pasokan.Deskripsi,
+		"jumlah":            pasokan.Jumlah,
+		"urgensi":           pasokan.Urgensi,
+		"waktu":             time.Now().Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+	return nil
+}
+
+// terimaStatusPasokan applies the requester's confirmation or cancellation to an assigned supply
+func terimaStatusPasokan(data models.StatusPermintaanEvent) error {
+	var pasokan models.PasokanSumberDaya
+	if err := database.DB.Where("permintaan_kota_id = ?", data.PermintaanKotaID).First(&pasokan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("⚠️ Pasokan untuk permintaan Kota %d tidak ditemukan", data.PermintaanKotaID)
+			return nil
+		}
+		return err
+	}
+	if pasokan.Status == data.Status {
+		return nil
+	}
+	if err := services.ValidateTransisiPermintaan(pasokan.Status, data.Status); err != nil {
+		log.Printf("⚠️ Status pasokan %d diabaikan: %v", pasokan.ID, err)
+		return nil
+	}
+
+	updates := map[string]interface{}{"status": data.Status}
+	if data.Status == services.PermintaanDiterima {
+		updates["waktu_diterima"] = data.Waktu
+	}
+	if err := database.DB.Model(&pasokan).Updates(updates).Error; err != nil {
+		return fmt.Errorf("update pasokan %d: %w", pasokan.ID, err)
+	}
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":       "status_pasokan",
+		"pasokan_id": pasokan.ID,
+		"status":     data.Status,
+		"catatan":    data.Catatan,
+		"waktu":      data.Waktu.Format(time.RFC3339),
+	})
+	broadcastToClients(string(message))
+	return nil
+}
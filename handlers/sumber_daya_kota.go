@@ -0,0 +1,255 @@
+// handlers/sumber_daya_kota.go
+package handlers
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FUNGSI DI FILE INI HANYA DIJALANKAN DI API KOTA, MEMBACA DB KOTA.
+// Permintaan masuk lewat Sync Worker; keputusan BPBD dikirim balik ke kecamatan
+// lewat topic Kafka Kota -> kecamatan.
+
+// GetPermintaanSumberDayaKota returns resource requests of all kecamatan, paginated (see list_query.go).
+// Query: status, jenis, urgensi, kecamatan_id, kecamatan_pemasok_id
+func GetPermintaanSumberDayaKota(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.PermintaanSumberDayaKota{})
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	if urgensi := c.Query("urgensi"); urgensi != "" {
+		query = query.Where("urgensi = ?", urgensi)
+	}
+	if kecamatanID := c.QueryInt("kecamatan_id", 0); kecamatanID > 0 {
+		query = query.Where("kecamatan_id = ?", kecamatanID)
+	}
+	if pemasokID := c.QueryInt("kecamatan_pemasok_id", 0); pemasokID > 0 {
+		query = query.Where("kecamatan_pemasok_id = ?", pemasokID)
+	}
+
+	return listPage[models.PermintaanSumberDayaKota](c, query, permintaanListSpec, "Failed to fetch permintaan", "Kecamatan", "KecamatanPemasok")
+}
+
+// GetPermintaanSumberDayaKotaByID returns a request with supplier suggestions.
+// Untuk logistik, kecamatan lain diurutkan dari kelebihan stok terbesar barang yang diminta.
+func GetPermintaanSumberDayaKotaByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var permintaan models.PermintaanSumberDayaKota
+	if err := database.DB.Preload("Kecamatan").Preload("KecamatanPemasok").First(&permintaan, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Permintaan not found",
+		})
+	}
+
+	saran := []fiber.Map{}
+	if permintaan.BarangKode != "" {
+		saran = saranPemasokLogistik(permintaan)
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"permintaan":    permintaan,
+			"saran_pemasok": saran,
+		},
+	})
+}
+
+// SetujuiPermintaanSumberDaya approves a request and assigns the supplying kecamatan
+// (kosong berarti BPBD memasok sendiri)
+func SetujuiPermintaanSumberDaya(c *fiber.Ctx) error {
+	var req models.KeputusanPermintaanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	permintaan, err := putuskanPermintaan(c, services.PermintaanDisetujui, func(tx *gorm.DB, p *models.PermintaanSumberDayaKota) error {
+		p.CatatanKota = req.Catatan
+		if req.KecamatanPemasokID == nil {
+			return nil
+		}
+		if *req.KecamatanPemasokID == p.KecamatanID {
+			return newResponseError(fiber.StatusBadRequest, "Supplier must be another kecamatan", fiber.Map{"field": "kecamatan_pemasok_id"})
+		}
+		var pemasok models.MasterKecamatan
+		if err := tx.First(&pemasok, *req.KecamatanPemasokID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newResponseError(fiber.StatusBadRequest, "Kecamatan pemasok not found", fiber.Map{"field": "kecamatan_pemasok_id"})
+			}
+			return err
+		}
+		p.KecamatanPemasokID = &pemasok.ID
+		p.KecamatanPemasok = &pemasok
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to approve permintaan")
+	}
+
+	if permintaan.KecamatanPemasokID != nil {
+		go messaging.PublishToKecamatan("PENUGASAN_PASOKAN", *permintaan.KecamatanPemasokID, permintaan.EventPenugasan())
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Permintaan approved",
+		"data":    permintaan,
+	})
+}
+
+// TolakPermintaanSumberDaya rejects a request; catatan explains why
+func TolakPermintaanSumberDaya(c *fiber.Ctx) error {
+	var req models.KeputusanPermintaanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	permintaan, err := putuskanPermintaan(c, services.PermintaanDitolak, func(tx *gorm.DB, p *models.PermintaanSumberDayaKota) error {
+		if strings.TrimSpace(req.Catatan) == "" {
+			return newResponseError(fiber.StatusBadRequest, "catatan is required when rejecting", fiber.Map{"field": "catatan"})
+		}
+		p.CatatanKota = req.Catatan
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to reject permintaan")
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Permintaan rejected",
+		"data":    permintaan,
+	})
+}
+
+// KirimPermintaanSumberDaya marks a request supplied directly by BPBD as sent
+func KirimPermintaanSumberDaya(c *fiber.Ctx) error {
+	permintaan, err := putuskanPermintaan(c, services.PermintaanDikirim, func(tx *gorm.DB, p *models.PermintaanSumberDayaKota) error {
+		if p.KecamatanPemasokID != nil {
+			return newResponseError(fiber.StatusUnprocessableEntity, "Permintaan is supplied by another kecamatan, it marks the shipment itself", fiber.Map{"kecamatan_pemasok_id": p.KecamatanPemasokID})
+		}
+		return nil
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to update permintaan")
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Permintaan marked as sent",
+		"data":    permintaan,
+	})
+}
+
+// putuskanPermintaan moves a request to status on behalf of BPBD, lets apply adjust it,
+// and sends the new status to the requesting kecamatan
+func putuskanPermintaan(c *fiber.Ctx, status string, apply func(tx *gorm.DB, p *models.PermintaanSumberDayaKota) error) (*models.PermintaanSumberDayaKota, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return nil, newResponseError(fiber.StatusBadRequest, "Invalid ID", nil)
+	}
+
+	userID := c.Locals("userID").(uint)
+	now := time.Now()
+
+	var permintaan models.PermintaanSumberDayaKota
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Kecamatan").Preload("KecamatanPemasok").First(&permintaan, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return newResponseError(fiber.StatusNotFound, "Permintaan not found", nil)
+			}
+			return err
+		}
+		if err := services.ValidateTransisiPermintaan(permintaan.Status, status); err != nil {
+			return newResponseError(fiber.StatusUnprocessableEntity, err.Error(), fiber.Map{"status": permintaan.Status})
+		}
+		if err := apply(tx, &permintaan); err != nil {
+			return err
+		}
+
+		permintaan.Status = status
+		switch status {
+		case services.PermintaanDisetujui, services.PermintaanDitolak:
+			permintaan.DiputuskanOleh = &userID
+			permintaan.WaktuDiputuskan = &now
+		case services.PermintaanDikirim:
+			permintaan.WaktuDikirim = &now
+		}
+		return tx.Omit(clause.Associations).Save(&permintaan).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go messaging.PublishToKecamatan("STATUS_PERMINTAAN_SUMBER_DAYA", permintaan.KecamatanID, permintaan.EventStatus(permintaan.CatatanKota, now))
+	return &permintaan, nil
+}
+
+// saranPemasokLogistik lists other kecamatan holding the requested item, largest surplus first
+func saranPemasokLogistik(permintaan models.PermintaanSumberDayaKota) []fiber.Map {
+	type saldo struct {
+		KecamatanID uint
+		Jumlah      float64
+		StokMinimum float64
+	}
+	var rows []saldo
+	database.DB.Model(&models.StokLogistikKota{}).
+		Select("kecamatan_id, SUM(jumlah) AS jumlah, SUM(stok_minimum) AS stok_minimum").
+		Where("barang_kode = ? AND kecamatan_id <> ?", permintaan.BarangKode, permintaan.KecamatanID).
+		Group("kecamatan_id").
+		Scan(&rows)
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Jumlah-rows[i].StokMinimum > rows[j].Jumlah-rows[j].StokMinimum
+	})
+
+	var kecamatan []models.MasterKecamatan
+	database.DB.Find(&kecamatan)
+	nama := make(map[uint]string, len(kecamatan))
+	for _, k := range kecamatan {
+		nama[k.ID] = k.Nama
+	}
+
+	saran := make([]fiber.Map, 0, len(rows))
+	for _, r := range rows {
+		kelebihan := r.Jumlah - r.StokMinimum
+		saran = append(saran, fiber.Map{
+			"kecamatan_id":   r.KecamatanID,
+			"kecamatan_nama": nama[r.KecamatanID],
+			"jumlah":         r.Jumlah,
+			"stok_minimum":   r.StokMinimum,
+			"kelebihan":      kelebihan,
+			"cukup":          kelebihan >= permintaan.Jumlah,
+		})
+	}
+	return saran
+}
@@ -0,0 +1,43 @@
+// handlers/sync.go
+package handlers
+
+import (
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+// kecamatanID returns the ID of this kecamatan in the kota master data (env KECAMATAN_ID)
+func kecamatanID() uint {
+	return uint(envInt("KECAMATAN_ID", 1))
+}
+
+// publishOkupansiTitikKumpul sends the current occupancy of a shelter to the sync pipeline.
+// Dipanggil sebagai goroutine setelah transaksi selesai.
+func publishOkupansiTitikKumpul(id uint) {
+	// Diambil sebelum membaca agar snapshot yang dibaca belakangan selalu berversi lebih besar
+	versi := time.Now().UnixMicro()
+	var titik models.TitikKumpul
+	if err := database.DB.Unscoped().First(&titik, id).Error; err != nil {
+		return
+	}
+
+	if titik.DeletedAt.Valid {
+		messaging.PublishEvent("DELETE_TITIK_KUMPUL", kecamatanID(), models.OkupansiTitikKumpulEvent{TitikKumpulID: titik.ID, Versi: versi})
+		return
+	}
+
+	messaging.PublishEvent("UPDATE_OKUPANSI_TITIK_KUMPUL", kecamatanID(), models.OkupansiTitikKumpulEvent{
+		TitikKumpulID: titik.ID,
+		Nama:          titik.Nama,
+		Jenis:         titik.Jenis,
+		Latitude:      titik.Latitude,
+		Longitude:     titik.Longitude,
+		Kapasitas:     titik.Kapasitas,
+		Terisi:        titik.Terisi,
+		Aktif:         titik.Aktif,
+		Versi:         versi,
+	})
+}
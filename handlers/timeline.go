@@ -0,0 +1,577 @@
+// handlers/timeline.go
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// maksLampiranPerEntri is the number of files one timeline entry may carry
+const maksLampiranPerEntri = 5
+
+// maksEntriSitrep is the number of latest timeline entries printed in a sitrep
+const maksEntriSitrep = 200
+
+// ekstensiDokumen lists accepted document types; foto dikenali dari isinya (image/*)
+var ekstensiDokumen = map[string]string{
+	".pdf":  "application/pdf",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".txt":  "text/plain; charset=utf-8",
+	".csv":  "text/csv; charset=utf-8",
+}
+
+// timelineListSpec defines sorting and search for timeline entries
+var timelineListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":    "id",
+		"waktu": "waktu",
+		"tipe":  "tipe",
+	},
+	DefaultSort: "-waktu",
+	Search:      []string{"isi LIKE ?", "lokasi LIKE ?"},
+}
+
+// berkasUnggahan is an uploaded file that passed validation
+type berkasUnggahan struct {
+	nama  string
+	tipe  string
+	jenis string
+	ext   string
+	data  []byte
+}
+
+// GetTimelineBencana returns the timeline of a bencana, paginated (see list_query.go).
+// Query: tipe, rt, rw
+func GetTimelineBencana(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	query := database.DB.Where("bencana_id = ?", id)
+	if tipe := c.Query("tipe"); tipe != "" {
+		query = query.Where("tipe = ?", tipe)
+	}
+	if rt := c.Query("rt"); rt != "" {
+		query = query.Where("rt = ?", rt)
+	}
+	if rw := c.Query("rw"); rw != "" {
+		query = query.Where("rw = ?", rw)
+	}
+
+	return listPage[models.TimelineBencana](c, query, timelineListSpec, "Failed to fetch timeline", "Lampiran", "Pencatat")
+}
+
+// CreateTimelineBencana posts an update to the timeline of a bencana. Body JSON atau
+// multipart/form-data dengan foto/dokumen pada field "lampiran". Angka korban &
+// kerusakan adalah jumlah kumulatif terbaru untuk RT/RW yang dilaporkan.
+func CreateTimelineBencana(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var bencana models.KejadianBencana
+	if err := database.DB.First(&bencana, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana not found",
+		})
+	}
+	if bencana.Status == "Draft" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana is still a draft, confirm it first",
+		})
+	}
+
+	var req models.CreateTimelineRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	now := time.Now()
+	waktu := now
+	if req.Waktu != "" {
+		if waktu, err = time.Parse(time.RFC3339, req.Waktu); err != nil || waktu.After(now.Add(5*time.Minute)) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "waktu must be an RFC3339 time that is not in the future",
+				"field":   "waktu",
+			})
+		}
+	}
+
+	dampak := req.Dampak()
+	if err := services.ValidasiDampak(dampak); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	berkas, err := bacaLampiran(c)
+	if err != nil {
+		return writeError(c, err, "Failed to read lampiran")
+	}
+
+	isi := strings.TrimSpace(req.Isi)
+	adaDampak := services.AdaDampak(dampak)
+	if isi == "" && !adaDampak && len(berkas) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "isi, dampak figures or lampiran is required",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	entri := models.TimelineBencana{
+		BencanaID:   bencana.ID,
+		Tipe:        "Update",
+		Isi:         isi,
+		RT:          strings.TrimSpace(req.RT),
+		RW:          strings.TrimSpace(req.RW),
+		Lokasi:      req.Lokasi,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Dampak:      dampak,
+		Waktu:       waktu,
+		DicatatOleh: &userID,
+	}
+	if adaDampak {
+		entri.Tipe = "Dampak"
+	}
+
+	// Berkas disimpan dulu; bila DB gagal, berkas yang sudah tersimpan dihapus lagi
+	nama := namaPenyimpananAktif()
+	store, err := penyimpanan(nama)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   true,
+			"message": "File storage is not available",
+		})
+	}
+	lampiran := make([]models.LampiranBencana, 0, len(berkas))
+	for _, b := range berkas {
+		hash := sha256.Sum256(b.data)
+		l := models.LampiranBencana{
+			BencanaID:    bencana.ID,
+			Jenis:        b.jenis,
+			NamaBerkas:   b.nama,
+			TipeKonten:   b.tipe,
+			Ukuran:       int64(len(b.data)),
+			SHA256:       hex.EncodeToString(hash[:]),
+			Penyimpanan:  nama,
+			DiunggahOleh: userID,
+		}
+		l.Kunci = fmt.Sprintf("bencana/%d/%s-%s%s", bencana.ID, now.Format("20060102T150405"), l.SHA256[:16], b.ext)
+		if err := store.Simpan(l.Kunci, l.TipeKonten, b.data); err != nil {
+			log.Printf("❌ Gagal menyimpan lampiran %s: %v", b.nama, err)
+			hapusBerkasLampiran(lampiran)
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to store lampiran " + b.nama,
+			})
+		}
+		lampiran = append(lampiran, l)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entri).Error; err != nil {
+			return err
+		}
+		for i := range lampiran {
+			lampiran[i].TimelineID = entri.ID
+		}
+		if len(lampiran) > 0 {
+			return tx.Create(&lampiran).Error
+		}
+		return nil
+	})
+	if err != nil {
+		hapusBerkasLampiran(lampiran)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create timeline entry",
+		})
+	}
+
+	database.DB.Preload("Lampiran").Preload("Pencatat").First(&entri, entri.ID)
+
+	message, _ := json.Marshal(fiber.Map{
+		"tipe":       "timeline_bencana",
+		"bencana_id": entri.BencanaID,
+		"entri":      entri,
+	})
+	broadcastToClients(string(message))
+
+	logActivity(userID, fmt.Sprintf("Menambah timeline bencana #%d (%s)", bencana.ID, entri.Tipe))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Timeline entry created successfully",
+		"data":    entri,
+	})
+}
+
+// DeleteTimelineBencana removes a wrong entry together with its files. Entri status
+// otomatis tidak dapat dihapus. Angka yang keliru sebaiknya dikoreksi dengan laporan baru.
+func DeleteTimelineBencana(c *fiber.Ctx) error {
+	var entri models.TimelineBencana
+	if err := database.DB.Preload("Lampiran").
+		Where("id = ? AND bencana_id = ?", c.Params("entri_id"), c.Params("id")).
+		First(&entri).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Timeline entry not found",
+		})
+	}
+	if entri.Tipe == "Status" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Status entries are recorded automatically and cannot be deleted",
+		})
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("timeline_id = ?", entri.ID).Delete(&models.LampiranBencana{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entri).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete timeline entry",
+		})
+	}
+	hapusBerkasLampiran(entri.Lampiran)
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, fmt.Sprintf("Menghapus entri timeline #%d bencana #%d", entri.ID, entri.BencanaID))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Timeline entry deleted successfully",
+	})
+}
+
+// GetLampiranBencana streams an attachment from the blob store it was uploaded to
+func GetLampiranBencana(c *fiber.Ctx) error {
+	var lampiran models.LampiranBencana
+	if err := database.DB.Where("id = ? AND bencana_id = ?", c.Params("lampiran_id"), c.Params("id")).
+		First(&lampiran).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Lampiran not found",
+		})
+	}
+
+	store, err := penyimpanan(lampiran.Penyimpanan)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   true,
+			"message": "File storage is not available",
+		})
+	}
+	isi, err := store.Ambil(lampiran.Kunci)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   true,
+				"message": "Lampiran file is missing from storage",
+			})
+		}
+		log.Printf("❌ Gagal mengambil lampiran #%d: %v", lampiran.ID, err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch lampiran",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, lampiran.TipeKonten)
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="`+strings.ReplaceAll(lampiran.NamaBerkas, `"`, "")+`"`)
+	return c.SendStream(isi, int(lampiran.Ukuran))
+}
+
+// GetDampakBencana returns the current casualty and damage figures, total dan per RT/RW
+func GetDampakBencana(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var entri []models.TimelineBencana
+	if err := database.DB.Where("bencana_id = ? AND tipe = ?", id, "Dampak").Find(&entri).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch dampak bencana",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  services.RekapDampak(entri),
+	})
+}
+
+// GetSitrepBencana generates the situation report of a bencana.
+// Query: format=html (default) | pdf | json
+func GetSitrepBencana(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var bencana models.KejadianBencana
+	if err := database.DB.First(&bencana, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Bencana not found",
+		})
+	}
+
+	sitrep, err := kumpulkanSitrep(bencana)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to build sitrep",
+		})
+	}
+
+	namaBerkas := fmt.Sprintf("sitrep-bencana-%d-%s", bencana.ID, sitrep.DibuatPada.Format("20060102-1504"))
+	switch c.Query("format", "html") {
+	case "json":
+		return c.JSON(fiber.Map{
+			"error": false,
+			"data":  sitrep,
+		})
+	case "pdf":
+		body, err := services.RenderSitrepPDF(sitrep)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to render sitrep",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, `inline; filename="`+namaBerkas+`.pdf"`)
+		return c.Send(body)
+	case "html":
+		body, err := services.RenderSitrepHTML(sitrep)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to render sitrep",
+			})
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		c.Set(fiber.HeaderContentDisposition, `inline; filename="`+namaBerkas+`.html"`)
+		return c.Send(body)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "format must be html, pdf or json",
+		})
+	}
+}
+
+// kumpulkanSitrep gathers the timeline, evacuation progress and needs of a bencana
+func kumpulkanSitrep(bencana models.KejadianBencana) (models.Sitrep, error) {
+	sitrep := models.Sitrep{
+		KecamatanID: kecamatanID(),
+		DibuatPada:  time.Now(),
+		Bencana:     bencana,
+		Evakuasi:    models.SitrepEvakuasi{PerStatus: map[string]int64{}},
+	}
+
+	// Angka dampak dihitung dari semua laporan, bukan hanya yang ditampilkan
+	var dampak []models.TimelineBencana
+	if err := database.DB.Where("bencana_id = ? AND tipe = ?", bencana.ID, "Dampak").Find(&dampak).Error; err != nil {
+		return sitrep, err
+	}
+	sitrep.Dampak = services.RekapDampak(dampak)
+
+	if err := database.DB.Where("bencana_id = ?", bencana.ID).Preload("Lampiran").
+		Order("waktu DESC, id DESC").Limit(maksEntriSitrep).Find(&sitrep.Timeline).Error; err != nil {
+		return sitrep, err
+	}
+	for i, j := 0, len(sitrep.Timeline)-1; i < j; i, j = i+1, j-1 {
+		sitrep.Timeline[i], sitrep.Timeline[j] = sitrep.Timeline[j], sitrep.Timeline[i]
+	}
+
+	// Progres evakuasi warga rentan terdampak
+	queryWargaTerdampak(bencana).Count(&sitrep.Evakuasi.WargaTerdampak)
+	var perStatus []struct {
+		StatusTerkini string
+		Jumlah        int64
+	}
+	database.DB.Model(&models.LogEvakuasi{}).Select("status_terkini, COUNT(*) AS jumlah").
+		Where("bencana_id = ?", bencana.ID).Group("status_terkini").Scan(&perStatus)
+	for _, s := range perStatus {
+		sitrep.Evakuasi.PerStatus[s.StatusTerkini] = s.Jumlah
+	}
+	queryWargaTerdampak(bencana).
+		Where("id NOT IN (?)", database.DB.Model(&models.LogEvakuasi{}).Select("warga_id").Where("bencana_id = ?", bencana.ID)).
+		Count(&sitrep.Evakuasi.BelumDitangani)
+
+	// Pengungsi yang masih berada di titik kumpul
+	database.DB.Model(&models.RegistrasiPengungsi{}).
+		Select("titik_kumpuls.nama AS titik_kumpul, titik_kumpuls.kapasitas, COUNT(*) AS jumlah").
+		Joins("JOIN titik_kumpuls ON titik_kumpuls.id = registrasi_pengungsis.titik_kumpul_id").
+		Where("registrasi_pengungsis.bencana_id = ? AND registrasi_pengungsis.status = ?", bencana.ID, "Di Lokasi").
+		Group("titik_kumpuls.id, titik_kumpuls.nama, titik_kumpuls.kapasitas").
+		Order("jumlah DESC").
+		Scan(&sitrep.Pengungsi)
+
+	database.DB.Model(&models.LaporanOrangHilang{}).Where("bencana_id = ? AND status = ?", bencana.ID, "Hilang").Count(&sitrep.OrangHilang.Hilang)
+	database.DB.Model(&models.LaporanOrangHilang{}).Where("bencana_id = ? AND status = ?", bencana.ID, "Ditemukan").Count(&sitrep.OrangHilang.Ditemukan)
+
+	// Kebutuhan: permintaan sumber daya yang belum diterima, paling mendesak lebih dulu
+	database.DB.Model(&models.PermintaanSumberDaya{}).
+		Select("jenis, deskripsi, jumlah, satuan, urgensi, status").
+		Where("bencana_id = ? AND status IN ?", bencana.ID, []string{"Diajukan", "Disetujui", "Dikirim"}).
+		Order("FIELD(urgensi, 'Kritis', 'Mendesak', 'Normal'), created_at").
+		Scan(&sitrep.Kebutuhan)
+
+	database.DB.Where("bencana_id = ? AND status = ?", bencana.ID, "Belum").Order("urutan, id").Find(&sitrep.TugasBelum)
+
+	return sitrep, nil
+}
+
+// catatTimeline records an automatic entry (mis. perubahan status) inside tx
+func catatTimeline(tx *gorm.DB, bencanaID uint, isi string, userID *uint) error {
+	return tx.Create(&models.TimelineBencana{
+		BencanaID:   bencanaID,
+		Tipe:        "Status",
+		Isi:         isi,
+		Waktu:       time.Now(),
+		DicatatOleh: userID,
+	}).Error
+}
+
+// BatasBodyTimeline is the body limit for a timeline entry: maksLampiranPerEntri files
+// of MAKS_LAMPIRAN_MB each plus 1 MB for the other form fields
+func BatasBodyTimeline() int {
+	return maksLampiranPerEntri*envInt("MAKS_LAMPIRAN_MB", 10)<<20 + 1<<20
+}
+
+// BatasBodyLaporanWarga is the body limit for a public report with one foto
+func BatasBodyLaporanWarga() int {
+	return envInt("MAKS_LAMPIRAN_MB", 10)<<20 + 1<<20
+}
+
+// bacaLampiran reads and validates the files in the "lampiran" multipart field
+func bacaLampiran(c *fiber.Ctx) ([]berkasUnggahan, error) {
+	if !strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		return nil, nil
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, newResponseError(fiber.StatusBadRequest, "Invalid multipart form", nil)
+	}
+	files := form.File["lampiran"]
+	if len(files) > maksLampiranPerEntri {
+		return nil, newResponseError(fiber.StatusBadRequest, fmt.Sprintf("At most %d lampiran per entry", maksLampiranPerEntri), nil)
+	}
+
+	maks := int64(envInt("MAKS_LAMPIRAN_MB", 10)) << 20
+	berkas := make([]berkasUnggahan, 0, len(files))
+	for _, fh := range files {
+		if fh.Size > maks {
+			return nil, newResponseError(fiber.StatusRequestEntityTooLarge,
+				fmt.Sprintf("%s is larger than %d MB", fh.Filename, maks>>20), fiber.Map{"berkas": fh.Filename})
+		}
+		b, err := bacaBerkas(fh)
+		if err != nil {
+			return nil, err
+		}
+		berkas = append(berkas, b)
+	}
+	return berkas, nil
+}
+
+// bacaBerkas loads one uploaded file and decides whether it is a Foto or a Dokumen
+func bacaBerkas(fh *multipart.FileHeader) (berkasUnggahan, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return berkasUnggahan{}, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return berkasUnggahan{}, err
+	}
+
+	b := berkasUnggahan{
+		nama: filepath.Base(fh.Filename),
+		ext:  strings.ToLower(filepath.Ext(fh.Filename)),
+		data: data,
+	}
+	terdeteksi := http.DetectContentType(data)
+	switch {
+	case strings.HasPrefix(terdeteksi, "image/"):
+		b.jenis, b.tipe = "Foto", terdeteksi
+	case ekstensiDokumen[b.ext] != "":
+		// PDF harus benar-benar PDF; format lain hanya dicek ekstensinya
+		if b.ext == ".pdf" && terdeteksi != "application/pdf" {
+			return b, newResponseError(fiber.StatusBadRequest, fh.Filename+" is not a valid PDF", fiber.Map{"berkas": fh.Filename})
+		}
+		b.jenis, b.tipe = "Dokumen", ekstensiDokumen[b.ext]
+	default:
+		return b, newResponseError(fiber.StatusUnsupportedMediaType,
+			fh.Filename+" is not a supported photo or document (pdf, doc, docx, xls, xlsx, txt, csv)", fiber.Map{"berkas": fh.Filename})
+	}
+	return b, nil
+}
+
+// hapusBerkasLampiran removes stored blobs; kegagalan hanya dicatat di log
+func hapusBerkasLampiran(lampiran []models.LampiranBencana) {
+	for _, l := range lampiran {
+		store, err := penyimpanan(l.Penyimpanan)
+		if err == nil {
+			err = store.Hapus(l.Kunci)
+		}
+		if err != nil {
+			log.Printf("⚠️ Gagal menghapus berkas lampiran %s: %v", l.Kunci, err)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+// handlers/titik_kumpul.go
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// titikKumpulListSpec defines sorting and search for the shelter list
+var titikKumpulListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":        "id",
+		"nama":      "nama",
+		"kapasitas": "kapasitas",
+		"terisi":    "terisi",
+		"rw":        "rw",
+	},
+	DefaultSort: "nama",
+	Search:      []string{"nama LIKE ?", "alamat LIKE ?"},
+}
+
+// GetAllTitikKumpul returns all shelters, paginated (see list_query.go)
+func GetAllTitikKumpul(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.TitikKumpul{})
+
+	// Filter by aktif
+	if aktif := c.Query("aktif"); aktif != "" {
+		query = query.Where("aktif = ?", c.QueryBool("aktif"))
+	}
+
+	// Filter by jenis
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+
+	// Filter by RW
+	if rw := c.Query("rw"); rw != "" {
+		query = query.Where("rw = ?", rw)
+	}
+
+	// Hanya yang masih punya tempat kosong
+	if c.QueryBool("tersedia") {
+		query = query.Where("terisi < kapasitas")
+	}
+
+	return listPage[models.TitikKumpul](c, query, titikKumpulListSpec, "Failed to fetch titik kumpul")
+}
+
+// GetTitikKumpulByID returns a shelter with the people currently checked in
+func GetTitikKumpulByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var titik models.TitikKumpul
+	if err := database.DB.First(&titik, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Titik kumpul not found",
+		})
+	}
+
+	var penghuni []models.RegistrasiPengungsi
+	queryPenghuni(database.DB, titik.ID).Order("nama").Find(&penghuni)
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"titik_kumpul":   titik,
+			"sisa_kapasitas": titik.SisaKapasitas(),
+			"penghuni":       penghuni,
+		},
+	})
+}
+
+// CreateTitikKumpul registers a new shelter
+func CreateTitikKumpul(c *fiber.Ctx) error {
+	var req models.TitikKumpulRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	titik := models.TitikKumpul{Aktif: true}
+	if err := applyTitikKumpul(&titik, req); err != nil {
+		return writeError(c, err, "Failed to create titik kumpul")
+	}
+
+	if err := database.DB.Create(&titik).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create titik kumpul",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Menambah titik kumpul: "+titik.Nama)
+
+	go publishOkupansiTitikKumpul(titik.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Titik kumpul created successfully",
+		"data":    titik,
+	})
+}
+
+// UpdateTitikKumpul updates a shelter's data; occupancy is never set by hand
+func UpdateTitikKumpul(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var titik models.TitikKumpul
+	if err := database.DB.First(&titik, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Titik kumpul not found",
+		})
+	}
+
+	var req models.TitikKumpulRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := applyTitikKumpul(&titik, req); err != nil {
+		return writeError(c, err, "Failed to update titik kumpul")
+	}
+
+	if err := database.DB.Save(&titik).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update titik kumpul",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Mengubah titik kumpul: "+titik.Nama)
+
+	go publishOkupansiTitikKumpul(titik.ID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Titik kumpul updated successfully",
+		"data":    titik,
+	})
+}
+
+// DeleteTitikKumpul removes a shelter that nobody is placed in anymore
+func DeleteTitikKumpul(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var titik models.TitikKumpul
+	if err := database.DB.First(&titik, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Titik kumpul not found",
+		})
+	}
+
+	if titik.Terisi > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Titik kumpul still has evacuees, deactivate it instead",
+			"terisi":  titik.Terisi,
+		})
+	}
+
+	if err := database.DB.Delete(&titik).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete titik kumpul",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Menghapus titik kumpul: "+titik.Nama)
+
+	go publishOkupansiTitikKumpul(titik.ID)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Titik kumpul deleted successfully",
+	})
+}
+
+// GetSaranTitikKumpul suggests shelters by distance and free capacity.
+// Query: lat, lng, jumlah, toilet_akses, pos_medis, generator; atau warga_id /
+// kartu_keluarga_id untuk mengambil lokasi, jumlah orang dan kebutuhan fasilitas dari data warga.
+func GetSaranTitikKumpul(c *fiber.Ctx) error {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	adaLokasi := errLat == nil && errLng == nil && services.AdaKoordinat(lat, lng)
+
+	jumlah := c.QueryInt("jumlah", 1)
+	butuh := services.KebutuhanFasilitas{
+		ToiletAkses: c.QueryBool("toilet_akses"),
+		PosMedis:    c.QueryBool("pos_medis"),
+		Generator:   c.QueryBool("generator"),
+	}
+
+	var rombongan []models.WargaRentan
+	if wargaID := c.QueryInt("warga_id", 0); wargaID > 0 {
+		database.DB.Preload("Kategori").Preload("KebutuhanPerawatan").Where("id = ?", wargaID).Find(&rombongan)
+	} else if keluargaID := c.QueryInt("kartu_keluarga_id", 0); keluargaID > 0 {
+		database.DB.Preload("Kategori").Preload("KebutuhanPerawatan").Where("kartu_keluarga_id = ?", keluargaID).Find(&rombongan)
+	}
+	if len(rombongan) > 0 {
+		if len(rombongan) > jumlah {
+			jumlah = len(rombongan)
+		}
+		for _, w := range rombongan {
+			butuh = butuh.Gabung(services.FasilitasUntukWarga(w.DaftarKategori(), w.KebutuhanPerawatan))
+			if !adaLokasi && services.AdaKoordinat(w.Latitude, w.Longitude) {
+				lat, lng, adaLokasi = w.Latitude, w.Longitude, true
+			}
+		}
+	}
+
+	var titik []models.TitikKumpul
+	if err := database.DB.Where("aktif = ?", true).Find(&titik).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch titik kumpul",
+		})
+	}
+
+	saran := services.SarankanTitikKumpul(lat, lng, adaLokasi, jumlah, butuh, titik)
+	if limit := c.QueryInt("limit", 5); limit > 0 && len(saran) > limit {
+		saran = saran[:limit]
+	}
+
+	return c.JSON(fiber.Map{
+		"error":     false,
+		"data":      saran,
+		"total":     len(saran),
+		"jumlah":    jumlah,
+		"fasilitas": butuh,
+	})
+}
+
+// tempatkanDiTitikKumpul records where an evacuated warga is staying: the warga
+// is checked in to the shelter registry and the log points to the shelter.
+// A full shelter is rejected unless force is set.
+func tempatkanDiTitikKumpul(tx *gorm.DB, log *models.LogEvakuasi, titikKumpulID *uint, userID uint, force bool) error {
+	if titikKumpulID == nil {
+		return newResponseError(fiber.StatusBadRequest, "titik_kumpul_id is required for status "+services.StatusDiTitikKumpul, fiber.Map{"field": "titik_kumpul_id"})
+	}
+
+	warga, err := cekWargaAda(tx, log.WargaID)
+	if err != nil {
+		return err
+	}
+
+	reg := registrasiWarga(*warga, log.BencanaID, *titikKumpulID, userID)
+	if err := daftarkanPengungsi(tx, reg, force); err != nil {
+		return err
+	}
+
+	log.TitikKumpulID = &reg.TitikKumpulID
+	return tx.Model(log).Update("titik_kumpul_id", reg.TitikKumpulID).Error
+}
+
+// kunciTitikKumpul loads a shelter with a row lock and checks it can take one more person
+func kunciTitikKumpul(tx *gorm.DB, id uint, force bool) (*models.TitikKumpul, error) {
+	var titik models.TitikKumpul
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&titik, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newResponseError(fiber.StatusBadRequest, "Titik kumpul not found", fiber.Map{"field": "titik_kumpul_id"})
+		}
+		return nil, err
+	}
+	if !titik.Aktif {
+		return nil, newResponseError(fiber.StatusUnprocessableEntity, "Titik kumpul is not active", fiber.Map{"field": "titik_kumpul_id"})
+	}
+	if titik.SisaKapasitas() < 1 && !force {
+		var aktif []models.TitikKumpul
+		tx.Where("aktif = ?", true).Find(&aktif)
+		saran := services.SarankanTitikKumpul(titik.Latitude, titik.Longitude, services.AdaKoordinat(titik.Latitude, titik.Longitude), 1, services.KebutuhanFasilitas{}, aktif)
+		if len(saran) > 3 {
+			saran = saran[:3]
+		}
+		return nil, newResponseError(fiber.StatusConflict, "Titik kumpul is full, choose another one or retry with ?force=true", fiber.Map{
+			"field":     "titik_kumpul_id",
+			"kapasitas": titik.Kapasitas,
+			"terisi":    titik.Terisi,
+			"saran":     saran,
+		})
+	}
+	return &titik, nil
+}
+
+// hitungOkupansi recounts the evacuees of a shelter from the registry (active bencana only)
+func hitungOkupansi(tx *gorm.DB, titikKumpulID uint) error {
+	var terisi int64
+	if err := queryPenghuni(tx, titikKumpulID).Model(&models.RegistrasiPengungsi{}).Count(&terisi).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.TitikKumpul{}).Where("id = ?", titikKumpulID).Update("terisi", terisi).Error
+}
+
+// hitungUlangSemuaOkupansi recounts every shelter, e.g. after a bencana is closed
+func hitungUlangSemuaOkupansi() {
+	var ids []uint
+	database.DB.Model(&models.TitikKumpul{}).Pluck("id", &ids)
+	for _, id := range ids {
+		if err := hitungOkupansi(database.DB, id); err == nil {
+			publishOkupansiTitikKumpul(id)
+		}
+	}
+}
+
+// queryPenghuni selects the registrations of people currently at a shelter for an active bencana
+func queryPenghuni(tx *gorm.DB, titikKumpulID uint) *gorm.DB {
+	return tx.Where("titik_kumpul_id = ? AND status = ?", titikKumpulID, "Di Lokasi").
+		Where("bencana_id IN (?)", tx.Model(&models.KejadianBencana{}).Select("id").Where("status = ?", "Aktif"))
+}
+
+// applyTitikKumpul validates a request and copies it into the model
+func applyTitikKumpul(titik *models.TitikKumpul, req models.TitikKumpulRequest) error {
+	if req.Nama == "" {
+		return newResponseError(fiber.StatusBadRequest, "nama is required", fiber.Map{"field": "nama"})
+	}
+	if req.Kapasitas < 1 {
+		return newResponseError(fiber.StatusBadRequest, "kapasitas must be a positive number", fiber.Map{"field": "kapasitas"})
+	}
+	if req.Jenis == "" {
+		req.Jenis = "Titik Kumpul"
+	}
+	if req.Jenis != "Titik Kumpul" && req.Jenis != "Shelter" {
+		return newResponseError(fiber.StatusBadRequest, "jenis must be Titik Kumpul or Shelter", fiber.Map{"field": "jenis"})
+	}
+
+	titik.Nama = req.Nama
+	titik.Jenis = req.Jenis
+	titik.Alamat = req.Alamat
+	titik.RT = req.RT
+	titik.RW = req.RW
+	titik.Latitude = req.Latitude
+	titik.Longitude = req.Longitude
+	titik.Kapasitas = req.Kapasitas
+	titik.ToiletAkses = req.ToiletAkses
+	titik.PosMedis = req.PosMedis
+	titik.Generator = req.Generator
+	titik.Catatan = req.Catatan
+	if req.Aktif != nil {
+		titik.Aktif = *req.Aktif
+	}
+	return nil
+}
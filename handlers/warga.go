@@ -2,17 +2,42 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
 	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
-// GetAllWarga returns all warga rentan with optional filters
-func GetAllWarga(c *fiber.Ctx) error {
-	var warga []models.WargaRentan
+// Ambang kemiripan untuk deteksi warga ganda yang terdaftar tanpa NIK
+const (
+	ambangKemiripanNama   = 0.85
+	ambangKemiripanAlamat = 0.80
+)
+
+// wargaListSpec defines sorting and free-text search for the warga list
+var wargaListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":             "id",
+		"nama":           "nama",
+		"skor_prioritas": "skor_prioritas",
+		"rt":             "rt",
+		"rw":             "rw",
+		"created_at":     "created_at",
+		"updated_at":     "updated_at",
+	},
+	DefaultSort: "-skor_prioritas,nama",
+	Search:      []string{"nama LIKE ?", "alamat LIKE ?", "nik LIKE ?"},
+}
 
+// GetAllWarga returns warga rentan with optional filters, paginated (see list_query.go)
+func GetAllWarga(c *fiber.Ctx) error {
 	query := database.DB
 
 	// Filter by RT
@@ -25,24 +50,12 @@ func GetAllWarga(c *fiber.Ctx) error {
 		query = query.Where("rw = ?", rw)
 	}
 
-	// Filter by kategori
+	// Filter by kategori (matches any of the warga's categories)
 	if kategori := c.Query("kategori_rentan"); kategori != "" {
-		query = query.Where("kategori_rentan = ?", kategori)
-	}
-
-	// Execute query
-	if err := query.Order("skor_prioritas DESC").Find(&warga).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to fetch warga data",
-		})
+		query = query.Where("id IN (?)", database.DB.Model(&models.KategoriWarga{}).Select("warga_id").Where("kategori = ?", kategori))
 	}
 
-	return c.JSON(fiber.Map{
-		"error": false,
-		"data":  warga,
-		"total": len(warga),
-	})
+	return listPage[models.WargaRentan](c, query, wargaListSpec, "Failed to fetch warga data", "Kategori", "KebutuhanPerawatan")
 }
 
 // GetWargaByID returns single warga by ID
@@ -56,7 +69,7 @@ func GetWargaByID(c *fiber.Ctx) error {
 	}
 
 	var warga models.WargaRentan
-	if err := database.DB.First(&warga, id).Error; err != nil {
+	if err := database.DB.Preload("KartuKeluarga").Preload("Kategori").Preload("KebutuhanPerawatan").Preload("Paparan").First(&warga, id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
 			"message": "Warga not found",
@@ -80,23 +93,40 @@ func CreateWarga(c *fiber.Ctx) error {
 		})
 	}
 
-	// Calculate priority score based on category
-	skorPrioritas := calculatePriorityScore(req.KategoriRentan)
-
 	warga := models.WargaRentan{
-		NIK:            req.NIK,
-		Nama:           req.Nama,
-		Alamat:         req.Alamat,
-		RT:             req.RT,
-		RW:             req.RW,
-		KategoriRentan: req.KategoriRentan,
-		SkorPrioritas:  skorPrioritas,
-		Latitude:       req.Latitude,
-		Longitude:      req.Longitude,
-		NoHP:           req.NoHP,
-	}
-
-	if err := database.DB.Create(&warga).Error; err != nil {
+		Nama:      req.Nama,
+		Alamat:    req.Alamat,
+		RT:        req.RT,
+		RW:        req.RW,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		NoHP:      req.NoHP,
+	}
+
+	// Set categories, care needs and priority score
+	if status, body := applyKerentananWarga(&warga, req); body != nil {
+		return c.Status(status).JSON(body)
+	}
+
+	// Members inherit location from their household
+	if status, body := applyKeluargaWarga(&warga, req.KartuKeluargaID); body != nil {
+		return c.Status(status).JSON(body)
+	}
+
+	// Validate NIK and check for duplicate persons
+	if status, body := applyIdentitasWarga(&warga, req, 0, c.QueryBool("force")); body != nil {
+		return c.Status(status).JSON(body)
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&warga).Error; err != nil {
+			return err
+		}
+		return catatRiwayatWarga(tx, "Create", userID, nil, warga)
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to create warga",
@@ -104,7 +134,6 @@ func CreateWarga(c *fiber.Ctx) error {
 	}
 
 	// Log activity
-	userID := c.Locals("userID").(uint)
 	logActivity(userID, "Menambahkan warga rentan: "+warga.Nama)
 
 	// -----------------------------------------------------------------
@@ -129,14 +158,6 @@ func UpdateWarga(c *fiber.Ctx) error {
 		})
 	}
 
-	var warga models.WargaRentan
-	if err := database.DB.First(&warga, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":   true,
-			"message": "Warga not found",
-		})
-	}
-
 	var req models.CreateWargaRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -145,27 +166,58 @@ func UpdateWarga(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update fields
-	warga.NIK = req.NIK
-	warga.Nama = req.Nama
-	warga.Alamat = req.Alamat
-	warga.RT = req.RT
-	warga.RW = req.RW
-	warga.KategoriRentan = req.KategoriRentan
-	warga.SkorPrioritas = calculatePriorityScore(req.KategoriRentan)
-	warga.Latitude = req.Latitude
-	warga.Longitude = req.Longitude
-	warga.NoHP = req.NoHP
+	userID := c.Locals("userID").(uint)
 
-	if err := database.DB.Save(&warga).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to update warga",
-		})
+	var warga models.WargaRentan
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Dibaca ulang di bawah kunci baris: edit bersamaan menunggu giliran dan riwayat
+		// dihitung dari data terbaru, bukan dari snapshot yang sudah basi
+		if err := kunciWarga(tx, &warga, uint(id), false); err != nil {
+			return err
+		}
+		sebelum := warga
+
+		// Update fields
+		warga.Nama = req.Nama
+		warga.Alamat = req.Alamat
+		warga.RT = req.RT
+		warga.RW = req.RW
+		warga.Latitude = req.Latitude
+		warga.Longitude = req.Longitude
+		warga.NoHP = req.NoHP
+
+		// Set categories, care needs and priority score
+		if status, body := applyKerentananWarga(&warga, req); body != nil {
+			return &responseError{status: status, body: body}
+		}
+
+		// Members inherit location from their household
+		if status, body := applyKeluargaWarga(&warga, req.KartuKeluargaID); body != nil {
+			return &responseError{status: status, body: body}
+		}
+
+		// Validate NIK and check for duplicate persons
+		if status, body := applyIdentitasWarga(&warga, req, warga.ID, c.QueryBool("force")); body != nil {
+			return &responseError{status: status, body: body}
+		}
+
+		// Categories and care needs are replaced as a whole
+		if err := tx.Where("warga_id = ?", warga.ID).Delete(&models.KategoriWarga{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("warga_id = ?", warga.ID).Delete(&models.KebutuhanPerawatan{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(&warga).Error; err != nil {
+			return err
+		}
+		return catatRiwayatWarga(tx, "Update", userID, &sebelum, warga)
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to update warga")
 	}
 
 	// Log activity
-	userID := c.Locals("userID").(uint)
 	logActivity(userID, "Mengupdate warga rentan: "+warga.Nama)
 
 	return c.JSON(fiber.Map{
@@ -185,23 +237,23 @@ func DeleteWarga(c *fiber.Ctx) error {
 		})
 	}
 
-	var warga models.WargaRentan
-	if err := database.DB.First(&warga, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":   true,
-			"message": "Warga not found",
-		})
-	}
+	userID := c.Locals("userID").(uint)
 
-	if err := database.DB.Delete(&warga).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to delete warga",
-		})
+	var warga models.WargaRentan
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kunciWarga(tx, &warga, uint(id), false); err != nil {
+			return err
+		}
+		if err := tx.Delete(&warga).Error; err != nil {
+			return err
+		}
+		return catatRiwayatWarga(tx, "Delete", userID, &warga, warga)
+	})
+	if err != nil {
+		return writeError(c, err, "Failed to delete warga")
 	}
 
 	// Log activity
-	userID := c.Locals("userID").(uint)
 	logActivity(userID, "Menghapus warga rentan: "+warga.Nama)
 
 	return c.JSON(fiber.Map{
@@ -210,21 +262,197 @@ func DeleteWarga(c *fiber.Ctx) error {
 	})
 }
 
-// Helper function to calculate priority score
-func calculatePriorityScore(kategori string) int {
-	scores := map[string]int{
-		"Disabilitas": 100,
-		"Sakit Keras": 95,
-		"Lansia":      90,
-		"Ibu Hamil":   85,
-		"Anak-anak":   80,
-		"Non-Rentan":  50,
+// pesanNIK maps a NIK validation error to an API message; detail berbahasa Indonesia hanya ke log
+func pesanNIK(err error) string {
+	log.Printf("⚠️ NIK ditolak: %v", err)
+	switch {
+	case errors.Is(err, services.ErrNIKLength):
+		return "NIK must be 16 digits"
+	case err == services.ErrNIKWilayah:
+		return "Invalid NIK region code"
+	case errors.Is(err, services.ErrNIKWilayah):
+		return "NIK region code does not belong to this kecamatan"
+	case errors.Is(err, services.ErrNIKTanggal):
+		return "Invalid NIK date of birth segment"
+	case errors.Is(err, services.ErrNIKNomorUrut):
+		return "NIK serial number must not be 0000"
+	default:
+		return "Invalid NIK"
 	}
+}
+
+// applyIdentitasWarga validates the NIK, fills in the data derived from it and
+// rejects duplicates. A nil body means the warga may be saved.
+// excludeID is the warga being updated (0 on create); force skips the fuzzy check.
+func applyIdentitasWarga(warga *models.WargaRentan, req models.CreateWargaRequest, excludeID uint, force bool) (int, fiber.Map) {
+	nik := strings.TrimSpace(req.NIK)
+	warga.NIK = nil
+	warga.TanggalLahir = nil
+	warga.JenisKelamin = ""
+
+	if nik != "" {
+		info, err := services.ParseNIK(nik)
+		if err == nil {
+			err = services.ValidateNIKWilayah(info, os.Getenv("KODE_WILAYAH"))
+		}
+		if err != nil {
+			return fiber.StatusBadRequest, fiber.Map{
+				"error":   true,
+				"message": pesanNIK(err),
+				"field":   "nik",
+			}
+		}
+
+		// Exact duplicate, including soft-deleted records (unique index still applies)
+		var existing models.WargaRentan
+		err = database.DB.Unscoped().Where("nik = ? AND id <> ?", nik, excludeID).First(&existing).Error
+		if err == nil {
+			return fiber.StatusConflict, fiber.Map{
+				"error":    true,
+				"message":  "NIK already registered",
+				"existing": wargaRingkas(existing),
+			}
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fiber.StatusInternalServerError, fiber.Map{
+				"error":   true,
+				"message": "Failed to check NIK",
+			}
+		}
+
+		warga.NIK = &nik
+		tanggalLahir := info.TanggalLahir
+		warga.TanggalLahir = &tanggalLahir
+		warga.JenisKelamin = info.JenisKelamin
+	}
+
+	if force {
+		return 0, nil
+	}
+
+	// Fuzzy duplicate check on nama + alamat; only meaningful if one side has no NIK
+	query := database.DB.Where("id <> ?", excludeID)
+	if warga.NIK != nil {
+		query = query.Where("nik IS NULL")
+	}
+	if warga.RT != "" {
+		query = query.Where("rt = ?", warga.RT)
+	}
+	if warga.RW != "" {
+		query = query.Where("rw = ?", warga.RW)
+	}
+
+	var candidates []models.WargaRentan
+	if err := query.Find(&candidates).Error; err != nil {
+		return fiber.StatusInternalServerError, fiber.Map{
+			"error":   true,
+			"message": "Failed to check duplicate warga",
+		}
+	}
+
+	nama := services.NormalizeText(warga.Nama)
+	alamat := services.NormalizeAlamat(warga.Alamat)
+	for _, cand := range candidates {
+		skorNama := services.Similarity(nama, services.NormalizeText(cand.Nama))
+		skorAlamat := services.Similarity(alamat, services.NormalizeAlamat(cand.Alamat))
+		if skorNama >= ambangKemiripanNama && skorAlamat >= ambangKemiripanAlamat {
+			return fiber.StatusConflict, fiber.Map{
+				"error":    true,
+				"message":  "Possible duplicate warga (same name and address). Resend with ?force=true if this is a different person",
+				"existing": wargaRingkas(cand),
+				"similarity": fiber.Map{
+					"nama":   skorNama,
+					"alamat": skorAlamat,
+				},
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// applyKerentananWarga validates the vulnerability categories and recalculates the
+// priority score from all categories and care needs. A nil body means the data is valid.
+func applyKerentananWarga(warga *models.WargaRentan, req models.CreateWargaRequest) (int, fiber.Map) {
+	daftar := req.Kategori
+	if len(daftar) == 0 && req.KategoriRentan != "" {
+		daftar = []string{req.KategoriRentan}
+	}
+	if len(daftar) == 0 {
+		return fiber.StatusBadRequest, fiber.Map{
+			"error":   true,
+			"message": "At least one kategori rentan is required",
+			"field":   "kategori",
+		}
+	}
+
+	seen := make(map[string]bool)
+	kategori := make([]string, 0, len(daftar))
+	for _, k := range daftar {
+		if !services.IsKategoriValid(k) {
+			return fiber.StatusBadRequest, fiber.Map{
+				"error":   true,
+				"message": "Unknown kategori rentan: " + k,
+				"field":   "kategori",
+			}
+		}
+		// "Non-Rentan" only makes sense on its own
+		if seen[k] || (k == "Non-Rentan" && len(daftar) > 1) {
+			continue
+		}
+		seen[k] = true
+		kategori = append(kategori, k)
+	}
+
+	warga.Kategori = make([]models.KategoriWarga, 0, len(kategori))
+	for _, k := range kategori {
+		warga.Kategori = append(warga.Kategori, models.KategoriWarga{Kategori: k})
+	}
+	warga.KategoriRentan = services.KategoriUtama(kategori)
+	warga.KebutuhanPerawatan = req.KebutuhanPerawatan
+	if warga.KebutuhanPerawatan != nil {
+		warga.KebutuhanPerawatan.Perlengkapan = warga.KebutuhanPerawatan.DaftarPerlengkapan()
+	}
+	warga.SkorPrioritas = services.HitungSkorPrioritas(kategori, req.KebutuhanPerawatan)
+	return 0, nil
+}
+
+// applyKeluargaWarga links the warga to a household and copies the household location.
+// A nil body means the link is valid (or no household was given).
+func applyKeluargaWarga(warga *models.WargaRentan, kartuKeluargaID *uint) (int, fiber.Map) {
+	warga.KartuKeluargaID = nil
+	if kartuKeluargaID == nil {
+		return 0, nil
+	}
+
+	var keluarga models.KartuKeluarga
+	if err := database.DB.First(&keluarga, *kartuKeluargaID).Error; err != nil {
+		return fiber.StatusBadRequest, fiber.Map{
+			"error":   true,
+			"message": "Keluarga not found",
+			"field":   "kartu_keluarga_id",
+		}
+	}
+
+	warga.KartuKeluargaID = &keluarga.ID
+	warga.Alamat = keluarga.Alamat
+	warga.RT = keluarga.RT
+	warga.RW = keluarga.RW
+	warga.Latitude = keluarga.Latitude
+	warga.Longitude = keluarga.Longitude
+	return 0, nil
+}
 
-	if score, exists := scores[kategori]; exists {
-		return score
+// wargaRingkas returns the fields needed to identify an existing record in error responses
+func wargaRingkas(w models.WargaRentan) fiber.Map {
+	return fiber.Map{
+		"id":      w.ID,
+		"nama":    w.Nama,
+		"alamat":  w.Alamat,
+		"rt":      w.RT,
+		"rw":      w.RW,
+		"deleted": w.DeletedAt.Valid,
 	}
-	return 50
 }
 
 // Helper function to update rekap wilayah (async)
@@ -0,0 +1,728 @@
+// handlers/zona_bahaya.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/database"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/messaging"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/services"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Zona bahaya (peta rawan banjir, longsor, dst.) diunggah sebagai GeoJSON.
+// Paparan tiap warga (zona mana saja yang memuat rumahnya) dihitung sekali
+// saat zona atau lokasi warga berubah, lalu dipakai untuk mengurutkan
+// prioritas evakuasi dan mengirim notifikasi lebih awal.
+
+// zonaBahayaListSpec defines sorting and search for hazard zones
+var zonaBahayaListSpec = listSpec{
+	Sortable: map[string]string{
+		"id":           "id",
+		"nama":         "nama",
+		"jenis_bahaya": "jenis_bahaya",
+		"kelas_risiko": "kelas_risiko",
+		"jumlah_warga": "jumlah_warga",
+		"created_at":   "created_at",
+	},
+	DefaultSort: "jenis_bahaya",
+	Search:      []string{"nama LIKE ?", "sumber LIKE ?"},
+}
+
+// GetAllZonaBahaya returns hazard zones, paginated (see list_query.go).
+// Query: jenis_bahaya, kelas_risiko, format=geojson (FeatureCollection tanpa paginasi, untuk peta)
+func GetAllZonaBahaya(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.ZonaBahaya{})
+
+	if jenis := c.Query("jenis_bahaya"); jenis != "" {
+		query = query.Where("jenis_bahaya = ?", jenis)
+	}
+	if kelas := c.Query("kelas_risiko"); kelas != "" {
+		query = query.Where("kelas_risiko = ?", kelas)
+	}
+
+	if c.Query("format") != "geojson" {
+		return listPage[models.ZonaBahaya](c, query, zonaBahayaListSpec, "Failed to fetch zona bahaya")
+	}
+
+	var zona []models.ZonaBahaya
+	if err := query.Order("jenis_bahaya, id").Find(&zona).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch zona bahaya",
+		})
+	}
+
+	features := make([]fiber.Map, 0, len(zona))
+	for _, z := range zona {
+		geometri, err := geometriZona(z.Geometri)
+		if err != nil {
+			continue
+		}
+		features = append(features, fiber.Map{
+			"type":     "Feature",
+			"id":       z.ID,
+			"geometry": geometri,
+			"properties": fiber.Map{
+				"nama":         z.Nama,
+				"jenis_bahaya": z.JenisBahaya,
+				"kelas_risiko": z.KelasRisiko,
+				"sumber":       z.Sumber,
+				"jumlah_warga": z.JumlahWarga,
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
+
+// GetZonaBahayaByID returns one hazard zone
+func GetZonaBahayaByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var zona models.ZonaBahaya
+	if err := database.DB.First(&zona, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Zona bahaya not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  zona,
+	})
+}
+
+// GetWargaZonaBahaya returns the warga living inside a hazard zone, paginated (see list_query.go)
+func GetWargaZonaBahaya(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	terpapar := database.DB.Model(&models.PaparanWarga{}).Select("warga_id").Where("zona_id = ?", id)
+	query := database.DB.Model(&models.WargaRentan{}).Where("id IN (?)", terpapar)
+
+	return listPage[models.WargaRentan](c, query, wargaListSpec, "Failed to fetch warga data", "Kategori", "KebutuhanPerawatan")
+}
+
+// CreateZonaBahaya stores one hazard zone and computes the exposure of warga inside it
+func CreateZonaBahaya(c *fiber.Ctx) error {
+	var req models.CreateZonaBahayaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	zona := models.ZonaBahaya{
+		Nama:        strings.TrimSpace(req.Nama),
+		JenisBahaya: req.JenisBahaya,
+		KelasRisiko: req.KelasRisiko,
+		Geometri:    req.Geometri,
+		Sumber:      req.Sumber,
+		Keterangan:  req.Keterangan,
+		DibuatOleh:  c.Locals("userID").(uint),
+	}
+	poligon, err := siapkanZona(&zona)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&zona).Error; err != nil {
+			return err
+		}
+		return hitungPaparanZona(tx, &zona, poligon)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create zona bahaya",
+		})
+	}
+
+	jadwalkanRekapPaparan()
+	logActivity(zona.DibuatOleh, fmt.Sprintf("Menambahkan zona bahaya %s (%s, %s)", zona.Nama, zona.JenisBahaya, zona.KelasRisiko))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Zona bahaya created successfully",
+		"data":    zona,
+	})
+}
+
+// fiturZona is one Feature of an uploaded hazard map
+type fiturZona struct {
+	Geometry   json.RawMessage `json:"geometry"`
+	Properties struct {
+		Nama        string `json:"nama"`
+		JenisBahaya string `json:"jenis_bahaya"`
+		KelasRisiko string `json:"kelas_risiko"`
+		Sumber      string `json:"sumber"`
+		Keterangan  string `json:"keterangan"`
+	} `json:"properties"`
+}
+
+// ImporZonaBahaya stores every Feature of a GeoJSON FeatureCollection as a hazard zone.
+// Properties: nama, jenis_bahaya, kelas_risiko, sumber, keterangan.
+// Query jenis_bahaya, kelas_risiko, sumber mengisi properti yang kosong;
+// ganti=true menghapus dulu zona lama dengan jenis bahaya yang sama.
+func ImporZonaBahaya(c *fiber.Ctx) error {
+	var koleksi struct {
+		Type     string      `json:"type"`
+		Features []fiturZona `json:"features"`
+	}
+	if err := json.Unmarshal(c.Body(), &koleksi); err != nil || koleksi.Type != "FeatureCollection" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Body must be a GeoJSON FeatureCollection",
+		})
+	}
+	if len(koleksi.Features) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "FeatureCollection has no features",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+	zona := make([]models.ZonaBahaya, len(koleksi.Features))
+	poligon := make([][]services.Poligon, len(koleksi.Features))
+	var kesalahan []fiber.Map
+	jenisDiimpor := make(map[string]bool)
+
+	for i, f := range koleksi.Features {
+		p := f.Properties
+		zona[i] = models.ZonaBahaya{
+			Nama:        strings.TrimSpace(p.Nama),
+			JenisBahaya: pilihTeks(p.JenisBahaya, c.Query("jenis_bahaya")),
+			KelasRisiko: pilihTeks(p.KelasRisiko, c.Query("kelas_risiko")),
+			Geometri:    f.Geometry,
+			Sumber:      pilihTeks(p.Sumber, c.Query("sumber")),
+			Keterangan:  p.Keterangan,
+			DibuatOleh:  userID,
+		}
+		if zona[i].Nama == "" {
+			zona[i].Nama = fmt.Sprintf("%s %s #%d", zona[i].JenisBahaya, zona[i].KelasRisiko, i+1)
+		}
+
+		var err error
+		if poligon[i], err = siapkanZona(&zona[i]); err != nil {
+			kesalahan = append(kesalahan, fiber.Map{"index": i, "message": err.Error()})
+			continue
+		}
+		jenisDiimpor[zona[i].JenisBahaya] = true
+	}
+	if len(kesalahan) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   true,
+			"message": "Some features are invalid, nothing was imported",
+			"errors":  kesalahan,
+		})
+	}
+
+	var diganti int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if c.QueryBool("ganti") {
+			var lama []uint
+			jenis := make([]string, 0, len(jenisDiimpor))
+			for j := range jenisDiimpor {
+				jenis = append(jenis, j)
+			}
+			if err := tx.Model(&models.ZonaBahaya{}).Where("jenis_bahaya IN ?", jenis).Pluck("id", &lama).Error; err != nil {
+				return err
+			}
+			if len(lama) > 0 {
+				if err := tx.Where("zona_id IN ?", lama).Delete(&models.PaparanWarga{}).Error; err != nil {
+					return err
+				}
+				result := tx.Delete(&models.ZonaBahaya{}, lama)
+				if result.Error != nil {
+					return result.Error
+				}
+				diganti = result.RowsAffected
+			}
+		}
+
+		for i := range zona {
+			if err := tx.Create(&zona[i]).Error; err != nil {
+				return err
+			}
+			if err := hitungPaparanZona(tx, &zona[i], poligon[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to import zona bahaya",
+		})
+	}
+
+	jadwalkanRekapPaparan()
+	logActivity(userID, fmt.Sprintf("Mengimpor %d zona bahaya (menggantikan %d)", len(zona), diganti))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Zona bahaya imported successfully",
+		"data": fiber.Map{
+			"jumlah":  len(zona),
+			"diganti": diganti,
+			"zona":    zona,
+			"paparan": rekapPaparan(),
+		},
+	})
+}
+
+// UpdateZonaBahaya updates a hazard zone; exposure dihitung ulang bila geometri atau kelas berubah
+func UpdateZonaBahaya(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var req models.UpdateZonaBahayaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	var zona models.ZonaBahaya
+	if err := database.DB.First(&zona, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Zona bahaya not found",
+		})
+	}
+
+	if nama := strings.TrimSpace(req.Nama); nama != "" {
+		zona.Nama = nama
+	}
+	if req.JenisBahaya != "" {
+		zona.JenisBahaya = req.JenisBahaya
+	}
+	if req.KelasRisiko != "" {
+		zona.KelasRisiko = req.KelasRisiko
+	}
+	if len(req.Geometri) > 0 && string(req.Geometri) != "null" {
+		zona.Geometri = req.Geometri
+	}
+	if req.Sumber != "" {
+		zona.Sumber = req.Sumber
+	}
+	if req.Keterangan != "" {
+		zona.Keterangan = req.Keterangan
+	}
+
+	poligon, err := siapkanZona(&zona)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&zona).Error; err != nil {
+			return err
+		}
+		return hitungPaparanZona(tx, &zona, poligon)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update zona bahaya",
+		})
+	}
+
+	jadwalkanRekapPaparan()
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Mengupdate zona bahaya: "+zona.Nama)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Zona bahaya updated successfully",
+		"data":    zona,
+	})
+}
+
+// DeleteZonaBahaya soft deletes a hazard zone and removes its exposure records
+func DeleteZonaBahaya(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid ID",
+		})
+	}
+
+	var zona models.ZonaBahaya
+	if err := database.DB.First(&zona, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Zona bahaya not found",
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("zona_id = ?", zona.ID).Delete(&models.PaparanWarga{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&zona).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete zona bahaya",
+		})
+	}
+
+	jadwalkanRekapPaparan()
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, "Menghapus zona bahaya: "+zona.Nama)
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Zona bahaya deleted successfully",
+	})
+}
+
+// HitungUlangPaparan recomputes the exposure of every warga against every zone
+func HitungUlangPaparan(c *fiber.Ctx) error {
+	var zona []models.ZonaBahaya
+	if err := database.DB.Find(&zona).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch zona bahaya",
+		})
+	}
+
+	var gagal []fiber.Map
+	for i := range zona {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			poligon, err := siapkanZona(&zona[i])
+			if err != nil {
+				return err
+			}
+			return hitungPaparanZona(tx, &zona[i], poligon)
+		})
+		if err != nil {
+			gagal = append(gagal, fiber.Map{"zona_id": zona[i].ID, "message": err.Error()})
+		}
+	}
+	// Paparan milik zona yang sudah dihapus tidak ikut dihitung lagi
+	database.DB.Where("zona_id NOT IN (?)", database.DB.Model(&models.ZonaBahaya{}).Select("id")).
+		Delete(&models.PaparanWarga{})
+
+	publikasiRekapPaparan()
+	userID := c.Locals("userID").(uint)
+	logActivity(userID, fmt.Sprintf("Menghitung ulang paparan %d zona bahaya", len(zona)))
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Paparan recomputed",
+		"data": fiber.Map{
+			"jumlah_zona": len(zona),
+			"gagal":       gagal,
+			"rekap":       rekapPaparan(),
+		},
+	})
+}
+
+// GetRekapPaparan returns how many warga live in each hazard type and risk class
+func GetRekapPaparan(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  rekapPaparan(),
+	})
+}
+
+// siapkanZona validates a zone and fills its bounding box; returns the parsed polygons
+func siapkanZona(zona *models.ZonaBahaya) ([]services.Poligon, error) {
+	if zona.Nama == "" {
+		return nil, fmt.Errorf("nama is required")
+	}
+	if !services.JenisBahayaValid(zona.JenisBahaya) {
+		return nil, fmt.Errorf("jenis_bahaya must be one of: %s", strings.Join(services.DaftarJenisBahaya, ", "))
+	}
+	if !services.KelasRisikoValid(zona.KelasRisiko) {
+		return nil, fmt.Errorf("kelas_risiko must be Rendah, Sedang or Tinggi")
+	}
+	if len(zona.Geometri) == 0 {
+		return nil, fmt.Errorf("geometri is required")
+	}
+
+	poligon, err := services.ParseGeoJSONPoligon(zona.Geometri)
+	if err != nil {
+		return nil, err
+	}
+	zona.MinLat, zona.MaxLat, zona.MinLng, zona.MaxLng = services.KotakBatas(poligon)
+	return poligon, nil
+}
+
+// geometriZona returns the geometry object of a stored zone (Feature dibuka menjadi geometrinya)
+func geometriZona(raw json.RawMessage) (json.RawMessage, error) {
+	var g struct {
+		Type     string          `json:"type"`
+		Geometry json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return nil, err
+	}
+	if g.Type == "Feature" {
+		return g.Geometry, nil
+	}
+	return raw, nil
+}
+
+// hitungPaparanZona rebuilds the exposure records of one zone
+func hitungPaparanZona(tx *gorm.DB, zona *models.ZonaBahaya, poligon []services.Poligon) error {
+	if err := tx.Where("zona_id = ?", zona.ID).Delete(&models.PaparanWarga{}).Error; err != nil {
+		return err
+	}
+
+	var warga []models.WargaRentan
+	if err := tx.Select("id", "latitude", "longitude").
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", zona.MinLat, zona.MaxLat, zona.MinLng, zona.MaxLng).
+		Find(&warga).Error; err != nil {
+		return err
+	}
+
+	var paparan []models.PaparanWarga
+	for _, w := range warga {
+		if !services.AdaKoordinat(w.Latitude, w.Longitude) {
+			continue
+		}
+		if services.DalamSalahSatuPoligon(poligon, services.Titik{Lat: w.Latitude, Lng: w.Longitude}) {
+			paparan = append(paparan, models.PaparanWarga{
+				WargaID:     w.ID,
+				ZonaID:      zona.ID,
+				JenisBahaya: zona.JenisBahaya,
+				KelasRisiko: zona.KelasRisiko,
+			})
+		}
+	}
+	if len(paparan) > 0 {
+		if err := tx.CreateInBatches(&paparan, 500).Error; err != nil {
+			return err
+		}
+	}
+
+	zona.JumlahWarga = len(paparan)
+	return tx.Model(zona).UpdateColumn("jumlah_warga", len(paparan)).Error
+}
+
+// perbaruiPaparanWarga recomputes which zones contain one warga (dipanggil setiap
+// kali data warga berubah, di dalam transaksi yang sama)
+func perbaruiPaparanWarga(tx *gorm.DB, warga models.WargaRentan, dihapus bool) error {
+	var zonaLama []uint
+	if err := tx.Model(&models.PaparanWarga{}).Where("warga_id = ?", warga.ID).Pluck("zona_id", &zonaLama).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("warga_id = ?", warga.ID).Delete(&models.PaparanWarga{}).Error; err != nil {
+		return err
+	}
+
+	zonaBerubah := zonaLama
+	if !dihapus && services.AdaKoordinat(warga.Latitude, warga.Longitude) {
+		var kandidat []models.ZonaBahaya
+		if err := tx.Where("min_lat <= ? AND max_lat >= ? AND min_lng <= ? AND max_lng >= ?",
+			warga.Latitude, warga.Latitude, warga.Longitude, warga.Longitude).
+			Find(&kandidat).Error; err != nil {
+			return err
+		}
+
+		titik := services.Titik{Lat: warga.Latitude, Lng: warga.Longitude}
+		for _, z := range kandidat {
+			poligon, err := services.ParseGeoJSONPoligon(z.Geometri)
+			if err != nil || !services.DalamSalahSatuPoligon(poligon, titik) {
+				continue
+			}
+			if err := tx.Create(&models.PaparanWarga{
+				WargaID:     warga.ID,
+				ZonaID:      z.ID,
+				JenisBahaya: z.JenisBahaya,
+				KelasRisiko: z.KelasRisiko,
+			}).Error; err != nil {
+				return err
+			}
+			zonaBerubah = append(zonaBerubah, z.ID)
+		}
+	}
+
+	if len(zonaBerubah) == 0 {
+		return nil
+	}
+	jumlah := tx.Model(&models.PaparanWarga{}).Select("COUNT(*)").Where("paparan_wargas.zona_id = zona_bahayas.id")
+	return tx.Model(&models.ZonaBahaya{}).Where("id IN ?", zonaBerubah).
+		UpdateColumn("jumlah_warga", jumlah).Error
+}
+
+// rekapPaparan counts exposed warga per hazard type and risk class. Warga yang masuk
+// beberapa zona dengan jenis sama dihitung sekali pada kelas risiko tertingginya.
+func rekapPaparan() []models.RekapPaparanItem {
+	var baris []struct {
+		WargaID        uint
+		JenisBahaya    string
+		KelasRisiko    string
+		KategoriRentan string
+	}
+	database.DB.Table("paparan_wargas").
+		Select("paparan_wargas.warga_id, paparan_wargas.jenis_bahaya, paparan_wargas.kelas_risiko, warga_rentans.kategori_rentan").
+		Joins("JOIN warga_rentans ON warga_rentans.id = paparan_wargas.warga_id AND warga_rentans.deleted_at IS NULL").
+		Scan(&baris)
+
+	type kunciWarga struct {
+		wargaID uint
+		jenis   string
+	}
+	tertinggi := make(map[kunciWarga]string)
+	rentan := make(map[uint]bool)
+	for _, b := range baris {
+		k := kunciWarga{b.WargaID, b.JenisBahaya}
+		if services.PeringkatRisiko(b.KelasRisiko) > services.PeringkatRisiko(tertinggi[k]) {
+			tertinggi[k] = b.KelasRisiko
+		}
+		rentan[b.WargaID] = b.KategoriRentan != "Non-Rentan"
+	}
+
+	perKelas := make(map[[2]string]*models.RekapPaparanItem)
+	for k, kelas := range tertinggi {
+		item, ok := perKelas[[2]string{k.jenis, kelas}]
+		if !ok {
+			item = &models.RekapPaparanItem{JenisBahaya: k.jenis, KelasRisiko: kelas}
+			perKelas[[2]string{k.jenis, kelas}] = item
+		}
+		item.JumlahWarga++
+		if rentan[k.wargaID] {
+			item.JumlahRentan++
+		}
+	}
+
+	rekap := make([]models.RekapPaparanItem, 0, len(perKelas))
+	for _, item := range perKelas {
+		rekap = append(rekap, *item)
+	}
+	sort.Slice(rekap, func(i, j int) bool {
+		if rekap[i].JenisBahaya != rekap[j].JenisBahaya {
+			return rekap[i].JenisBahaya < rekap[j].JenisBahaya
+		}
+		return services.PeringkatRisiko(rekap[i].KelasRisiko) > services.PeringkatRisiko(rekap[j].KelasRisiko)
+	})
+	return rekap
+}
+
+var (
+	rekapPaparanMu    sync.Mutex
+	rekapPaparanTimer *time.Timer
+)
+
+// jadwalkanRekapPaparan sends the exposure recap to Kota a few seconds after the
+// last change, sehingga impor massal warga tidak mengirim ratusan event
+func jadwalkanRekapPaparan() {
+	rekapPaparanMu.Lock()
+	defer rekapPaparanMu.Unlock()
+
+	jeda := time.Duration(envInt("JEDA_REKAP_PAPARAN_DETIK", 10)) * time.Second
+	if rekapPaparanTimer != nil {
+		rekapPaparanTimer.Stop()
+	}
+	rekapPaparanTimer = time.AfterFunc(jeda, publikasiRekapPaparan)
+}
+
+// publikasiRekapPaparan publishes the full exposure recap of this kecamatan to Kota
+func publikasiRekapPaparan() {
+	messaging.PublishEvent("REKAP_PAPARAN", kecamatanID(), models.RekapPaparanEvent{
+		Rekap: rekapPaparan(),
+		Waktu: time.Now(),
+	})
+	log.Println("🗺️ Rekap paparan zona bahaya dikirim ke Kota")
+}
+
+// preloadPaparan loads each warga's exposure to the hazard types of a disaster
+func preloadPaparan(query *gorm.DB, jenisBencana string) *gorm.DB {
+	return query.Preload("Paparan", "jenis_bahaya IN ?", jenisBahayaUntuk(jenisBencana))
+}
+
+// filterTerpapar keeps only warga inside a zone of the hazard types of a disaster
+func filterTerpapar(query *gorm.DB, jenisBencana string) *gorm.DB {
+	terpapar := database.DB.Model(&models.PaparanWarga{}).
+		Select("warga_id").
+		Where("jenis_bahaya IN ?", jenisBahayaUntuk(jenisBencana))
+	return query.Where("id IN (?)", terpapar)
+}
+
+// peringkatPaparan returns the highest risk rank among the preloaded exposures of a warga
+func peringkatPaparan(w models.WargaRentan) int {
+	peringkat := 0
+	for _, p := range w.Paparan {
+		if r := services.PeringkatRisiko(p.KelasRisiko); r > peringkat {
+			peringkat = r
+		}
+	}
+	return peringkat
+}
+
+// urutkanMenurutPaparan moves warga in higher risk zones to the front; urutan skor
+// prioritas di dalam kelas yang sama dipertahankan
+func urutkanMenurutPaparan(warga []models.WargaRentan) {
+	sort.SliceStable(warga, func(i, j int) bool {
+		return peringkatPaparan(warga[i]) > peringkatPaparan(warga[j])
+	})
+}
+
+// nomorWargaTerpapar returns phone numbers of warga inside zones of the given hazard types
+func nomorWargaTerpapar(jenisBahaya []string) []string {
+	terpapar := database.DB.Model(&models.PaparanWarga{}).
+		Select("warga_id").
+		Where("jenis_bahaya IN ?", jenisBahaya)
+
+	var nomor []string
+	database.DB.Model(&models.WargaRentan{}).
+		Where("id IN (?)", terpapar).
+		Where("no_hp IS NOT NULL AND no_hp != ''").
+		Pluck("no_hp", &nomor)
+	return nomor
+}
+
+// pilihTeks returns nilai, or cadangan when nilai is empty
+func pilihTeks(nilai, cadangan string) string {
+	if s := strings.TrimSpace(nilai); s != "" {
+		return s
+	}
+	return cadangan
+}
@@ -0,0 +1,79 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Topic event Kota -> kecamatan. Setiap kecamatan membaca dengan group sendiri
+// sehingga semua kecamatan menerima semua pesan dan menyaring berdasarkan kecamatan_id.
+const TopicKota = "kota-events"
+
+// Event adalah pesan yang diterima kecamatan dari Kota
+type Event struct {
+	Action      string          `json:"action"`
+	KecamatanID uint            `json:"kecamatan_id"` // 0 = untuk semua kecamatan
+	Timestamp   time.Time       `json:"timestamp"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// StartKecamatanConsumer membaca topic Kota dan memanggil handle untuk setiap event
+// yang ditujukan ke kecamatan ini. Offset baru di-commit setelah handle berhasil, sehingga
+// event yang gagal diproses (mis. DB mati) dicoba lagi, bukan hilang. Pesan yang tidak bisa
+// di-decode langsung di-commit karena tidak akan pernah berhasil. Dijalankan sebagai goroutine.
+func StartKecamatanConsumer(brokerUrl string, topic string, kecamatanID uint, handle func(Event) error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  []string{brokerUrl},
+		Topic:    topic,
+		GroupID:  fmt.Sprintf("kecamatan-%d-group", kecamatanID),
+		MinBytes: 1,
+		MaxBytes: 10e6, // 10MB
+	})
+	defer reader.Close()
+
+	log.Printf("✅ Kafka Consumer Kecamatan %d siap di topic: %s", kecamatanID, topic)
+
+	ctx := context.Background()
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			log.Printf("❌ Error baca event Kota: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(m.Value, &event); err != nil {
+			log.Printf("❌ Event Kota tidak valid: %v", err)
+		} else if event.KecamatanID == 0 || event.KecamatanID == kecamatanID {
+			log.Printf("📥 Event Kota Masuk: %s", event.Action)
+			prosesSampaiBerhasil(event, handle)
+		}
+
+		if err := reader.CommitMessages(ctx, m); err != nil {
+			log.Printf("❌ Gagal commit offset event Kota: %v", err)
+		}
+	}
+}
+
+// prosesSampaiBerhasil mengulang handle dengan jeda yang makin panjang sampai berhasil.
+// Event berikutnya di partisi yang sama menunggu, sehingga urutan event tetap terjaga.
+func prosesSampaiBerhasil(event Event, handle func(Event) error) {
+	jeda := 5 * time.Second
+	for {
+		err := handle(event)
+		if err == nil {
+			return
+		}
+		log.Printf("❌ Gagal memproses event Kota %s, dicoba lagi dalam %s: %v", event.Action, jeda, err)
+		time.Sleep(jeda)
+		if jeda < time.Minute {
+			jeda *= 2
+		}
+	}
+}
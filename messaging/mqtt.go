@@ -0,0 +1,65 @@
+package messaging
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// MQTTConfig adalah pengaturan koneksi ke broker MQTT
+type MQTTConfig struct {
+	Broker    string // host:port (mis. localhost:1883) atau URL tcp://, ssl://, ws://
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration
+}
+
+// StartMQTTSubscriber subscribes to topic (boleh memakai wildcard + / #) with QoS 1 and calls
+// handle for every message. Klien menyambung ulang sendiri dan subscribe lagi setiap kali
+// tersambung; fungsi ini tidak pernah kembali sehingga dijalankan sebagai goroutine.
+func StartMQTTSubscriber(cfg MQTTConfig, topic string, handle func(topic string, payload []byte)) {
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = 60 * time.Second
+	}
+	broker := cfg.Broker
+	if !strings.Contains(broker, "://") {
+		broker = "tcp://" + broker
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetKeepAlive(cfg.KeepAlive).
+		SetCleanSession(false). // broker menyimpan pesan QoS 1 selama kita terputus
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetMaxReconnectInterval(time.Minute).
+		SetOrderMatters(false)
+
+	opts.SetOnConnectHandler(func(c mqtt.Client) {
+		token := c.Subscribe(topic, 1, func(_ mqtt.Client, m mqtt.Message) {
+			handle(m.Topic(), m.Payload())
+		})
+		if token.Wait() && token.Error() != nil {
+			log.Printf("❌ Subscribe MQTT %s gagal: %v", topic, token.Error())
+			return
+		}
+		log.Printf("✅ MQTT subscriber siap di %s, topic: %s", cfg.Broker, topic)
+	})
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		log.Printf("❌ Koneksi MQTT %s terputus: %v", cfg.Broker, err)
+	})
+
+	client := mqtt.NewClient(opts)
+	// Dengan ConnectRetry token baru selesai setelah koneksi pertama berhasil
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		log.Printf("❌ Koneksi MQTT %s gagal: %v", cfg.Broker, token.Error())
+	}
+	select {}
+}
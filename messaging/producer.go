@@ -12,6 +12,9 @@ import (
 // Writer adalah koneksi kita ke Kafka
 var writer *kafka.Writer
 
+// downstreamWriter mengirim event dari Kota ke kecamatan (topic TopicKota)
+var downstreamWriter *kafka.Writer
+
 // InitKafkaProducer membuka koneksi ke Kafka
 func InitKafkaProducer(brokerUrl string, topic string) {
 	writer = &kafka.Writer{
@@ -22,8 +25,28 @@ func InitKafkaProducer(brokerUrl string, topic string) {
 	log.Println("✅ Kafka Producer siap di topic:", topic)
 }
 
+// InitKafkaDownstream membuka koneksi untuk mengirim event Kota -> kecamatan
+func InitKafkaDownstream(brokerUrl string, topic string) {
+	downstreamWriter = &kafka.Writer{
+		Addr:     kafka.TCP(brokerUrl),
+		Topic:    topic,
+		Balancer: &kafka.LeastBytes{},
+	}
+	log.Println("✅ Kafka Producer downstream siap di topic:", topic)
+}
+
 // PublishEvent mengirim data apa saja ke Kafka
 func PublishEvent(action string, kecamatanID uint, data interface{}) {
+	kirimEvent(writer, action, kecamatanID, data)
+}
+
+// PublishToKecamatan mengirim event dari Kota ke satu kecamatan
+// (kecamatanID 0 berarti semua kecamatan)
+func PublishToKecamatan(action string, kecamatanID uint, data interface{}) {
+	kirimEvent(downstreamWriter, action, kecamatanID, data)
+}
+
+func kirimEvent(writer *kafka.Writer, action string, kecamatanID uint, data interface{}) {
 	// Struktur pesan yang akan dikirim
 	message := map[string]interface{}{
 		"action":       action, // Contoh: "CREATE_BENCANA"
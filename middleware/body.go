@@ -0,0 +1,51 @@
+// middleware/body.go
+package middleware
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects request bodies larger than maks bytes with 413. App berjalan dengan
+// StreamRequestBody sehingga body dibaca di sini (bukan oleh server) dan rute unggahan
+// bisa diberi batas sendiri; lewati (boleh nil) melompati rute tersebut pada batas global.
+func BodyLimit(maks int, lewati func(*fiber.Ctx) bool) fiber.Handler {
+	tolak := func(c *fiber.Ctx) error {
+		// Sisa body tidak dibaca, jadi koneksi tidak bisa dipakai untuk request berikutnya
+		c.Context().SetConnectionClose()
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Request body is larger than %d MB", maks>>20),
+		})
+	}
+
+	return func(c *fiber.Ctx) error {
+		if lewati != nil && lewati(c) {
+			return c.Next()
+		}
+
+		req := c.Request()
+		if req.Header.ContentLength() > maks {
+			return tolak(c)
+		}
+		if !req.IsBodyStream() {
+			return c.Next()
+		}
+
+		// Body chunked atau belum terbaca: baca paling banyak maks+1 byte
+		data, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(maks)+1))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to read request body",
+			})
+		}
+		if len(data) > maks {
+			return tolak(c)
+		}
+		req.SetBody(data)
+		return c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+// middleware/ratelimit.go
+package middleware
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// RateLimit limits public endpoints per client IP. Batas per jendela dibaca dari
+// variabel lingkungan envKey (default def); 0 atau negatif memakai default.
+func RateLimit(envKey string, def int, jendela time.Duration) fiber.Handler {
+	maks := def
+	if n, err := strconv.Atoi(os.Getenv(envKey)); err == nil && n > 0 {
+		maks = n
+	}
+
+	return limiter.New(limiter.Config{
+		Max:               maks,
+		Expiration:        jendela,
+		LimiterMiddleware: limiter.SlidingWindow{},
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":   true,
+				"message": "Too many requests, please try again later",
+			})
+		},
+	})
+}
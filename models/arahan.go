@@ -0,0 +1,97 @@
+// models/arahan.go
+package models
+
+import "time"
+
+// ArahanKota model (status siaga, arahan, atau alokasi sumber daya dari BPBD ke kecamatan)
+type ArahanKota struct {
+	ID              uint                   `gorm:"primarykey" json:"id"`
+	Jenis           string                 `gorm:"type:enum('Tingkat Siaga','Arahan','Alokasi Sumber Daya');not null;index" json:"jenis"`
+	Judul           string                 `gorm:"not null" json:"judul"`
+	Isi             string                 `gorm:"type:text" json:"isi"`
+	TingkatSiaga    string                 `gorm:"size:10" json:"tingkat_siaga"` // Normal, Waspada, Siaga, Awas
+	JenisBencana    string                 `json:"jenis_bencana"`
+	SumberDaya      string                 `json:"sumber_daya"` // Untuk alokasi, mis. "Perahu karet"
+	Jumlah          float64                `gorm:"type:decimal(12,2)" json:"jumlah"`
+	Satuan          string                 `json:"satuan"`
+	SebarkanKeWarga bool                   `gorm:"default:false" json:"sebarkan_ke_warga"`
+	BerlakuSampai   *time.Time             `json:"berlaku_sampai"`
+	DikirimOleh     uint                   `gorm:"not null" json:"dikirim_oleh"`
+	Target          []MasterKecamatan      `gorm:"many2many:arahan_kota_target" json:"target"` // Kosong = semua kecamatan
+	Penerimaan      []PenerimaanArahanKota `gorm:"foreignKey:ArahanKotaID" json:"penerimaan,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+}
+
+// PenerimaanArahanKota model (tanda terima arahan dari setiap kecamatan, diisi Sync Worker)
+type PenerimaanArahanKota struct {
+	ID                uint            `gorm:"primarykey" json:"id"`
+	ArahanKotaID      uint            `gorm:"not null;uniqueIndex:idx_arahan_kecamatan" json:"arahan_kota_id"`
+	KecamatanID       uint            `gorm:"not null;uniqueIndex:idx_arahan_kecamatan" json:"kecamatan_id"`
+	Kecamatan         MasterKecamatan `gorm:"foreignKey:KecamatanID" json:"kecamatan,omitempty"`
+	WaktuDiterima     *time.Time      `json:"waktu_diterima"`
+	WaktuDikonfirmasi *time.Time      `json:"waktu_dikonfirmasi"`
+	DikonfirmasiOleh  string          `json:"dikonfirmasi_oleh"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// ArahanKecamatan model (salinan lokal arahan Kota yang diterima kecamatan ini)
+type ArahanKecamatan struct {
+	ID                uint       `gorm:"primarykey" json:"id"`
+	ArahanKotaID      uint       `gorm:"not null;uniqueIndex" json:"arahan_kota_id"`
+	Jenis             string     `gorm:"not null;index" json:"jenis"`
+	Judul             string     `gorm:"not null" json:"judul"`
+	Isi               string     `gorm:"type:text" json:"isi"`
+	TingkatSiaga      string     `gorm:"size:10" json:"tingkat_siaga"`
+	JenisBencana      string     `json:"jenis_bencana"`
+	SumberDaya        string     `json:"sumber_daya"`
+	Jumlah            float64    `gorm:"type:decimal(12,2)" json:"jumlah"`
+	Satuan            string     `json:"satuan"`
+	SebarkanKeWarga   bool       `json:"sebarkan_ke_warga"`
+	BerlakuSampai     *time.Time `json:"berlaku_sampai"`
+	WaktuDikirim      time.Time  `json:"waktu_dikirim"`
+	WaktuDiterima     time.Time  `json:"waktu_diterima"`
+	DikonfirmasiOleh  *uint      `json:"dikonfirmasi_oleh"`
+	WaktuDikonfirmasi *time.Time `json:"waktu_dikonfirmasi"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// DTO for sending a directive from Kota
+type CreateArahanKotaRequest struct {
+	Jenis           string     `json:"jenis" validate:"required"`
+	Judul           string     `json:"judul" validate:"required"`
+	Isi             string     `json:"isi"`
+	TingkatSiaga    string     `json:"tingkat_siaga"`
+	JenisBencana    string     `json:"jenis_bencana"`
+	SumberDaya      string     `json:"sumber_daya"`
+	Jumlah          float64    `json:"jumlah"`
+	Satuan          string     `json:"satuan"`
+	SebarkanKeWarga bool       `json:"sebarkan_ke_warga"`
+	BerlakuSampai   *time.Time `json:"berlaku_sampai"`
+	KecamatanIDs    []uint     `json:"kecamatan_ids"` // Kosong = semua kecamatan
+}
+
+// ArahanKotaEvent is the payload sent from Kota to kecamatan
+type ArahanKotaEvent struct {
+	ArahanKotaID    uint       `json:"arahan_kota_id"`
+	Jenis           string     `json:"jenis"`
+	Judul           string     `json:"judul"`
+	Isi             string     `json:"isi"`
+	TingkatSiaga    string     `json:"tingkat_siaga"`
+	JenisBencana    string     `json:"jenis_bencana"`
+	SumberDaya      string     `json:"sumber_daya"`
+	Jumlah          float64    `json:"jumlah"`
+	Satuan          string     `json:"satuan"`
+	SebarkanKeWarga bool       `json:"sebarkan_ke_warga"`
+	BerlakuSampai   *time.Time `json:"berlaku_sampai"`
+	WaktuDikirim    time.Time  `json:"waktu_dikirim"`
+}
+
+// PenerimaanArahanEvent is the receipt a kecamatan sends back (status Diterima / Dikonfirmasi)
+type PenerimaanArahanEvent struct {
+	ArahanKotaID uint      `json:"arahan_kota_id"`
+	Status       string    `json:"status"`
+	Oleh         string    `json:"oleh"`
+	Waktu        time.Time `json:"waktu"`
+}
@@ -0,0 +1,57 @@
+// models/dispatch.go
+package models
+
+import "time"
+
+// TugasEvakuasi model (penugasan warga prioritas ke relawan oleh koordinator)
+type TugasEvakuasi struct {
+	ID              uint            `gorm:"primarykey" json:"id"`
+	BencanaID       uint            `gorm:"not null;index" json:"bencana_id"`
+	Bencana         KejadianBencana `gorm:"foreignKey:BencanaID" json:"bencana,omitempty"`
+	WargaID         uint            `gorm:"not null;index" json:"warga_id"`
+	Warga           WargaRentan     `gorm:"foreignKey:WargaID" json:"warga,omitempty"`
+	RelawanID       uint            `gorm:"not null;index" json:"relawan_id"`
+	Relawan         User            `gorm:"foreignKey:RelawanID" json:"relawan,omitempty"`
+	DitugaskanOleh  uint            `gorm:"not null" json:"ditugaskan_oleh"`
+	Metode          string          `gorm:"type:enum('Manual','Otomatis');not null" json:"metode"`
+	Status          string          `gorm:"type:enum('Ditugaskan','Diterima','Ditolak','Selesai','Dibatalkan');not null;default:'Ditugaskan'" json:"status"`
+	SkorPrioritas   int             `json:"skor_prioritas"`
+	JarakKm         *float64        `json:"jarak_km"` // Jarak relawan ke warga saat ditugaskan (jika diketahui)
+	AlasanTolak     string          `json:"alasan_tolak"`
+	WaktuDitugaskan time.Time       `gorm:"not null" json:"waktu_ditugaskan"`
+	WaktuDirespon   *time.Time      `json:"waktu_direspon"`
+	WaktuSelesai    *time.Time      `json:"waktu_selesai"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// EskalasiEvakuasi model (warga prioritas yang belum tertangani melewati batas waktu)
+type EskalasiEvakuasi struct {
+	ID            uint        `gorm:"primarykey" json:"id"`
+	BencanaID     uint        `gorm:"not null;index" json:"bencana_id"`
+	WargaID       uint        `gorm:"not null;index" json:"warga_id"`
+	Warga         WargaRentan `gorm:"foreignKey:WargaID" json:"warga,omitempty"`
+	TugasID       *uint       `json:"tugas_id"`
+	Alasan        string      `gorm:"type:enum('Belum Ditugaskan','Tidak Direspon','Macet');not null" json:"alasan"`
+	Ditangani     bool        `gorm:"default:false" json:"ditangani"`
+	DitanganiOleh *uint       `json:"ditangani_oleh"`
+	Waktu         time.Time   `gorm:"not null" json:"waktu"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// DTO for manual assignment
+type AssignTugasRequest struct {
+	WargaID   uint `json:"warga_id" validate:"required"`
+	RelawanID uint `json:"relawan_id" validate:"required"`
+}
+
+// DTO for auto assignment
+type AutoAssignRequest struct {
+	Limit int `json:"limit"` // Maksimal warga yang ditugaskan sekali jalan (0 = semua)
+}
+
+// DTO for declining a task
+type TolakTugasRequest struct {
+	Alasan string `json:"alasan"`
+}
@@ -0,0 +1,286 @@
+// models/jenis_bencana.go
+package models
+
+import "time"
+
+// JenisBencana model (taksonomi jenis bencana). Dikelola API Kota lalu disalin ke
+// setiap kecamatan, sehingga "banjir", "BANJIR" dan "flood" tercatat sebagai satu jenis.
+type JenisBencana struct {
+	ID               uint            `gorm:"primarykey" json:"id"`
+	Kode             string          `gorm:"size:16;not null;uniqueIndex" json:"kode"` // Kode singkat per kategori kejadian DIBI BNPB
+	Nama             string          `gorm:"size:64;not null;uniqueIndex" json:"nama"` // Nama baku yang disimpan di KejadianBencana
+	KategoriNasional string          `gorm:"type:enum('Hidrometeorologi','Geologi','Non-Alam','Sosial');not null" json:"kategori_nasional"`
+	Alias            []string        `gorm:"type:text;serializer:json" json:"alias"`        // Ejaan lain yang dipetakan ke jenis ini
+	JenisBahaya      []string        `gorm:"type:text;serializer:json" json:"jenis_bahaya"` // Zona bahaya terkait (lihat ZonaBahaya)
+	Playbook         PlaybookBencana `gorm:"type:longtext;serializer:json" json:"playbook"`
+	Aktif            bool            `gorm:"not null;default:true" json:"aktif"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// PlaybookBencana is the standard response of a disaster type
+type PlaybookBencana struct {
+	// Placeholder: {jenis}, {level}, {deskripsi}, {waktu}
+	TemplateNotifikasi string `json:"template_notifikasi"`
+	// Status evakuasi yang wajib dilalui warga; status terakhir = evakuasi dianggap tuntas
+	StatusWajib []string `json:"status_wajib"`
+	// Jenis titik kumpul yang direkomendasikan (Titik Kumpul / Shelter)
+	JenisShelter []string `json:"jenis_shelter"`
+	// Lewati titik kumpul yang berada di zona bahaya jenis ini (risiko Sedang/Tinggi)
+	HindariZonaBahaya bool           `json:"hindari_zona_bahaya"`
+	KriteriaShelter   string         `json:"kriteria_shelter"`
+	Checklist         []ItemPlaybook `json:"checklist"`
+}
+
+// ItemPlaybook is one checklist item, dibuat menjadi TugasBencana saat bencana dilaporkan
+type ItemPlaybook struct {
+	Judul      string `json:"judul"`
+	Deskripsi  string `json:"deskripsi"`
+	Peran      string `json:"peran"`       // RT, RW, Relawan, Admin_Kecamatan
+	BatasMenit int    `json:"batas_menit"` // Tenggat sejak bencana dimulai (0 = tanpa tenggat)
+}
+
+// TugasBencana model (checklist playbook yang dijalankan untuk satu bencana)
+type TugasBencana struct {
+	ID               uint            `gorm:"primarykey" json:"id"`
+	BencanaID        uint            `gorm:"not null;index" json:"bencana_id"`
+	Bencana          KejadianBencana `gorm:"foreignKey:BencanaID" json:"-"`
+	Urutan           int             `gorm:"not null" json:"urutan"`
+	Judul            string          `gorm:"not null" json:"judul"`
+	Deskripsi        string          `gorm:"type:text" json:"deskripsi"`
+	Peran            string          `gorm:"size:20" json:"peran"`
+	BatasWaktu       *time.Time      `json:"batas_waktu"`
+	Sumber           string          `gorm:"type:enum('Playbook','Manual');not null;default:'Playbook'" json:"sumber"`
+	Status           string          `gorm:"type:enum('Belum','Selesai','Dilewati');not null;default:'Belum';index" json:"status"`
+	Catatan          string          `gorm:"type:text" json:"catatan"`
+	DiselesaikanOleh *uint           `json:"diselesaikan_oleh"`
+	WaktuSelesai     *time.Time      `json:"waktu_selesai"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// DTO for creating or replacing a disaster type (API Kota)
+type JenisBencanaRequest struct {
+	Kode             string          `json:"kode"`
+	Nama             string          `json:"nama"`
+	KategoriNasional string          `json:"kategori_nasional"`
+	Alias            []string        `json:"alias"`
+	JenisBahaya      []string        `json:"jenis_bahaya"`
+	Playbook         PlaybookBencana `json:"playbook"`
+	Aktif            *bool           `json:"aktif"`
+}
+
+// DTO for adding a manual task to a bencana
+type CreateTugasBencanaRequest struct {
+	Judul      string `json:"judul"`
+	Deskripsi  string `json:"deskripsi"`
+	Peran      string `json:"peran"`
+	BatasMenit int    `json:"batas_menit"`
+}
+
+// DTO for updating the status of a task
+type UpdateTugasBencanaRequest struct {
+	Status  string `json:"status"` // Belum, Selesai, Dilewati
+	Catatan string `json:"catatan"`
+}
+
+// JenisBencanaEvent is the full taxonomy Kota sends to every kecamatan
+type JenisBencanaEvent struct {
+	Jenis []JenisBencana `json:"jenis"`
+}
+
+// langkahEvakuasiPenuh adalah alur evakuasi lengkap sampai titik kumpul
+var langkahEvakuasiPenuh = []string{"Dalam Proses", "Terevakuasi", "Di Titik Kumpul"}
+
+// checklistDasar berlaku untuk semua jenis yang membutuhkan evakuasi
+var checklistDasar = []ItemPlaybook{
+	{Judul: "Aktifkan posko kecamatan", Peran: "Admin_Kecamatan", BatasMenit: 30},
+	{Judul: "Verifikasi laporan dan wilayah terdampak", Peran: "RW", BatasMenit: 30},
+	{Judul: "Buka titik kumpul yang direkomendasikan", Peran: "RW", BatasMenit: 60},
+	{Judul: "Dispatch relawan ke warga prioritas", Peran: "Admin_Kecamatan", BatasMenit: 60},
+	{Judul: "Kirim laporan situasi awal ke BPBD Kota", Peran: "Admin_Kecamatan", BatasMenit: 120},
+}
+
+func gabungChecklist(khusus ...ItemPlaybook) []ItemPlaybook {
+	return append(append([]ItemPlaybook(nil), checklistDasar...), khusus...)
+}
+
+// JenisBencanaBawaan returns the built-in taxonomy seeded into an empty database
+func JenisBencanaBawaan() []JenisBencana {
+	return []JenisBencana{
+		{
+			Kode: "BJR", Nama: "Banjir", KategoriNasional: "Hidrometeorologi",
+			Alias:       []string{"flood", "banjir genangan", "genangan", "banjir kiriman"},
+			JenisBahaya: []string{"Banjir"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[BANJIR] Banjir dilaporkan ({level}) pada {waktu}. {deskripsi} Matikan listrik, amankan dokumen, dan menuju titik kumpul terdekat.",
+				StatusWajib:        langkahEvakuasiPenuh,
+				JenisShelter:       []string{"Shelter", "Titik Kumpul"},
+				HindariZonaBahaya:  true,
+				KriteriaShelter:    "Bangunan tinggi di luar zona rawan banjir",
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Pantau tinggi muka air di sensor dan pintu air", Peran: "Relawan", BatasMenit: 30},
+					ItemPlaybook{Judul: "Siapkan perahu karet dan pelampung", Peran: "Relawan", BatasMenit: 60},
+					ItemPlaybook{Judul: "Koordinasi pemadaman listrik di area tergenang", Peran: "Admin_Kecamatan", BatasMenit: 60},
+				),
+			},
+		},
+		{
+			Kode: "BJB", Nama: "Banjir Bandang", KategoriNasional: "Hidrometeorologi",
+			Alias:       []string{"flash flood", "banjir lumpur"},
+			JenisBahaya: []string{"Banjir", "Tanah Longsor"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[BANJIR BANDANG] {waktu}: {deskripsi} Segera menjauhi sungai dan lereng, menuju tempat tinggi.",
+				StatusWajib:        langkahEvakuasiPenuh,
+				JenisShelter:       []string{"Shelter", "Titik Kumpul"},
+				HindariZonaBahaya:  true,
+				KriteriaShelter:    "Lokasi tinggi, jauh dari alur sungai",
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Tutup akses jalan dan jembatan di alur sungai", Peran: "RW", BatasMenit: 30},
+				),
+			},
+		},
+		{
+			Kode: "ROB", Nama: "Banjir Rob", KategoriNasional: "Hidrometeorologi",
+			Alias:       []string{"rob", "banjir pasang", "tidal flood"},
+			JenisBahaya: []string{"Banjir Rob"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[ROB] Banjir rob {waktu}. {deskripsi} Tinggikan barang berharga dan waspadai pasang berikutnya.",
+				StatusWajib:        []string{"Dalam Proses", "Terevakuasi"},
+				JenisShelter:       []string{"Titik Kumpul", "Shelter"},
+				HindariZonaBahaya:  true,
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Cek jadwal pasang tertinggi dari BMKG Maritim", Peran: "Admin_Kecamatan", BatasMenit: 30},
+				),
+			},
+		},
+		{
+			Kode: "TLS", Nama: "Tanah Longsor", KategoriNasional: "Hidrometeorologi",
+			Alias:       []string{"longsor", "landslide", "gerakan tanah"},
+			JenisBahaya: []string{"Tanah Longsor"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[LONGSOR] Tanah longsor dilaporkan ({level}) pada {waktu}. {deskripsi} Jauhi lereng dan tebing.",
+				StatusWajib:        langkahEvakuasiPenuh,
+				JenisShelter:       []string{"Shelter", "Titik Kumpul"},
+				HindariZonaBahaya:  true,
+				KriteriaShelter:    "Lahan datar, jauh dari lereng",
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Pasang garis batas area longsor", Peran: "RT", BatasMenit: 30},
+					ItemPlaybook{Judul: "Data warga tertimbun / hilang", Peran: "Relawan", BatasMenit: 60},
+				),
+			},
+		},
+		{
+			Kode: "GMB", Nama: "Gempabumi", KategoriNasional: "Geologi",
+			Alias:       []string{"gempa", "gempa bumi", "earthquake"},
+			JenisBahaya: []string{"Gempabumi"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[GEMPA] Gempabumi {waktu}. {deskripsi} Keluar dari bangunan, jauhi bangunan retak, dan menuju titik kumpul terbuka.",
+				StatusWajib:        langkahEvakuasiPenuh,
+				JenisShelter:       []string{"Titik Kumpul"},
+				KriteriaShelter:    "Lapangan terbuka, jauh dari bangunan tinggi",
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Periksa kerusakan bangunan publik", Peran: "RW", BatasMenit: 120},
+					ItemPlaybook{Judul: "Waspadai gempa susulan dan informasi tsunami BMKG", Peran: "Admin_Kecamatan", BatasMenit: 15},
+				),
+			},
+		},
+		{
+			Kode: "TSN", Nama: "Tsunami", KategoriNasional: "Geologi",
+			Alias:       []string{"tsunami"},
+			JenisBahaya: []string{"Tsunami"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[TSUNAMI] {waktu}: {deskripsi} SEGERA menuju tempat tinggi / shelter tsunami, jangan kembali sebelum dinyatakan aman.",
+				StatusWajib:        langkahEvakuasiPenuh,
+				JenisShelter:       []string{"Shelter"},
+				HindariZonaBahaya:  true,
+				KriteriaShelter:    "Shelter tsunami / bukit di luar zona genangan",
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Bunyikan sirene dan pengumuman masjid", Peran: "RT", BatasMenit: 5},
+					ItemPlaybook{Judul: "Arahkan arus evakuasi menjauhi pantai", Peran: "Relawan", BatasMenit: 10},
+				),
+			},
+		},
+		{
+			Kode: "PTB", Nama: "Puting Beliung", KategoriNasional: "Hidrometeorologi",
+			Alias:       []string{"angin puting beliung", "angin kencang", "cuaca ekstrem", "tornado"},
+			JenisBahaya: []string{"Angin Puting Beliung"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[ANGIN KENCANG] {waktu}: {deskripsi} Berlindung di bangunan kokoh, jauhi pohon dan baliho.",
+				StatusWajib:        []string{"Dalam Proses", "Terevakuasi"},
+				JenisShelter:       []string{"Shelter"},
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Bersihkan pohon tumbang dari jalur evakuasi", Peran: "Relawan", BatasMenit: 120},
+				),
+			},
+		},
+		{
+			Kode: "KBP", Nama: "Kebakaran Permukiman", KategoriNasional: "Non-Alam",
+			Alias:       []string{"kebakaran", "kebakaran rumah", "fire"},
+			JenisBahaya: []string{"Kebakaran"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[KEBAKARAN] {waktu}: {deskripsi} Jauhi lokasi, beri jalan untuk pemadam kebakaran.",
+				StatusWajib:        []string{"Dalam Proses", "Terevakuasi"},
+				JenisShelter:       []string{"Titik Kumpul", "Shelter"},
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Hubungi Damkar (113)", Peran: "RT", BatasMenit: 5},
+				),
+			},
+		},
+		{
+			Kode: "KHL", Nama: "Kebakaran Hutan dan Lahan", KategoriNasional: "Hidrometeorologi",
+			Alias:       []string{"karhutla", "kebakaran hutan", "kebakaran lahan"},
+			JenisBahaya: []string{"Kebakaran"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[KARHUTLA] {waktu}: {deskripsi} Kurangi aktivitas luar ruang dan gunakan masker.",
+				StatusWajib:        []string{"Dalam Proses", "Terevakuasi"},
+				JenisShelter:       []string{"Shelter"},
+				HindariZonaBahaya:  true,
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Bagikan masker ke warga rentan", Peran: "Relawan", BatasMenit: 120},
+				),
+			},
+		},
+		{
+			Kode: "EGA", Nama: "Erupsi Gunung Api", KategoriNasional: "Geologi",
+			Alias:       []string{"erupsi", "letusan gunung", "gunung meletus", "volcano"},
+			JenisBahaya: []string{"Erupsi Gunung Api"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[ERUPSI] {waktu}: {deskripsi} Keluar dari radius bahaya dan gunakan masker.",
+				StatusWajib:        langkahEvakuasiPenuh,
+				JenisShelter:       []string{"Shelter"},
+				HindariZonaBahaya:  true,
+				KriteriaShelter:    "Di luar radius Kawasan Rawan Bencana (KRB)",
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Pantau status gunung api dari PVMBG", Peran: "Admin_Kecamatan", BatasMenit: 15},
+				),
+			},
+		},
+		{
+			Kode: "KKR", Nama: "Kekeringan", KategoriNasional: "Hidrometeorologi",
+			Alias:       []string{"drought", "krisis air bersih"},
+			JenisBahaya: []string{"Kekeringan"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[KEKERINGAN] {deskripsi} Hemat air; distribusi air bersih akan diumumkan RT/RW.",
+				Checklist: []ItemPlaybook{
+					{Judul: "Data kebutuhan air bersih per RT", Peran: "RT", BatasMenit: 1440},
+					{Judul: "Ajukan permintaan truk tangki air ke Kota", Peran: "Admin_Kecamatan", BatasMenit: 1440},
+				},
+			},
+		},
+		{
+			Kode: "GEA", Nama: "Gelombang Ekstrem dan Abrasi", KategoriNasional: "Hidrometeorologi",
+			Alias:       []string{"abrasi", "gelombang tinggi", "gelombang ekstrem"},
+			JenisBahaya: []string{"Abrasi", "Banjir Rob"},
+			Playbook: PlaybookBencana{
+				TemplateNotifikasi: "[GELOMBANG TINGGI] {waktu}: {deskripsi} Nelayan tidak melaut, jauhi bibir pantai.",
+				StatusWajib:        []string{"Dalam Proses", "Terevakuasi"},
+				JenisShelter:       []string{"Titik Kumpul", "Shelter"},
+				HindariZonaBahaya:  true,
+				Checklist: gabungChecklist(
+					ItemPlaybook{Judul: "Larangan melaut untuk nelayan", Peran: "RW", BatasMenit: 30},
+				),
+			},
+		},
+	}
+}
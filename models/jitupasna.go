@@ -0,0 +1,138 @@
+// models/jitupasna.go
+package models
+
+import "time"
+
+// AsesmenKerusakan model (formulir pengkajian kebutuhan pascabencana / Jitupasna untuk
+// satu objek: rumah satu KK, satu fasilitas umum, atau usaha warga). Diisi petugas RT,
+// diverifikasi Admin_Kecamatan; hanya asesmen terverifikasi yang direkap ke Kota.
+type AsesmenKerusakan struct {
+	ID                uint                    `gorm:"primarykey" json:"id"`
+	BencanaID         uint                    `gorm:"not null;index" json:"bencana_id"`
+	Bencana           *KejadianBencana        `gorm:"foreignKey:BencanaID" json:"bencana,omitempty"`
+	Objek             string                  `gorm:"type:enum('Rumah','Fasilitas Umum','Usaha');not null;index" json:"objek"`
+	KartuKeluargaID   *uint                   `gorm:"index" json:"kartu_keluarga_id"` // Wajib untuk Rumah, opsional untuk Usaha
+	KartuKeluarga     *KartuKeluarga          `gorm:"foreignKey:KartuKeluargaID" json:"kartu_keluarga,omitempty"`
+	JenisFasilitas    string                  `gorm:"size:32" json:"jenis_fasilitas"` // Untuk Fasilitas Umum, mis. Sekolah, Jembatan
+	NamaObjek         string                  `json:"nama_objek"`                     // Nama fasilitas/usaha; untuk rumah = kepala keluarga
+	Sektor            string                  `gorm:"size:32;not null;index" json:"sektor"`
+	Subsektor         string                  `gorm:"size:32;not null" json:"subsektor"`
+	Alamat            string                  `gorm:"type:text" json:"alamat"`
+	RT                string                  `gorm:"size:8;index" json:"rt"`
+	RW                string                  `gorm:"size:8" json:"rw"`
+	Latitude          float64                 `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude         float64                 `gorm:"type:decimal(11,8)" json:"longitude"`
+	TingkatKerusakan  string                  `gorm:"type:enum('Tidak Rusak','Ringan','Sedang','Berat');not null;default:'Tidak Rusak'" json:"tingkat_kerusakan"`
+	NilaiKerusakan    float64                 `gorm:"type:decimal(15,2)" json:"nilai_kerusakan"` // Perkiraan biaya penggantian aset fisik (Rp)
+	NilaiKerugian     float64                 `gorm:"type:decimal(15,2)" json:"nilai_kerugian"`  // Kerugian di luar aset fisik, mis. layanan terhenti (Rp)
+	Catatan           string                  `gorm:"type:text" json:"catatan"`
+	Penghidupan       []KerugianPenghidupan   `gorm:"foreignKey:AsesmenID" json:"penghidupan,omitempty"`
+	Kebutuhan         []KebutuhanPascabencana `gorm:"foreignKey:AsesmenID" json:"kebutuhan,omitempty"`
+	Status            string                  `gorm:"type:enum('Diajukan','Diverifikasi','Ditolak');not null;default:'Diajukan';index" json:"status"`
+	CatatanVerifikasi string                  `gorm:"type:text" json:"catatan_verifikasi"`
+	DinilaiOleh       uint                    `gorm:"not null" json:"dinilai_oleh"`
+	Penilai           *User                   `gorm:"foreignKey:DinilaiOleh" json:"penilai,omitempty"`
+	DiverifikasiOleh  *uint                   `json:"diverifikasi_oleh"`
+	WaktuVerifikasi   *time.Time              `json:"waktu_verifikasi"`
+	CreatedAt         time.Time               `json:"created_at"`
+	UpdatedAt         time.Time               `json:"updated_at"`
+}
+
+// KerugianPenghidupan model (kehilangan mata pencaharian dalam satu asesmen)
+type KerugianPenghidupan struct {
+	ID               uint    `gorm:"primarykey" json:"id"`
+	AsesmenID        uint    `gorm:"not null;index" json:"asesmen_id"`
+	Subsektor        string  `gorm:"size:32;not null" json:"subsektor"` // Pertanian, Perikanan, Peternakan, Perdagangan, Industri, Pariwisata
+	Uraian           string  `gorm:"not null" json:"uraian"`            // Mis. "Sawah padi puso", "Warung kelontong"
+	Jumlah           float64 `gorm:"type:decimal(12,2)" json:"jumlah"`
+	Satuan           string  `json:"satuan"` // Mis. ha, ekor, unit
+	PekerjaTerdampak int     `json:"pekerja_terdampak"`
+	NilaiKerugian    float64 `gorm:"type:decimal(15,2);not null" json:"nilai_kerugian"`
+}
+
+// KebutuhanPascabencana model (kebutuhan pemulihan yang dicatat dalam satu asesmen)
+type KebutuhanPascabencana struct {
+	ID            uint    `gorm:"primarykey" json:"id"`
+	AsesmenID     uint    `gorm:"not null;index" json:"asesmen_id"`
+	Sektor        string  `gorm:"size:32;not null" json:"sektor"`
+	Subsektor     string  `gorm:"size:32;not null" json:"subsektor"`
+	Uraian        string  `gorm:"not null" json:"uraian"` // Mis. "Perbaikan atap", "Bibit padi"
+	Jumlah        float64 `gorm:"type:decimal(12,2)" json:"jumlah"`
+	Satuan        string  `json:"satuan"`
+	EstimasiBiaya float64 `gorm:"type:decimal(15,2);not null" json:"estimasi_biaya"`
+	Prioritas     string  `gorm:"type:enum('Tinggi','Sedang','Rendah');not null;default:'Sedang'" json:"prioritas"`
+}
+
+// RekapJitupasnaKota model (rekap asesmen terverifikasi per bencana kecamatan, sektor dan subsektor di DB Kota)
+type RekapJitupasnaKota struct {
+	ID             uint            `gorm:"primarykey" json:"id"`
+	KecamatanID    uint            `gorm:"not null;uniqueIndex:idx_jitupasna_kecamatan" json:"kecamatan_id"`
+	Kecamatan      MasterKecamatan `gorm:"foreignKey:KecamatanID" json:"kecamatan"`
+	BencanaID      uint            `gorm:"not null;uniqueIndex:idx_jitupasna_kecamatan" json:"bencana_id"` // ID di DB kecamatan
+	JenisBencana   string          `json:"jenis_bencana"`
+	KodeJenis      string          `gorm:"size:16" json:"kode_jenis"`
+	WaktuMulai     time.Time       `json:"waktu_mulai"`
+	Sektor         string          `gorm:"size:32;not null;uniqueIndex:idx_jitupasna_kecamatan" json:"sektor"`
+	Subsektor      string          `gorm:"size:32;not null;uniqueIndex:idx_jitupasna_kecamatan" json:"subsektor"`
+	JumlahObjek    int             `json:"jumlah_objek"`
+	JumlahKK       int             `json:"jumlah_kk"`
+	RusakRingan    int             `json:"rusak_ringan"`
+	RusakSedang    int             `json:"rusak_sedang"`
+	RusakBerat     int             `json:"rusak_berat"`
+	Pekerja        int             `json:"pekerja_terdampak"`
+	NilaiKerusakan float64         `gorm:"type:decimal(17,2)" json:"nilai_kerusakan"`
+	NilaiKerugian  float64         `gorm:"type:decimal(17,2)" json:"nilai_kerugian"`
+	NilaiKebutuhan float64         `gorm:"type:decimal(17,2)" json:"nilai_kebutuhan"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// RekapJitupasnaItem holds the figures of one sektor/subsektor
+type RekapJitupasnaItem struct {
+	Sektor         string  `json:"sektor"`
+	Subsektor      string  `json:"subsektor"`
+	JumlahObjek    int     `json:"jumlah_objek"`
+	JumlahKK       int     `json:"jumlah_kk"`
+	RusakRingan    int     `json:"rusak_ringan"`
+	RusakSedang    int     `json:"rusak_sedang"`
+	RusakBerat     int     `json:"rusak_berat"`
+	Pekerja        int     `json:"pekerja_terdampak"`
+	NilaiKerusakan float64 `json:"nilai_kerusakan"`
+	NilaiKerugian  float64 `json:"nilai_kerugian"`
+	NilaiKebutuhan float64 `json:"nilai_kebutuhan"`
+}
+
+// RekapJitupasnaEvent is the full verified recap of one bencana sent to the kota
+type RekapJitupasnaEvent struct {
+	BencanaID    uint                 `json:"bencana_id"`
+	JenisBencana string               `json:"jenis_bencana"`
+	KodeJenis    string               `json:"kode_jenis"`
+	WaktuMulai   time.Time            `json:"waktu_mulai"`
+	Rekap        []RekapJitupasnaItem `json:"rekap"`
+	Waktu        time.Time            `json:"waktu"`
+}
+
+// DTO for creating/updating an assessment
+type AsesmenKerusakanRequest struct {
+	BencanaID        uint                    `json:"bencana_id"`
+	Objek            string                  `json:"objek"`
+	KartuKeluargaID  *uint                   `json:"kartu_keluarga_id"`
+	JenisFasilitas   string                  `json:"jenis_fasilitas"`
+	NamaObjek        string                  `json:"nama_objek"`
+	Alamat           string                  `json:"alamat"`
+	RT               string                  `json:"rt"`
+	RW               string                  `json:"rw"`
+	Latitude         float64                 `json:"latitude"`
+	Longitude        float64                 `json:"longitude"`
+	TingkatKerusakan string                  `json:"tingkat_kerusakan"`
+	NilaiKerusakan   float64                 `json:"nilai_kerusakan"`
+	NilaiKerugian    float64                 `json:"nilai_kerugian"`
+	Catatan          string                  `json:"catatan"`
+	Penghidupan      []KerugianPenghidupan   `json:"penghidupan"`
+	Kebutuhan        []KebutuhanPascabencana `json:"kebutuhan"`
+}
+
+// DTO for verifying an assessment
+type VerifikasiAsesmenRequest struct {
+	Status  string `json:"status"` // Diverifikasi atau Ditolak
+	Catatan string `json:"catatan"`
+}
@@ -0,0 +1,44 @@
+// models/keluarga.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KartuKeluarga model (satu rumah tangga / satu titik jemput evakuasi)
+type KartuKeluarga struct {
+	ID             uint           `gorm:"primarykey" json:"id"`
+	NoKK           string         `gorm:"unique;size:16;not null" json:"no_kk"`
+	KepalaKeluarga string         `gorm:"not null" json:"kepala_keluarga"`
+	Alamat         string         `gorm:"type:text" json:"alamat"`
+	RT             string         `json:"rt"`
+	RW             string         `json:"rw"`
+	Latitude       float64        `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude      float64        `gorm:"type:decimal(11,8)" json:"longitude"`
+	Anggota        []WargaRentan  `gorm:"foreignKey:KartuKeluargaID" json:"anggota,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// DTO for Create/Update Kartu Keluarga
+type CreateKeluargaRequest struct {
+	NoKK           string  `json:"no_kk" validate:"required"`
+	KepalaKeluarga string  `json:"kepala_keluarga" validate:"required"`
+	Alamat         string  `json:"alamat"`
+	RT             string  `json:"rt"`
+	RW             string  `json:"rw"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+}
+
+// DTO for household-level evacuation log
+type LogEvakuasiKeluargaRequest struct {
+	BencanaID       uint   `json:"bencana_id" validate:"required"`
+	KartuKeluargaID uint   `json:"kartu_keluarga_id" validate:"required"`
+	StatusTerkini   string `json:"status_terkini" validate:"required"`
+	TitikKumpulID   *uint  `json:"titik_kumpul_id"` // Wajib bila status_terkini "Di Titik Kumpul"
+	Catatan         string `json:"catatan"`
+}
@@ -0,0 +1,79 @@
+// models/kerentanan.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KategoriWarga model (satu warga bisa masuk beberapa kategori rentan sekaligus)
+type KategoriWarga struct {
+	ID       uint   `gorm:"primarykey"`
+	WargaID  uint   `gorm:"not null;uniqueIndex:idx_warga_kategori"`
+	Kategori string `gorm:"type:enum('Lansia','Disabilitas','Anak-anak','Ibu Hamil','Sakit Keras','Non-Rentan');not null;uniqueIndex:idx_warga_kategori"`
+}
+
+// MarshalJSON menampilkan kategori sebagai string biasa, mis. ["Lansia","Disabilitas"]
+func (k KategoriWarga) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.Kategori)
+}
+
+// UnmarshalJSON membaca kategori dari string biasa (dipakai saat rollback dari snapshot)
+func (k *KategoriWarga) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &k.Kategori)
+}
+
+// DaftarKategori returns the category names of a warga, e.g. ["Lansia","Disabilitas"]
+func (w WargaRentan) DaftarKategori() []string {
+	kategori := make([]string, 0, len(w.Kategori))
+	for _, k := range w.Kategori {
+		kategori = append(kategori, k.Kategori)
+	}
+	if len(kategori) == 0 && w.KategoriRentan != "" {
+		kategori = append(kategori, w.KategoriRentan)
+	}
+	return kategori
+}
+
+// KebutuhanPerawatan model (kebutuhan khusus saat evakuasi)
+type KebutuhanPerawatan struct {
+	ID              uint      `gorm:"primarykey" json:"-"`
+	WargaID         uint      `gorm:"uniqueIndex;not null" json:"-"`
+	KursiRoda       bool      `json:"kursi_roda"`
+	Tandu           bool      `json:"tandu"`
+	Oksigen         bool      `json:"oksigen"`
+	JadwalCuciDarah string    `json:"jadwal_cuci_darah"` // Contoh: "Senin & Kamis 08:00, RSUD Bangkalan"
+	Obat            string    `gorm:"type:text" json:"obat"`
+	NamaPendamping  string    `json:"nama_pendamping"`
+	NoHPPendamping  string    `json:"no_hp_pendamping"`
+	Catatan         string    `gorm:"type:text" json:"catatan"`
+	Perlengkapan    []string  `gorm:"-" json:"perlengkapan"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// AfterFind mengisi daftar perlengkapan yang harus dibawa relawan
+func (k *KebutuhanPerawatan) AfterFind(tx *gorm.DB) error {
+	k.Perlengkapan = k.DaftarPerlengkapan()
+	return nil
+}
+
+// DaftarPerlengkapan returns the equipment a relawan must bring for this warga
+func (k KebutuhanPerawatan) DaftarPerlengkapan() []string {
+	perlengkapan := []string{}
+	if k.KursiRoda {
+		perlengkapan = append(perlengkapan, "Kursi roda")
+	}
+	if k.Tandu {
+		perlengkapan = append(perlengkapan, "Tandu")
+	}
+	if k.Oksigen {
+		perlengkapan = append(perlengkapan, "Tabung oksigen portabel")
+	}
+	if k.Obat != "" {
+		perlengkapan = append(perlengkapan, "Obat rutin pribadi")
+	}
+	return perlengkapan
+}
@@ -0,0 +1,48 @@
+// models/keselamatan.go
+package models
+
+import "time"
+
+// PenerimaNotifikasi model (satu warga penerima notifikasi darurat beserta tautan balasannya)
+type PenerimaNotifikasi struct {
+	ID           uint       `gorm:"primarykey" json:"id"`
+	NotifikasiID uint       `gorm:"not null;index" json:"notifikasi_id"`
+	BencanaID    uint       `gorm:"not null;index" json:"bencana_id"`
+	WargaID      uint       `gorm:"not null;index" json:"warga_id"`
+	NoHP         string     `gorm:"size:20;index" json:"no_hp"`   // Format 62xxx, untuk mencocokkan balasan SMS/WhatsApp
+	TokenHash    string     `gorm:"size:64;uniqueIndex" json:"-"` // Token tautan hanya ada di pesan yang dikirim
+	DiterimaPada *time.Time `json:"diterima_pada"`                // Balasan pertama warga atas notifikasi ini
+	Kanal        string     `gorm:"size:16" json:"kanal"`         // Tautan / SMS / WhatsApp
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// StatusKeselamatan model (roster keselamatan warga per bencana, diperbarui dari balasan warga).
+// Status Diterima = peringatan sudah dikonfirmasi tetapi kondisi warga belum dilaporkan.
+type StatusKeselamatan struct {
+	ID                  uint         `gorm:"primarykey" json:"id"`
+	BencanaID           uint         `gorm:"not null;uniqueIndex:idx_keselamatan_warga" json:"bencana_id"`
+	WargaID             uint         `gorm:"not null;uniqueIndex:idx_keselamatan_warga" json:"warga_id"`
+	Warga               *WargaRentan `gorm:"foreignKey:WargaID" json:"warga,omitempty"`
+	Status              string       `gorm:"type:enum('Belum Merespons','Diterima','Aman','Butuh Bantuan');not null;default:'Belum Merespons';index" json:"status"`
+	Kanal               string       `gorm:"size:16" json:"kanal"` // Tautan / SMS / WhatsApp / Petugas
+	Catatan             string       `gorm:"type:text" json:"catatan"`
+	WaktuNotifikasi     time.Time    `gorm:"not null" json:"waktu_notifikasi"` // Peringatan pertama yang diterima warga untuk bencana ini
+	WaktuRespons        *time.Time   `json:"waktu_respons"`
+	Diprioritaskan      bool         `gorm:"default:false;index" json:"diprioritaskan"` // Tidak merespons dalam batas waktu, didahulukan di daftar prioritas evakuasi
+	WaktuDiprioritaskan *time.Time   `json:"waktu_diprioritaskan"`
+	DicatatOleh         *uint        `json:"dicatat_oleh"` // Petugas yang mencatat hasil telepon/kunjungan
+	UpdatedAt           time.Time    `json:"updated_at"`
+}
+
+// RekapKeselamatan counts the roster of one bencana per status
+type RekapKeselamatan struct {
+	Total          int            `json:"total"`
+	PerStatus      map[string]int `json:"per_status"`
+	Diprioritaskan int            `json:"diprioritaskan"`
+}
+
+// DTO for a resident answering an alert through the tokenised link
+type ResponKeselamatanRequest struct {
+	Status  string `json:"status" validate:"required"` // Diterima / Aman / Butuh Bantuan
+	Catatan string `json:"catatan"`
+}
@@ -0,0 +1,102 @@
+// models/laporan_warga.go
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LaporanWarga model (laporan bahaya dari masyarakat tanpa akun: web publik, SMS, WhatsApp).
+// Laporan menunggu verifikasi RT/RW sebelum menjadi KejadianBencana.
+type LaporanWarga struct {
+	ID                uint       `gorm:"primarykey" json:"id"`
+	Kode              string     `gorm:"size:16;uniqueIndex;not null" json:"kode"` // Kode lacak yang diberikan ke pelapor
+	Sumber            string     `gorm:"type:enum('Web','SMS','WhatsApp');not null" json:"sumber"`
+	NamaPelapor       string     `json:"nama_pelapor"`
+	NoHP              string     `gorm:"size:20;index" json:"no_hp"` // Format 62xxx, juga tujuan kabar status
+	JenisBencana      string     `gorm:"not null" json:"jenis_bencana"`
+	KodeJenis         string     `gorm:"size:16" json:"kode_jenis"`
+	Deskripsi         string     `gorm:"type:text" json:"deskripsi"`
+	RT                string     `gorm:"size:8" json:"rt"`
+	RW                string     `gorm:"size:8" json:"rw"`
+	Lokasi            string     `json:"lokasi"`
+	Latitude          float64    `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude         float64    `gorm:"type:decimal(11,8)" json:"longitude"`
+	FotoPenyimpanan   string     `gorm:"size:16" json:"-"`
+	FotoKunci         string     `json:"-"`
+	FotoTipe          string     `gorm:"size:100" json:"foto_tipe,omitempty"`
+	FotoUkuran        int64      `json:"foto_ukuran,omitempty"`
+	FotoSHA256        string     `gorm:"size:64" json:"-"`
+	FotoURL           string     `gorm:"-" json:"foto_url,omitempty"`
+	Status            string     `gorm:"type:enum('Menunggu','Dikonfirmasi','Ditolak','Digabung');not null;default:'Menunggu';index" json:"status"`
+	BencanaID         *uint      `gorm:"index" json:"bencana_id"` // Bencana hasil konfirmasi / tujuan penggabungan
+	CatatanVerifikasi string     `gorm:"type:text" json:"catatan_verifikasi"`
+	DiverifikasiOleh  *uint      `json:"diverifikasi_oleh"`
+	Verifikator       *User      `gorm:"foreignKey:DiverifikasiOleh" json:"verifikator,omitempty"`
+	WaktuVerifikasi   *time.Time `json:"waktu_verifikasi"`
+	IP                string     `gorm:"size:45" json:"-"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// AfterFind mengisi URL foto laporan lewat API kecamatan
+func (l *LaporanWarga) AfterFind(tx *gorm.DB) error {
+	if l.FotoKunci != "" {
+		l.FotoURL = fmt.Sprintf("/api/v1/laporan-warga/%d/foto", l.ID)
+	}
+	return nil
+}
+
+// StatusLaporanWarga is what a reporter may see about their own report (tanpa data pelapor lain)
+type StatusLaporanWarga struct {
+	Kode            string     `json:"kode"`
+	Status          string     `json:"status"`
+	JenisBencana    string     `json:"jenis_bencana"`
+	Catatan         string     `json:"catatan"`
+	BencanaID       *uint      `json:"bencana_id"`
+	Dilaporkan      time.Time  `json:"dilaporkan"`
+	WaktuVerifikasi *time.Time `json:"waktu_verifikasi"`
+}
+
+// StatusPublik returns the public view of the report
+func (l LaporanWarga) StatusPublik() StatusLaporanWarga {
+	return StatusLaporanWarga{
+		Kode:            l.Kode,
+		Status:          l.Status,
+		JenisBencana:    l.JenisBencana,
+		Catatan:         l.CatatanVerifikasi,
+		BencanaID:       l.BencanaID,
+		Dilaporkan:      l.CreatedAt,
+		WaktuVerifikasi: l.WaktuVerifikasi,
+	}
+}
+
+// DTO for a public report (JSON atau multipart dengan field "foto")
+type CreateLaporanWargaRequest struct {
+	NamaPelapor  string  `json:"nama_pelapor" form:"nama_pelapor"`
+	NoHP         string  `json:"no_hp" form:"no_hp" validate:"required"`
+	JenisBencana string  `json:"jenis_bencana" form:"jenis_bencana" validate:"required"`
+	Deskripsi    string  `json:"deskripsi" form:"deskripsi"`
+	RT           string  `json:"rt" form:"rt"`
+	RW           string  `json:"rw" form:"rw"`
+	Lokasi       string  `json:"lokasi" form:"lokasi"`
+	Latitude     float64 `json:"latitude" form:"latitude"`
+	Longitude    float64 `json:"longitude" form:"longitude"`
+}
+
+// DTO for confirming a report into a new bencana
+type KonfirmasiLaporanWargaRequest struct {
+	JenisBencana string `json:"jenis_bencana"` // Koreksi jenis bila pelapor keliru
+	Level        string `json:"level"`         // Lokal_RT (default) / Kecamatan
+	Deskripsi    string `json:"deskripsi"`     // Default: deskripsi pelapor
+	BuatBaru     bool   `json:"buat_baru"`     // Abaikan bencana serupa yang sudah ada
+	Catatan      string `json:"catatan"`
+}
+
+// DTO for rejecting a report or merging it into an existing bencana
+type KeputusanLaporanWargaRequest struct {
+	BencanaID uint   `json:"bencana_id"` // Wajib untuk penggabungan
+	Catatan   string `json:"catatan"`
+}
@@ -0,0 +1,182 @@
+// models/logistik.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GudangLogistik model (gudang atau posko penyimpanan bantuan di kecamatan)
+type GudangLogistik struct {
+	ID              uint           `gorm:"primarykey" json:"id"`
+	Nama            string         `gorm:"not null" json:"nama"`
+	Jenis           string         `gorm:"type:enum('Gudang','Posko');not null;default:'Posko'" json:"jenis"`
+	Alamat          string         `gorm:"type:text" json:"alamat"`
+	Latitude        float64        `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude       float64        `gorm:"type:decimal(11,8)" json:"longitude"`
+	TitikKumpulID   *uint          `gorm:"index" json:"titik_kumpul_id"` // Posko yang menempel di titik kumpul
+	PenanggungJawab string         `json:"penanggung_jawab"`
+	NoHP            string         `json:"no_hp"`
+	Aktif           bool           `gorm:"not null" json:"aktif"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BarangLogistik model (katalog barang bantuan: beras, air, selimut, obat)
+type BarangLogistik struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	Kode        string         `gorm:"unique;size:32;not null" json:"kode"` // Kode yang sama dipakai antar kecamatan
+	Nama        string         `gorm:"not null" json:"nama"`
+	Kategori    string         `gorm:"type:enum('Pangan','Air','Sandang','Kesehatan','Kebersihan','Hunian','Lainnya');not null;default:'Lainnya'" json:"kategori"`
+	Satuan      string         `gorm:"not null" json:"satuan"`                 // Mis. "kg", "dus", "lembar"
+	StokMinimum float64        `gorm:"type:decimal(12,2)" json:"stok_minimum"` // Per gudang; di bawahnya dianggap kurang
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// StokLogistik model (saldo satu barang di satu gudang, hanya diubah lewat mutasi)
+type StokLogistik struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	GudangID  uint            `gorm:"not null;uniqueIndex:idx_gudang_barang" json:"gudang_id"`
+	Gudang    *GudangLogistik `gorm:"foreignKey:GudangID" json:"gudang,omitempty"`
+	BarangID  uint            `gorm:"not null;uniqueIndex:idx_gudang_barang" json:"barang_id"`
+	Barang    *BarangLogistik `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+	Jumlah    float64         `gorm:"type:decimal(12,2);not null;default:0" json:"jumlah"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// MutasiStok model (kartu stok: setiap barang masuk/keluar dari gudang)
+type MutasiStok struct {
+	ID           uint            `gorm:"primarykey" json:"id"`
+	GudangID     uint            `gorm:"not null;index" json:"gudang_id"`
+	Gudang       *GudangLogistik `gorm:"foreignKey:GudangID" json:"gudang,omitempty"`
+	BarangID     uint            `gorm:"not null;index" json:"barang_id"`
+	Barang       *BarangLogistik `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+	Jenis        string          `gorm:"type:enum('Masuk','Keluar','Transfer Masuk','Transfer Keluar','Distribusi','Penyesuaian');not null;index" json:"jenis"`
+	Jumlah       float64         `gorm:"type:decimal(12,2);not null" json:"jumlah"` // Negatif untuk barang keluar
+	SaldoSetelah float64         `gorm:"type:decimal(12,2);not null" json:"saldo_setelah"`
+	BencanaID    *uint           `gorm:"index" json:"bencana_id"`
+	Sumber       string          `json:"sumber"`         // Donatur / asal barang untuk barang masuk
+	GudangLainID *uint           `json:"gudang_lain_id"` // Pasangan transfer
+	DistribusiID *uint           `gorm:"index" json:"distribusi_id"`
+	Catatan      string          `gorm:"type:text" json:"catatan"`
+	PetugasID    uint            `gorm:"not null" json:"petugas_id"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// DistribusiBantuan model (penyaluran bantuan ke titik kumpul atau keluarga)
+type DistribusiBantuan struct {
+	ID               uint             `gorm:"primarykey" json:"id"`
+	GudangID         uint             `gorm:"not null;index" json:"gudang_id"`
+	Gudang           *GudangLogistik  `gorm:"foreignKey:GudangID" json:"gudang,omitempty"`
+	BencanaID        *uint            `gorm:"index" json:"bencana_id"`
+	TujuanJenis      string           `gorm:"type:enum('Titik Kumpul','Keluarga');not null" json:"tujuan_jenis"`
+	TitikKumpulID    *uint            `gorm:"index" json:"titik_kumpul_id"`
+	TitikKumpul      *TitikKumpul     `gorm:"foreignKey:TitikKumpulID" json:"titik_kumpul,omitempty"`
+	KartuKeluargaID  *uint            `gorm:"index" json:"kartu_keluarga_id"`
+	KartuKeluarga    *KartuKeluarga   `gorm:"foreignKey:KartuKeluargaID" json:"kartu_keluarga,omitempty"`
+	PenerimaNama     string           `gorm:"not null" json:"penerima_nama"`
+	PenerimaWargaID  *uint            `json:"penerima_warga_id"` // Terisi bila penerima terverifikasi sebagai warga
+	MetodeVerifikasi string           `gorm:"type:enum('QR','NIK','Petugas');not null" json:"metode_verifikasi"`
+	Catatan          string           `gorm:"type:text" json:"catatan"`
+	PetugasID        uint             `gorm:"not null" json:"petugas_id"`
+	Item             []DistribusiItem `gorm:"foreignKey:DistribusiID" json:"item,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+}
+
+// DistribusiItem model (barang dalam satu penyaluran)
+type DistribusiItem struct {
+	ID           uint            `gorm:"primarykey" json:"id"`
+	DistribusiID uint            `gorm:"not null;index" json:"distribusi_id"`
+	BarangID     uint            `gorm:"not null" json:"barang_id"`
+	Barang       *BarangLogistik `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+	Jumlah       float64         `gorm:"type:decimal(12,2);not null" json:"jumlah"`
+}
+
+// StokLogistikKota model (saldo stok per gudang kecamatan di DB Kota)
+type StokLogistikKota struct {
+	ID          uint            `gorm:"primarykey" json:"id"`
+	KecamatanID uint            `gorm:"not null;uniqueIndex:idx_kecamatan_gudang_barang" json:"kecamatan_id"`
+	Kecamatan   MasterKecamatan `gorm:"foreignKey:KecamatanID" json:"kecamatan,omitempty"`
+	GudangID    uint            `gorm:"not null;uniqueIndex:idx_kecamatan_gudang_barang" json:"gudang_id"` // ID di DB kecamatan
+	GudangNama  string          `json:"gudang_nama"`
+	BarangKode  string          `gorm:"size:32;not null;uniqueIndex:idx_kecamatan_gudang_barang" json:"barang_kode"`
+	BarangNama  string          `json:"barang_nama"`
+	Kategori    string          `json:"kategori"`
+	Satuan      string          `json:"satuan"`
+	Jumlah      float64         `gorm:"type:decimal(12,2)" json:"jumlah"`
+	StokMinimum float64         `gorm:"type:decimal(12,2)" json:"stok_minimum"`
+	LastSync    *time.Time      `json:"last_sync"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// DTO for Create / Update Gudang Logistik
+type GudangLogistikRequest struct {
+	Nama            string  `json:"nama" validate:"required"`
+	Jenis           string  `json:"jenis"`
+	Alamat          string  `json:"alamat"`
+	Latitude        float64 `json:"latitude"`
+	Longitude       float64 `json:"longitude"`
+	TitikKumpulID   *uint   `json:"titik_kumpul_id"`
+	PenanggungJawab string  `json:"penanggung_jawab"`
+	NoHP            string  `json:"no_hp"`
+	Aktif           *bool   `json:"aktif"`
+}
+
+// DTO for Create / Update Barang Logistik
+type BarangLogistikRequest struct {
+	Kode        string  `json:"kode" validate:"required"`
+	Nama        string  `json:"nama" validate:"required"`
+	Kategori    string  `json:"kategori"`
+	Satuan      string  `json:"satuan" validate:"required"`
+	StokMinimum float64 `json:"stok_minimum"`
+}
+
+// DTO for a stock movement. Jenis: Masuk, Keluar, Transfer, atau Penyesuaian
+// (untuk penyesuaian, jumlah adalah saldo hasil stock opname).
+type MutasiStokRequest struct {
+	BarangID     uint    `json:"barang_id" validate:"required"`
+	Jenis        string  `json:"jenis" validate:"required"`
+	Jumlah       float64 `json:"jumlah" validate:"required"`
+	GudangTujuan *uint   `json:"gudang_tujuan_id"` // Wajib untuk transfer
+	BencanaID    *uint   `json:"bencana_id"`
+	Sumber       string  `json:"sumber"`
+	Catatan      string  `json:"catatan"`
+}
+
+// DTO for recording an aid distribution. Penerima keluarga diverifikasi dengan
+// QR warga atau NIK anggota KK; penerima di titik kumpul cukup dicatat petugas.
+type DistribusiBantuanRequest struct {
+	BencanaID       *uint  `json:"bencana_id"`
+	TitikKumpulID   *uint  `json:"titik_kumpul_id"`
+	KartuKeluargaID *uint  `json:"kartu_keluarga_id"`
+	PenerimaNama    string `json:"penerima_nama"`
+	PenerimaQR      string `json:"penerima_qr"`
+	PenerimaNIK     string `json:"penerima_nik"`
+	Catatan         string `json:"catatan"`
+	Item            []struct {
+		BarangID uint    `json:"barang_id"`
+		Jumlah   float64 `json:"jumlah"`
+	} `json:"item" validate:"required"`
+}
+
+// StokLogistikEvent is the payload synced to the kota database (saldo lengkap satu gudang)
+type StokLogistikEvent struct {
+	GudangID   uint              `json:"gudang_id"`
+	GudangNama string            `json:"gudang_nama"`
+	Item       []StokBarangEvent `json:"item"`
+}
+
+// StokBarangEvent is one line of StokLogistikEvent
+type StokBarangEvent struct {
+	BarangKode  string  `json:"barang_kode"`
+	BarangNama  string  `json:"barang_nama"`
+	Kategori    string  `json:"kategori"`
+	Satuan      string  `json:"satuan"`
+	Jumlah      float64 `json:"jumlah"`
+	StokMinimum float64 `json:"stok_minimum"`
+}
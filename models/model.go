@@ -23,33 +23,47 @@ type User struct {
 
 // WargaRentan model
 type WargaRentan struct {
-	ID             uint           `gorm:"primarykey" json:"id"`
-	NIK            string         `gorm:"unique;not null" json:"nik"`
-	Nama           string         `gorm:"not null" json:"nama"`
-	Alamat         string         `gorm:"type:text" json:"alamat"`
-	RT             string         `json:"rt"`
-	RW             string         `json:"rw"`
-	KategoriRentan string         `gorm:"type:enum('Lansia','Disabilitas','Anak-anak','Ibu Hamil','Sakit Keras','Non-Rentan');not null" json:"kategori_rentan"`
-	SkorPrioritas  int            `json:"skor_prioritas"`
-	Latitude       float64        `gorm:"type:decimal(10,8)" json:"latitude"`
-	Longitude      float64        `gorm:"type:decimal(11,8)" json:"longitude"`
-	NoHP           string         `json:"no_hp"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                 uint                `gorm:"primarykey" json:"id"`
+	NIK                *string             `gorm:"unique" json:"nik"`
+	Nama               string              `gorm:"not null" json:"nama"`
+	TanggalLahir       *time.Time          `gorm:"type:date" json:"tanggal_lahir"`
+	JenisKelamin       string              `gorm:"size:1" json:"jenis_kelamin"`
+	Alamat             string              `gorm:"type:text" json:"alamat"`
+	RT                 string              `json:"rt"`
+	RW                 string              `json:"rw"`
+	KategoriRentan     string              `gorm:"type:enum('Lansia','Disabilitas','Anak-anak','Ibu Hamil','Sakit Keras','Non-Rentan');not null" json:"kategori_rentan"` // Kategori utama (skor tertinggi)
+	Kategori           []KategoriWarga     `gorm:"foreignKey:WargaID" json:"kategori"`
+	KebutuhanPerawatan *KebutuhanPerawatan `gorm:"foreignKey:WargaID" json:"kebutuhan_perawatan,omitempty"`
+	SkorPrioritas      int                 `json:"skor_prioritas"`
+	Latitude           float64             `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude          float64             `gorm:"type:decimal(11,8)" json:"longitude"`
+	NoHP               string              `json:"no_hp"`
+	KartuKeluargaID    *uint               `gorm:"index" json:"kartu_keluarga_id"`
+	KartuKeluarga      *KartuKeluarga      `gorm:"foreignKey:KartuKeluargaID" json:"kartu_keluarga,omitempty"`
+	Paparan            []PaparanWarga      `gorm:"foreignKey:WargaID" json:"paparan,omitempty"`     // Zona bahaya tempat rumah warga berada
+	Keselamatan        []StatusKeselamatan `gorm:"foreignKey:WargaID" json:"keselamatan,omitempty"` // Roster keselamatan, dimuat untuk satu bencana
+	CreatedAt          time.Time           `json:"created_at"`
+	UpdatedAt          time.Time           `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt      `gorm:"index" json:"-"`
 }
 
 // KejadianBencana model
 type KejadianBencana struct {
 	ID            uint           `gorm:"primarykey" json:"id"`
-	JenisBencana  string         `gorm:"not null" json:"jenis_bencana"`
+	JenisBencana  string         `gorm:"not null" json:"jenis_bencana"`   // Nama baku dari taksonomi JenisBencana
+	KodeJenis     string         `gorm:"size:16;index" json:"kode_jenis"` // Kode JenisBencana (kosong untuk data lama yang tidak dikenali)
 	Level         string         `gorm:"type:enum('Lokal_RT','Kecamatan');not null" json:"level"`
 	WaktuMulai    time.Time      `gorm:"not null" json:"waktu_mulai"`
 	WaktuSelesai  *time.Time     `json:"waktu_selesai"`
-	Status        string         `gorm:"type:enum('Aktif','Selesai');not null;default:'Aktif'" json:"status"`
+	Status        string         `gorm:"type:enum('Draft','Aktif','Selesai','Digabung');not null;default:'Aktif'" json:"status"` // Draft = dibuat otomatis oleh sensor, menunggu konfirmasi
 	UserPelaporID uint           `gorm:"not null" json:"user_pelapor_id"`
 	UserPelapor   User           `gorm:"foreignKey:UserPelaporID" json:"user_pelapor,omitempty"`
 	Deskripsi     string         `gorm:"type:text" json:"deskripsi"`
+	RT            string         `gorm:"size:8" json:"rt"` // Lokasi laporan pertama, dipakai deteksi laporan ganda
+	RW            string         `gorm:"size:8" json:"rw"`
+	Latitude      float64        `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude     float64        `gorm:"type:decimal(11,8)" json:"longitude"`
+	DigabungKeID  *uint          `gorm:"index" json:"digabung_ke_id"` // Diisi saat Status "Digabung"
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -57,17 +71,36 @@ type KejadianBencana struct {
 
 // LogEvakuasi model
 type LogEvakuasi struct {
-	ID            uint            `gorm:"primarykey" json:"id"`
-	BencanaID     uint            `gorm:"not null" json:"bencana_id"`
-	Bencana       KejadianBencana `gorm:"foreignKey:BencanaID" json:"bencana,omitempty"`
-	WargaID       uint            `gorm:"not null" json:"warga_id"`
-	Warga         WargaRentan     `gorm:"foreignKey:WargaID" json:"warga,omitempty"`
-	RelawanID     uint            `gorm:"not null" json:"relawan_id"`
-	Relawan       User            `gorm:"foreignKey:RelawanID" json:"relawan,omitempty"`
-	StatusTerkini string          `gorm:"type:enum('Menunggu','Dalam Proses','Teevakuasi','Di Titik Kumpul');not null" json:"status_terkini"`
-	WaktuUpdate   time.Time       `gorm:"not null" json:"waktu_update"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
+	ID              uint                    `gorm:"primarykey" json:"id"`
+	BencanaID       uint                    `gorm:"not null;uniqueIndex:idx_log_bencana_warga" json:"bencana_id"` // Satu log per warga per bencana
+	Bencana         KejadianBencana         `gorm:"foreignKey:BencanaID" json:"bencana,omitempty"`
+	WargaID         uint                    `gorm:"not null;uniqueIndex:idx_log_bencana_warga" json:"warga_id"`
+	Warga           WargaRentan             `gorm:"foreignKey:WargaID" json:"warga,omitempty"`
+	KartuKeluargaID *uint                   `gorm:"index" json:"kartu_keluarga_id"`
+	RelawanID       uint                    `gorm:"not null" json:"relawan_id"`
+	Relawan         User                    `gorm:"foreignKey:RelawanID" json:"relawan,omitempty"`
+	StatusTerkini   string                  `gorm:"type:enum('Menunggu','Dalam Proses','Terevakuasi','Di Titik Kumpul');not null" json:"status_terkini"`
+	TitikKumpulID   *uint                   `gorm:"index" json:"titik_kumpul_id"` // Diisi saat status "Di Titik Kumpul"
+	TitikKumpul     *TitikKumpul            `gorm:"foreignKey:TitikKumpulID" json:"titik_kumpul,omitempty"`
+	Catatan         string                  `gorm:"type:text" json:"catatan"`
+	Riwayat         []RiwayatStatusEvakuasi `gorm:"foreignKey:LogEvakuasiID" json:"riwayat,omitempty"`
+	WaktuUpdate     time.Time               `gorm:"not null" json:"waktu_update"`
+	CreatedAt       time.Time               `json:"created_at"`
+	UpdatedAt       time.Time               `json:"updated_at"`
+}
+
+// RiwayatStatusEvakuasi model (setiap perubahan status evakuasi, tidak pernah diubah)
+type RiwayatStatusEvakuasi struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	LogEvakuasiID uint      `gorm:"not null;index" json:"log_evakuasi_id"`
+	BencanaID     uint      `gorm:"not null;index" json:"bencana_id"`
+	WargaID       uint      `gorm:"not null;index" json:"warga_id"`
+	UserID        uint      `gorm:"not null" json:"user_id"`
+	User          User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	StatusDari    *string   `json:"status_dari"` // nil untuk status pertama
+	StatusKe      string    `gorm:"not null" json:"status_ke"`
+	Catatan       string    `gorm:"type:text" json:"catatan"`
+	Waktu         time.Time `gorm:"not null" json:"waktu"`
 }
 
 // SystemLog model
@@ -81,11 +114,13 @@ type SystemLog struct {
 
 // MasterKecamatan model
 type MasterKecamatan struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	Nama      string         `gorm:"not null" json:"nama"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          uint           `gorm:"primarykey" json:"id"`
+	Nama        string         `gorm:"not null" json:"nama"`
+	KodeWilayah string         `gorm:"size:16;index" json:"kode_wilayah"` // Kode Kemendagri, mis. 352601
+	Batas       string         `gorm:"type:longtext" json:"-"`            // GeoJSON Polygon/MultiPolygon, lihat /kecamatan/:id/wilayah
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // AdminKota model
@@ -137,22 +172,46 @@ type LoginResponse struct {
 
 // DTO for Create Warga
 type CreateWargaRequest struct {
-	NIK            string  `json:"nik" validate:"required"`
-	Nama           string  `json:"nama" validate:"required"`
-	Alamat         string  `json:"alamat"`
-	RT             string  `json:"rt"`
-	RW             string  `json:"rw"`
-	KategoriRentan string  `json:"kategori_rentan" validate:"required"`
-	Latitude       float64 `json:"latitude"`
-	Longitude      float64 `json:"longitude"`
-	NoHP           string  `json:"no_hp"`
+	NIK                string              `json:"nik"`
+	Nama               string              `json:"nama" validate:"required"`
+	Alamat             string              `json:"alamat"`
+	RT                 string              `json:"rt"`
+	RW                 string              `json:"rw"`
+	KategoriRentan     string              `json:"kategori_rentan"`
+	Kategori           []string            `json:"kategori"` // Boleh lebih dari satu; kategori_rentan dipakai bila kosong
+	KebutuhanPerawatan *KebutuhanPerawatan `json:"kebutuhan_perawatan"`
+	Latitude           float64             `json:"latitude"`
+	Longitude          float64             `json:"longitude"`
+	NoHP               string              `json:"no_hp"`
+	KartuKeluargaID    *uint               `json:"kartu_keluarga_id"`
+}
+
+// DTO for Create Log Evakuasi
+type CreateLogEvakuasiRequest struct {
+	BencanaID     uint   `json:"bencana_id" validate:"required"`
+	WargaID       uint   `json:"warga_id" validate:"required"`
+	StatusTerkini string `json:"status_terkini" validate:"required"`
+	Catatan       string `json:"catatan"`
+}
+
+// DTO for Update Log Evakuasi
+type UpdateLogEvakuasiRequest struct {
+	StatusTerkini string `json:"status_terkini" validate:"required"`
+	TitikKumpulID *uint  `json:"titik_kumpul_id"` // Wajib bila status_terkini "Di Titik Kumpul"
+	Catatan       string `json:"catatan"`
 }
 
 // DTO for Create Bencana
 type CreateBencanaRequest struct {
-	JenisBencana string `json:"jenis_bencana" validate:"required"`
-	Level        string `json:"level" validate:"required"`
-	Deskripsi    string `json:"deskripsi"`
+	JenisBencana string  `json:"jenis_bencana" validate:"required"`
+	Level        string  `json:"level" validate:"required"`
+	Deskripsi    string  `json:"deskripsi"`
+	RT           string  `json:"rt"`
+	RW           string  `json:"rw"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	GabungKe     *uint   `json:"gabung_ke"` // Bergabung ke bencana yang sudah dilaporkan, tidak membuat bencana baru
+	BuatBaru     bool    `json:"buat_baru"` // Tetap buat bencana baru meskipun ada laporan serupa
 }
 
 type RegisterRequest struct {
@@ -0,0 +1,77 @@
+// models/orang_hilang.go
+package models
+
+import "time"
+
+// LaporanOrangHilang model (laporan orang hilang selama bencana)
+type LaporanOrangHilang struct {
+	ID                    uint                 `gorm:"primarykey" json:"id"`
+	BencanaID             uint                 `gorm:"not null;index" json:"bencana_id"`
+	WargaID               *uint                `gorm:"index" json:"warga_id"` // Kosong bila orangnya tidak terdata
+	Warga                 *WargaRentan         `gorm:"foreignKey:WargaID" json:"warga,omitempty"`
+	Nama                  string               `gorm:"not null;index" json:"nama"`
+	NIK                   string               `gorm:"size:16" json:"nik"`
+	JenisKelamin          string               `gorm:"size:1" json:"jenis_kelamin"`
+	Umur                  *int                 `json:"umur"`
+	CiriCiri              string               `gorm:"type:text" json:"ciri_ciri"` // Pakaian, tinggi badan, tanda khusus
+	TerakhirTerlihatDi    string               `json:"terakhir_terlihat_di"`
+	TerakhirTerlihatWaktu *time.Time           `json:"terakhir_terlihat_waktu"`
+	TerakhirLatitude      float64              `gorm:"type:decimal(10,8)" json:"terakhir_latitude"`
+	TerakhirLongitude     float64              `gorm:"type:decimal(11,8)" json:"terakhir_longitude"`
+	PelaporNama           string               `gorm:"not null" json:"pelapor_nama"`
+	PelaporNoHP           string               `json:"pelapor_no_hp"`
+	PelaporHubungan       string               `json:"pelapor_hubungan"` // Mis. "Anak", "Tetangga"
+	DicatatOleh           uint                 `gorm:"not null" json:"dicatat_oleh"`
+	Status                string               `gorm:"type:enum('Hilang','Ditemukan');not null;default:'Hilang';index" json:"status"`
+	RegistrasiID          *uint                `json:"registrasi_id"` // Registrasi pengungsi tempat ditemukan
+	Registrasi            *RegistrasiPengungsi `gorm:"foreignKey:RegistrasiID" json:"registrasi,omitempty"`
+	KeteranganDitemukan   string               `gorm:"type:text" json:"keterangan_ditemukan"`
+	WaktuDitemukan        *time.Time           `json:"waktu_ditemukan"`
+	DitemukanOleh         *uint                `json:"ditemukan_oleh"`
+	CreatedAt             time.Time            `json:"created_at"`
+	UpdatedAt             time.Time            `json:"updated_at"`
+}
+
+// RekapOrangHilangKota model (agregasi jumlah orang hilang per bencana kecamatan di DB Kota)
+type RekapOrangHilangKota struct {
+	ID              uint            `gorm:"primarykey" json:"id"`
+	KecamatanID     uint            `gorm:"not null;uniqueIndex:idx_kecamatan_bencana_hilang" json:"kecamatan_id"`
+	Kecamatan       MasterKecamatan `gorm:"foreignKey:KecamatanID" json:"kecamatan,omitempty"`
+	BencanaID       uint            `gorm:"not null;uniqueIndex:idx_kecamatan_bencana_hilang" json:"bencana_id"` // ID di DB kecamatan
+	JumlahHilang    int             `json:"jumlah_hilang"`
+	JumlahDitemukan int             `json:"jumlah_ditemukan"`
+	LastSync        *time.Time      `json:"last_sync"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// DTO for filing a missing person report
+type CreateLaporanOrangHilangRequest struct {
+	BencanaID             uint       `json:"bencana_id" validate:"required"`
+	WargaID               *uint      `json:"warga_id"`
+	Nama                  string     `json:"nama"`
+	NIK                   string     `json:"nik"`
+	JenisKelamin          string     `json:"jenis_kelamin"`
+	Umur                  *int       `json:"umur"`
+	CiriCiri              string     `json:"ciri_ciri"`
+	TerakhirTerlihatDi    string     `json:"terakhir_terlihat_di"`
+	TerakhirTerlihatWaktu *time.Time `json:"terakhir_terlihat_waktu"`
+	TerakhirLatitude      float64    `json:"terakhir_latitude"`
+	TerakhirLongitude     float64    `json:"terakhir_longitude"`
+	PelaporNama           string     `json:"pelapor_nama" validate:"required"`
+	PelaporNoHP           string     `json:"pelapor_no_hp"`
+	PelaporHubungan       string     `json:"pelapor_hubungan"`
+}
+
+// DTO for marking a missing person as found
+type TandaiDitemukanRequest struct {
+	RegistrasiID *uint  `json:"registrasi_id"` // Isi bila ditemukan di titik kumpul
+	Keterangan   string `json:"keterangan"`
+}
+
+// OrangHilangEvent is the payload synced to the kota database
+type OrangHilangEvent struct {
+	BencanaID       uint `json:"bencana_id"`
+	JumlahHilang    int  `json:"jumlah_hilang"`
+	JumlahDitemukan int  `json:"jumlah_ditemukan"`
+}
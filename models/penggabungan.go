@@ -0,0 +1,61 @@
+// models/penggabungan.go
+package models
+
+import "time"
+
+// NotifikasiBencana model (setiap notifikasi yang dikirim untuk satu bencana)
+type NotifikasiBencana struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	BencanaID      uint      `gorm:"not null;index" json:"bencana_id"`
+	Jenis          string    `gorm:"type:enum('Laporan','Darurat');not null" json:"jenis"` // Laporan = otomatis saat bencana dilaporkan
+	Level          string    `gorm:"size:20" json:"level"`
+	Pesan          string    `gorm:"type:text;not null" json:"pesan"`
+	JumlahPenerima int       `json:"jumlah_penerima"`         // Nomor WhatsApp yang dikirimi
+	JumlahRespons  int       `gorm:"-" json:"jumlah_respons"` // Penerima yang sudah membalas (notifikasi Darurat)
+	DikirimOleh    *uint     `json:"dikirim_oleh"`
+	Waktu          time.Time `gorm:"not null" json:"waktu"`
+}
+
+// PenggabunganBencana model (jejak audit penggabungan laporan ganda, tidak pernah diubah)
+type PenggabunganBencana struct {
+	ID              uint           `gorm:"primarykey" json:"id"`
+	BencanaID       uint           `gorm:"not null;index" json:"bencana_id"`     // Bencana yang digabungkan (berstatus Digabung)
+	DigabungKeID    uint           `gorm:"not null;index" json:"digabung_ke_id"` // Bencana yang dipertahankan
+	JenisBencana    string         `json:"jenis_bencana"`
+	WaktuMulai      time.Time      `json:"waktu_mulai"`
+	Deskripsi       string         `gorm:"type:text" json:"deskripsi"` // Deskripsi bencana yang digabungkan
+	Alasan          string         `gorm:"type:text" json:"alasan"`
+	Rincian         map[string]int `gorm:"type:text;serializer:json" json:"rincian"` // Jumlah data yang dipindahkan per jenis
+	DigabungkanOleh uint           `gorm:"not null" json:"digabungkan_oleh"`
+	Pengguna        *User          `gorm:"foreignKey:DigabungkanOleh" json:"pengguna,omitempty"`
+	Waktu           time.Time      `gorm:"not null" json:"waktu"`
+}
+
+// PenggabunganBencanaKota model (penggabungan bencana di semua kecamatan, di DB Kota)
+type PenggabunganBencanaKota struct {
+	ID           uint            `gorm:"primarykey" json:"id"`
+	KecamatanID  uint            `gorm:"not null;index" json:"kecamatan_id"`
+	Kecamatan    MasterKecamatan `gorm:"foreignKey:KecamatanID" json:"kecamatan"`
+	BencanaID    uint            `gorm:"not null" json:"bencana_id"`     // ID di DB kecamatan
+	DigabungKeID uint            `gorm:"not null" json:"digabung_ke_id"` // ID di DB kecamatan
+	JenisBencana string          `json:"jenis_bencana"`
+	Alasan       string          `gorm:"type:text" json:"alasan"`
+	Waktu        time.Time       `json:"waktu"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// BencanaDigabungEvent is sent to the kota when two bencana of a kecamatan are merged
+type BencanaDigabungEvent struct {
+	BencanaID    uint      `json:"bencana_id"`
+	DigabungKeID uint      `json:"digabung_ke_id"`
+	JenisBencana string    `json:"jenis_bencana"`
+	Alasan       string    `json:"alasan"`
+	KurangiTotal bool      `json:"kurangi_total"` // Keduanya sudah dikirim ke Kota sebagai bencana Aktif
+	Waktu        time.Time `json:"waktu"`
+}
+
+// DTO for merging a bencana into another
+type GabungBencanaRequest struct {
+	Ke     uint   `json:"ke" validate:"required"` // Bencana yang dipertahankan
+	Alasan string `json:"alasan"`
+}
@@ -0,0 +1,61 @@
+// models/pengungsi.go
+package models
+
+import "time"
+
+// RegistrasiPengungsi model (buku tamu titik kumpul: masuk, pindah, keluar).
+// Satu baris per kedatangan di satu titik kumpul; pindah menutup baris lama
+// dan membuat baris baru di titik kumpul tujuan.
+type RegistrasiPengungsi struct {
+	ID              uint         `gorm:"primarykey" json:"id"`
+	TitikKumpulID   uint         `gorm:"not null;index" json:"titik_kumpul_id"`
+	TitikKumpul     *TitikKumpul `gorm:"foreignKey:TitikKumpulID" json:"titik_kumpul,omitempty"`
+	BencanaID       uint         `gorm:"not null;index" json:"bencana_id"`
+	WargaID         *uint        `gorm:"index" json:"warga_id"` // Kosong untuk pengungsi yang tidak terdata
+	Warga           *WargaRentan `gorm:"foreignKey:WargaID" json:"warga,omitempty"`
+	KartuKeluargaID *uint        `gorm:"index" json:"kartu_keluarga_id"`
+	Nama            string       `gorm:"not null;index" json:"nama"`
+	NIK             string       `gorm:"size:16;index" json:"nik"`
+	JenisKelamin    string       `gorm:"size:1" json:"jenis_kelamin"`
+	Umur            *int         `json:"umur"`
+	Alamat          string       `gorm:"type:text" json:"alamat"`
+	NoHP            string       `json:"no_hp"`
+	Status          string       `gorm:"type:enum('Di Lokasi','Pindah','Keluar');not null;default:'Di Lokasi';index" json:"status"`
+	WaktuMasuk      time.Time    `gorm:"not null" json:"waktu_masuk"`
+	WaktuKeluar     *time.Time   `json:"waktu_keluar"`
+	PindahDariID    *uint        `json:"pindah_dari_id"` // Registrasi sebelumnya bila datang karena pindah
+	PindahKeID      *uint        `json:"pindah_ke_id"`   // Registrasi di titik kumpul tujuan
+	TujuanKeluar    string       `json:"tujuan_keluar"`  // Mis. "Pulang ke rumah", "RSUD Bangkalan"
+	Catatan         string       `gorm:"type:text" json:"catatan"`
+	PetugasMasukID  uint         `gorm:"not null" json:"petugas_masuk_id"`
+	PetugasKeluarID *uint        `json:"petugas_keluar_id"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+// DTO for shelter check-in. Isi salah satu: qr (hasil scan), warga_id,
+// atau data diri untuk pengungsi yang tidak terdata.
+type CheckInPengungsiRequest struct {
+	BencanaID    uint   `json:"bencana_id" validate:"required"`
+	QR           string `json:"qr"`
+	WargaID      *uint  `json:"warga_id"`
+	Nama         string `json:"nama"`
+	NIK          string `json:"nik"`
+	JenisKelamin string `json:"jenis_kelamin"`
+	Umur         *int   `json:"umur"`
+	Alamat       string `json:"alamat"`
+	NoHP         string `json:"no_hp"`
+	Catatan      string `json:"catatan"`
+}
+
+// DTO for moving an evacuee to another shelter
+type PindahPengungsiRequest struct {
+	TitikKumpulID uint   `json:"titik_kumpul_id" validate:"required"`
+	Catatan       string `json:"catatan"`
+}
+
+// DTO for shelter check-out
+type CheckOutPengungsiRequest struct {
+	TujuanKeluar string `json:"tujuan_keluar"`
+	Catatan      string `json:"catatan"`
+}
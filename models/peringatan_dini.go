@@ -0,0 +1,104 @@
+// models/peringatan_dini.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AreaPeringatan is one affected area of a warning (CAP <area> atau hasil konversi feed JSON)
+type AreaPeringatan struct {
+	Nama      string             `json:"nama"`
+	Kode      []string           `json:"kode,omitempty"`      // Geocode wilayah (kode Kemendagri)
+	Poligon   [][][2]float64     `json:"poligon,omitempty"`   // Tiap poligon berisi titik [lat, lng] seperti CAP
+	Lingkaran []LingkaranWilayah `json:"lingkaran,omitempty"` // Mis. radius guncangan gempa
+}
+
+// LingkaranWilayah is a circular area (CAP <circle>)
+type LingkaranWilayah struct {
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+	RadiusKm float64 `json:"radius_km"`
+}
+
+// PeringatanDini model (peringatan cuaca / gempa resmi yang diimpor API Kota)
+type PeringatanDini struct {
+	ID              uint              `gorm:"primarykey" json:"id"`
+	Sumber          string            `gorm:"size:64;not null;uniqueIndex:idx_sumber_identifier" json:"sumber"` // Nama feed, mis. bmkg-gempa
+	Identifier      string            `gorm:"size:191;not null;uniqueIndex:idx_sumber_identifier" json:"identifier"`
+	Pengirim        string            `json:"pengirim"`
+	StatusCAP       string            `gorm:"size:16;default:'Actual'" json:"status_cap"` // Actual, Exercise, Test, ...
+	JenisPesan      string            `gorm:"type:enum('Alert','Update','Cancel');not null;default:'Alert'" json:"jenis_pesan"`
+	Referensi       string            `gorm:"type:text" json:"referensi"`  // Identifier yang diperbarui / dibatalkan
+	Kategori        string            `json:"kategori"`                    // Met, Geo, ...
+	Jenis           string            `gorm:"not null;index" json:"jenis"` // Mis. Hujan Lebat, Gempabumi
+	Judul           string            `json:"judul"`
+	Deskripsi       string            `gorm:"type:text" json:"deskripsi"`
+	Instruksi       string            `gorm:"type:text" json:"instruksi"`
+	Keparahan       string            `gorm:"size:16" json:"keparahan"` // Extreme, Severe, Moderate, Minor, Unknown
+	Urgensi         string            `gorm:"size:16" json:"urgensi"`
+	Kepastian       string            `gorm:"size:16" json:"kepastian"`
+	Tingkat         string            `gorm:"size:10" json:"tingkat"` // Waspada, Siaga, Awas
+	Magnitudo       *float64          `gorm:"type:decimal(4,1)" json:"magnitudo"`
+	KedalamanKm     *float64          `gorm:"type:decimal(7,1)" json:"kedalaman_km"`
+	Latitude        float64           `gorm:"type:decimal(10,8)" json:"latitude"` // Episenter / pusat peringatan
+	Longitude       float64           `gorm:"type:decimal(11,8)" json:"longitude"`
+	Area            []AreaPeringatan  `gorm:"type:longtext;serializer:json" json:"area"`
+	WaktuKirim      time.Time         `gorm:"not null;index" json:"waktu_kirim"`
+	BerlakuMulai    *time.Time        `json:"berlaku_mulai"`
+	BerlakuSampai   *time.Time        `json:"berlaku_sampai"`
+	Status          string            `gorm:"type:enum('Aktif','Diperbarui','Dibatalkan');not null;default:'Aktif';index" json:"status"`
+	Target          []MasterKecamatan `gorm:"many2many:peringatan_dini_kecamatan" json:"target"`
+	WaktuDiteruskan *time.Time        `json:"waktu_diteruskan"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// PeringatanDiniKecamatan model (salinan lokal peringatan resmi yang diteruskan Kota)
+type PeringatanDiniKecamatan struct {
+	ID               uint       `gorm:"primarykey" json:"id"`
+	PeringatanKotaID uint       `gorm:"not null;uniqueIndex" json:"peringatan_kota_id"`
+	Jenis            string     `gorm:"not null;index" json:"jenis"`
+	Judul            string     `json:"judul"`
+	Deskripsi        string     `gorm:"type:text" json:"deskripsi"`
+	Instruksi        string     `gorm:"type:text" json:"instruksi"`
+	Keparahan        string     `json:"keparahan"`
+	Tingkat          string     `gorm:"size:10" json:"tingkat"`
+	Magnitudo        *float64   `gorm:"type:decimal(4,1)" json:"magnitudo"`
+	Latitude         float64    `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude        float64    `gorm:"type:decimal(11,8)" json:"longitude"`
+	Wilayah          string     `gorm:"type:text" json:"wilayah"` // Area peringatan yang mengenai kecamatan ini
+	BerlakuMulai     *time.Time `json:"berlaku_mulai"`
+	BerlakuSampai    *time.Time `json:"berlaku_sampai"`
+	Status           string     `gorm:"type:enum('Aktif','Diperbarui','Dibatalkan');not null;default:'Aktif';index" json:"status"`
+	WaktuKirim       time.Time  `json:"waktu_kirim"`
+	WaktuDiterima    time.Time  `json:"waktu_diterima"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// PeringatanDiniEvent is the payload Kota sends to an affected kecamatan
+type PeringatanDiniEvent struct {
+	PeringatanKotaID uint       `json:"peringatan_kota_id"`
+	Jenis            string     `json:"jenis"`
+	Judul            string     `json:"judul"`
+	Deskripsi        string     `json:"deskripsi"`
+	Instruksi        string     `json:"instruksi"`
+	Keparahan        string     `json:"keparahan"`
+	Tingkat          string     `json:"tingkat"`
+	Magnitudo        *float64   `json:"magnitudo"`
+	Latitude         float64    `json:"latitude"`
+	Longitude        float64    `json:"longitude"`
+	Wilayah          []string   `json:"wilayah"`
+	BerlakuMulai     *time.Time `json:"berlaku_mulai"`
+	BerlakuSampai    *time.Time `json:"berlaku_sampai"`
+	Status           string     `json:"status"`       // Aktif, atau Dibatalkan untuk pembatalan
+	Menggantikan     []uint     `json:"menggantikan"` // ID Kota peringatan yang diperbarui / dibatalkan
+	WaktuKirim       time.Time  `json:"waktu_kirim"`
+}
+
+// DTO for setting the area code and boundary of a kecamatan
+type UpdateWilayahKecamatanRequest struct {
+	KodeWilayah string          `json:"kode_wilayah"`
+	Batas       json.RawMessage `json:"batas"` // GeoJSON Polygon / MultiPolygon / Feature
+}
@@ -0,0 +1,115 @@
+// models/relawan.go
+package models
+
+import "time"
+
+// ProfilRelawan model (profil kesiapan satu user ber-role Relawan)
+type ProfilRelawan struct {
+	ID             uint               `gorm:"primarykey" json:"id"`
+	UserID         uint               `gorm:"not null;uniqueIndex" json:"user_id"`
+	User           User               `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Keahlian       []KeahlianRelawan  `gorm:"foreignKey:ProfilID;constraint:OnDelete:CASCADE" json:"keahlian"`
+	Peralatan      []PeralatanRelawan `gorm:"foreignKey:ProfilID;constraint:OnDelete:CASCADE" json:"peralatan"`
+	Jadwal         []JadwalRelawan    `gorm:"foreignKey:ProfilID;constraint:OnDelete:CASCADE" json:"jadwal"`
+	SedangBertugas bool               `gorm:"default:false;index" json:"sedang_bertugas"`
+	ShiftAktifID   *uint              `json:"shift_aktif_id"`
+	Latitude       float64            `gorm:"type:decimal(10,8)" json:"latitude"` // Lokasi terakhir yang diketahui
+	Longitude      float64            `gorm:"type:decimal(11,8)" json:"longitude"`
+	WaktuLokasi    *time.Time         `json:"waktu_lokasi"`
+	Catatan        string             `gorm:"type:text" json:"catatan"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// KeahlianRelawan model (satu keahlian relawan, mis. "Operator Perahu")
+type KeahlianRelawan struct {
+	ID       uint   `gorm:"primarykey" json:"id"`
+	ProfilID uint   `gorm:"not null;uniqueIndex:idx_profil_keahlian" json:"profil_id"`
+	Keahlian string `gorm:"size:50;not null;uniqueIndex:idx_profil_keahlian;index" json:"keahlian"`
+}
+
+// PeralatanRelawan model (peralatan pribadi yang dibawa relawan)
+type PeralatanRelawan struct {
+	ID       uint   `gorm:"primarykey" json:"id"`
+	ProfilID uint   `gorm:"not null;index" json:"profil_id"`
+	Nama     string `gorm:"not null" json:"nama"`
+	Jumlah   int    `gorm:"default:1" json:"jumlah"`
+}
+
+// JadwalRelawan model (slot ketersediaan mingguan; jam_selesai < jam_mulai berarti lewat tengah malam)
+type JadwalRelawan struct {
+	ID         uint   `gorm:"primarykey" json:"id"`
+	ProfilID   uint   `gorm:"not null;index" json:"profil_id"`
+	Hari       int    `gorm:"not null" json:"hari"` // 0 = Minggu ... 6 = Sabtu
+	JamMulai   string `gorm:"size:5;not null" json:"jam_mulai"`
+	JamSelesai string `gorm:"size:5;not null" json:"jam_selesai"`
+}
+
+// ShiftRelawan model (riwayat check-in / check-out tugas relawan)
+type ShiftRelawan struct {
+	ID             uint             `gorm:"primarykey" json:"id"`
+	RelawanID      uint             `gorm:"not null;index" json:"relawan_id"`
+	Relawan        User             `gorm:"foreignKey:RelawanID" json:"relawan,omitempty"`
+	BencanaID      *uint            `gorm:"index" json:"bencana_id"`
+	Bencana        *KejadianBencana `gorm:"foreignKey:BencanaID" json:"bencana,omitempty"`
+	WaktuMulai     time.Time        `gorm:"not null" json:"waktu_mulai"`
+	WaktuSelesai   *time.Time       `json:"waktu_selesai"`
+	LatitudeMulai  float64          `gorm:"type:decimal(10,8)" json:"latitude_mulai"`
+	LongitudeMulai float64          `gorm:"type:decimal(11,8)" json:"longitude_mulai"`
+	AlasanSelesai  string           `json:"alasan_selesai"` // Check-out, Bencana Selesai, Melewati Batas Shift
+	Catatan        string           `gorm:"type:text" json:"catatan"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// LokasiRelawan model (satu ping GPS relawan selama shift, dihapus setelah masa retensi)
+type LokasiRelawan struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	RelawanID uint      `gorm:"not null;index:idx_lokasi_relawan_waktu" json:"relawan_id"`
+	ShiftID   uint      `gorm:"not null;index" json:"shift_id"`
+	BencanaID *uint     `gorm:"index" json:"bencana_id"`
+	TugasID   *uint     `gorm:"index" json:"tugas_id"` // Tugas evakuasi yang sedang dikerjakan
+	Latitude  float64   `gorm:"type:decimal(10,8);not null" json:"latitude"`
+	Longitude float64   `gorm:"type:decimal(11,8);not null" json:"longitude"`
+	AkurasiM  float64   `json:"akurasi_m"`
+	Waktu     time.Time `gorm:"not null;index:idx_lokasi_relawan_waktu;index" json:"waktu"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DTO for updating a relawan profile (field kosong tidak diubah, slice diganti seluruhnya)
+type UpdateProfilRelawanRequest struct {
+	Keahlian  *[]string                  `json:"keahlian"`
+	Peralatan *[]PeralatanRelawanRequest `json:"peralatan"`
+	Jadwal    *[]JadwalRelawanRequest    `json:"jadwal"`
+	Catatan   *string                    `json:"catatan"`
+}
+
+// PeralatanRelawanRequest is one equipment item in a profile update
+type PeralatanRelawanRequest struct {
+	Nama   string `json:"nama"`
+	Jumlah int    `json:"jumlah"`
+}
+
+// JadwalRelawanRequest is one weekly availability slot in a profile update
+type JadwalRelawanRequest struct {
+	Hari       int    `json:"hari"`
+	JamMulai   string `json:"jam_mulai"`
+	JamSelesai string `json:"jam_selesai"`
+}
+
+// DTO for shift check-in
+type CheckInShiftRequest struct {
+	BencanaID *uint   `json:"bencana_id"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Catatan   string  `json:"catatan"`
+}
+
+// DTO for a GPS ping during a shift
+type PingLokasiRequest struct {
+	Latitude  float64    `json:"latitude" validate:"required"`
+	Longitude float64    `json:"longitude" validate:"required"`
+	AkurasiM  float64    `json:"akurasi_m"`
+	Waktu     *time.Time `json:"waktu"`    // Waktu di perangkat; kosong = waktu server
+	TugasID   *uint      `json:"tugas_id"` // Kosong = tugas yang sedang diterima relawan (jika ada)
+}
@@ -0,0 +1,32 @@
+// models/riwayat.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RiwayatWarga model (satu baris per versi data WargaRentan)
+type RiwayatWarga struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	WargaID   uint            `gorm:"not null;uniqueIndex:idx_warga_versi" json:"warga_id"`
+	Versi     int             `gorm:"not null;uniqueIndex:idx_warga_versi" json:"versi"`
+	Aksi      string          `gorm:"type:enum('Create','Update','Delete','Restore','Rollback');not null" json:"aksi"`
+	UserID    uint            `gorm:"not null" json:"user_id"`
+	User      User            `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Perubahan json.RawMessage `gorm:"type:json;not null" json:"perubahan"` // []PerubahanField
+	Snapshot  json.RawMessage `gorm:"type:json;not null" json:"snapshot"`  // Data lengkap setelah perubahan
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// PerubahanField mencatat perubahan satu field
+type PerubahanField struct {
+	Field string      `json:"field"`
+	Dari  interface{} `json:"dari"`
+	Ke    interface{} `json:"ke"`
+}
+
+// DTO for Rollback Warga
+type RollbackWargaRequest struct {
+	Versi int `json:"versi" validate:"required"`
+}
@@ -0,0 +1,135 @@
+// models/sensor.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Sensor model (pos duga air, penakar hujan, dan sensor peringatan dini lainnya)
+type Sensor struct {
+	ID                  uint                 `gorm:"primarykey" json:"id"`
+	Kode                string               `gorm:"size:64;uniqueIndex;not null" json:"kode"` // ID perangkat, juga dipakai di topic MQTT
+	Nama                string               `gorm:"not null" json:"nama"`
+	Jenis               string               `gorm:"type:enum('Tinggi Muka Air','Curah Hujan','Kelembapan Tanah','Lainnya');not null" json:"jenis"`
+	Satuan              string               `gorm:"size:16" json:"satuan"` // cm, mm/jam, dst.
+	Lokasi              string               `json:"lokasi"`
+	Latitude            float64              `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude           float64              `gorm:"type:decimal(11,8)" json:"longitude"`
+	JenisBencana        string               `gorm:"not null;default:'Banjir'" json:"jenis_bencana"` // Untuk draft bencana otomatis
+	TokenHash           string               `gorm:"size:64;index" json:"-"`
+	Aktif               bool                 `gorm:"default:true" json:"aktif"`
+	BacaanTerakhir      *float64             `json:"bacaan_terakhir"`
+	WaktuBacaanTerakhir *time.Time           `json:"waktu_bacaan_terakhir"`
+	Aturan              []AturanAmbangSensor `gorm:"foreignKey:SensorID" json:"aturan,omitempty"`
+	CreatedAt           time.Time            `json:"created_at"`
+	UpdatedAt           time.Time            `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt       `gorm:"index" json:"-"`
+}
+
+// BacaanSensor model (deret waktu nilai sensor)
+type BacaanSensor struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	SensorID  uint      `gorm:"not null;index:idx_sensor_waktu" json:"sensor_id"`
+	Nilai     float64   `gorm:"type:decimal(12,3);not null" json:"nilai"`
+	Waktu     time.Time `gorm:"not null;index:idx_sensor_waktu" json:"waktu"`
+	Sumber    string    `gorm:"type:enum('HTTP','MQTT');not null" json:"sumber"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AturanAmbangSensor model (aturan ambang batas yang memicu peringatan)
+type AturanAmbangSensor struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	SensorID     uint      `gorm:"not null;index" json:"sensor_id"`
+	Nama         string    `json:"nama"`
+	Operator     string    `gorm:"size:2;not null" json:"operator"` // >, >=, <, <=
+	Ambang       float64   `gorm:"type:decimal(12,3);not null" json:"ambang"`
+	JumlahBacaan int       `gorm:"not null;default:1" json:"jumlah_bacaan"` // Bacaan berturut-turut yang harus melewati ambang
+	Tingkat      string    `gorm:"type:enum('Waspada','Siaga','Awas');not null" json:"tingkat"`
+	JedaMenit    int       `gorm:"not null;default:60" json:"jeda_menit"` // Jeda sebelum aturan yang sama boleh memicu lagi
+	Aktif        bool      `gorm:"default:true" json:"aktif"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PeringatanSensor model (ambang terlewati, menunggu konfirmasi Admin_Kecamatan)
+type PeringatanSensor struct {
+	ID              uint               `gorm:"primarykey" json:"id"`
+	SensorID        uint               `gorm:"not null;index" json:"sensor_id"`
+	Sensor          Sensor             `gorm:"foreignKey:SensorID" json:"sensor,omitempty"`
+	AturanID        uint               `gorm:"not null;index" json:"aturan_id"`
+	Aturan          AturanAmbangSensor `gorm:"foreignKey:AturanID" json:"aturan,omitempty"`
+	BacaanID        uint               `gorm:"not null" json:"bacaan_id"`
+	Nilai           float64            `gorm:"type:decimal(12,3)" json:"nilai"`
+	Tingkat         string             `json:"tingkat"`
+	BencanaID       *uint              `gorm:"index" json:"bencana_id"` // Draft bencana yang dibuat otomatis
+	Bencana         *KejadianBencana   `gorm:"foreignKey:BencanaID" json:"bencana,omitempty"`
+	Status          string             `gorm:"type:enum('Menunggu','Dikonfirmasi','Ditolak');not null;default:'Menunggu';index" json:"status"`
+	DiputuskanOleh  *uint              `json:"diputuskan_oleh"`
+	WaktuDiputuskan *time.Time         `json:"waktu_diputuskan"`
+	Catatan         string             `gorm:"type:text" json:"catatan"`
+	Waktu           time.Time          `gorm:"not null" json:"waktu"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+}
+
+// DTO for registering a sensor
+type CreateSensorRequest struct {
+	Kode         string  `json:"kode" validate:"required"`
+	Nama         string  `json:"nama" validate:"required"`
+	Jenis        string  `json:"jenis" validate:"required"`
+	Satuan       string  `json:"satuan"`
+	Lokasi       string  `json:"lokasi"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	JenisBencana string  `json:"jenis_bencana"`
+}
+
+// DTO for updating a sensor (kode tidak bisa diubah)
+type UpdateSensorRequest struct {
+	Nama         string  `json:"nama"`
+	Jenis        string  `json:"jenis"`
+	Satuan       string  `json:"satuan"`
+	Lokasi       string  `json:"lokasi"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	JenisBencana string  `json:"jenis_bencana"`
+	Aktif        *bool   `json:"aktif"`
+}
+
+// BacaanSensorRequest is one reading sent by a sensor
+type BacaanSensorRequest struct {
+	Nilai *float64   `json:"nilai"`
+	Waktu *time.Time `json:"waktu"` // Kosong = waktu server
+}
+
+// DTO for HTTP ingestion: satu bacaan (nilai, waktu) atau beberapa sekaligus (bacaan)
+type IngestBacaanRequest struct {
+	BacaanSensorRequest
+	Bacaan []BacaanSensorRequest `json:"bacaan"`
+}
+
+// MQTTBacaanPayload is the JSON payload published to sensor/<kode>/bacaan
+type MQTTBacaanPayload struct {
+	Token string     `json:"token"`
+	Nilai *float64   `json:"nilai"`
+	Waktu *time.Time `json:"waktu"`
+}
+
+// DTO for creating / updating a threshold rule
+type AturanAmbangRequest struct {
+	Nama         string  `json:"nama"`
+	Operator     string  `json:"operator" validate:"required"`
+	Ambang       float64 `json:"ambang" validate:"required"`
+	JumlahBacaan int     `json:"jumlah_bacaan"`
+	Tingkat      string  `json:"tingkat" validate:"required"`
+	JedaMenit    int     `json:"jeda_menit"`
+	Aktif        *bool   `json:"aktif"`
+}
+
+// DTO for confirming or rejecting a sensor alert
+type KeputusanPeringatanRequest struct {
+	Level   string `json:"level"` // Lokal_RT / Kecamatan saat konfirmasi (default Kecamatan)
+	Catatan string `json:"catatan"`
+}
@@ -0,0 +1,198 @@
+// models/sumber_daya.go
+package models
+
+import "time"
+
+// PermintaanSumberDaya model (permintaan bantuan kecamatan ini ke Kota / kecamatan lain)
+type PermintaanSumberDaya struct {
+	ID                   uint       `gorm:"primarykey" json:"id"`
+	BencanaID            *uint      `gorm:"index" json:"bencana_id"`
+	Jenis                string     `gorm:"type:enum('Perahu','Relawan','Logistik','Kendaraan','Medis','Alat Berat','Lainnya');not null" json:"jenis"`
+	Deskripsi            string     `gorm:"type:text;not null" json:"deskripsi"`
+	BarangKode           string     `gorm:"size:32" json:"barang_kode"` // Kode katalog logistik bila jenis Logistik
+	Jumlah               float64    `gorm:"type:decimal(12,2);not null" json:"jumlah"`
+	Satuan               string     `json:"satuan"`
+	Urgensi              string     `gorm:"type:enum('Normal','Mendesak','Kritis');not null;default:'Normal'" json:"urgensi"`
+	LokasiTujuan         string     `json:"lokasi_tujuan"`
+	Latitude             float64    `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude            float64    `gorm:"type:decimal(11,8)" json:"longitude"`
+	Status               string     `gorm:"type:enum('Diajukan','Disetujui','Ditolak','Dikirim','Diterima','Dibatalkan');not null;default:'Diajukan';index" json:"status"`
+	PermintaanKotaID     *uint      `json:"permintaan_kota_id"`   // ID di DB Kota, terisi setelah diputuskan BPBD
+	KecamatanPemasokID   *uint      `json:"kecamatan_pemasok_id"` // Kosong bila dipasok langsung oleh BPBD
+	KecamatanPemasokNama string     `json:"kecamatan_pemasok_nama"`
+	CatatanKota          string     `gorm:"type:text" json:"catatan_kota"`
+	PembatalanDiminta    *time.Time `json:"pembatalan_diminta"` // Pembatalan dikirim ke Kota, menunggu konfirmasi
+	DiajukanOleh         uint       `gorm:"not null" json:"diajukan_oleh"`
+	WaktuDiputuskan      *time.Time `json:"waktu_diputuskan"`
+	WaktuDikirim         *time.Time `json:"waktu_dikirim"`
+	WaktuDiterima        *time.Time `json:"waktu_diterima"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// PasokanSumberDaya model (permintaan kecamatan lain yang ditugaskan BPBD untuk dipasok kecamatan ini)
+type PasokanSumberDaya struct {
+	ID                   uint       `gorm:"primarykey" json:"id"`
+	PermintaanKotaID     uint       `gorm:"not null;uniqueIndex" json:"permintaan_kota_id"`
+	KecamatanPemintaID   uint       `gorm:"not null" json:"kecamatan_peminta_id"`
+	KecamatanPemintaNama string     `json:"kecamatan_peminta_nama"`
+	Jenis                string     `gorm:"not null" json:"jenis"`
+	Deskripsi            string     `gorm:"type:text" json:"deskripsi"`
+	BarangKode           string     `gorm:"size:32" json:"barang_kode"`
+	Jumlah               float64    `gorm:"type:decimal(12,2)" json:"jumlah"`
+	Satuan               string     `json:"satuan"`
+	Urgensi              string     `json:"urgensi"`
+	LokasiTujuan         string     `json:"lokasi_tujuan"`
+	Latitude             float64    `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude            float64    `gorm:"type:decimal(11,8)" json:"longitude"`
+	CatatanKota          string     `gorm:"type:text" json:"catatan_kota"`
+	Status               string     `gorm:"type:enum('Disetujui','Dikirim','Diterima','Dibatalkan');not null;default:'Disetujui';index" json:"status"`
+	GudangID             *uint      `json:"gudang_id"` // Gudang asal bila barang logistik dikeluarkan dari stok
+	DikirimOleh          *uint      `json:"dikirim_oleh"`
+	CatatanPengiriman    string     `gorm:"type:text" json:"catatan_pengiriman"`
+	WaktuDikirim         *time.Time `json:"waktu_dikirim"`
+	WaktuDiterima        *time.Time `json:"waktu_diterima"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// PermintaanSumberDayaKota model (semua permintaan antar kecamatan di DB Kota)
+type PermintaanSumberDayaKota struct {
+	ID                 uint             `gorm:"primarykey" json:"id"`
+	KecamatanID        uint             `gorm:"not null;uniqueIndex:idx_kecamatan_permintaan" json:"kecamatan_id"` // Kecamatan peminta
+	Kecamatan          MasterKecamatan  `gorm:"foreignKey:KecamatanID" json:"kecamatan,omitempty"`
+	PermintaanID       uint             `gorm:"not null;uniqueIndex:idx_kecamatan_permintaan" json:"permintaan_id"` // ID di DB kecamatan peminta
+	BencanaID          *uint            `json:"bencana_id"`
+	Jenis              string           `gorm:"not null;index" json:"jenis"`
+	Deskripsi          string           `gorm:"type:text" json:"deskripsi"`
+	BarangKode         string           `gorm:"size:32" json:"barang_kode"`
+	Jumlah             float64          `gorm:"type:decimal(12,2)" json:"jumlah"`
+	Satuan             string           `json:"satuan"`
+	Urgensi            string           `gorm:"index" json:"urgensi"`
+	LokasiTujuan       string           `json:"lokasi_tujuan"`
+	Latitude           float64          `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude          float64          `gorm:"type:decimal(11,8)" json:"longitude"`
+	Status             string           `gorm:"type:enum('Diajukan','Disetujui','Ditolak','Dikirim','Diterima','Dibatalkan');not null;default:'Diajukan';index" json:"status"`
+	KecamatanPemasokID *uint            `gorm:"index" json:"kecamatan_pemasok_id"` // Kosong = dipasok langsung BPBD
+	KecamatanPemasok   *MasterKecamatan `gorm:"foreignKey:KecamatanPemasokID" json:"kecamatan_pemasok,omitempty"`
+	CatatanKota        string           `gorm:"type:text" json:"catatan_kota"`
+	DiputuskanOleh     *uint            `json:"diputuskan_oleh"`
+	WaktuDiajukan      time.Time        `json:"waktu_diajukan"`
+	WaktuDiputuskan    *time.Time       `json:"waktu_diputuskan"`
+	WaktuDikirim       *time.Time       `json:"waktu_dikirim"`
+	WaktuDiterima      *time.Time       `json:"waktu_diterima"`
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+}
+
+// DTO for raising a resource request
+type CreatePermintaanSumberDayaRequest struct {
+	BencanaID    *uint   `json:"bencana_id"`
+	Jenis        string  `json:"jenis" validate:"required"`
+	Deskripsi    string  `json:"deskripsi" validate:"required"`
+	BarangKode   string  `json:"barang_kode"`
+	Jumlah       float64 `json:"jumlah" validate:"required"`
+	Satuan       string  `json:"satuan"`
+	Urgensi      string  `json:"urgensi"`
+	LokasiTujuan string  `json:"lokasi_tujuan"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+}
+
+// DTO for BPBD's decision on a resource request
+type KeputusanPermintaanRequest struct {
+	KecamatanPemasokID *uint  `json:"kecamatan_pemasok_id"` // Kosong = BPBD memasok sendiri
+	Catatan            string `json:"catatan"`
+}
+
+// DTO for a supplier marking goods as sent
+type KirimPasokanRequest struct {
+	GudangID *uint  `json:"gudang_id"` // Isi untuk mengeluarkan barang logistik dari stok gudang
+	Catatan  string `json:"catatan"`
+}
+
+// PermintaanSumberDayaEvent is the payload of a new request sent to the kota database
+type PermintaanSumberDayaEvent struct {
+	PermintaanID  uint      `json:"permintaan_id"`
+	BencanaID     *uint     `json:"bencana_id"`
+	Jenis         string    `json:"jenis"`
+	Deskripsi     string    `json:"deskripsi"`
+	BarangKode    string    `json:"barang_kode"`
+	Jumlah        float64   `json:"jumlah"`
+	Satuan        string    `json:"satuan"`
+	Urgensi       string    `json:"urgensi"`
+	LokasiTujuan  string    `json:"lokasi_tujuan"`
+	Latitude      float64   `json:"latitude"`
+	Longitude     float64   `json:"longitude"`
+	WaktuDiajukan time.Time `json:"waktu_diajukan"`
+}
+
+// StatusPermintaanEvent carries a status change in both directions. Kecamatan peminta
+// memakai permintaan_id, kecamatan pemasok memakai permintaan_kota_id.
+type StatusPermintaanEvent struct {
+	PermintaanID         uint      `json:"permintaan_id"`
+	PermintaanKotaID     uint      `json:"permintaan_kota_id"`
+	Status               string    `json:"status"`
+	KecamatanPemasokID   *uint     `json:"kecamatan_pemasok_id"`
+	KecamatanPemasokNama string    `json:"kecamatan_pemasok_nama"`
+	Catatan              string    `json:"catatan"`
+	Waktu                time.Time `json:"waktu"`
+	// JawabanPembatalan marks Kota's answer to a cancellation sent by the requester
+	JawabanPembatalan bool `json:"jawaban_pembatalan,omitempty"`
+}
+
+// PenugasanPasokanEvent is sent by Kota to the kecamatan assigned as supplier
+type PenugasanPasokanEvent struct {
+	PermintaanKotaID     uint    `json:"permintaan_kota_id"`
+	KecamatanPemintaID   uint    `json:"kecamatan_peminta_id"`
+	KecamatanPemintaNama string  `json:"kecamatan_peminta_nama"`
+	Jenis                string  `json:"jenis"`
+	Deskripsi            string  `json:"deskripsi"`
+	BarangKode           string  `json:"barang_kode"`
+	Jumlah               float64 `json:"jumlah"`
+	Satuan               string  `json:"satuan"`
+	Urgensi              string  `json:"urgensi"`
+	LokasiTujuan         string  `json:"lokasi_tujuan"`
+	Latitude             float64 `json:"latitude"`
+	Longitude            float64 `json:"longitude"`
+	Catatan              string  `json:"catatan"`
+}
+
+// EventStatus builds the status event sent back to the requesting kecamatan.
+// KecamatanPemasok harus sudah di-preload agar namanya ikut terkirim.
+func (p PermintaanSumberDayaKota) EventStatus(catatan string, waktu time.Time) StatusPermintaanEvent {
+	event := StatusPermintaanEvent{
+		PermintaanID:       p.PermintaanID,
+		PermintaanKotaID:   p.ID,
+		Status:             p.Status,
+		KecamatanPemasokID: p.KecamatanPemasokID,
+		Catatan:            catatan,
+		Waktu:              waktu,
+	}
+	if p.KecamatanPemasok != nil {
+		event.KecamatanPemasokNama = p.KecamatanPemasok.Nama
+	} else if p.WaktuDiputuskan != nil && p.Status != "Ditolak" {
+		event.KecamatanPemasokNama = "BPBD Kota"
+	}
+	return event
+}
+
+// EventPenugasan builds the assignment event sent to the supplying kecamatan.
+// Kecamatan (peminta) harus sudah di-preload.
+func (p PermintaanSumberDayaKota) EventPenugasan() PenugasanPasokanEvent {
+	return PenugasanPasokanEvent{
+		PermintaanKotaID:     p.ID,
+		KecamatanPemintaID:   p.KecamatanID,
+		KecamatanPemintaNama: p.Kecamatan.Nama,
+		Jenis:                p.Jenis,
+		Deskripsi:            p.Deskripsi,
+		BarangKode:           p.BarangKode,
+		Jumlah:               p.Jumlah,
+		Satuan:               p.Satuan,
+		Urgensi:              p.Urgensi,
+		LokasiTujuan:         p.LokasiTujuan,
+		Latitude:             p.Latitude,
+		Longitude:            p.Longitude,
+		Catatan:              p.CatatanKota,
+	}
+}
@@ -0,0 +1,166 @@
+// models/timeline.go
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TimelineBencana model (satu entri kronologi bencana: update lapangan RT/RW,
+// angka korban & kerusakan, atau perubahan status yang dicatat sistem)
+type TimelineBencana struct {
+	ID          uint              `gorm:"primarykey" json:"id"`
+	BencanaID   uint              `gorm:"not null;index" json:"bencana_id"`
+	Tipe        string            `gorm:"type:enum('Update','Dampak','Status');not null;default:'Update';index" json:"tipe"`
+	Isi         string            `gorm:"type:text" json:"isi"`
+	RT          string            `gorm:"size:8" json:"rt"` // Wilayah yang dilaporkan; kosong = seluruh kecamatan
+	RW          string            `gorm:"size:8" json:"rw"`
+	Lokasi      string            `json:"lokasi"`
+	Latitude    float64           `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude   float64           `gorm:"type:decimal(11,8)" json:"longitude"`
+	Dampak      DampakBencana     `gorm:"embedded" json:"dampak"`
+	Waktu       time.Time         `gorm:"not null;index" json:"waktu"` // Waktu kejadian di lapangan (boleh mundur)
+	DicatatOleh *uint             `json:"dicatat_oleh"`                // Kosong untuk entri otomatis
+	Pencatat    *User             `gorm:"foreignKey:DicatatOleh" json:"pencatat,omitempty"`
+	Lampiran    []LampiranBencana `gorm:"foreignKey:TimelineID" json:"lampiran,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// DampakBencana holds casualty and damage figures for one wilayah. Angka adalah
+// jumlah kumulatif terbaru di wilayah tersebut (bukan tambahan); nil = tidak dilaporkan.
+type DampakBencana struct {
+	Meninggal        *int `json:"meninggal,omitempty"`
+	Hilang           *int `json:"hilang,omitempty"`
+	LukaBerat        *int `json:"luka_berat,omitempty"`
+	LukaRingan       *int `json:"luka_ringan,omitempty"`
+	Mengungsi        *int `json:"mengungsi,omitempty"`
+	RumahRusakBerat  *int `json:"rumah_rusak_berat,omitempty"`
+	RumahRusakSedang *int `json:"rumah_rusak_sedang,omitempty"`
+	RumahRusakRingan *int `json:"rumah_rusak_ringan,omitempty"`
+	RumahTerendam    *int `json:"rumah_terendam,omitempty"`
+	FasilitasRusak   *int `json:"fasilitas_rusak,omitempty"` // Sekolah, tempat ibadah, puskesmas, jembatan
+}
+
+// LampiranBencana model (foto atau dokumen pada entri timeline). Isi berkas
+// disimpan di blob store (lokal atau S3); DB hanya menyimpan kuncinya.
+type LampiranBencana struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	BencanaID    uint      `gorm:"not null;index" json:"bencana_id"`
+	TimelineID   uint      `gorm:"not null;index" json:"timeline_id"`
+	Jenis        string    `gorm:"type:enum('Foto','Dokumen');not null" json:"jenis"`
+	NamaBerkas   string    `gorm:"not null" json:"nama_berkas"`
+	TipeKonten   string    `gorm:"size:128" json:"tipe_konten"`
+	Ukuran       int64     `json:"ukuran"`
+	SHA256       string    `gorm:"size:64" json:"sha256"`
+	Penyimpanan  string    `gorm:"size:16;not null" json:"penyimpanan"` // Nama blob store saat diunggah, mis. lokal / s3
+	Kunci        string    `gorm:"not null" json:"-"`
+	URL          string    `gorm:"-" json:"url"`
+	DiunggahOleh uint      `gorm:"not null" json:"diunggah_oleh"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AfterFind mengisi URL unduhan lampiran lewat API kecamatan
+func (l *LampiranBencana) AfterFind(tx *gorm.DB) error {
+	l.URL = fmt.Sprintf("/api/v1/bencana/%d/lampiran/%d", l.BencanaID, l.ID)
+	return nil
+}
+
+// DampakWilayah is the latest reported figures of one RT/RW
+type DampakWilayah struct {
+	RT             string        `json:"rt"`
+	RW             string        `json:"rw"`
+	Dampak         DampakBencana `json:"dampak"`
+	DiperbaruiPada time.Time     `json:"diperbarui_pada"`
+}
+
+// RekapDampakBencana sums the latest figures of every wilayah
+type RekapDampakBencana struct {
+	Total          DampakBencana   `json:"total"`
+	PerWilayah     []DampakWilayah `json:"per_wilayah"`
+	JumlahLaporan  int             `json:"jumlah_laporan"`
+	DiperbaruiPada *time.Time      `json:"diperbarui_pada"`
+}
+
+// DTO for a timeline entry. Dikirim sebagai JSON atau multipart/form-data
+// (berkas pada field "lampiran", boleh lebih dari satu).
+type CreateTimelineRequest struct {
+	Isi              string  `json:"isi" form:"isi"`
+	RT               string  `json:"rt" form:"rt"`
+	RW               string  `json:"rw" form:"rw"`
+	Lokasi           string  `json:"lokasi" form:"lokasi"`
+	Latitude         float64 `json:"latitude" form:"latitude"`
+	Longitude        float64 `json:"longitude" form:"longitude"`
+	Waktu            string  `json:"waktu" form:"waktu"` // RFC3339, kosong = sekarang
+	Meninggal        *int    `json:"meninggal" form:"meninggal"`
+	Hilang           *int    `json:"hilang" form:"hilang"`
+	LukaBerat        *int    `json:"luka_berat" form:"luka_berat"`
+	LukaRingan       *int    `json:"luka_ringan" form:"luka_ringan"`
+	Mengungsi        *int    `json:"mengungsi" form:"mengungsi"`
+	RumahRusakBerat  *int    `json:"rumah_rusak_berat" form:"rumah_rusak_berat"`
+	RumahRusakSedang *int    `json:"rumah_rusak_sedang" form:"rumah_rusak_sedang"`
+	RumahRusakRingan *int    `json:"rumah_rusak_ringan" form:"rumah_rusak_ringan"`
+	RumahTerendam    *int    `json:"rumah_terendam" form:"rumah_terendam"`
+	FasilitasRusak   *int    `json:"fasilitas_rusak" form:"fasilitas_rusak"`
+}
+
+// Dampak returns the figures of the request
+func (r CreateTimelineRequest) Dampak() DampakBencana {
+	return DampakBencana{
+		Meninggal:        r.Meninggal,
+		Hilang:           r.Hilang,
+		LukaBerat:        r.LukaBerat,
+		LukaRingan:       r.LukaRingan,
+		Mengungsi:        r.Mengungsi,
+		RumahRusakBerat:  r.RumahRusakBerat,
+		RumahRusakSedang: r.RumahRusakSedang,
+		RumahRusakRingan: r.RumahRusakRingan,
+		RumahTerendam:    r.RumahTerendam,
+		FasilitasRusak:   r.FasilitasRusak,
+	}
+}
+
+// Sitrep is the situation report of one bencana (dirender ke HTML, PDF atau JSON)
+type Sitrep struct {
+	KecamatanID uint               `json:"kecamatan_id"`
+	DibuatPada  time.Time          `json:"dibuat_pada"`
+	Bencana     KejadianBencana    `json:"bencana"`
+	Dampak      RekapDampakBencana `json:"dampak"`
+	Evakuasi    SitrepEvakuasi     `json:"evakuasi"`
+	Pengungsi   []SitrepPengungsi  `json:"pengungsi"`
+	OrangHilang SitrepOrangHilang  `json:"orang_hilang"`
+	Kebutuhan   []SitrepKebutuhan  `json:"kebutuhan"`
+	TugasBelum  []TugasBencana     `json:"tugas_belum"`
+	Timeline    []TimelineBencana  `json:"timeline"`
+}
+
+// SitrepEvakuasi summarises evacuation progress of a bencana
+type SitrepEvakuasi struct {
+	WargaTerdampak int64            `json:"warga_terdampak"`
+	BelumDitangani int64            `json:"belum_ditangani"`
+	PerStatus      map[string]int64 `json:"per_status"`
+}
+
+// SitrepPengungsi is the occupancy of one shelter for a bencana
+type SitrepPengungsi struct {
+	TitikKumpul string `json:"titik_kumpul"`
+	Kapasitas   int    `json:"kapasitas"`
+	Jumlah      int64  `json:"jumlah"`
+}
+
+// SitrepOrangHilang counts missing person reports of a bencana
+type SitrepOrangHilang struct {
+	Hilang    int64 `json:"hilang"`
+	Ditemukan int64 `json:"ditemukan"`
+}
+
+// SitrepKebutuhan is one open resource request of a bencana
+type SitrepKebutuhan struct {
+	Jenis     string  `json:"jenis"`
+	Deskripsi string  `json:"deskripsi"`
+	Jumlah    float64 `json:"jumlah"`
+	Satuan    string  `json:"satuan"`
+	Urgensi   string  `json:"urgensi"`
+	Status    string  `json:"status"`
+}
@@ -0,0 +1,90 @@
+// models/titik_kumpul.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TitikKumpul model (shelter / titik kumpul evakuasi di kecamatan)
+type TitikKumpul struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	Nama        string         `gorm:"not null" json:"nama"`
+	Jenis       string         `gorm:"type:enum('Titik Kumpul','Shelter');not null;default:'Titik Kumpul'" json:"jenis"`
+	Alamat      string         `gorm:"type:text" json:"alamat"`
+	RT          string         `json:"rt"`
+	RW          string         `json:"rw"`
+	Latitude    float64        `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude   float64        `gorm:"type:decimal(11,8)" json:"longitude"`
+	Kapasitas   int            `gorm:"not null" json:"kapasitas"`
+	Terisi      int            `gorm:"default:0" json:"terisi"` // Dihitung ulang dari registrasi pengungsi
+	ToiletAkses bool           `gorm:"default:false" json:"toilet_akses"`
+	PosMedis    bool           `gorm:"default:false" json:"pos_medis"`
+	Generator   bool           `gorm:"default:false" json:"generator"`
+	Aktif       bool           `gorm:"not null" json:"aktif"`
+	Catatan     string         `gorm:"type:text" json:"catatan"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// SisaKapasitas returns how many more people fit in the shelter
+func (t TitikKumpul) SisaKapasitas() int {
+	if sisa := t.Kapasitas - t.Terisi; sisa > 0 {
+		return sisa
+	}
+	return 0
+}
+
+// OkupansiTitikKumpulKota model (agregasi okupansi titik kumpul di DB Kota)
+type OkupansiTitikKumpulKota struct {
+	ID            uint            `gorm:"primarykey" json:"id"`
+	KecamatanID   uint            `gorm:"not null;uniqueIndex:idx_kecamatan_titik_kumpul" json:"kecamatan_id"`
+	Kecamatan     MasterKecamatan `gorm:"foreignKey:KecamatanID" json:"kecamatan,omitempty"`
+	TitikKumpulID uint            `gorm:"not null;uniqueIndex:idx_kecamatan_titik_kumpul" json:"titik_kumpul_id"` // ID di DB kecamatan
+	Nama          string          `gorm:"not null" json:"nama"`
+	Jenis         string          `json:"jenis"`
+	Latitude      float64         `gorm:"type:decimal(10,8)" json:"latitude"`
+	Longitude     float64         `gorm:"type:decimal(11,8)" json:"longitude"`
+	Kapasitas     int             `json:"kapasitas"`
+	Terisi        int             `json:"terisi"`
+	Aktif         bool            `json:"aktif"`
+	LastSync      *time.Time      `json:"last_sync"`
+	Versi         int64           `json:"versi"` // Versi event terakhir yang diterapkan, lihat OkupansiTitikKumpulEvent
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt  `gorm:"index" json:"-"` // Dihapus di kecamatan; baris disimpan agar event lama tidak menghidupkannya lagi
+}
+
+// DTO for Create / Update Titik Kumpul
+type TitikKumpulRequest struct {
+	Nama        string  `json:"nama" validate:"required"`
+	Jenis       string  `json:"jenis"`
+	Alamat      string  `json:"alamat"`
+	RT          string  `json:"rt"`
+	RW          string  `json:"rw"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Kapasitas   int     `json:"kapasitas" validate:"required"`
+	ToiletAkses bool    `json:"toilet_akses"`
+	PosMedis    bool    `json:"pos_medis"`
+	Generator   bool    `json:"generator"`
+	Aktif       *bool   `json:"aktif"`
+	Catatan     string  `json:"catatan"`
+}
+
+// OkupansiTitikKumpulEvent is the payload synced to the kota database
+type OkupansiTitikKumpulEvent struct {
+	TitikKumpulID uint    `json:"titik_kumpul_id"`
+	Nama          string  `json:"nama"`
+	Jenis         string  `json:"jenis"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	Kapasitas     int     `json:"kapasitas"`
+	Terisi        int     `json:"terisi"`
+	Aktif         bool    `json:"aktif"`
+	// Versi adalah waktu data dibaca di kecamatan (mikrodetik Unix). Kafka tidak menjamin
+	// urutan antar-event, jadi Kota hanya menerapkan event yang lebih baru dari yang tersimpan.
+	Versi int64 `json:"versi"`
+}
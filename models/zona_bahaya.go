@@ -0,0 +1,86 @@
+// models/zona_bahaya.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ZonaBahaya model (poligon rawan bencana per jenis bahaya dan kelas risiko)
+type ZonaBahaya struct {
+	ID          uint            `gorm:"primarykey" json:"id"`
+	Nama        string          `gorm:"not null" json:"nama"`
+	JenisBahaya string          `gorm:"size:64;not null;index" json:"jenis_bahaya"` // Lihat services.DaftarJenisBahaya
+	KelasRisiko string          `gorm:"type:enum('Rendah','Sedang','Tinggi');not null;index" json:"kelas_risiko"`
+	Geometri    json.RawMessage `gorm:"type:longtext;not null" json:"geometri"` // GeoJSON Polygon / MultiPolygon / Feature
+	MinLat      float64         `gorm:"type:decimal(10,8)" json:"-"`            // Kotak batas untuk prafilter warga
+	MaxLat      float64         `gorm:"type:decimal(10,8)" json:"-"`
+	MinLng      float64         `gorm:"type:decimal(11,8)" json:"-"`
+	MaxLng      float64         `gorm:"type:decimal(11,8)" json:"-"`
+	Sumber      string          `json:"sumber"` // Mis. InaRISK, kajian risiko BPBD
+	Keterangan  string          `gorm:"type:text" json:"keterangan"`
+	JumlahWarga int             `gorm:"default:0" json:"jumlah_warga"` // Warga di dalam zona (dihitung ulang otomatis)
+	DibuatOleh  uint            `json:"dibuat_oleh"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+// PaparanWarga model (warga yang berada di dalam suatu zona bahaya, dihitung dari koordinat rumah)
+type PaparanWarga struct {
+	ID          uint        `gorm:"primarykey" json:"id"`
+	WargaID     uint        `gorm:"not null;uniqueIndex:idx_paparan_warga_zona" json:"warga_id"`
+	ZonaID      uint        `gorm:"not null;uniqueIndex:idx_paparan_warga_zona;index" json:"zona_id"`
+	Zona        *ZonaBahaya `gorm:"foreignKey:ZonaID" json:"zona,omitempty"`
+	JenisBahaya string      `gorm:"size:64;not null;index" json:"jenis_bahaya"`
+	KelasRisiko string      `gorm:"size:10;not null" json:"kelas_risiko"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// RekapPaparanKota model (jumlah warga terpapar per kecamatan di DB Kota, diisi Sync Worker)
+type RekapPaparanKota struct {
+	ID           uint            `gorm:"primarykey" json:"id"`
+	KecamatanID  uint            `gorm:"not null;uniqueIndex:idx_paparan_kecamatan" json:"kecamatan_id"`
+	Kecamatan    MasterKecamatan `gorm:"foreignKey:KecamatanID" json:"kecamatan"`
+	JenisBahaya  string          `gorm:"size:64;not null;uniqueIndex:idx_paparan_kecamatan" json:"jenis_bahaya"`
+	KelasRisiko  string          `gorm:"size:10;not null;uniqueIndex:idx_paparan_kecamatan" json:"kelas_risiko"`
+	JumlahWarga  int             `json:"jumlah_warga"`
+	JumlahRentan int             `json:"jumlah_rentan"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// DTO for creating a single hazard zone
+type CreateZonaBahayaRequest struct {
+	Nama        string          `json:"nama"`
+	JenisBahaya string          `json:"jenis_bahaya"`
+	KelasRisiko string          `json:"kelas_risiko"`
+	Geometri    json.RawMessage `json:"geometri"`
+	Sumber      string          `json:"sumber"`
+	Keterangan  string          `json:"keterangan"`
+}
+
+// DTO for updating a hazard zone (geometri kosong = tidak diubah)
+type UpdateZonaBahayaRequest struct {
+	Nama        string          `json:"nama"`
+	JenisBahaya string          `json:"jenis_bahaya"`
+	KelasRisiko string          `json:"kelas_risiko"`
+	Geometri    json.RawMessage `json:"geometri"`
+	Sumber      string          `json:"sumber"`
+	Keterangan  string          `json:"keterangan"`
+}
+
+// RekapPaparanItem is the exposure count of one hazard type and risk class
+type RekapPaparanItem struct {
+	JenisBahaya  string `json:"jenis_bahaya"`
+	KelasRisiko  string `json:"kelas_risiko"`
+	JumlahWarga  int    `json:"jumlah_warga"`
+	JumlahRentan int    `json:"jumlah_rentan"`
+}
+
+// RekapPaparanEvent is the payload sent to Kota (seluruh rekap kecamatan, menggantikan yang lama)
+type RekapPaparanEvent struct {
+	Rekap []RekapPaparanItem `json:"rekap"`
+	Waktu time.Time          `json:"waktu"`
+}
@@ -0,0 +1,100 @@
+// services/dispatch.go
+package services
+
+import "sort"
+
+const (
+	// Jarak pengganti bila lokasi relawan atau warga belum diketahui
+	jarakTidakDiketahuiKm = 5.0
+	// Setiap tugas aktif relawan dihitung setara jarak tempuh sejauh ini
+	bobotBebanKm = 2.0
+)
+
+// KandidatRelawan adalah relawan yang bisa menerima tugas
+type KandidatRelawan struct {
+	ID        uint
+	Latitude  float64
+	Longitude float64
+	AdaLokasi bool
+	Beban     int // Jumlah tugas aktif saat ini
+}
+
+// TargetWarga adalah warga prioritas yang belum ditugaskan
+type TargetWarga struct {
+	ID               uint
+	Latitude         float64
+	Longitude        float64
+	SkorPrioritas    int
+	PeringkatPaparan int // Kelas risiko zona bahaya tertinggi untuk jenis bencana ini (0 = di luar zona)
+	// Lihat PeringkatKeselamatan: meminta bantuan / tidak membalas peringatan didahulukan
+	PeringkatKeselamatan int
+}
+
+// Penugasan adalah hasil alokasi satu warga ke satu relawan
+type Penugasan struct {
+	WargaID   uint
+	RelawanID uint
+	JarakKm   *float64
+}
+
+// AlokasiTugas membagi warga ke relawan secara greedy: warga yang meminta bantuan atau
+// tidak membalas peringatan, lalu warga di zona bahaya dengan
+// kelas risiko tertinggi lalu skor tertinggi didahulukan dan diberikan ke relawan dengan biaya terkecil (jarak + beban kerja).
+// Relawan dengan beban >= maxBeban dilewati; ditolak berisi pasangan
+// [wargaID, relawanID] yang sudah pernah ditolak relawan tersebut.
+func AlokasiTugas(warga []TargetWarga, relawan []KandidatRelawan, maxBeban int, ditolak map[[2]uint]bool) []Penugasan {
+	antrian := append([]TargetWarga(nil), warga...)
+	sort.SliceStable(antrian, func(i, j int) bool {
+		if antrian[i].PeringkatKeselamatan != antrian[j].PeringkatKeselamatan {
+			return antrian[i].PeringkatKeselamatan > antrian[j].PeringkatKeselamatan
+		}
+		if antrian[i].PeringkatPaparan != antrian[j].PeringkatPaparan {
+			return antrian[i].PeringkatPaparan > antrian[j].PeringkatPaparan
+		}
+		return antrian[i].SkorPrioritas > antrian[j].SkorPrioritas
+	})
+
+	kandidat := append([]KandidatRelawan(nil), relawan...)
+	var hasil []Penugasan
+
+	for _, w := range antrian {
+		terbaik := -1
+		var biayaTerbaik float64
+		var jarakTerbaik *float64
+
+		for i, r := range kandidat {
+			if r.Beban >= maxBeban || ditolak[[2]uint{w.ID, r.ID}] {
+				continue
+			}
+
+			jarak := jarakTidakDiketahuiKm
+			var jarakPtr *float64
+			if r.AdaLokasi && AdaKoordinat(w.Latitude, w.Longitude) {
+				jarak = JarakKm(r.Latitude, r.Longitude, w.Latitude, w.Longitude)
+				jarakPtr = &jarak
+			}
+
+			biaya := jarak + bobotBebanKm*float64(r.Beban)
+			if terbaik == -1 || biaya < biayaTerbaik {
+				terbaik, biayaTerbaik, jarakTerbaik = i, biaya, jarakPtr
+			}
+		}
+
+		if terbaik == -1 {
+			// Semua relawan penuh; sisa warga menunggu (akan dieskalasi bila terlalu lama)
+			continue
+		}
+
+		hasil = append(hasil, Penugasan{WargaID: w.ID, RelawanID: kandidat[terbaik].ID, JarakKm: jarakTerbaik})
+
+		// Relawan akan berada di lokasi warga ini untuk tugas berikutnya
+		kandidat[terbaik].Beban++
+		if AdaKoordinat(w.Latitude, w.Longitude) {
+			kandidat[terbaik].Latitude = w.Latitude
+			kandidat[terbaik].Longitude = w.Longitude
+			kandidat[terbaik].AdaLokasi = true
+		}
+	}
+
+	return hasil
+}
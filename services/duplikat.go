@@ -0,0 +1,86 @@
+// services/duplikat.go
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+// KriteriaDuplikat is how close two reports must be to count as the same incident
+type KriteriaDuplikat struct {
+	RadiusKm float64
+	Jendela  time.Duration
+}
+
+// KandidatDuplikat is an existing bencana that probably describes the same incident
+type KandidatDuplikat struct {
+	Bencana      models.KejadianBencana `json:"bencana"`
+	JarakKm      *float64               `json:"jarak_km"`
+	SelisihMenit int                    `json:"selisih_menit"`
+	Alasan       string                 `json:"alasan"`
+}
+
+// CariDuplikatBencana returns the bencana in daftar that match baru: jenis sama, dimulai
+// dalam jendela waktu, dan lokasinya berdekatan. Lokasi dianggap berdekatan bila salah
+// satunya tingkat Kecamatan, jarak koordinat dalam radius, RT/RW sama, atau lokasi salah
+// satunya belum diketahui. Hasil diurutkan dari yang paling mirip.
+func CariDuplikatBencana(baru models.KejadianBencana, daftar []models.KejadianBencana, k KriteriaDuplikat) []KandidatDuplikat {
+	var hasil []KandidatDuplikat
+	for _, b := range daftar {
+		if b.ID == baru.ID || !jenisSama(baru, b) {
+			continue
+		}
+		selisih := baru.WaktuMulai.Sub(b.WaktuMulai)
+		if math.Abs(float64(selisih)) > float64(k.Jendela) {
+			continue
+		}
+
+		kandidat := KandidatDuplikat{Bencana: b, SelisihMenit: int(math.Abs(selisih.Minutes()))}
+		adaKoordinat := AdaKoordinat(baru.Latitude, baru.Longitude) && AdaKoordinat(b.Latitude, b.Longitude)
+		if adaKoordinat {
+			jarak := math.Round(JarakKm(baru.Latitude, baru.Longitude, b.Latitude, b.Longitude)*100) / 100
+			kandidat.JarakKm = &jarak
+		}
+		switch {
+		case baru.Level == "Kecamatan" || b.Level == "Kecamatan":
+			kandidat.Alasan = "Bencana tingkat kecamatan"
+		case adaKoordinat:
+			if *kandidat.JarakKm > k.RadiusKm {
+				continue
+			}
+			kandidat.Alasan = fmt.Sprintf("Berjarak %.2f km", *kandidat.JarakKm)
+		case baru.RW != "" && b.RW != "":
+			if baru.RW != b.RW || (baru.RT != "" && b.RT != "" && baru.RT != b.RT) {
+				continue
+			}
+			kandidat.Alasan = "Wilayah RT/RW sama"
+		default:
+			kandidat.Alasan = "Lokasi belum diketahui"
+		}
+		hasil = append(hasil, kandidat)
+	}
+
+	sort.SliceStable(hasil, func(i, j int) bool {
+		ji, jj := hasil[i].JarakKm != nil, hasil[j].JarakKm != nil
+		if ji != jj {
+			return ji
+		}
+		if ji && *hasil[i].JarakKm != *hasil[j].JarakKm {
+			return *hasil[i].JarakKm < *hasil[j].JarakKm
+		}
+		return hasil[i].SelisihMenit < hasil[j].SelisihMenit
+	})
+	return hasil
+}
+
+func jenisSama(a, b models.KejadianBencana) bool {
+	if a.KodeJenis != "" && b.KodeJenis != "" {
+		return a.KodeJenis == b.KodeJenis
+	}
+	return strings.EqualFold(strings.TrimSpace(a.JenisBencana), strings.TrimSpace(b.JenisBencana))
+}
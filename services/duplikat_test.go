@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+func TestCariDuplikatBencana(t *testing.T) {
+	mulai := time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC)
+	k := KriteriaDuplikat{RadiusKm: 1, Jendela: 6 * time.Hour}
+	// Laporan baru di Bangkalan; 0,01 derajat lintang sekitar 1,1 km
+	baru := models.KejadianBencana{
+		ID: 100, JenisBencana: "Banjir", KodeJenis: "BJR", Level: "Lokal_RT",
+		WaktuMulai: mulai, RT: "01", RW: "02", Latitude: -7.03, Longitude: 112.74,
+	}
+	bencana := func(id uint, ubah func(*models.KejadianBencana)) models.KejadianBencana {
+		b := models.KejadianBencana{ID: id, JenisBencana: "Banjir", KodeJenis: "BJR", Level: "Lokal_RT", WaktuMulai: mulai}
+		ubah(&b)
+		return b
+	}
+
+	tests := []struct {
+		nama   string
+		lama   models.KejadianBencana
+		cocok  bool
+		alasan string
+	}{
+		{"koordinat dalam radius", bencana(1, func(b *models.KejadianBencana) { b.Latitude, b.Longitude = -7.035, 112.74 }), true, "Berjarak 0.56 km"},
+		{"koordinat di luar radius", bencana(2, func(b *models.KejadianBencana) { b.Latitude, b.Longitude = -7.05, 112.74 }), false, ""},
+		{"tingkat kecamatan mengabaikan jarak", bencana(3, func(b *models.KejadianBencana) { b.Level = "Kecamatan"; b.Latitude, b.Longitude = -7.2, 112.9 }), true, "Bencana tingkat kecamatan"},
+		{"jenis berbeda", bencana(4, func(b *models.KejadianBencana) { b.KodeJenis = "LSR"; b.JenisBencana = "Longsor" }), false, ""},
+		{"kode kosong dicocokkan lewat nama", bencana(5, func(b *models.KejadianBencana) { b.KodeJenis = ""; b.JenisBencana = " banjir " }), true, "Lokasi belum diketahui"},
+		{"di luar jendela waktu", bencana(6, func(b *models.KejadianBencana) { b.WaktuMulai = mulai.Add(-7 * time.Hour) }), false, ""},
+		{"tepat di batas jendela", bencana(7, func(b *models.KejadianBencana) { b.WaktuMulai = mulai.Add(6 * time.Hour) }), true, "Lokasi belum diketahui"},
+		{"RT/RW sama tanpa koordinat", bencana(8, func(b *models.KejadianBencana) { b.RT, b.RW = "01", "02" }), true, "Wilayah RT/RW sama"},
+		{"RW sama, RT kosong", bencana(9, func(b *models.KejadianBencana) { b.RW = "02" }), true, "Wilayah RT/RW sama"},
+		{"RT berbeda", bencana(10, func(b *models.KejadianBencana) { b.RT, b.RW = "03", "02" }), false, ""},
+		{"RW berbeda", bencana(11, func(b *models.KejadianBencana) { b.RT, b.RW = "01", "05" }), false, ""},
+		{"bencana yang sama", bencana(100, func(b *models.KejadianBencana) {}), false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			hasil := CariDuplikatBencana(baru, []models.KejadianBencana{tt.lama}, k)
+			if (len(hasil) == 1) != tt.cocok {
+				t.Fatalf("CariDuplikatBencana = %+v, want cocok %v", hasil, tt.cocok)
+			}
+			if tt.cocok && hasil[0].Alasan != tt.alasan {
+				t.Errorf("Alasan = %q, want %q", hasil[0].Alasan, tt.alasan)
+			}
+		})
+	}
+}
+
+func TestCariDuplikatBencanaUrutan(t *testing.T) {
+	mulai := time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC)
+	baru := models.KejadianBencana{ID: 100, KodeJenis: "BJR", Level: "Lokal_RT", WaktuMulai: mulai, Latitude: -7.03, Longitude: 112.74}
+	daftar := []models.KejadianBencana{
+		{ID: 1, KodeJenis: "BJR", Level: "Kecamatan", WaktuMulai: mulai.Add(-time.Hour)},                                         // tanpa koordinat
+		{ID: 2, KodeJenis: "BJR", Level: "Lokal_RT", WaktuMulai: mulai, Latitude: -7.038, Longitude: 112.74},                     // ~0,9 km
+		{ID: 3, KodeJenis: "BJR", Level: "Lokal_RT", WaktuMulai: mulai.Add(-2 * time.Hour), Latitude: -7.032, Longitude: 112.74}, // ~0,2 km
+		{ID: 4, KodeJenis: "BJR", Level: "Lokal_RT", WaktuMulai: mulai.Add(-30 * time.Minute)},                                   // lokasi belum diketahui
+	}
+
+	hasil := CariDuplikatBencana(baru, daftar, KriteriaDuplikat{RadiusKm: 1, Jendela: 6 * time.Hour})
+	var urutan []uint
+	for _, h := range hasil {
+		urutan = append(urutan, h.Bencana.ID)
+	}
+	// Yang berkoordinat dulu (terdekat), lalu sisanya menurut selisih waktu
+	want := []uint{3, 2, 4, 1}
+	if len(urutan) != len(want) {
+		t.Fatalf("urutan = %v, want %v", urutan, want)
+	}
+	for i := range want {
+		if urutan[i] != want[i] {
+			t.Fatalf("urutan = %v, want %v", urutan, want)
+		}
+	}
+	if hasil[0].JarakKm == nil || *hasil[0].JarakKm != 0.22 || hasil[0].SelisihMenit != 120 {
+		t.Errorf("kandidat pertama jarak/selisih = %v/%d, want 0.22/120", hasil[0].JarakKm, hasil[0].SelisihMenit)
+	}
+}
@@ -0,0 +1,66 @@
+// services/evakuasi.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Status evakuasi seorang warga
+const (
+	StatusMenunggu      = "Menunggu"
+	StatusDalamProses   = "Dalam Proses"
+	StatusTerevakuasi   = "Terevakuasi"
+	StatusDiTitikKumpul = "Di Titik Kumpul"
+)
+
+// Alur evakuasi: Menunggu -> Dalam Proses -> Terevakuasi -> Di Titik Kumpul.
+// "Dalam Proses" boleh kembali ke "Menunggu" bila relawan gagal menjangkau warga.
+var transisiEvakuasi = map[string][]string{
+	StatusMenunggu:      {StatusDalamProses},
+	StatusDalamProses:   {StatusTerevakuasi, StatusMenunggu},
+	StatusTerevakuasi:   {StatusDiTitikKumpul},
+	StatusDiTitikKumpul: {},
+}
+
+// Status yang boleh dipakai saat log evakuasi pertama kali dibuat
+var statusAwalEvakuasi = []string{StatusMenunggu, StatusDalamProses}
+
+var ErrTransisiEvakuasi = errors.New("transisi status evakuasi tidak diizinkan")
+
+// IsStatusEvakuasiValid checks whether status is a known evacuation status
+func IsStatusEvakuasiValid(status string) bool {
+	_, ok := transisiEvakuasi[status]
+	return ok
+}
+
+// ValidateStatusAwalEvakuasi checks the status of a newly created evacuation log
+func ValidateStatusAwalEvakuasi(status string) error {
+	for _, s := range statusAwalEvakuasi {
+		if s == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: log baru harus berstatus %s", ErrTransisiEvakuasi, strings.Join(statusAwalEvakuasi, " atau "))
+}
+
+// ValidateTransisiEvakuasi checks whether a log may move from status dari to ke
+func ValidateTransisiEvakuasi(dari, ke string) error {
+	for _, s := range transisiEvakuasi[dari] {
+		if s == ke {
+			return nil
+		}
+	}
+
+	berikutnya := transisiEvakuasi[dari]
+	if len(berikutnya) == 0 {
+		return fmt.Errorf("%w: %q adalah status akhir", ErrTransisiEvakuasi, dari)
+	}
+	return fmt.Errorf("%w: dari %q hanya boleh ke %s", ErrTransisiEvakuasi, dari, strings.Join(berikutnya, ", "))
+}
+
+// StatusEvakuasiBerikutnya returns the statuses reachable from status
+func StatusEvakuasiBerikutnya(status string) []string {
+	return transisiEvakuasi[status]
+}
@@ -0,0 +1,22 @@
+// services/geo.go
+package services
+
+import "math"
+
+const radiusBumiKm = 6371.0
+
+// JarakKm menghitung jarak garis lurus (haversine) antara dua koordinat dalam kilometer
+func JarakKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * radiusBumiKm * math.Asin(math.Sqrt(a))
+}
+
+// AdaKoordinat reports whether a coordinate has been filled in (0,0 means unknown)
+func AdaKoordinat(lat, lng float64) bool {
+	return lat != 0 || lng != 0
+}
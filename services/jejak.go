@@ -0,0 +1,45 @@
+// services/jejak.go
+package services
+
+import "github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+
+// PanjangJejakKm menjumlahkan jarak antar ping berurutan (ping harus sudah urut waktu)
+func PanjangJejakKm(titik []models.LokasiRelawan) float64 {
+	var total float64
+	for i := 1; i < len(titik); i++ {
+		total += JarakKm(titik[i-1].Latitude, titik[i-1].Longitude, titik[i].Latitude, titik[i].Longitude)
+	}
+	return total
+}
+
+// JejakGeoJSON mengubah jejak relawan menjadi FeatureCollection berisi satu LineString
+// dengan waktu tiap titik di properties.waktu (urutannya sama dengan koordinat)
+func JejakGeoJSON(relawanID uint, titik []models.LokasiRelawan) map[string]interface{} {
+	garis := make([][]float64, 0, len(titik))
+	waktu := make([]string, 0, len(titik))
+	for _, t := range titik {
+		// GeoJSON memakai urutan [longitude, latitude]
+		garis = append(garis, []float64{t.Longitude, t.Latitude})
+		waktu = append(waktu, t.Waktu.Format("2006-01-02T15:04:05Z07:00"))
+	}
+
+	return map[string]interface{}{
+		"type": "FeatureCollection",
+		"features": []interface{}{
+			map[string]interface{}{
+				"type": "Feature",
+				"geometry": map[string]interface{}{
+					"type":        "LineString",
+					"coordinates": garis,
+				},
+				"properties": map[string]interface{}{
+					"jenis":          "jejak",
+					"relawan_id":     relawanID,
+					"jumlah_titik":   len(titik),
+					"total_jarak_km": PanjangJejakKm(titik),
+					"waktu":          waktu,
+				},
+			},
+		},
+	}
+}
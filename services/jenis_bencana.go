@@ -0,0 +1,147 @@
+// services/jenis_bencana.go
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+// CocokkanJenisBencana finds the disaster type whose kode, nama or alias matches teks
+// (tanpa membedakan huruf besar/kecil dan tanda baca). Jenis nonaktif diabaikan.
+func CocokkanJenisBencana(daftar []models.JenisBencana, teks string) *models.JenisBencana {
+	kunci := NormalizeText(teks)
+	if kunci == "" {
+		return nil
+	}
+	for i, j := range daftar {
+		if !j.Aktif {
+			continue
+		}
+		if NormalizeText(j.Kode) == kunci || NormalizeText(j.Nama) == kunci {
+			return &daftar[i]
+		}
+		for _, a := range j.Alias {
+			if NormalizeText(a) == kunci {
+				return &daftar[i]
+			}
+		}
+	}
+	return nil
+}
+
+var kategoriNasionalValid = map[string]bool{"Hidrometeorologi": true, "Geologi": true, "Non-Alam": true, "Sosial": true}
+
+var peranTugasValid = map[string]bool{"RT": true, "RW": true, "Relawan": true, "Admin_Kecamatan": true}
+
+// PeranTugasValid reports whether a task can be assigned to the role
+func PeranTugasValid(peran string) bool {
+	return peranTugasValid[peran]
+}
+
+// ValidasiJenisBencana checks a disaster type and its playbook
+func ValidasiJenisBencana(j models.JenisBencana) error {
+	if strings.TrimSpace(j.Kode) == "" || strings.TrimSpace(j.Nama) == "" {
+		return fmt.Errorf("kode dan nama wajib diisi")
+	}
+	if strings.ContainsAny(j.Kode, " /") {
+		return fmt.Errorf("kode tidak boleh berisi spasi atau '/'")
+	}
+	if !kategoriNasionalValid[j.KategoriNasional] {
+		return fmt.Errorf("kategori_nasional harus Hidrometeorologi, Geologi, Non-Alam atau Sosial")
+	}
+	for _, b := range j.JenisBahaya {
+		if !JenisBahayaValid(b) {
+			return fmt.Errorf("jenis_bahaya %q tidak dikenal", b)
+		}
+	}
+
+	p := j.Playbook
+	sebelumnya := -1
+	for _, s := range p.StatusWajib {
+		urutan := UrutanStatusEvakuasi(s)
+		if urutan < 0 {
+			return fmt.Errorf("status_wajib %q bukan status evakuasi", s)
+		}
+		if urutan <= sebelumnya {
+			return fmt.Errorf("status_wajib harus mengikuti alur evakuasi %s", strings.Join(alurEvakuasi, " -> "))
+		}
+		sebelumnya = urutan
+	}
+	for _, s := range p.JenisShelter {
+		if s != "Titik Kumpul" && s != "Shelter" {
+			return fmt.Errorf("jenis_shelter %q harus Titik Kumpul atau Shelter", s)
+		}
+	}
+	for i, item := range p.Checklist {
+		if strings.TrimSpace(item.Judul) == "" {
+			return fmt.Errorf("checklist[%d]: judul wajib diisi", i)
+		}
+		if item.Peran != "" && !PeranTugasValid(item.Peran) {
+			return fmt.Errorf("checklist[%d]: peran %q tidak dikenal", i, item.Peran)
+		}
+		if item.BatasMenit < 0 {
+			return fmt.Errorf("checklist[%d]: batas_menit tidak boleh negatif", i)
+		}
+	}
+	return nil
+}
+
+// alurEvakuasi adalah urutan status evakuasi dari awal sampai akhir
+var alurEvakuasi = []string{StatusMenunggu, StatusDalamProses, StatusTerevakuasi, StatusDiTitikKumpul}
+
+// UrutanStatusEvakuasi returns the position of a status in the evacuation flow, or -1
+func UrutanStatusEvakuasi(status string) int {
+	for i, s := range alurEvakuasi {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// StatusEvakuasiTuntas reports whether status already reached the last required step
+func StatusEvakuasiTuntas(status string, statusWajib []string) bool {
+	if len(statusWajib) == 0 {
+		return true
+	}
+	return UrutanStatusEvakuasi(status) >= UrutanStatusEvakuasi(statusWajib[len(statusWajib)-1])
+}
+
+// RenderTemplateNotifikasi fills the {jenis}, {level}, {deskripsi} and {waktu} placeholders
+func RenderTemplateNotifikasi(template string, bencana models.KejadianBencana) string {
+	level := "tingkat kecamatan"
+	if bencana.Level == "Lokal_RT" {
+		level = "lokal RT"
+	}
+	return strings.TrimSpace(strings.NewReplacer(
+		"{jenis}", bencana.JenisBencana,
+		"{level}", level,
+		"{deskripsi}", strings.TrimSpace(bencana.Deskripsi),
+		"{waktu}", bencana.WaktuMulai.Format("02/01/2006 15:04"),
+	).Replace(template))
+}
+
+// TugasDariPlaybook turns the checklist of a playbook into tasks of one bencana
+func TugasDariPlaybook(bencana models.KejadianBencana, playbook models.PlaybookBencana) []models.TugasBencana {
+	tugas := make([]models.TugasBencana, 0, len(playbook.Checklist))
+	for i, item := range playbook.Checklist {
+		t := models.TugasBencana{
+			BencanaID: bencana.ID,
+			Urutan:    i + 1,
+			Judul:     item.Judul,
+			Deskripsi: item.Deskripsi,
+			Peran:     item.Peran,
+			Sumber:    "Playbook",
+			Status:    "Belum",
+		}
+		if item.BatasMenit > 0 {
+			batas := bencana.WaktuMulai.Add(time.Duration(item.BatasMenit) * time.Minute)
+			t.BatasWaktu = &batas
+		}
+		tugas = append(tugas, t)
+	}
+	return tugas
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+// Sektor dan subsektor mengikuti pedoman Pengkajian Kebutuhan Pascabencana (Jitupasna) BNPB
+const (
+	SektorPermukiman    = "Permukiman"
+	SektorInfrastruktur = "Infrastruktur"
+	SektorSosial        = "Sosial"
+	SektorEkonomi       = "Ekonomi Produktif"
+	SektorLintasSektor  = "Lintas Sektor"
+)
+
+// UrutanSektorJitupasna is the order sectors appear in recap tables
+var UrutanSektorJitupasna = []string{SektorPermukiman, SektorInfrastruktur, SektorSosial, SektorEkonomi, SektorLintasSektor}
+
+// SubsektorJitupasna lists the subsectors of every sector, in table order
+var SubsektorJitupasna = map[string][]string{
+	SektorPermukiman:    {"Perumahan", "Prasarana Lingkungan"},
+	SektorInfrastruktur: {"Transportasi", "Energi", "Air dan Sanitasi", "Telekomunikasi"},
+	SektorSosial:        {"Pendidikan", "Kesehatan", "Agama"},
+	SektorEkonomi:       {"Pertanian", "Perikanan", "Peternakan", "Perdagangan", "Industri", "Pariwisata"},
+	SektorLintasSektor:  {"Pemerintahan", "Lingkungan Hidup", "Keuangan"},
+}
+
+// FasilitasJitupasna maps a public facility type to its sector and subsector
+var FasilitasJitupasna = map[string][2]string{
+	"Sekolah":           {SektorSosial, "Pendidikan"},
+	"Puskesmas":         {SektorSosial, "Kesehatan"},
+	"Posyandu":          {SektorSosial, "Kesehatan"},
+	"Rumah Sakit":       {SektorSosial, "Kesehatan"},
+	"Tempat Ibadah":     {SektorSosial, "Agama"},
+	"Jalan":             {SektorInfrastruktur, "Transportasi"},
+	"Jembatan":          {SektorInfrastruktur, "Transportasi"},
+	"Jaringan Listrik":  {SektorInfrastruktur, "Energi"},
+	"Air Bersih":        {SektorInfrastruktur, "Air dan Sanitasi"},
+	"Drainase":          {SektorInfrastruktur, "Air dan Sanitasi"},
+	"Sanitasi":          {SektorInfrastruktur, "Air dan Sanitasi"},
+	"Telekomunikasi":    {SektorInfrastruktur, "Telekomunikasi"},
+	"Balai Warga":       {SektorPermukiman, "Prasarana Lingkungan"},
+	"Pos Ronda":         {SektorPermukiman, "Prasarana Lingkungan"},
+	"Pasar":             {SektorEkonomi, "Perdagangan"},
+	"Kantor Pemerintah": {SektorLintasSektor, "Pemerintahan"},
+}
+
+var (
+	tingkatKerusakanValid = []string{"Tidak Rusak", "Ringan", "Sedang", "Berat"}
+	prioritasValid        = []string{"Tinggi", "Sedang", "Rendah"}
+)
+
+// DaftarJenisFasilitas returns the known facility types, sorted
+func DaftarJenisFasilitas() []string {
+	daftar := make([]string, 0, len(FasilitasJitupasna))
+	for jenis := range FasilitasJitupasna {
+		daftar = append(daftar, jenis)
+	}
+	sort.Strings(daftar)
+	return daftar
+}
+
+// SubsektorValid reports whether subsektor belongs to sektor
+func SubsektorValid(sektor, subsektor string) bool {
+	for _, s := range SubsektorJitupasna[sektor] {
+		if s == subsektor {
+			return true
+		}
+	}
+	return false
+}
+
+// KlasifikasiAsesmen validates an assessment and fills in its sector and subsector.
+// Rumah = Permukiman/Perumahan, fasilitas umum dari jenisnya, usaha dari kerugian penghidupan pertamanya.
+func KlasifikasiAsesmen(a *models.AsesmenKerusakan) error {
+	if !mengandung(tingkatKerusakanValid, a.TingkatKerusakan) {
+		return fmt.Errorf("tingkat_kerusakan must be one of %s", strings.Join(tingkatKerusakanValid, ", "))
+	}
+	if a.NilaiKerusakan < 0 || a.NilaiKerugian < 0 {
+		return errors.New("nilai_kerusakan and nilai_kerugian must not be negative")
+	}
+
+	switch a.Objek {
+	case "Rumah":
+		if a.KartuKeluargaID == nil {
+			return errors.New("kartu_keluarga_id is required for Rumah")
+		}
+		a.Sektor, a.Subsektor = SektorPermukiman, "Perumahan"
+	case "Fasilitas Umum":
+		kelas, ok := FasilitasJitupasna[a.JenisFasilitas]
+		if !ok {
+			return fmt.Errorf("jenis_fasilitas must be one of %s", strings.Join(DaftarJenisFasilitas(), ", "))
+		}
+		if strings.TrimSpace(a.NamaObjek) == "" {
+			return errors.New("nama_objek is required for Fasilitas Umum")
+		}
+		a.Sektor, a.Subsektor = kelas[0], kelas[1]
+	case "Usaha":
+		if len(a.Penghidupan) == 0 {
+			return errors.New("penghidupan is required for Usaha")
+		}
+		a.Sektor, a.Subsektor = SektorEkonomi, a.Penghidupan[0].Subsektor
+	default:
+		return errors.New("objek must be Rumah, Fasilitas Umum or Usaha")
+	}
+
+	for i, p := range a.Penghidupan {
+		if !SubsektorValid(SektorEkonomi, p.Subsektor) {
+			return fmt.Errorf("penghidupan[%d].subsektor must be one of %s", i, strings.Join(SubsektorJitupasna[SektorEkonomi], ", "))
+		}
+		if strings.TrimSpace(p.Uraian) == "" {
+			return fmt.Errorf("penghidupan[%d].uraian is required", i)
+		}
+		if p.NilaiKerugian < 0 || p.Jumlah < 0 || p.PekerjaTerdampak < 0 {
+			return fmt.Errorf("penghidupan[%d] must not contain negative values", i)
+		}
+	}
+	for i := range a.Kebutuhan {
+		k := &a.Kebutuhan[i]
+		if !SubsektorValid(k.Sektor, k.Subsektor) {
+			return fmt.Errorf("kebutuhan[%d]: unknown sektor/subsektor %q/%q", i, k.Sektor, k.Subsektor)
+		}
+		if strings.TrimSpace(k.Uraian) == "" {
+			return fmt.Errorf("kebutuhan[%d].uraian is required", i)
+		}
+		if k.EstimasiBiaya < 0 || k.Jumlah < 0 {
+			return fmt.Errorf("kebutuhan[%d] must not contain negative values", i)
+		}
+		if k.Prioritas == "" {
+			k.Prioritas = "Sedang"
+		}
+		if !mengandung(prioritasValid, k.Prioritas) {
+			return fmt.Errorf("kebutuhan[%d].prioritas must be one of %s", i, strings.Join(prioritasValid, ", "))
+		}
+	}
+	return nil
+}
+
+// RekapJitupasna aggregates assessments per sector and subsector: jumlah objek dan
+// tingkat kerusakan dari objeknya, kerugian penghidupan ke subsektor ekonomi masing-masing,
+// dan kebutuhan ke sektor/subsektor yang dipilih petugas.
+func RekapJitupasna(asesmen []models.AsesmenKerusakan) []models.RekapJitupasnaItem {
+	indeks := map[[2]string]*models.RekapJitupasnaItem{}
+	kk := map[[2]string]map[uint]bool{}
+	ambil := func(sektor, subsektor string) *models.RekapJitupasnaItem {
+		kunci := [2]string{sektor, subsektor}
+		if r, ok := indeks[kunci]; ok {
+			return r
+		}
+		r := &models.RekapJitupasnaItem{Sektor: sektor, Subsektor: subsektor}
+		indeks[kunci] = r
+		kk[kunci] = map[uint]bool{}
+		return r
+	}
+
+	for _, a := range asesmen {
+		r := ambil(a.Sektor, a.Subsektor)
+		r.JumlahObjek++
+		switch a.TingkatKerusakan {
+		case "Ringan":
+			r.RusakRingan++
+		case "Sedang":
+			r.RusakSedang++
+		case "Berat":
+			r.RusakBerat++
+		}
+		r.NilaiKerusakan += a.NilaiKerusakan
+		r.NilaiKerugian += a.NilaiKerugian
+		if a.KartuKeluargaID != nil {
+			kk[[2]string{a.Sektor, a.Subsektor}][*a.KartuKeluargaID] = true
+		}
+
+		for _, p := range a.Penghidupan {
+			r := ambil(SektorEkonomi, p.Subsektor)
+			r.Pekerja += p.PekerjaTerdampak
+			r.NilaiKerugian += p.NilaiKerugian
+			if a.KartuKeluargaID != nil {
+				kk[[2]string{SektorEkonomi, p.Subsektor}][*a.KartuKeluargaID] = true
+			}
+		}
+		for _, k := range a.Kebutuhan {
+			ambil(k.Sektor, k.Subsektor).NilaiKebutuhan += k.EstimasiBiaya
+		}
+	}
+
+	rekap := make([]models.RekapJitupasnaItem, 0, len(indeks))
+	for kunci, r := range indeks {
+		r.JumlahKK = len(kk[kunci])
+		rekap = append(rekap, *r)
+	}
+	UrutkanRekapJitupasna(rekap)
+	return rekap
+}
+
+// UrutkanRekapJitupasna sorts recap rows in the standard sector/subsector order
+func UrutkanRekapJitupasna(rekap []models.RekapJitupasnaItem) {
+	sort.SliceStable(rekap, func(i, j int) bool {
+		si, sj := urutanDalam(UrutanSektorJitupasna, rekap[i].Sektor), urutanDalam(UrutanSektorJitupasna, rekap[j].Sektor)
+		if si != sj {
+			return si < sj
+		}
+		return urutanDalam(SubsektorJitupasna[rekap[i].Sektor], rekap[i].Subsektor) <
+			urutanDalam(SubsektorJitupasna[rekap[j].Sektor], rekap[j].Subsektor)
+	})
+}
+
+// TotalSektorJitupasna sums recap rows per sector (Subsektor kosong), plus a grand
+// total row with Sektor "Total"
+func TotalSektorJitupasna(rekap []models.RekapJitupasnaItem) []models.RekapJitupasnaItem {
+	per := map[string]*models.RekapJitupasnaItem{}
+	total := models.RekapJitupasnaItem{Sektor: "Total"}
+	for _, r := range rekap {
+		s, ok := per[r.Sektor]
+		if !ok {
+			s = &models.RekapJitupasnaItem{Sektor: r.Sektor}
+			per[r.Sektor] = s
+		}
+		for _, tujuan := range []*models.RekapJitupasnaItem{s, &total} {
+			tujuan.JumlahObjek += r.JumlahObjek
+			tujuan.JumlahKK += r.JumlahKK
+			tujuan.RusakRingan += r.RusakRingan
+			tujuan.RusakSedang += r.RusakSedang
+			tujuan.RusakBerat += r.RusakBerat
+			tujuan.Pekerja += r.Pekerja
+			tujuan.NilaiKerusakan += r.NilaiKerusakan
+			tujuan.NilaiKerugian += r.NilaiKerugian
+			tujuan.NilaiKebutuhan += r.NilaiKebutuhan
+		}
+	}
+
+	hasil := make([]models.RekapJitupasnaItem, 0, len(per)+1)
+	for _, sektor := range UrutanSektorJitupasna {
+		if s, ok := per[sektor]; ok {
+			hasil = append(hasil, *s)
+		}
+	}
+	return append(hasil, total)
+}
+
+func urutanDalam(daftar []string, nilai string) int {
+	for i, d := range daftar {
+		if d == nilai {
+			return i
+		}
+	}
+	return len(daftar)
+}
+
+func mengandung(daftar []string, nilai string) bool {
+	return urutanDalam(daftar, nilai) < len(daftar)
+}
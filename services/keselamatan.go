@@ -0,0 +1,73 @@
+// services/keselamatan.go
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Status roster keselamatan warga
+const (
+	KeselamatanBelum        = "Belum Merespons"
+	KeselamatanDiterima     = "Diterima"
+	KeselamatanAman         = "Aman"
+	KeselamatanButuhBantuan = "Butuh Bantuan"
+)
+
+// NormalisasiStatusKeselamatan accepts the status as typed by a resident or client
+// ("aman", "butuh_bantuan", "tolong", ...). Returns "" when it is not a response.
+func NormalisasiStatusKeselamatan(s string) string {
+	switch strings.ToLower(strings.NewReplacer("_", " ", "-", " ").Replace(strings.TrimSpace(s))) {
+	case "diterima", "terima", "ok":
+		return KeselamatanDiterima
+	case "aman", "selamat":
+		return KeselamatanAman
+	case "butuh bantuan", "tolong", "sos", "darurat":
+		return KeselamatanButuhBantuan
+	}
+	return ""
+}
+
+// GabungStatusKeselamatan returns the roster status after a response from the warga
+// (tautan / SMS / WhatsApp). Konfirmasi "Diterima" tidak menimpa kondisi yang sudah
+// dilaporkan, dan "Butuh Bantuan" hanya bisa dicabut petugas (UpdateKeselamatanWarga).
+func GabungStatusKeselamatan(lama, baru string) string {
+	switch {
+	case lama == KeselamatanButuhBantuan:
+		return lama
+	case baru == KeselamatanDiterima && lama == KeselamatanAman:
+		return lama
+	}
+	return baru
+}
+
+// PeringkatKeselamatan ranks warga in the evacuation list: yang meminta bantuan paling
+// atas, lalu warga yang tidak merespons dalam batas waktu (termasuk yang sesudahnya hanya
+// membalas "Diterima" tanpa mengabarkan kondisinya), lalu sisanya.
+func PeringkatKeselamatan(status string, diprioritaskan bool) int {
+	switch {
+	case status == KeselamatanButuhBantuan:
+		return 2
+	case diprioritaskan && (status == KeselamatanBelum || status == KeselamatanDiterima):
+		return 1
+	}
+	return 0
+}
+
+// BuatTokenKeselamatan returns a random link token and its hash (hanya hash yang disimpan)
+func BuatTokenKeselamatan() (token, hash string, err error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, HashTokenKeselamatan(token), nil
+}
+
+// HashTokenKeselamatan returns the hex SHA-256 of a link token
+func HashTokenKeselamatan(token string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(token))))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,94 @@
+package services
+
+import "testing"
+
+func TestNormalisasiStatusKeselamatan(t *testing.T) {
+	tests := []struct {
+		masukan string
+		want    string
+	}{
+		{"aman", KeselamatanAman},
+		{" SELAMAT ", KeselamatanAman},
+		{"ok", KeselamatanDiterima},
+		{"Diterima", KeselamatanDiterima},
+		{"butuh_bantuan", KeselamatanButuhBantuan},
+		{"Butuh-Bantuan", KeselamatanButuhBantuan},
+		{"TOLONG", KeselamatanButuhBantuan},
+		{"sos", KeselamatanButuhBantuan},
+		{"Belum Merespons", ""},
+		{"", ""},
+		{"halo", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalisasiStatusKeselamatan(tt.masukan); got != tt.want {
+			t.Errorf("NormalisasiStatusKeselamatan(%q) = %q, want %q", tt.masukan, got, tt.want)
+		}
+	}
+}
+
+func TestGabungStatusKeselamatan(t *testing.T) {
+	tests := []struct {
+		lama, baru, want string
+	}{
+		{KeselamatanBelum, KeselamatanDiterima, KeselamatanDiterima},
+		{KeselamatanBelum, KeselamatanAman, KeselamatanAman},
+		{KeselamatanBelum, KeselamatanButuhBantuan, KeselamatanButuhBantuan},
+		{KeselamatanDiterima, KeselamatanAman, KeselamatanAman},
+		{KeselamatanDiterima, KeselamatanButuhBantuan, KeselamatanButuhBantuan},
+		{KeselamatanAman, KeselamatanDiterima, KeselamatanAman},
+		{KeselamatanAman, KeselamatanButuhBantuan, KeselamatanButuhBantuan},
+		// Balasan warga tidak menurunkan permintaan bantuan; hanya petugas yang boleh
+		{KeselamatanButuhBantuan, KeselamatanAman, KeselamatanButuhBantuan},
+		{KeselamatanButuhBantuan, KeselamatanDiterima, KeselamatanButuhBantuan},
+		{KeselamatanButuhBantuan, KeselamatanButuhBantuan, KeselamatanButuhBantuan},
+	}
+	for _, tt := range tests {
+		if got := GabungStatusKeselamatan(tt.lama, tt.baru); got != tt.want {
+			t.Errorf("GabungStatusKeselamatan(%q, %q) = %q, want %q", tt.lama, tt.baru, got, tt.want)
+		}
+	}
+}
+
+func TestPeringkatKeselamatan(t *testing.T) {
+	tests := []struct {
+		status         string
+		diprioritaskan bool
+		want           int
+	}{
+		{KeselamatanButuhBantuan, false, 2},
+		{KeselamatanButuhBantuan, true, 2},
+		{KeselamatanBelum, true, 1},
+		{KeselamatanDiterima, true, 1},
+		{KeselamatanBelum, false, 0},
+		{KeselamatanDiterima, false, 0},
+		{KeselamatanAman, true, 0},
+		{KeselamatanAman, false, 0},
+	}
+	for _, tt := range tests {
+		if got := PeringkatKeselamatan(tt.status, tt.diprioritaskan); got != tt.want {
+			t.Errorf("PeringkatKeselamatan(%q, %v) = %d, want %d", tt.status, tt.diprioritaskan, got, tt.want)
+		}
+	}
+}
+
+func TestTokenKeselamatan(t *testing.T) {
+	token, hash, err := BuatTokenKeselamatan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 32 || hash != HashTokenKeselamatan(token) {
+		t.Fatalf("BuatTokenKeselamatan = %q, %q", token, hash)
+	}
+	// Token dari tautan yang diketik ulang tetap cocok
+	if HashTokenKeselamatan(" "+token+" ") != hash {
+		t.Error("HashTokenKeselamatan tidak memangkas spasi")
+	}
+	// SHA-256("abc")
+	if got := HashTokenKeselamatan("ABC"); got != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("HashTokenKeselamatan(ABC) = %s", got)
+	}
+	token2, _, _ := BuatTokenKeselamatan()
+	if token2 == token {
+		t.Error("BuatTokenKeselamatan mengembalikan token yang sama dua kali")
+	}
+}
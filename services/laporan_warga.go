@@ -0,0 +1,115 @@
+// services/laporan_warga.go
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// alfabetKodeLaporan leaves out characters that are easily misread over SMS (0/O, 1/I/L)
+const alfabetKodeLaporan = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// BuatKodeLaporan returns a random tracking code such as "LW-7KQ2MX"
+func BuatKodeLaporan() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = alfabetKodeLaporan[int(b[i])%len(alfabetKodeLaporan)]
+	}
+	return "LW-" + string(b), nil
+}
+
+// NormalisasiNoHP converts an Indonesian phone number to the 62xxx form.
+// "0812-3456-789", "+62 812 3456 789" dan "whatsapp:+62812..." menjadi "62812...".
+// Returns "" when the input does not look like a phone number.
+func NormalisasiNoHP(s string) string {
+	var digit strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digit.WriteRune(r)
+		}
+	}
+	n := digit.String()
+	switch {
+	case strings.HasPrefix(n, "62"):
+	case strings.HasPrefix(n, "0"):
+		n = "62" + n[1:]
+	case strings.HasPrefix(n, "8"):
+		n = "62" + n
+	}
+	if len(n) < 10 || len(n) > 15 {
+		return ""
+	}
+	return n
+}
+
+// aliasPerintahPesan maps the first word of an inbound SMS/WhatsApp to its command
+var aliasPerintahPesan = map[string]string{
+	"LAPOR":   "LAPOR",
+	"LAPORAN": "LAPOR",
+	"CEK":     "CEK",
+	"STATUS":  "CEK",
+	"BANTUAN": "BANTUAN",
+	"INFO":    "BANTUAN",
+	"HELP":    "BANTUAN",
+	"AMAN":    "AMAN",
+	"SELAMAT": "AMAN",
+	"TOLONG":  "TOLONG",
+	"SOS":     "TOLONG",
+	"DARURAT": "TOLONG",
+	"OK":      "TERIMA",
+	"TERIMA":  "TERIMA",
+}
+
+// UraiPerintahPesan splits an inbound message into its command keyword and the rest.
+// Perintah kosong bila kata pertama bukan kata kunci yang dikenal.
+func UraiPerintahPesan(teks string) (perintah, argumen string) {
+	teks = strings.TrimSpace(teks)
+	kata, sisa, _ := strings.Cut(teks, " ")
+	if i := strings.IndexAny(kata, "#:"); i > 0 {
+		// "LAPOR#BANJIR ..." atau "CEK:LW-XXXX"
+		kata, sisa = kata[:i], kata[i+1:]+" "+sisa
+	}
+	perintah = aliasPerintahPesan[strings.ToUpper(kata)]
+	if perintah == "" {
+		return "", teks
+	}
+	return perintah, strings.TrimSpace(sisa)
+}
+
+// PecahJenisLaporan takes the jenis bencana from the start of a "LAPOR" message.
+// Nama jenis bisa lebih dari satu kata ("Tanah Longsor"), jadi awalan terpanjang
+// yang dikenali cocok dipakai. Returns empty jenis when none matches.
+func PecahJenisLaporan(argumen string, cocok func(string) bool) (jenis, sisa string) {
+	kata := strings.Fields(argumen)
+	for n := min(3, len(kata)); n > 0; n-- {
+		calon := strings.Join(kata[:n], " ")
+		if cocok(calon) {
+			return calon, strings.Join(kata[n:], " ")
+		}
+	}
+	return "", argumen
+}
+
+var (
+	polaRT = regexp.MustCompile(`(?i)\bRT\s*[.:]?\s*0*(\d{1,3})\b`)
+	polaRW = regexp.MustCompile(`(?i)\bRW\s*[.:]?\s*0*(\d{1,3})\b`)
+)
+
+// CariRTRW finds "RT 3/RW 05"-style mentions in free text, formatted as "003" / "005"
+func CariRTRW(teks string) (rt, rw string) {
+	if m := polaRT.FindStringSubmatch(teks); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		rt = fmt.Sprintf("%03d", n)
+	}
+	if m := polaRW.FindStringSubmatch(teks); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		rw = fmt.Sprintf("%03d", n)
+	}
+	return rt, rw
+}
@@ -0,0 +1,87 @@
+// services/logistik.go
+package services
+
+import "sort"
+
+// SaldoKecamatan adalah total stok satu barang di satu kecamatan (dari DB Kota)
+type SaldoKecamatan struct {
+	KecamatanID uint
+	BarangKode  string
+	Jumlah      float64
+	StokMinimum float64
+}
+
+// SaranPemindahan adalah usulan memindahkan stok dari kecamatan yang berlebih
+type SaranPemindahan struct {
+	BarangKode      string  `json:"barang_kode"`
+	DariKecamatanID uint    `json:"dari_kecamatan_id"`
+	KeKecamatanID   uint    `json:"ke_kecamatan_id"`
+	Jumlah          float64 `json:"jumlah"`
+}
+
+// SaranRebalancing mengusulkan pemindahan stok antar kecamatan per barang.
+// Kecamatan di bawah stok minimum diisi dari kecamatan yang punya kelebihan di atas
+// minimumnya sendiri, kekurangan terbesar dilayani lebih dulu dari kelebihan terbesar.
+func SaranRebalancing(saldo []SaldoKecamatan) []SaranPemindahan {
+	type posisi struct {
+		kecamatanID uint
+		selisih     float64
+	}
+
+	kurang := make(map[string][]posisi)
+	lebih := make(map[string][]posisi)
+	dikenal := make(map[string]bool)
+	var kodeList []string
+	for _, s := range saldo {
+		if !dikenal[s.BarangKode] {
+			dikenal[s.BarangKode] = true
+			kodeList = append(kodeList, s.BarangKode)
+		}
+		selisih := s.Jumlah - s.StokMinimum
+		switch {
+		case selisih < 0:
+			kurang[s.BarangKode] = append(kurang[s.BarangKode], posisi{s.KecamatanID, -selisih})
+		case selisih > 0:
+			lebih[s.BarangKode] = append(lebih[s.BarangKode], posisi{s.KecamatanID, selisih})
+		}
+	}
+	sort.Strings(kodeList)
+
+	urutkan := func(p []posisi) {
+		sort.SliceStable(p, func(i, j int) bool {
+			if p[i].selisih != p[j].selisih {
+				return p[i].selisih > p[j].selisih
+			}
+			return p[i].kecamatanID < p[j].kecamatanID
+		})
+	}
+
+	saran := []SaranPemindahan{}
+	for _, kode := range kodeList {
+		butuh, ada := kurang[kode], lebih[kode]
+		urutkan(butuh)
+		urutkan(ada)
+
+		j := 0
+		for _, k := range butuh {
+			for k.selisih > 0 && j < len(ada) {
+				jumlah := k.selisih
+				if ada[j].selisih < jumlah {
+					jumlah = ada[j].selisih
+				}
+				saran = append(saran, SaranPemindahan{
+					BarangKode:      kode,
+					DariKecamatanID: ada[j].kecamatanID,
+					KeKecamatanID:   k.kecamatanID,
+					Jumlah:          jumlah,
+				})
+				k.selisih -= jumlah
+				ada[j].selisih -= jumlah
+				if ada[j].selisih <= 0 {
+					j++
+				}
+			}
+		}
+	}
+	return saran
+}
@@ -0,0 +1,108 @@
+// services/nik.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// NIKInfo berisi data yang bisa diturunkan dari struktur NIK
+// Format NIK (16 digit): PPKKCC DDMMYY SSSS
+//   - PPKKCC : kode wilayah (provinsi, kabupaten/kota, kecamatan)
+//   - DDMMYY : tanggal lahir (tanggal + 40 untuk perempuan)
+//   - SSSS   : nomor urut
+type NIKInfo struct {
+	KodeWilayah  string
+	TanggalLahir time.Time
+	JenisKelamin string // "L" atau "P"
+	NomorUrut    string
+}
+
+var (
+	ErrNIKLength    = errors.New("NIK harus terdiri dari 16 digit angka")
+	ErrNIKWilayah   = errors.New("kode wilayah NIK tidak valid")
+	ErrNIKTanggal   = errors.New("segmen tanggal lahir NIK tidak valid")
+	ErrNIKNomorUrut = errors.New("nomor urut NIK tidak boleh 0000")
+)
+
+// ParseNIK memvalidasi struktur NIK dan mengembalikan data turunannya
+func ParseNIK(nik string) (*NIKInfo, error) {
+	if len(nik) != 16 {
+		return nil, ErrNIKLength
+	}
+	for _, r := range nik {
+		if r < '0' || r > '9' {
+			return nil, ErrNIKLength
+		}
+	}
+
+	// Kode provinsi, kabupaten dan kecamatan tidak boleh 00
+	kode := nik[0:6]
+	if kode[0:2] == "00" || kode[2:4] == "00" || kode[4:6] == "00" {
+		return nil, ErrNIKWilayah
+	}
+
+	day, _ := strconv.Atoi(nik[6:8])
+	month, _ := strconv.Atoi(nik[8:10])
+	yy, _ := strconv.Atoi(nik[10:12])
+
+	jenisKelamin := "L"
+	if day > 40 {
+		day -= 40
+		jenisKelamin = "P"
+	}
+
+	// Dua digit tahun: anggap abad ini kecuali hasilnya di masa depan
+	now := time.Now()
+	year := 2000 + yy
+	if year > now.Year() {
+		year -= 100
+	}
+
+	if month < 1 || month > 12 || day < 1 {
+		return nil, ErrNIKTanggal
+	}
+	tanggalLahir := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	// time.Date menormalkan tanggal yang tidak ada (misal 31 Februari)
+	if tanggalLahir.Day() != day || tanggalLahir.After(now) {
+		return nil, ErrNIKTanggal
+	}
+
+	if nik[12:16] == "0000" {
+		return nil, ErrNIKNomorUrut
+	}
+
+	return &NIKInfo{
+		KodeWilayah:  kode,
+		TanggalLahir: tanggalLahir,
+		JenisKelamin: jenisKelamin,
+		NomorUrut:    nik[12:16],
+	}, nil
+}
+
+// ValidateNIKWilayah memastikan kode wilayah NIK sesuai dengan kecamatan ini.
+// kodeKecamatan kosong berarti pengecekan dilewati (belum dikonfigurasi).
+func ValidateNIKWilayah(info *NIKInfo, kodeKecamatan string) error {
+	if kodeKecamatan == "" || info.KodeWilayah == kodeKecamatan {
+		return nil
+	}
+	return fmt.Errorf("%w: %s bukan wilayah kecamatan ini (%s)", ErrNIKWilayah, info.KodeWilayah, kodeKecamatan)
+}
+
+// ValidateNoKK memvalidasi struktur nomor Kartu Keluarga (16 digit, kode wilayah di depan)
+func ValidateNoKK(noKK string, kodeKecamatan string) error {
+	if len(noKK) != 16 {
+		return errors.New("nomor KK harus terdiri dari 16 digit angka")
+	}
+	for _, r := range noKK {
+		if r < '0' || r > '9' {
+			return errors.New("nomor KK harus terdiri dari 16 digit angka")
+		}
+	}
+	if kodeKecamatan != "" && noKK[0:6] != kodeKecamatan {
+		return fmt.Errorf("%w: %s bukan wilayah kecamatan ini (%s)", ErrNIKWilayah, noKK[0:6], kodeKecamatan)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseNIK(t *testing.T) {
+	tests := []struct {
+		nama         string
+		nik          string
+		err          error
+		tanggal      time.Time
+		jenisKelamin string
+	}{
+		{"laki-laki abad ini", "352601" + "170805" + "0001", nil, time.Date(2005, 8, 17, 0, 0, 0, 0, time.Local), "L"},
+		{"perempuan tanggal + 40", "352601" + "570805" + "0002", nil, time.Date(2005, 8, 17, 0, 0, 0, 0, time.Local), "P"},
+		{"tahun masa depan jadi abad lalu", "352601" + "010199" + "0003", nil, time.Date(1999, 1, 1, 0, 0, 0, 0, time.Local), "L"},
+		{"29 Februari tahun kabisat", "352601" + "290200" + "0004", nil, time.Date(2000, 2, 29, 0, 0, 0, 0, time.Local), "L"},
+		{"kurang dari 16 digit", "35260117080500", ErrNIKLength, time.Time{}, ""},
+		{"ada huruf", "352601170805000A", ErrNIKLength, time.Time{}, ""},
+		{"kode provinsi 00", "002601" + "170805" + "0001", ErrNIKWilayah, time.Time{}, ""},
+		{"kode kecamatan 00", "352600" + "170805" + "0001", ErrNIKWilayah, time.Time{}, ""},
+		{"bulan 13", "352601" + "171305" + "0001", ErrNIKTanggal, time.Time{}, ""},
+		{"tanggal 0", "352601" + "000805" + "0001", ErrNIKTanggal, time.Time{}, ""},
+		{"31 Februari", "352601" + "310205" + "0001", ErrNIKTanggal, time.Time{}, ""},
+		{"29 Februari bukan kabisat", "352601" + "290201" + "0001", ErrNIKTanggal, time.Time{}, ""},
+		{"nomor urut 0000", "352601" + "170805" + "0000", ErrNIKNomorUrut, time.Time{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			info, err := ParseNIK(tt.nik)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseNIK(%q) error = %v, want %v", tt.nik, err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if !info.TanggalLahir.Equal(tt.tanggal) {
+				t.Errorf("TanggalLahir = %v, want %v", info.TanggalLahir, tt.tanggal)
+			}
+			if info.JenisKelamin != tt.jenisKelamin {
+				t.Errorf("JenisKelamin = %q, want %q", info.JenisKelamin, tt.jenisKelamin)
+			}
+			if info.KodeWilayah != tt.nik[:6] || info.NomorUrut != tt.nik[12:] {
+				t.Errorf("KodeWilayah/NomorUrut = %q/%q", info.KodeWilayah, info.NomorUrut)
+			}
+		})
+	}
+}
+
+func TestParseNIKTanggalMasaDepan(t *testing.T) {
+	besok := time.Now().AddDate(0, 0, 1)
+	if besok.Year() != time.Now().Year() {
+		t.Skip("besok sudah tahun berikutnya")
+	}
+	nik := "352601" + besok.Format("020106") + "0001"
+	if _, err := ParseNIK(nik); !errors.Is(err, ErrNIKTanggal) {
+		t.Fatalf("ParseNIK(%q) error = %v, want %v", nik, err, ErrNIKTanggal)
+	}
+}
+
+func TestValidateNIKWilayah(t *testing.T) {
+	info := &NIKInfo{KodeWilayah: "352601"}
+	tests := []struct {
+		kode  string
+		valid bool
+	}{
+		{"", true},
+		{"352601", true},
+		{"352602", false},
+	}
+	for _, tt := range tests {
+		err := ValidateNIKWilayah(info, tt.kode)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateNIKWilayah(%q) error = %v, want valid %v", tt.kode, err, tt.valid)
+		}
+		if err != nil && !errors.Is(err, ErrNIKWilayah) {
+			t.Errorf("ValidateNIKWilayah(%q) error = %v, want ErrNIKWilayah", tt.kode, err)
+		}
+	}
+}
+
+func TestValidateNoKK(t *testing.T) {
+	tests := []struct {
+		noKK  string
+		kode  string
+		valid bool
+	}{
+		{"3526010101050001", "", true},
+		{"3526010101050001", "352601", true},
+		{"3526010101050001", "352602", false},
+		{"352601010105000", "", false},
+		{"35260101010500X1", "", false},
+	}
+	for _, tt := range tests {
+		err := ValidateNoKK(tt.noKK, tt.kode)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateNoKK(%q, %q) error = %v, want valid %v", tt.noKK, tt.kode, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// services/orang_hilang.go
+package services
+
+// Skor minimal agar registrasi pengungsi disarankan sebagai kecocokan laporan orang hilang
+const BatasKecocokanOrang = 0.8
+
+// DataOrang adalah data identitas yang dibandingkan saat mencocokkan orang hilang
+type DataOrang struct {
+	WargaID      *uint
+	Nama         string
+	NIK          string
+	JenisKelamin string
+	Umur         *int
+}
+
+// SkorKecocokan menilai seberapa mungkin dua data menunjuk orang yang sama (0..1).
+// ID warga atau NIK yang sama langsung dianggap cocok; NIK atau jenis kelamin
+// (atau dua ID warga) yang berbeda langsung dianggap tidak cocok. Selain itu dipakai kemiripan nama,
+// dikoreksi oleh selisih umur bila keduanya diketahui.
+func SkorKecocokan(a, b DataOrang) float64 {
+	if a.WargaID != nil && b.WargaID != nil {
+		if *a.WargaID == *b.WargaID {
+			return 1
+		}
+		return 0
+	}
+	if a.NIK != "" && b.NIK != "" {
+		if a.NIK == b.NIK {
+			return 1
+		}
+		return 0
+	}
+	if a.JenisKelamin != "" && b.JenisKelamin != "" && a.JenisKelamin != b.JenisKelamin {
+		return 0
+	}
+
+	skor := Similarity(NormalizeText(a.Nama), NormalizeText(b.Nama))
+
+	if a.Umur != nil && b.Umur != nil {
+		selisih := *a.Umur - *b.Umur
+		if selisih < 0 {
+			selisih = -selisih
+		}
+		switch {
+		case selisih <= 2:
+			skor += 0.05
+		case selisih > 10:
+			skor *= 0.5
+		}
+	}
+
+	if skor > 1 {
+		skor = 1
+	}
+	return skor
+}
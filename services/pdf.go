@@ -0,0 +1,55 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Laporan teks PDF (kertas A4, font bawaan Helvetica) untuk laporan seperti sitrep
+
+const (
+	pdfMargin      = 50.0 // point
+	pdfUkuranTeks  = 10.0
+	pdfUkuranJudul = 14.0
+)
+
+// BarisPDF is one paragraph of a text PDF
+type BarisPDF struct {
+	Teks  string
+	Judul bool // Tebal dan lebih besar, mis. judul bagian
+}
+
+// BuatPDFTeks lays out paragraphs top to bottom with word wrap, page breaks and page numbers
+func BuatPDFTeks(baris []BarisPDF) ([]byte, error) {
+	pdf := fpdf.New("P", "pt", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.AliasNbPages("")
+	// Font bawaan hanya mengenal cp1252; teks UTF-8 diterjemahkan dulu
+	teks := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin / 2)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 8, fmt.Sprintf("Halaman %d / {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	for _, b := range baris {
+		if b.Judul {
+			pdf.Ln(6) // Jarak sebelum judul bagian
+			pdf.SetFont("Helvetica", "B", pdfUkuranJudul)
+			pdf.MultiCell(0, pdfUkuranJudul*1.4, teks(b.Teks), "", "L", false)
+			continue
+		}
+		pdf.SetFont("Helvetica", "", pdfUkuranTeks)
+		pdf.MultiCell(0, pdfUkuranTeks*1.4, teks(b.Teks), "", "L", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBuatPDFTeks(t *testing.T) {
+	tests := []struct {
+		nama    string
+		baris   []BarisPDF
+		halaman int
+	}{
+		{"kosong", nil, 1},
+		{"judul dan paragraf", []BarisPDF{{Teks: "Laporan Situasi – Banjir", Judul: true}, {Teks: "Warga (RT 01) “aman” • é"}}, 1},
+		{"paragraf panjang dibungkus", []BarisPDF{{Teks: strings.Repeat("kata ", 2000)}}, 0},
+		{"banyak baris pindah halaman", func() []BarisPDF {
+			baris := make([]BarisPDF, 120)
+			for i := range baris {
+				baris[i] = BarisPDF{Teks: "baris"}
+			}
+			return baris
+		}(), 3},
+	}
+
+	halaman := regexp.MustCompile(`/Type /Page\b`)
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			pdf, err := BuatPDFTeks(tt.baris)
+			if err != nil {
+				t.Fatalf("BuatPDFTeks: %v", err)
+			}
+			if !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.Contains(pdf, []byte("%%EOF")) {
+				t.Fatalf("bukan dokumen PDF: %.40q", pdf)
+			}
+			got := len(halaman.FindAll(pdf, -1))
+			if tt.halaman > 0 && got != tt.halaman {
+				t.Errorf("jumlah halaman = %d, want %d", got, tt.halaman)
+			}
+			if tt.halaman == 0 && got < 2 {
+				t.Errorf("jumlah halaman = %d, want lebih dari 1", got)
+			}
+		})
+	}
+}
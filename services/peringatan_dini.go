@@ -0,0 +1,584 @@
+// services/peringatan_dini.go
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+// Format feed peringatan yang dikenali ParseFeedPeringatan
+const (
+	FormatFeedOtomatis = "auto"
+	FormatFeedCAP      = "cap"
+	FormatFeedJSON     = "json"
+)
+
+// HasilFeed is the result of parsing one feed document. Indeks RSS/Atom tidak
+// berisi peringatan, hanya Tautan ke dokumen CAP yang perlu diambil terpisah.
+type HasilFeed struct {
+	Peringatan []models.PeringatanDini
+	Tautan     []string
+}
+
+// ParseFeedPeringatan parses a CAP/XML or JSON document (format auto, cap, json)
+func ParseFeedPeringatan(data []byte, format string) (HasilFeed, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(data) == 0 {
+		return HasilFeed{}, errors.New("dokumen feed kosong")
+	}
+
+	if format == "" || format == FormatFeedOtomatis {
+		format = FormatFeedJSON
+		if data[0] == '<' {
+			format = FormatFeedCAP
+		}
+	}
+
+	switch format {
+	case FormatFeedCAP:
+		return parseFeedXML(data)
+	case FormatFeedJSON:
+		return parseFeedJSON(data)
+	default:
+		return HasilFeed{}, fmt.Errorf("format feed %q tidak dikenal (auto, cap, json)", format)
+	}
+}
+
+// ---------------------------------------------------------------
+// CAP 1.2 (dan indeks RSS/Atom berisi tautan ke dokumen CAP)
+// ---------------------------------------------------------------
+
+type capAlert struct {
+	Identifier string    `xml:"identifier"`
+	Sender     string    `xml:"sender"`
+	Sent       string    `xml:"sent"`
+	Status     string    `xml:"status"`
+	MsgType    string    `xml:"msgType"`
+	References string    `xml:"references"`
+	Info       []capInfo `xml:"info"`
+}
+
+type capInfo struct {
+	Language    string    `xml:"language"`
+	Category    []string  `xml:"category"`
+	Event       string    `xml:"event"`
+	Urgency     string    `xml:"urgency"`
+	Severity    string    `xml:"severity"`
+	Certainty   string    `xml:"certainty"`
+	Effective   string    `xml:"effective"`
+	Onset       string    `xml:"onset"`
+	Expires     string    `xml:"expires"`
+	SenderName  string    `xml:"senderName"`
+	Headline    string    `xml:"headline"`
+	Description string    `xml:"description"`
+	Instruction string    `xml:"instruction"`
+	Area        []capArea `xml:"area"`
+}
+
+type capArea struct {
+	AreaDesc string       `xml:"areaDesc"`
+	Polygon  []string     `xml:"polygon"`
+	Circle   []string     `xml:"circle"`
+	Geocode  []capGeocode `xml:"geocode"`
+}
+
+type capGeocode struct {
+	ValueName string `xml:"valueName"`
+	Value     string `xml:"value"`
+}
+
+// Indeks feed: RSS <item><link> atau Atom <entry><link href>
+type feedIndeks struct {
+	XMLName xml.Name
+	Items   []struct {
+		Link string `xml:"link"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		Links []struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+func parseFeedXML(data []byte) (HasilFeed, error) {
+	var root struct{ XMLName xml.Name }
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return HasilFeed{}, fmt.Errorf("XML tidak valid: %w", err)
+	}
+
+	switch root.XMLName.Local {
+	case "alert":
+		p, err := ParseCAP(data)
+		if err != nil {
+			return HasilFeed{}, err
+		}
+		return HasilFeed{Peringatan: []models.PeringatanDini{p}}, nil
+	case "rss", "feed":
+		var indeks feedIndeks
+		if err := xml.Unmarshal(data, &indeks); err != nil {
+			return HasilFeed{}, fmt.Errorf("indeks feed tidak valid: %w", err)
+		}
+		var hasil HasilFeed
+		for _, item := range indeks.Items {
+			if link := strings.TrimSpace(item.Link); link != "" {
+				hasil.Tautan = append(hasil.Tautan, link)
+			}
+		}
+		for _, entry := range indeks.Entries {
+			for _, link := range entry.Links {
+				if href := strings.TrimSpace(link.Href); href != "" {
+					hasil.Tautan = append(hasil.Tautan, href)
+				}
+			}
+		}
+		return hasil, nil
+	default:
+		return HasilFeed{}, fmt.Errorf("dokumen XML <%s> bukan CAP atau RSS/Atom", root.XMLName.Local)
+	}
+}
+
+// ParseCAP converts one CAP 1.2 <alert> into a PeringatanDini.
+// Bila ada beberapa <info> (multi bahasa), yang berbahasa Indonesia dipakai.
+func ParseCAP(data []byte) (models.PeringatanDini, error) {
+	var alert capAlert
+	if err := xml.Unmarshal(data, &alert); err != nil {
+		return models.PeringatanDini{}, fmt.Errorf("CAP tidak valid: %w", err)
+	}
+	if strings.TrimSpace(alert.Identifier) == "" {
+		return models.PeringatanDini{}, errors.New("CAP tanpa identifier")
+	}
+	if len(alert.Info) == 0 && alert.MsgType != "Cancel" {
+		return models.PeringatanDini{}, fmt.Errorf("CAP %s tanpa <info>", alert.Identifier)
+	}
+
+	sent, err := parseWaktuFeed(alert.Sent)
+	if err != nil {
+		return models.PeringatanDini{}, fmt.Errorf("CAP %s: sent tidak valid: %w", alert.Identifier, err)
+	}
+
+	p := models.PeringatanDini{
+		Identifier: strings.TrimSpace(alert.Identifier),
+		Pengirim:   alert.Sender,
+		StatusCAP:  alert.Status,
+		JenisPesan: alert.MsgType,
+		Referensi:  strings.TrimSpace(alert.References),
+		WaktuKirim: sent,
+	}
+	if p.JenisPesan == "" {
+		p.JenisPesan = "Alert"
+	}
+	if p.StatusCAP == "" {
+		p.StatusCAP = "Actual"
+	}
+
+	if len(alert.Info) == 0 {
+		p.Jenis = "Pembatalan"
+		return p, nil
+	}
+
+	info := alert.Info[0]
+	for _, i := range alert.Info {
+		if lang := strings.ToLower(i.Language); strings.HasPrefix(lang, "id") || strings.HasPrefix(lang, "in") {
+			info = i
+			break
+		}
+	}
+
+	p.Kategori = strings.Join(info.Category, ",")
+	p.Jenis = info.Event
+	p.Judul = info.Headline
+	p.Deskripsi = strings.TrimSpace(info.Description)
+	p.Instruksi = strings.TrimSpace(info.Instruction)
+	p.Keparahan = info.Severity
+	p.Urgensi = info.Urgency
+	p.Kepastian = info.Certainty
+	p.Tingkat = TingkatDariKeparahan(info.Severity)
+	if info.SenderName != "" {
+		p.Pengirim = info.SenderName
+	}
+	if p.Jenis == "" {
+		p.Jenis = "Peringatan"
+	}
+
+	for _, w := range []struct {
+		teks   string
+		tujuan **time.Time
+	}{{info.Onset, &p.BerlakuMulai}, {info.Effective, &p.BerlakuMulai}, {info.Expires, &p.BerlakuSampai}} {
+		if w.teks == "" || *w.tujuan != nil {
+			continue
+		}
+		t, err := parseWaktuFeed(w.teks)
+		if err != nil {
+			return models.PeringatanDini{}, fmt.Errorf("CAP %s: waktu tidak valid: %w", p.Identifier, err)
+		}
+		*w.tujuan = &t
+	}
+
+	for _, a := range info.Area {
+		area, err := areaDariCAP(a)
+		if err != nil {
+			return models.PeringatanDini{}, fmt.Errorf("CAP %s: %w", p.Identifier, err)
+		}
+		p.Area = append(p.Area, area)
+	}
+	return p, nil
+}
+
+// areaDariCAP converts a CAP <area>; polygon "lat,lon lat,lon ...", circle "lat,lon radius_km"
+func areaDariCAP(a capArea) (models.AreaPeringatan, error) {
+	area := models.AreaPeringatan{Nama: strings.TrimSpace(a.AreaDesc)}
+	for _, g := range a.Geocode {
+		if v := strings.TrimSpace(g.Value); v != "" {
+			area.Kode = append(area.Kode, v)
+		}
+	}
+
+	for _, teks := range a.Polygon {
+		var poligon [][2]float64
+		for _, pasangan := range strings.Fields(teks) {
+			lat, lng, err := parseLatLng(pasangan)
+			if err != nil {
+				return area, fmt.Errorf("polygon %q: %w", a.AreaDesc, err)
+			}
+			poligon = append(poligon, [2]float64{lat, lng})
+		}
+		if len(poligon) < 3 {
+			return area, fmt.Errorf("polygon %q kurang dari 3 titik", a.AreaDesc)
+		}
+		area.Poligon = append(area.Poligon, poligon)
+	}
+
+	for _, teks := range a.Circle {
+		bagian := strings.Fields(teks)
+		if len(bagian) != 2 {
+			return area, fmt.Errorf("circle %q harus \"lat,lon radius\"", a.AreaDesc)
+		}
+		lat, lng, err := parseLatLng(bagian[0])
+		if err != nil {
+			return area, fmt.Errorf("circle %q: %w", a.AreaDesc, err)
+		}
+		radius, err := strconv.ParseFloat(bagian[1], 64)
+		if err != nil || radius < 0 {
+			return area, fmt.Errorf("radius circle %q tidak valid", a.AreaDesc)
+		}
+		area.Lingkaran = append(area.Lingkaran, models.LingkaranWilayah{Lat: lat, Lng: lng, RadiusKm: radius})
+	}
+	return area, nil
+}
+
+// ---------------------------------------------------------------
+// JSON: format BMKG (Infogempa) atau format kanonik sistem ini
+// ---------------------------------------------------------------
+
+// gempaBMKG mengikuti autogempa.json / gempaterkini.json / gempadirasakan.json BMKG
+type gempaBMKG struct {
+	Tanggal     string `json:"Tanggal"`
+	Jam         string `json:"Jam"`
+	DateTime    string `json:"DateTime"`
+	Coordinates string `json:"Coordinates"`
+	Magnitude   string `json:"Magnitude"`
+	Kedalaman   string `json:"Kedalaman"`
+	Wilayah     string `json:"Wilayah"`
+	Potensi     string `json:"Potensi"`
+	Dirasakan   string `json:"Dirasakan"`
+}
+
+func parseFeedJSON(data []byte) (HasilFeed, error) {
+	if data[0] == '[' {
+		return parsePeringatanKanonik(data)
+	}
+
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return HasilFeed{}, fmt.Errorf("JSON tidak valid: %w", err)
+	}
+
+	if raw, ok := root["Infogempa"]; ok {
+		var info struct {
+			Gempa json.RawMessage `json:"gempa"`
+		}
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return HasilFeed{}, fmt.Errorf("Infogempa tidak valid: %w", err)
+		}
+		// autogempa.json berisi satu objek, gempaterkini.json berisi array
+		var daftar []gempaBMKG
+		if t := bytes.TrimSpace(info.Gempa); len(t) > 0 && t[0] == '{' {
+			var g gempaBMKG
+			if err := json.Unmarshal(t, &g); err != nil {
+				return HasilFeed{}, fmt.Errorf("data gempa tidak valid: %w", err)
+			}
+			daftar = append(daftar, g)
+		} else if err := json.Unmarshal(info.Gempa, &daftar); err != nil {
+			return HasilFeed{}, fmt.Errorf("data gempa tidak valid: %w", err)
+		}
+
+		var hasil HasilFeed
+		for _, g := range daftar {
+			p, err := peringatanGempa(g)
+			if err != nil {
+				return HasilFeed{}, err
+			}
+			hasil.Peringatan = append(hasil.Peringatan, p)
+		}
+		return hasil, nil
+	}
+
+	if raw, ok := root["peringatan"]; ok {
+		return parsePeringatanKanonik(raw)
+	}
+	if _, ok := root["identifier"]; ok {
+		return parsePeringatanKanonik(append(append([]byte{'['}, data...), ']'))
+	}
+	return HasilFeed{}, errors.New("JSON bukan Infogempa BMKG maupun daftar peringatan")
+}
+
+// parsePeringatanKanonik reads an array in the same shape as PeringatanDini's JSON
+func parsePeringatanKanonik(data []byte) (HasilFeed, error) {
+	var daftar []models.PeringatanDini
+	if err := json.Unmarshal(data, &daftar); err != nil {
+		return HasilFeed{}, fmt.Errorf("daftar peringatan tidak valid: %w", err)
+	}
+
+	for i := range daftar {
+		p := &daftar[i]
+		if strings.TrimSpace(p.Identifier) == "" || strings.TrimSpace(p.Jenis) == "" {
+			return HasilFeed{}, fmt.Errorf("peringatan ke-%d: identifier dan jenis wajib diisi", i+1)
+		}
+		// Field milik database Kota tidak boleh ikut dari feed
+		p.ID, p.Target, p.WaktuDiteruskan, p.Status = 0, nil, nil, ""
+		if p.JenisPesan == "" {
+			p.JenisPesan = "Alert"
+		}
+		if p.StatusCAP == "" {
+			p.StatusCAP = "Actual"
+		}
+		if p.WaktuKirim.IsZero() {
+			p.WaktuKirim = time.Now()
+		}
+		if p.Tingkat == "" {
+			p.Tingkat = TingkatDariKeparahan(p.Keparahan)
+		}
+	}
+	return HasilFeed{Peringatan: daftar}, nil
+}
+
+// peringatanGempa converts one BMKG earthquake record
+func peringatanGempa(g gempaBMKG) (models.PeringatanDini, error) {
+	waktu, err := parseWaktuFeed(g.DateTime)
+	if err != nil {
+		return models.PeringatanDini{}, fmt.Errorf("DateTime gempa %q tidak valid", g.DateTime)
+	}
+	lat, lng, err := parseLatLng(g.Coordinates)
+	if err != nil {
+		return models.PeringatanDini{}, fmt.Errorf("Coordinates gempa %q: %w", g.Coordinates, err)
+	}
+	mag, err := strconv.ParseFloat(strings.TrimSpace(g.Magnitude), 64)
+	if err != nil {
+		return models.PeringatanDini{}, fmt.Errorf("Magnitude gempa %q tidak valid", g.Magnitude)
+	}
+
+	p := models.PeringatanDini{
+		Identifier: "gempa-" + waktu.UTC().Format("20060102T150405Z") + "-" + strings.ReplaceAll(g.Coordinates, " ", ""),
+		Pengirim:   "BMKG",
+		StatusCAP:  "Actual",
+		JenisPesan: "Alert",
+		Kategori:   "Geo",
+		Jenis:      "Gempabumi",
+		Judul:      fmt.Sprintf("Gempa M%.1f %s", mag, g.Wilayah),
+		Deskripsi:  strings.TrimSpace(fmt.Sprintf("%s %s. %s. Kedalaman %s. %s", g.Tanggal, g.Jam, g.Wilayah, g.Kedalaman, g.Potensi)),
+		Tingkat:    TingkatGempa(mag, g.Potensi),
+		Magnitudo:  &mag,
+		Latitude:   lat,
+		Longitude:  lng,
+		WaktuKirim: waktu,
+	}
+	p.Keparahan = map[string]string{"Awas": "Extreme", "Siaga": "Severe", "Waspada": "Moderate"}[p.Tingkat]
+	if kedalaman, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(g.Kedalaman), "km")), 64); err == nil {
+		p.KedalamanKm = &kedalaman
+	}
+
+	// Gempa dianggap relevan selama sehari sejak kejadian
+	mulai, sampai := waktu, waktu.Add(24*time.Hour)
+	p.BerlakuMulai, p.BerlakuSampai = &mulai, &sampai
+
+	p.Area = append(p.Area, models.AreaPeringatan{
+		Nama:      "Radius guncangan " + g.Wilayah,
+		Lingkaran: []models.LingkaranWilayah{{Lat: lat, Lng: lng, RadiusKm: RadiusGuncanganKm(mag)}},
+	})
+	// "Dirasakan": "III Bangkalan, II-III Surabaya" -> area per nama tempat
+	for _, bagian := range strings.Split(g.Dirasakan, ",") {
+		kata := strings.Fields(bagian)
+		if len(kata) > 1 && strings.Trim(kata[0], "IVX-") == "" {
+			kata = kata[1:]
+		}
+		if nama := strings.Join(kata, " "); nama != "" {
+			p.Area = append(p.Area, models.AreaPeringatan{Nama: nama})
+		}
+	}
+	return p, nil
+}
+
+// TingkatDariKeparahan maps a CAP severity to the Waspada/Siaga/Awas levels used locally
+func TingkatDariKeparahan(keparahan string) string {
+	switch keparahan {
+	case "Extreme":
+		return "Awas"
+	case "Severe":
+		return "Siaga"
+	default:
+		return "Waspada"
+	}
+}
+
+// TingkatGempa maps magnitude and tsunami potential to a warning level
+func TingkatGempa(magnitudo float64, potensi string) string {
+	p := strings.ToLower(potensi)
+	tsunami := strings.Contains(p, "tsunami") && !strings.Contains(p, "tidak berpotensi")
+	switch {
+	case tsunami || magnitudo >= 7:
+		return "Awas"
+	case magnitudo >= 6:
+		return "Siaga"
+	default:
+		return "Waspada"
+	}
+}
+
+// RadiusGuncanganKm is a rough felt radius: 10^(0.5*M - 1) km (M5 ≈ 32 km, M6 = 100 km)
+func RadiusGuncanganKm(magnitudo float64) float64 {
+	return math.Pow(10, 0.5*magnitudo-1)
+}
+
+// IdentifierReferensi extracts identifiers from a CAP references list ("sender,identifier,sent ...")
+func IdentifierReferensi(referensi string) []string {
+	var hasil []string
+	for _, ref := range strings.Fields(referensi) {
+		bagian := strings.Split(ref, ",")
+		if len(bagian) >= 2 {
+			hasil = append(hasil, bagian[1])
+		} else {
+			hasil = append(hasil, bagian[0])
+		}
+	}
+	return hasil
+}
+
+// parseWaktuFeed accepts RFC3339 and BMKG's "2006-01-02 15:04:05" (dianggap UTC)
+func parseWaktuFeed(teks string) (time.Time, error) {
+	teks = strings.TrimSpace(teks)
+	if t, err := time.Parse(time.RFC3339, teks); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02 15:04:05", teks)
+}
+
+// parseLatLng parses "lat,lng"
+func parseLatLng(teks string) (float64, float64, error) {
+	bagian := strings.Split(strings.TrimSpace(teks), ",")
+	if len(bagian) != 2 {
+		return 0, 0, fmt.Errorf("koordinat %q harus \"lat,lng\"", teks)
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(bagian[0]), 64)
+	lng, err2 := strconv.ParseFloat(strings.TrimSpace(bagian[1]), 64)
+	if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, fmt.Errorf("koordinat %q tidak valid", teks)
+	}
+	return lat, lng, nil
+}
+
+// ---------------------------------------------------------------
+// Pencocokan area peringatan dengan wilayah kecamatan
+// ---------------------------------------------------------------
+
+// WilayahKecamatan is a kecamatan with its boundary already parsed
+type WilayahKecamatan struct {
+	ID      uint
+	Nama    string
+	Kode    string
+	Poligon []Poligon
+}
+
+// SiapkanWilayah parses every kecamatan boundary once. Batas yang tidak valid
+// dikembalikan di gagal agar bisa dilaporkan; kecamatan itu tetap dicocokkan lewat kode/nama.
+func SiapkanWilayah(kecamatan []models.MasterKecamatan) (wilayah []WilayahKecamatan, gagal map[uint]error) {
+	gagal = map[uint]error{}
+	for _, k := range kecamatan {
+		w := WilayahKecamatan{ID: k.ID, Nama: k.Nama, Kode: hanyaDigit(k.KodeWilayah)}
+		if strings.TrimSpace(k.Batas) != "" {
+			poligon, err := ParseGeoJSONPoligon([]byte(k.Batas))
+			if err != nil {
+				gagal[k.ID] = err
+			} else {
+				w.Poligon = poligon
+			}
+		}
+		wilayah = append(wilayah, w)
+	}
+	return wilayah, gagal
+}
+
+// KecamatanTerdampak returns, per kecamatan ID, the names of the areas that hit it.
+// Sebuah area mengenai kecamatan bila kode wilayahnya saling berawalan (kabupaten
+// mencakup kecamatan, desa berada di dalam kecamatan), geometrinya bersinggungan
+// dengan batas kecamatan, atau namanya menyebut nama kecamatan.
+func KecamatanTerdampak(area []models.AreaPeringatan, wilayah []WilayahKecamatan) map[uint][]string {
+	hasil := map[uint][]string{}
+	for _, w := range wilayah {
+		for _, a := range area {
+			if areaMengenai(a, w) {
+				hasil[w.ID] = append(hasil[w.ID], a.Nama)
+			}
+		}
+	}
+	return hasil
+}
+
+func areaMengenai(a models.AreaPeringatan, w WilayahKecamatan) bool {
+	if w.Kode != "" {
+		for _, kode := range a.Kode {
+			kode = hanyaDigit(kode)
+			if kode != "" && (strings.HasPrefix(w.Kode, kode) || strings.HasPrefix(kode, w.Kode)) {
+				return true
+			}
+		}
+	}
+
+	for _, batas := range w.Poligon {
+		for _, p := range a.Poligon {
+			poligon := make(Poligon, len(p))
+			for i, t := range p {
+				poligon[i] = Titik{Lat: t[0], Lng: t[1]}
+			}
+			if PoligonBersinggungan(poligon, batas) {
+				return true
+			}
+		}
+		for _, l := range a.Lingkaran {
+			if LingkaranMenyentuhPoligon(Titik{Lat: l.Lat, Lng: l.Lng}, l.RadiusKm, batas) {
+				return true
+			}
+		}
+	}
+
+	nama := NormalizeText(strings.TrimPrefix(strings.ToLower(w.Nama), "kecamatan "))
+	return nama != "" && strings.Contains(" "+NormalizeText(a.Nama)+" ", " "+nama+" ")
+}
+
+func hanyaDigit(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
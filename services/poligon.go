@@ -0,0 +1,203 @@
+// services/poligon.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Titik adalah satu koordinat
+type Titik struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Poligon adalah satu ring luar. Lubang (ring dalam) GeoJSON diabaikan;
+// cukup untuk batas wilayah dan zona bahaya yang dipakai sistem ini.
+type Poligon []Titik
+
+// geojsonGeometri menampung Geometry, Feature maupun FeatureCollection
+type geojsonGeometri struct {
+	Type        string            `json:"type"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+	Geometry    *geojsonGeometri  `json:"geometry"`
+	Features    []geojsonGeometri `json:"features"`
+}
+
+// ParseGeoJSONPoligon reads a Polygon / MultiPolygon geometry, Feature or
+// FeatureCollection and returns the outer ring of every polygon
+func ParseGeoJSONPoligon(raw []byte) ([]Poligon, error) {
+	var g geojsonGeometri
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return nil, fmt.Errorf("GeoJSON tidak valid: %w", err)
+	}
+	hasil, err := poligonDariGeometri(g)
+	if err != nil {
+		return nil, err
+	}
+	if len(hasil) == 0 {
+		return nil, errors.New("GeoJSON tidak berisi Polygon / MultiPolygon")
+	}
+	return hasil, nil
+}
+
+func poligonDariGeometri(g geojsonGeometri) ([]Poligon, error) {
+	switch g.Type {
+	case "FeatureCollection":
+		var hasil []Poligon
+		for _, f := range g.Features {
+			p, err := poligonDariGeometri(f)
+			if err != nil {
+				return nil, err
+			}
+			hasil = append(hasil, p...)
+		}
+		return hasil, nil
+	case "Feature":
+		if g.Geometry == nil {
+			return nil, nil
+		}
+		return poligonDariGeometri(*g.Geometry)
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &rings); err != nil {
+			return nil, fmt.Errorf("koordinat Polygon tidak valid: %w", err)
+		}
+		p, err := ringLuar(rings)
+		if err != nil {
+			return nil, err
+		}
+		return []Poligon{p}, nil
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("koordinat MultiPolygon tidak valid: %w", err)
+		}
+		hasil := make([]Poligon, 0, len(polygons))
+		for _, rings := range polygons {
+			p, err := ringLuar(rings)
+			if err != nil {
+				return nil, err
+			}
+			hasil = append(hasil, p)
+		}
+		return hasil, nil
+	default:
+		return nil, fmt.Errorf("geometri %q tidak didukung", g.Type)
+	}
+}
+
+// ringLuar converts the first ring ([lng, lat] pairs) to a Poligon
+func ringLuar(rings [][][]float64) (Poligon, error) {
+	if len(rings) == 0 || len(rings[0]) < 3 {
+		return nil, errors.New("poligon membutuhkan minimal 3 titik")
+	}
+	p := make(Poligon, 0, len(rings[0]))
+	for _, c := range rings[0] {
+		if len(c) < 2 {
+			return nil, errors.New("koordinat poligon harus [longitude, latitude]")
+		}
+		p = append(p, Titik{Lat: c[1], Lng: c[0]})
+	}
+	return p, nil
+}
+
+// DalamPoligon reports whether t lies inside p (ray casting; lng = x, lat = y)
+func DalamPoligon(p Poligon, t Titik) bool {
+	dalam := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Lat > t.Lat) != (b.Lat > t.Lat) &&
+			t.Lng < (b.Lng-a.Lng)*(t.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			dalam = !dalam
+		}
+	}
+	return dalam
+}
+
+// DalamSalahSatuPoligon reports whether t lies inside any of the polygons
+func DalamSalahSatuPoligon(poligon []Poligon, t Titik) bool {
+	for _, p := range poligon {
+		if DalamPoligon(p, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// PoligonBersinggungan reports whether two polygons overlap or touch
+func PoligonBersinggungan(a, b Poligon) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	if DalamPoligon(b, a[0]) || DalamPoligon(a, b[0]) {
+		return true
+	}
+	for i := range a {
+		a1, a2 := a[i], a[(i+1)%len(a)]
+		for j := range b {
+			if segmenBerpotongan(a1, a2, b[j], b[(j+1)%len(b)]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LingkaranMenyentuhPoligon reports whether a circle (radius in km) overlaps p
+func LingkaranMenyentuhPoligon(pusat Titik, radiusKm float64, p Poligon) bool {
+	if len(p) == 0 {
+		return false
+	}
+	if DalamPoligon(p, pusat) {
+		return true
+	}
+	for i := range p {
+		if jarakKeSegmenKm(pusat, p[i], p[(i+1)%len(p)]) <= radiusKm {
+			return true
+		}
+	}
+	return false
+}
+
+// segmenBerpotongan reports whether segment p1-p2 intersects q1-q2
+func segmenBerpotongan(p1, p2, q1, q2 Titik) bool {
+	d1 := orientasi(q1, q2, p1)
+	d2 := orientasi(q1, q2, p2)
+	d3 := orientasi(p1, p2, q1)
+	d4 := orientasi(p1, p2, q2)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && diSegmen(q1, q2, p1)) || (d2 == 0 && diSegmen(q1, q2, p2)) ||
+		(d3 == 0 && diSegmen(p1, p2, q1)) || (d4 == 0 && diSegmen(p1, p2, q2))
+}
+
+func orientasi(a, b, c Titik) float64 {
+	return (b.Lng-a.Lng)*(c.Lat-a.Lat) - (b.Lat-a.Lat)*(c.Lng-a.Lng)
+}
+
+// diSegmen reports whether c (collinear with a-b) lies within the segment bounds
+func diSegmen(a, b, c Titik) bool {
+	return math.Min(a.Lng, b.Lng) <= c.Lng && c.Lng <= math.Max(a.Lng, b.Lng) &&
+		math.Min(a.Lat, b.Lat) <= c.Lat && c.Lat <= math.Max(a.Lat, b.Lat)
+}
+
+// jarakKeSegmenKm is the distance from t to segment a-b, dihitung pada proyeksi
+// equirectangular di sekitar t (akurat untuk jarak puluhan kilometer)
+func jarakKeSegmenKm(t, a, b Titik) float64 {
+	kx := 111.32 * math.Cos(t.Lat*math.Pi/180)
+	const ky = 110.574
+	ax, ay := (a.Lng-t.Lng)*kx, (a.Lat-t.Lat)*ky
+	bx, by := (b.Lng-t.Lng)*kx, (b.Lat-t.Lat)*ky
+
+	dx, dy := bx-ax, by-ay
+	panjang := dx*dx + dy*dy
+	u := 0.0
+	if panjang > 0 {
+		u = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/panjang))
+	}
+	return math.Hypot(ax+u*dx, ay+u*dy)
+}
@@ -0,0 +1,133 @@
+package services
+
+import "testing"
+
+// persegi adalah kotak 1x1 derajat dengan sudut kiri bawah di (lat, lng)
+func persegi(lat, lng float64) Poligon {
+	return Poligon{{lat, lng}, {lat, lng + 1}, {lat + 1, lng + 1}, {lat + 1, lng}}
+}
+
+func TestParseGeoJSONPoligon(t *testing.T) {
+	tests := []struct {
+		nama   string
+		raw    string
+		galat  bool
+		jumlah int
+	}{
+		{"Polygon", `{"type":"Polygon","coordinates":[[[112,-7],[113,-7],[113,-6],[112,-7]]]}`, false, 1},
+		{"MultiPolygon", `{"type":"MultiPolygon","coordinates":[[[[112,-7],[113,-7],[113,-6]]],[[[110,-7],[111,-7],[111,-6]]]]}`, false, 2},
+		{"Feature", `{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[112,-7],[113,-7],[113,-6]]]}}`, false, 1},
+		{"FeatureCollection", `{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[112,-7],[113,-7],[113,-6]]]}},{"type":"Feature","geometry":null}]}`, false, 1},
+		{"bukan JSON", `{`, true, 0},
+		{"Point tidak didukung", `{"type":"Point","coordinates":[112,-7]}`, true, 0},
+		{"kurang dari 3 titik", `{"type":"Polygon","coordinates":[[[112,-7],[113,-7]]]}`, true, 0},
+		{"koordinat tidak lengkap", `{"type":"Polygon","coordinates":[[[112],[113,-7],[113,-6]]]}`, true, 0},
+		{"FeatureCollection kosong", `{"type":"FeatureCollection","features":[]}`, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			hasil, err := ParseGeoJSONPoligon([]byte(tt.raw))
+			if (err != nil) != tt.galat {
+				t.Fatalf("ParseGeoJSONPoligon error = %v, want error %v", err, tt.galat)
+			}
+			if len(hasil) != tt.jumlah {
+				t.Errorf("jumlah poligon = %d, want %d", len(hasil), tt.jumlah)
+			}
+		})
+	}
+
+	// GeoJSON memakai urutan [lng, lat]
+	hasil, _ := ParseGeoJSONPoligon([]byte(`{"type":"Polygon","coordinates":[[[112.7,-7.1],[113,-7],[113,-6]]]}`))
+	if got := hasil[0][0]; got != (Titik{Lat: -7.1, Lng: 112.7}) {
+		t.Errorf("titik pertama = %+v, want lat -7.1 lng 112.7", got)
+	}
+}
+
+func TestDalamPoligon(t *testing.T) {
+	kotak := persegi(0, 0)
+	bentukL := Poligon{{0, 0}, {0, 2}, {1, 2}, {1, 1}, {2, 1}, {2, 0}}
+
+	tests := []struct {
+		nama  string
+		p     Poligon
+		titik Titik
+		want  bool
+	}{
+		{"tengah kotak", kotak, Titik{0.5, 0.5}, true},
+		{"di luar kanan", kotak, Titik{0.5, 1.5}, false},
+		{"di luar bawah", kotak, Titik{-0.5, 0.5}, false},
+		{"sejajar sudut di luar", kotak, Titik{1, 2}, false},
+		{"kaki L", bentukL, Titik{0.5, 1.5}, true},
+		{"lekukan L", bentukL, Titik{1.5, 1.5}, false},
+		{"poligon kosong", nil, Titik{0, 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := DalamPoligon(tt.p, tt.titik); got != tt.want {
+				t.Errorf("DalamPoligon(%+v) = %v, want %v", tt.titik, got, tt.want)
+			}
+		})
+	}
+
+	if !DalamSalahSatuPoligon([]Poligon{persegi(5, 5), kotak}, Titik{0.5, 0.5}) {
+		t.Error("DalamSalahSatuPoligon = false, want true")
+	}
+	if DalamSalahSatuPoligon([]Poligon{persegi(5, 5), kotak}, Titik{3, 3}) {
+		t.Error("DalamSalahSatuPoligon = true, want false")
+	}
+}
+
+func TestPoligonBersinggungan(t *testing.T) {
+	tests := []struct {
+		nama string
+		a, b Poligon
+		want bool
+	}{
+		{"tumpang tindih sebagian", persegi(0, 0), persegi(0.5, 0.5), true},
+		{"satu di dalam yang lain", Poligon{{-1, -1}, {-1, 3}, {3, 3}, {3, -1}}, persegi(0, 0), true},
+		{"berbagi sisi", persegi(0, 0), persegi(0, 1), true},
+		{"bersentuhan di sudut", persegi(0, 0), persegi(1, 1), true},
+		{"saling silang tanpa titik di dalam", Poligon{{0, -1}, {0, 2}, {1, 2}, {1, -1}}, Poligon{{-1, 0}, {-1, 1}, {2, 1}, {2, 0}}, true},
+		{"terpisah", persegi(0, 0), persegi(3, 3), false},
+		{"poligon kosong", persegi(0, 0), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := PoligonBersinggungan(tt.a, tt.b); got != tt.want {
+				t.Errorf("PoligonBersinggungan = %v, want %v", got, tt.want)
+			}
+			if got := PoligonBersinggungan(tt.b, tt.a); got != tt.want {
+				t.Errorf("PoligonBersinggungan (dibalik) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLingkaranMenyentuhPoligon(t *testing.T) {
+	// Kotak kecil sekitar 1,1 km di ekuator
+	kotak := Poligon{{0, 0}, {0, 0.01}, {0.01, 0.01}, {0.01, 0}}
+
+	tests := []struct {
+		nama     string
+		pusat    Titik
+		radiusKm float64
+		want     bool
+	}{
+		{"pusat di dalam", Titik{0.005, 0.005}, 0.1, true},
+		{"sekitar 1,1 km dari sisi, radius 2 km", Titik{0.005, 0.02}, 2, true},
+		{"sekitar 1,1 km dari sisi, radius 1 km", Titik{0.005, 0.02}, 1, false},
+		{"dekat sudut", Titik{-0.005, -0.005}, 1, true},
+		{"jauh", Titik{1, 1}, 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := LingkaranMenyentuhPoligon(tt.pusat, tt.radiusKm, kotak); got != tt.want {
+				t.Errorf("LingkaranMenyentuhPoligon(%+v, %v) = %v, want %v", tt.pusat, tt.radiusKm, got, tt.want)
+			}
+		})
+	}
+	if LingkaranMenyentuhPoligon(Titik{0, 0}, 1, nil) {
+		t.Error("LingkaranMenyentuhPoligon(poligon kosong) = true, want false")
+	}
+}
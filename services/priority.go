@@ -0,0 +1,91 @@
+// services/priority.go
+package services
+
+import "github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+
+// Skor dasar per kategori rentan
+var skorKategori = map[string]int{
+	"Disabilitas": 100,
+	"Sakit Keras": 95,
+	"Lansia":      90,
+	"Ibu Hamil":   85,
+	"Anak-anak":   80,
+	"Non-Rentan":  50,
+}
+
+const (
+	// Tambahan skor untuk setiap kategori rentan selain kategori utama
+	bonusKategoriTambahan = 5
+	// Tambahan skor untuk setiap anggota rentan selain yang paling prioritas
+	bonusAnggotaRentan = 5
+)
+
+// Tambahan skor untuk kebutuhan perawatan yang memperlambat atau mempersulit evakuasi
+const (
+	bonusOksigen   = 10
+	bonusCuciDarah = 8
+	bonusTandu     = 6
+	bonusKursiRoda = 4
+)
+
+// IsKategoriValid checks whether kategori is a known vulnerability category
+func IsKategoriValid(kategori string) bool {
+	_, ok := skorKategori[kategori]
+	return ok
+}
+
+// KategoriUtama returns the category with the highest base score
+func KategoriUtama(kategori []string) string {
+	utama := "Non-Rentan"
+	for _, k := range kategori {
+		if skorKategori[k] > skorKategori[utama] {
+			utama = k
+		}
+	}
+	return utama
+}
+
+// HitungSkorPrioritas menghitung skor prioritas evakuasi seorang warga dari
+// semua kategori rentannya dan kebutuhan perawatannya (boleh nil)
+func HitungSkorPrioritas(kategori []string, kebutuhan *models.KebutuhanPerawatan) int {
+	utama := KategoriUtama(kategori)
+	skor := skorKategori[utama]
+
+	for _, k := range kategori {
+		if k != "Non-Rentan" && k != utama {
+			skor += bonusKategoriTambahan
+		}
+	}
+
+	if kebutuhan != nil {
+		if kebutuhan.Oksigen {
+			skor += bonusOksigen
+		}
+		if kebutuhan.JadwalCuciDarah != "" {
+			skor += bonusCuciDarah
+		}
+		if kebutuhan.Tandu {
+			skor += bonusTandu
+		}
+		if kebutuhan.KursiRoda {
+			skor += bonusKursiRoda
+		}
+	}
+
+	return skor
+}
+
+// SkorPrioritasKeluarga menghitung skor satu rumah tangga dari skor anggota rentannya.
+// Rumah dengan beberapa anggota rentan didahulukan karena satu kunjungan
+// relawan mengevakuasi semuanya sekaligus.
+func SkorPrioritasKeluarga(skorAnggotaRentan []int) int {
+	if len(skorAnggotaRentan) == 0 {
+		return 0
+	}
+
+	tertinggi := 0
+	for _, skor := range skorAnggotaRentan {
+		tertinggi = max(tertinggi, skor)
+	}
+	return tertinggi + bonusAnggotaRentan*(len(skorAnggotaRentan)-1)
+}
@@ -0,0 +1,48 @@
+// services/qr.go
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Awalan isi QR warga; versi dinaikkan bila format berubah
+const prefixQRWarga = "SMB1.W."
+
+// ErrQRTidakValid is returned for QR codes that were not issued by this system
+var ErrQRTidakValid = errors.New("QR code is not valid")
+
+// TokenQRWarga membuat isi QR untuk seorang warga: "SMB1.W.<id>.<tanda tangan>".
+// Tanda tangan HMAC mencegah QR dipalsukan dengan mengganti ID.
+func TokenQRWarga(wargaID uint, secret string) string {
+	id := strconv.FormatUint(uint64(wargaID), 10)
+	return prefixQRWarga + id + "." + tandaTanganQR(id, secret)
+}
+
+// ParseTokenQRWarga memeriksa isi QR dan mengembalikan ID warga
+func ParseTokenQRWarga(token, secret string) (uint, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(token), prefixQRWarga)
+	if !ok {
+		return 0, ErrQRTidakValid
+	}
+	id, sig, ok := strings.Cut(rest, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(tandaTanganQR(id, secret))) {
+		return 0, ErrQRTidakValid
+	}
+	wargaID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || wargaID == 0 {
+		return 0, ErrQRTidakValid
+	}
+	return uint(wargaID), nil
+}
+
+func tandaTanganQR(id, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(prefixQRWarga + id))
+	// 12 byte sudah cukup dan menjaga QR tetap kecil
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:12])
+}
@@ -0,0 +1,105 @@
+// services/relawan.go
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+// KeahlianRelawanValid adalah daftar keahlian yang bisa dipilih relawan
+var KeahlianRelawanValid = map[string]bool{
+	"Pertolongan Pertama": true,
+	"Renang":              true,
+	"Operator Perahu":     true,
+	"Pengemudi":           true,
+	"Medis":               true,
+	"SAR":                 true,
+	"Komunikasi Radio":    true,
+	"Dapur Umum":          true,
+}
+
+// parseJam mengubah "HH:MM" menjadi menit sejak tengah malam
+func parseJam(jam string) (int, error) {
+	t, err := time.Parse("15:04", jam)
+	if err != nil {
+		return 0, fmt.Errorf("jam %q harus berformat HH:MM", jam)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ValidateJadwal memeriksa hari (0-6) dan format jam satu slot ketersediaan
+func ValidateJadwal(j models.JadwalRelawanRequest) error {
+	if j.Hari < 0 || j.Hari > 6 {
+		return fmt.Errorf("hari harus 0 (Minggu) sampai 6 (Sabtu)")
+	}
+	mulai, err := parseJam(j.JamMulai)
+	if err != nil {
+		return err
+	}
+	selesai, err := parseJam(j.JamSelesai)
+	if err != nil {
+		return err
+	}
+	if mulai == selesai {
+		return fmt.Errorf("jam_mulai dan jam_selesai tidak boleh sama")
+	}
+	return nil
+}
+
+// TersediaPadaJadwal reports whether t falls inside one of the weekly slots.
+// Slot yang melewati tengah malam (22:00-06:00) berlanjut ke hari berikutnya.
+func TersediaPadaJadwal(jadwal []models.JadwalRelawan, t time.Time) bool {
+	hari := int(t.Weekday())
+	menit := t.Hour()*60 + t.Minute()
+
+	for _, j := range jadwal {
+		mulai, err1 := parseJam(j.JamMulai)
+		selesai, err2 := parseJam(j.JamSelesai)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if mulai < selesai {
+			if j.Hari == hari && menit >= mulai && menit < selesai {
+				return true
+			}
+			continue
+		}
+		// Lewat tengah malam
+		if j.Hari == hari && menit >= mulai {
+			return true
+		}
+		if (j.Hari+1)%7 == hari && menit < selesai {
+			return true
+		}
+	}
+	return false
+}
+
+// RelawanTersedia adalah relawan yang cocok untuk dikirim ke suatu titik
+type RelawanTersedia struct {
+	models.ProfilRelawan
+	Terjadwal bool     `json:"terjadwal"` // Tidak sedang bertugas tetapi jadwalnya mencakup waktu ini
+	Beban     int      `json:"beban"`     // Jumlah tugas evakuasi aktif
+	JarakKm   *float64 `json:"jarak_km"`  // Kosong bila lokasi terakhir belum diketahui
+}
+
+// UrutkanRelawanTersedia menaruh relawan yang sedang bertugas lebih dulu,
+// lalu yang terdekat, lalu yang bebannya paling ringan. Lokasi tidak diketahui di akhir.
+func UrutkanRelawanTersedia(relawan []RelawanTersedia) {
+	sort.SliceStable(relawan, func(i, j int) bool {
+		a, b := relawan[i], relawan[j]
+		if a.SedangBertugas != b.SedangBertugas {
+			return a.SedangBertugas
+		}
+		if (a.JarakKm == nil) != (b.JarakKm == nil) {
+			return a.JarakKm != nil
+		}
+		if a.JarakKm != nil && *a.JarakKm != *b.JarakKm {
+			return *a.JarakKm < *b.JarakKm
+		}
+		return a.Beban < b.Beban
+	})
+}
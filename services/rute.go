@@ -0,0 +1,273 @@
+// services/rute.go
+package services
+
+import (
+	"encoding/xml"
+	"sort"
+	"time"
+)
+
+// TitikRute adalah satu warga yang harus dikunjungi relawan
+type TitikRute struct {
+	WargaID       uint    `json:"warga_id"`
+	TugasID       uint    `json:"tugas_id"`
+	Nama          string  `json:"nama"`
+	Alamat        string  `json:"alamat"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	SkorPrioritas int     `json:"skor_prioritas"`
+}
+
+// LangkahRute adalah titik pada urutan kunjungan beserta jaraknya
+type LangkahRute struct {
+	Urutan int `json:"urutan"`
+	TitikRute
+	JarakKm          float64 `json:"jarak_km"`           // Dari titik sebelumnya
+	JarakKumulatifKm float64 `json:"jarak_kumulatif_km"` // Dari titik awal
+}
+
+// Rute adalah hasil perencanaan urutan kunjungan
+type Rute struct {
+	AdaTitikAwal bool          `json:"ada_titik_awal"`
+	AwalLat      float64       `json:"awal_latitude"`
+	AwalLng      float64       `json:"awal_longitude"`
+	Langkah      []LangkahRute `json:"langkah"`
+	TotalJarakKm float64       `json:"total_jarak_km"`
+	TanpaLokasi  []TitikRute   `json:"tanpa_lokasi"` // Warga tanpa koordinat, dikunjungi terakhir
+}
+
+// RencanaRute menyusun urutan kunjungan dengan nearest-neighbour lalu diperbaiki 2-opt.
+// Biaya rute adalah jumlah jarak tempuh sampai tiap warga dikalikan bobot skor
+// prioritasnya, sehingga warga prioritas tinggi cenderung didatangi lebih dulu
+// tanpa membuat relawan bolak-balik. Jarak memakai garis lurus (haversine),
+// sepenuhnya offline. Bila adaAwal false, rute dimulai dari warga dengan skor tertinggi.
+func RencanaRute(awalLat, awalLng float64, adaAwal bool, titik []TitikRute) Rute {
+	rute := Rute{AdaTitikAwal: adaAwal, AwalLat: awalLat, AwalLng: awalLng}
+
+	var berlokasi []TitikRute
+	for _, t := range titik {
+		if AdaKoordinat(t.Latitude, t.Longitude) {
+			berlokasi = append(berlokasi, t)
+		} else {
+			rute.TanpaLokasi = append(rute.TanpaLokasi, t)
+		}
+	}
+	sort.SliceStable(rute.TanpaLokasi, func(i, j int) bool {
+		return rute.TanpaLokasi[i].SkorPrioritas > rute.TanpaLokasi[j].SkorPrioritas
+	})
+
+	if len(berlokasi) == 0 {
+		return rute
+	}
+
+	if !adaAwal {
+		// Mulai dari warga paling prioritas
+		sort.SliceStable(berlokasi, func(i, j int) bool {
+			return berlokasi[i].SkorPrioritas > berlokasi[j].SkorPrioritas
+		})
+		rute.AwalLat, rute.AwalLng = berlokasi[0].Latitude, berlokasi[0].Longitude
+	}
+
+	urutan := nearestNeighbour(rute.AwalLat, rute.AwalLng, berlokasi)
+	urutan = perbaiki2Opt(rute.AwalLat, rute.AwalLng, urutan, !adaAwal)
+
+	lat, lng := rute.AwalLat, rute.AwalLng
+	for i, t := range urutan {
+		jarak := JarakKm(lat, lng, t.Latitude, t.Longitude)
+		rute.TotalJarakKm += jarak
+		rute.Langkah = append(rute.Langkah, LangkahRute{
+			Urutan:           i + 1,
+			TitikRute:        t,
+			JarakKm:          jarak,
+			JarakKumulatifKm: rute.TotalJarakKm,
+		})
+		lat, lng = t.Latitude, t.Longitude
+	}
+
+	return rute
+}
+
+// bobotPrioritas mengubah skor prioritas menjadi bobot biaya (skor 100 = bobot 1)
+func bobotPrioritas(skor int) float64 {
+	if skor < 1 {
+		skor = 1
+	}
+	return float64(skor) / 100
+}
+
+// biayaRute menjumlahkan jarak tempuh sampai tiap titik dikali bobot prioritasnya
+func biayaRute(awalLat, awalLng float64, urutan []TitikRute) float64 {
+	var biaya, tempuh float64
+	lat, lng := awalLat, awalLng
+	for _, t := range urutan {
+		tempuh += JarakKm(lat, lng, t.Latitude, t.Longitude)
+		biaya += tempuh * bobotPrioritas(t.SkorPrioritas)
+		lat, lng = t.Latitude, t.Longitude
+	}
+	// Total jarak ikut dihitung agar rute tetap pendek di antara warga berskor sama
+	return biaya + tempuh
+}
+
+// nearestNeighbour selalu memilih titik dengan jarak terbobot terkecil berikutnya
+func nearestNeighbour(lat, lng float64, titik []TitikRute) []TitikRute {
+	sisa := append([]TitikRute(nil), titik...)
+	urutan := make([]TitikRute, 0, len(titik))
+
+	for len(sisa) > 0 {
+		terbaik := 0
+		var nilaiTerbaik float64
+		for i, t := range sisa {
+			nilai := JarakKm(lat, lng, t.Latitude, t.Longitude) / bobotPrioritas(t.SkorPrioritas)
+			if i == 0 || nilai < nilaiTerbaik {
+				terbaik, nilaiTerbaik = i, nilai
+			}
+		}
+
+		t := sisa[terbaik]
+		urutan = append(urutan, t)
+		lat, lng = t.Latitude, t.Longitude
+		sisa = append(sisa[:terbaik], sisa[terbaik+1:]...)
+	}
+	return urutan
+}
+
+// perbaiki2Opt membalik segmen rute selama biaya masih bisa turun.
+// Bila kunciPertama true, titik pertama (titik awal) tidak ikut dipindah.
+func perbaiki2Opt(awalLat, awalLng float64, urutan []TitikRute, kunciPertama bool) []TitikRute {
+	mulai := 0
+	if kunciPertama {
+		mulai = 1
+	}
+
+	terbaik := biayaRute(awalLat, awalLng, urutan)
+	for membaik := true; membaik; {
+		membaik = false
+		for i := mulai; i < len(urutan)-1; i++ {
+			for j := i + 1; j < len(urutan); j++ {
+				balikSegmen(urutan, i, j)
+				if biaya := biayaRute(awalLat, awalLng, urutan); biaya < terbaik-1e-9 {
+					terbaik = biaya
+					membaik = true
+				} else {
+					balikSegmen(urutan, i, j)
+				}
+			}
+		}
+	}
+	return urutan
+}
+
+func balikSegmen(urutan []TitikRute, i, j int) {
+	for ; i < j; i, j = i+1, j-1 {
+		urutan[i], urutan[j] = urutan[j], urutan[i]
+	}
+}
+
+// RuteGeoJSON mengekspor rute sebagai FeatureCollection: satu LineString jalur
+// dan satu Point per warga (urutan kunjungan di properties).
+// Rute tanpa warga berlokasi menghasilkan FeatureCollection kosong.
+func RuteGeoJSON(rute Rute) map[string]interface{} {
+	features := make([]interface{}, 0, len(rute.Langkah)+1)
+
+	if len(rute.Langkah) > 0 {
+		// GeoJSON memakai urutan [longitude, latitude]
+		garis := [][]float64{{rute.AwalLng, rute.AwalLat}}
+		for _, l := range rute.Langkah {
+			garis = append(garis, []float64{l.Longitude, l.Latitude})
+		}
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "LineString",
+				"coordinates": garis,
+			},
+			"properties": map[string]interface{}{
+				"jenis":          "rute",
+				"total_jarak_km": rute.TotalJarakKm,
+			},
+		})
+	}
+
+	for _, l := range rute.Langkah {
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "Point",
+				"coordinates": []float64{l.Longitude, l.Latitude},
+			},
+			"properties": map[string]interface{}{
+				"jenis":              "warga",
+				"urutan":             l.Urutan,
+				"warga_id":           l.WargaID,
+				"tugas_id":           l.TugasID,
+				"nama":               l.Nama,
+				"alamat":             l.Alamat,
+				"skor_prioritas":     l.SkorPrioritas,
+				"jarak_km":           l.JarakKm,
+				"jarak_kumulatif_km": l.JarakKumulatifKm,
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	}
+}
+
+type gpxDokumen struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	Nama      string        `xml:"metadata>name"`
+	Waktu     string        `xml:"metadata>time"`
+	Waypoints []gpxTitik    `xml:"wpt"`
+	Route     gpxRouteBlock `xml:"rte"`
+}
+
+type gpxRouteBlock struct {
+	Nama   string     `xml:"name"`
+	Points []gpxTitik `xml:"rtept"`
+}
+
+type gpxTitik struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Nama string  `xml:"name,omitempty"`
+	Desc string  `xml:"desc,omitempty"`
+}
+
+// RuteGPX mengekspor rute sebagai GPX 1.1 (waypoint per warga + satu rte).
+// Rute tanpa warga berlokasi menghasilkan rte kosong.
+func RuteGPX(nama string, rute Rute, waktu time.Time) ([]byte, error) {
+	doc := gpxDokumen{
+		Version: "1.1",
+		Creator: "Sistem Mitigasi Bencana",
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Nama:    nama,
+		Waktu:   waktu.UTC().Format(time.RFC3339),
+		Route:   gpxRouteBlock{Nama: nama},
+	}
+
+	// Tanpa titik awal, rute dimulai dari warga pertama sehingga titik awal tidak ditulis ulang
+	if rute.AdaTitikAwal && len(rute.Langkah) > 0 {
+		doc.Route.Points = append(doc.Route.Points, gpxTitik{Lat: rute.AwalLat, Lon: rute.AwalLng, Nama: "Titik awal"})
+	}
+	for _, l := range rute.Langkah {
+		titik := gpxTitik{
+			Lat:  l.Latitude,
+			Lon:  l.Longitude,
+			Nama: l.Nama,
+			Desc: l.Alamat,
+		}
+		doc.Waypoints = append(doc.Waypoints, titik)
+		doc.Route.Points = append(doc.Route.Points, titik)
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRuteKosong(t *testing.T) {
+	// Semua warga tanpa koordinat: tidak ada jalur maupun titik awal yang diekspor
+	rute := RencanaRute(-7.04, 112.73, true, []TitikRute{{WargaID: 1, Nama: "Tanpa lokasi"}})
+
+	features := RuteGeoJSON(rute)["features"].([]interface{})
+	if len(features) != 0 {
+		t.Errorf("jumlah feature = %d, want 0", len(features))
+	}
+
+	gpx, err := RuteGPX("Rute kosong", rute, time.Now())
+	if err != nil {
+		t.Fatalf("RuteGPX error = %v", err)
+	}
+	if strings.Contains(string(gpx), "<rtept") || strings.Contains(string(gpx), "<wpt") {
+		t.Errorf("GPX rute kosong masih berisi titik:\n%s", gpx)
+	}
+}
+
+func TestRuteGPXTitikAwal(t *testing.T) {
+	titik := []TitikRute{
+		{WargaID: 1, Nama: "A", Latitude: -7.05, Longitude: 112.74, SkorPrioritas: 90},
+		{WargaID: 2, Nama: "B", Latitude: -7.06, Longitude: 112.75, SkorPrioritas: 50},
+	}
+	tests := []struct {
+		nama    string
+		adaAwal bool
+		want    int
+	}{
+		{"dengan titik awal", true, 3},
+		{"tanpa titik awal", false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			rute := RencanaRute(-7.04, 112.73, tt.adaAwal, titik)
+			gpx, err := RuteGPX("Rute", rute, time.Now())
+			if err != nil {
+				t.Fatalf("RuteGPX error = %v", err)
+			}
+			if got := strings.Count(string(gpx), "<rtept"); got != tt.want {
+				t.Errorf("jumlah rtept = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
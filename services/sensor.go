@@ -0,0 +1,60 @@
+// services/sensor.go
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// OperatorAmbangValid adalah operator perbandingan yang boleh dipakai aturan ambang
+var OperatorAmbangValid = map[string]bool{">": true, ">=": true, "<": true, "<=": true}
+
+// MelewatiAmbang reports whether nilai crosses ambang for the given operator
+func MelewatiAmbang(operator string, nilai, ambang float64) bool {
+	switch operator {
+	case ">":
+		return nilai > ambang
+	case ">=":
+		return nilai >= ambang
+	case "<":
+		return nilai < ambang
+	case "<=":
+		return nilai <= ambang
+	}
+	return false
+}
+
+// AmbangTerlewati reports whether the latest jumlah readings (terbaru lebih dulu)
+// all cross the threshold. Bacaan yang kurang dari jumlah dianggap belum terlewati.
+func AmbangTerlewati(operator string, ambang float64, jumlah int, terbaru []float64) bool {
+	if jumlah < 1 {
+		jumlah = 1
+	}
+	if len(terbaru) < jumlah {
+		return false
+	}
+	for _, nilai := range terbaru[:jumlah] {
+		if !MelewatiAmbang(operator, nilai, ambang) {
+			return false
+		}
+	}
+	return true
+}
+
+// BuatTokenSensor generates a random ingestion token and its stored hash.
+// Token hanya ditampilkan sekali saat dibuat.
+func BuatTokenSensor() (token, hash string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, HashTokenSensor(token), nil
+}
+
+// HashTokenSensor returns the hex SHA-256 of a sensor token
+func HashTokenSensor(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
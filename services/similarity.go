@@ -0,0 +1,79 @@
+// services/similarity.go
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Singkatan alamat yang umum ditulis berbeda-beda oleh petugas
+var singkatanAlamat = map[string]string{
+	"jl":    "jalan",
+	"jln":   "jalan",
+	"gg":    "gang",
+	"kp":    "kampung",
+	"kmp":   "kampung",
+	"dsn":   "dusun",
+	"ds":    "desa",
+	"no":    "nomor",
+	"perum": "perumahan",
+}
+
+// NormalizeText mengecilkan huruf, membuang tanda baca dan merapikan spasi
+func NormalizeText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// NormalizeAlamat seperti NormalizeText, ditambah penyeragaman singkatan
+func NormalizeAlamat(s string) string {
+	words := strings.Fields(NormalizeText(s))
+	for i, w := range words {
+		if full, ok := singkatanAlamat[w]; ok {
+			words[i] = full
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Similarity mengembalikan kemiripan dua string (0..1) berbasis jarak Levenshtein
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
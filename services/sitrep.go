@@ -0,0 +1,266 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+const formatWaktuSitrep = "02 Jan 2006 15:04"
+
+// barisDampak is one row of the casualty/damage table
+type barisDampak struct {
+	Label      string
+	Total      string
+	PerWilayah []string
+}
+
+// tabelDampak lays out the reported figures; kolom yang belum pernah dilaporkan dilewati
+func tabelDampak(rekap models.RekapDampakBencana) []barisDampak {
+	var tabel []barisDampak
+	for _, k := range DaftarKolomDampak {
+		total := k.Nilai(rekap.Total)
+		if total == nil {
+			continue
+		}
+		baris := barisDampak{Label: k.Label, Total: fmt.Sprint(*total)}
+		for _, w := range rekap.PerWilayah {
+			nilai := "-"
+			if v := k.Nilai(w.Dampak); v != nil {
+				nilai = fmt.Sprint(*v)
+			}
+			baris.PerWilayah = append(baris.PerWilayah, nilai)
+		}
+		tabel = append(tabel, baris)
+	}
+	return tabel
+}
+
+// NamaWilayahDampak labels a DampakWilayah, mis. "RT 002 / RW 001"
+func NamaWilayahDampak(w models.DampakWilayah) string {
+	var bagian []string
+	if w.RT != "" {
+		bagian = append(bagian, "RT "+w.RT)
+	}
+	if w.RW != "" {
+		bagian = append(bagian, "RW "+w.RW)
+	}
+	if len(bagian) == 0 {
+		return "Umum"
+	}
+	return strings.Join(bagian, " / ")
+}
+
+// DurasiSitrep formats how long a bencana has been going on
+func DurasiSitrep(mulai time.Time, selesai *time.Time, sekarang time.Time) string {
+	akhir := sekarang
+	if selesai != nil {
+		akhir = *selesai
+	}
+	d := akhir.Sub(mulai)
+	if d < 0 {
+		d = 0
+	}
+	jam := int(d.Hours())
+	if jam >= 24 {
+		return fmt.Sprintf("%d hari %d jam", jam/24, jam%24)
+	}
+	return fmt.Sprintf("%d jam %d menit", jam, int(d.Minutes())%60)
+}
+
+var templateSitrep = template.Must(template.New("sitrep").Funcs(template.FuncMap{
+	"waktu":         func(t time.Time) string { return t.Format(formatWaktuSitrep) },
+	"namaWilayah":   NamaWilayahDampak,
+	"tabelDampak":   tabelDampak,
+	"urutanStatus":  func() []string { return alurEvakuasi },
+	"ringkasDampak": ringkasDampak,
+	"durasi":        DurasiSitrep,
+}).Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>Sitrep {{.Bencana.JenisBencana}} #{{.Bencana.ID}}</title>
+<style>
+body { font-family: Arial, Helvetica, sans-serif; font-size: 13px; margin: 24px; color: #222; }
+h1 { font-size: 20px; margin-bottom: 4px; }
+h2 { font-size: 15px; border-bottom: 1px solid #999; padding-bottom: 2px; margin-top: 22px; }
+table { border-collapse: collapse; margin-top: 6px; }
+th, td { border: 1px solid #bbb; padding: 3px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+td.angka { text-align: right; }
+.kecil { color: #666; font-size: 11px; }
+</style>
+</head>
+<body>
+<h1>Laporan Situasi (Sitrep) – {{.Bencana.JenisBencana}}</h1>
+<div class="kecil">Kecamatan #{{.KecamatanID}} · Bencana #{{.Bencana.ID}} · dibuat {{waktu .DibuatPada}}</div>
+
+<h2>1. Ringkasan Kejadian</h2>
+<table>
+<tr><th>Jenis</th><td>{{.Bencana.JenisBencana}}{{if .Bencana.KodeJenis}} ({{.Bencana.KodeJenis}}){{end}}</td></tr>
+<tr><th>Level</th><td>{{.Bencana.Level}}</td></tr>
+<tr><th>Status</th><td>{{.Bencana.Status}}</td></tr>
+<tr><th>Mulai</th><td>{{waktu .Bencana.WaktuMulai}} ({{durasi .Bencana.WaktuMulai .Bencana.WaktuSelesai .DibuatPada}})</td></tr>
+{{if .Bencana.WaktuSelesai}}<tr><th>Selesai</th><td>{{waktu .Bencana.WaktuSelesai}}</td></tr>{{end}}
+<tr><th>Deskripsi</th><td>{{.Bencana.Deskripsi}}</td></tr>
+</table>
+
+<h2>2. Korban &amp; Kerusakan</h2>
+{{$tabel := tabelDampak .Dampak}}
+{{if $tabel}}
+<table>
+<tr><th></th><th>Total</th>{{range .Dampak.PerWilayah}}<th>{{namaWilayah .}}</th>{{end}}</tr>
+{{range $tabel}}<tr><th>{{.Label}}</th><td class="angka"><b>{{.Total}}</b></td>{{range .PerWilayah}}<td class="angka">{{.}}</td>{{end}}</tr>
+{{end}}
+</table>
+<div class="kecil">Dari {{.Dampak.JumlahLaporan}} laporan{{if .Dampak.DiperbaruiPada}}, terakhir {{waktu .Dampak.DiperbaruiPada}}{{end}}.</div>
+{{else}}<p>Belum ada laporan angka korban/kerusakan.</p>{{end}}
+
+<h2>3. Evakuasi</h2>
+<table>
+<tr><th>Warga rentan terdampak</th><td class="angka">{{.Evakuasi.WargaTerdampak}}</td></tr>
+<tr><th>Belum ditangani</th><td class="angka">{{.Evakuasi.BelumDitangani}}</td></tr>
+{{$per := .Evakuasi.PerStatus}}{{range urutanStatus}}<tr><th>{{.}}</th><td class="angka">{{index $per .}}</td></tr>
+{{end}}
+</table>
+{{if .OrangHilang.Hilang}}<p>Orang hilang (terdaftar): <b>{{.OrangHilang.Hilang}}</b> masih dicari, {{.OrangHilang.Ditemukan}} sudah ditemukan.</p>
+{{else if .OrangHilang.Ditemukan}}<p>Orang hilang (terdaftar): semua ditemukan ({{.OrangHilang.Ditemukan}}).</p>{{end}}
+
+<h2>4. Pengungsian</h2>
+{{if .Pengungsi}}
+<table>
+<tr><th>Titik kumpul</th><th>Pengungsi</th><th>Kapasitas</th></tr>
+{{range .Pengungsi}}<tr><td>{{.TitikKumpul}}</td><td class="angka">{{.Jumlah}}</td><td class="angka">{{.Kapasitas}}</td></tr>
+{{end}}
+</table>
+{{else}}<p>Belum ada pengungsi terdaftar di titik kumpul.</p>{{end}}
+
+<h2>5. Kebutuhan</h2>
+{{if .Kebutuhan}}
+<table>
+<tr><th>Jenis</th><th>Deskripsi</th><th>Jumlah</th><th>Urgensi</th><th>Status</th></tr>
+{{range .Kebutuhan}}<tr><td>{{.Jenis}}</td><td>{{.Deskripsi}}</td><td class="angka">{{.Jumlah}} {{.Satuan}}</td><td>{{.Urgensi}}</td><td>{{.Status}}</td></tr>
+{{end}}
+</table>
+{{else}}<p>Tidak ada permintaan sumber daya yang masih terbuka.</p>{{end}}
+{{if .TugasBelum}}
+<p>Tugas playbook yang belum selesai:</p>
+<ul>{{range .TugasBelum}}<li>{{.Judul}}{{if .Peran}} ({{.Peran}}){{end}}</li>{{end}}</ul>
+{{end}}
+
+<h2>6. Kronologi</h2>
+{{if .Timeline}}
+<table>
+<tr><th>Waktu</th><th>Wilayah</th><th>Update</th></tr>
+{{range .Timeline}}<tr><td>{{waktu .Waktu}}</td><td>{{if or .RT .RW}}{{if .RT}}RT {{.RT}} {{end}}{{if .RW}}RW {{.RW}}{{end}}{{end}}</td>
+<td>{{.Isi}}{{with ringkasDampak .Dampak}}<br><span class="kecil">{{.}}</span>{{end}}{{range .Lampiran}}<br><a href="{{.URL}}">{{.NamaBerkas}}</a>{{end}}</td></tr>
+{{end}}
+</table>
+{{else}}<p>Belum ada entri timeline.</p>{{end}}
+</body>
+</html>
+`))
+
+// RenderSitrepHTML renders a situation report as a standalone HTML page
+func RenderSitrepHTML(s models.Sitrep) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templateSitrep.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderSitrepPDF renders the same report as a plain text PDF
+func RenderSitrepPDF(s models.Sitrep) ([]byte, error) {
+	b := s.Bencana
+	baris := []BarisPDF{
+		{Teks: "Laporan Situasi (Sitrep) - " + b.JenisBencana, Judul: true},
+		{Teks: fmt.Sprintf("Kecamatan #%d, bencana #%d, dibuat %s", s.KecamatanID, b.ID, s.DibuatPada.Format(formatWaktuSitrep))},
+		{Teks: "1. Ringkasan Kejadian", Judul: true},
+		{Teks: fmt.Sprintf("Level %s, status %s, mulai %s (%s)", b.Level, b.Status, b.WaktuMulai.Format(formatWaktuSitrep),
+			DurasiSitrep(b.WaktuMulai, b.WaktuSelesai, s.DibuatPada))},
+	}
+	if b.Deskripsi != "" {
+		baris = append(baris, BarisPDF{Teks: b.Deskripsi})
+	}
+
+	baris = append(baris, BarisPDF{Teks: "2. Korban & Kerusakan", Judul: true})
+	if tabel := tabelDampak(s.Dampak); len(tabel) > 0 {
+		for _, t := range tabel {
+			rincian := make([]string, 0, len(t.PerWilayah))
+			for i, w := range s.Dampak.PerWilayah {
+				rincian = append(rincian, NamaWilayahDampak(w)+": "+t.PerWilayah[i])
+			}
+			baris = append(baris, BarisPDF{Teks: fmt.Sprintf("- %s: %s (%s)", t.Label, t.Total, strings.Join(rincian, ", "))})
+		}
+		baris = append(baris, BarisPDF{Teks: fmt.Sprintf("Dari %d laporan.", s.Dampak.JumlahLaporan)})
+	} else {
+		baris = append(baris, BarisPDF{Teks: "Belum ada laporan angka korban/kerusakan."})
+	}
+
+	baris = append(baris,
+		BarisPDF{Teks: "3. Evakuasi", Judul: true},
+		BarisPDF{Teks: fmt.Sprintf("Warga rentan terdampak %d, belum ditangani %d", s.Evakuasi.WargaTerdampak, s.Evakuasi.BelumDitangani)},
+	)
+	for _, status := range alurEvakuasi {
+		baris = append(baris, BarisPDF{Teks: fmt.Sprintf("- %s: %d", status, s.Evakuasi.PerStatus[status])})
+	}
+	if s.OrangHilang.Hilang+s.OrangHilang.Ditemukan > 0 {
+		baris = append(baris, BarisPDF{Teks: fmt.Sprintf("Orang hilang terdaftar: %d masih dicari, %d ditemukan", s.OrangHilang.Hilang, s.OrangHilang.Ditemukan)})
+	}
+
+	baris = append(baris, BarisPDF{Teks: "4. Pengungsian", Judul: true})
+	if len(s.Pengungsi) == 0 {
+		baris = append(baris, BarisPDF{Teks: "Belum ada pengungsi terdaftar di titik kumpul."})
+	}
+	for _, p := range s.Pengungsi {
+		baris = append(baris, BarisPDF{Teks: fmt.Sprintf("- %s: %d pengungsi (kapasitas %d)", p.TitikKumpul, p.Jumlah, p.Kapasitas)})
+	}
+
+	baris = append(baris, BarisPDF{Teks: "5. Kebutuhan", Judul: true})
+	if len(s.Kebutuhan) == 0 {
+		baris = append(baris, BarisPDF{Teks: "Tidak ada permintaan sumber daya yang masih terbuka."})
+	}
+	for _, k := range s.Kebutuhan {
+		baris = append(baris, BarisPDF{Teks: fmt.Sprintf("- [%s] %s: %s, %v %s (%s)", k.Urgensi, k.Jenis, k.Deskripsi, k.Jumlah, k.Satuan, k.Status)})
+	}
+	for _, t := range s.TugasBelum {
+		baris = append(baris, BarisPDF{Teks: "- Tugas belum selesai: " + t.Judul})
+	}
+
+	baris = append(baris, BarisPDF{Teks: "6. Kronologi", Judul: true})
+	if len(s.Timeline) == 0 {
+		baris = append(baris, BarisPDF{Teks: "Belum ada entri timeline."})
+	}
+	for _, e := range s.Timeline {
+		teks := e.Waktu.Format(formatWaktuSitrep)
+		if wilayah := NamaWilayahDampak(models.DampakWilayah{RT: e.RT, RW: e.RW}); wilayah != "Umum" {
+			teks += " [" + wilayah + "]"
+		}
+		teks += " " + e.Isi
+		if d := ringkasDampak(e.Dampak); d != "" {
+			teks += " (" + d + ")"
+		}
+		if len(e.Lampiran) > 0 {
+			teks += fmt.Sprintf(" [%d lampiran]", len(e.Lampiran))
+		}
+		baris = append(baris, BarisPDF{Teks: teks})
+	}
+
+	return BuatPDFTeks(baris)
+}
+
+// ringkasDampak formats the figures of one entry, mis. "Meninggal 2, Rumah terendam 40"
+func ringkasDampak(d models.DampakBencana) string {
+	var bagian []string
+	for _, k := range DaftarKolomDampak {
+		if v := k.Nilai(d); v != nil {
+			bagian = append(bagian, fmt.Sprintf("%s %d", k.Label, *v))
+		}
+	}
+	return strings.Join(bagian, ", ")
+}
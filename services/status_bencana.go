@@ -0,0 +1,49 @@
+// services/status_bencana.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Status kejadian bencana
+const (
+	BencanaDraft    = "Draft"
+	BencanaAktif    = "Aktif"
+	BencanaSelesai  = "Selesai"
+	BencanaDigabung = "Digabung"
+)
+
+// Alur status lewat PUT /bencana/:id/status: bencana Aktif hanya bisa ditutup. Draft
+// dikonfirmasi/ditolak lewat peringatan sensor, Digabung lewat penggabungan, dan
+// bencana yang sudah Selesai tidak dibuka lagi (laporkan sebagai bencana baru).
+var transisiBencana = map[string][]string{
+	BencanaDraft:    {},
+	BencanaAktif:    {BencanaSelesai},
+	BencanaSelesai:  {},
+	BencanaDigabung: {},
+}
+
+var ErrTransisiBencana = errors.New("transisi status bencana tidak diizinkan")
+
+// IsStatusBencanaValid reports whether status is a known bencana status
+func IsStatusBencanaValid(status string) bool {
+	_, ok := transisiBencana[status]
+	return ok
+}
+
+// ValidateTransisiBencana checks whether a bencana may move from status dari to ke
+func ValidateTransisiBencana(dari, ke string) error {
+	for _, s := range transisiBencana[dari] {
+		if s == ke {
+			return nil
+		}
+	}
+
+	berikutnya := transisiBencana[dari]
+	if len(berikutnya) == 0 {
+		return fmt.Errorf("%w: %q tidak dapat diubah lewat perubahan status", ErrTransisiBencana, dari)
+	}
+	return fmt.Errorf("%w: dari %q hanya boleh ke %s", ErrTransisiBencana, dari, strings.Join(berikutnya, ", "))
+}
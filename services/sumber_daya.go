@@ -0,0 +1,47 @@
+// services/sumber_daya.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Status permintaan sumber daya antar kecamatan
+const (
+	PermintaanDiajukan   = "Diajukan"
+	PermintaanDisetujui  = "Disetujui"
+	PermintaanDitolak    = "Ditolak"
+	PermintaanDikirim    = "Dikirim"
+	PermintaanDiterima   = "Diterima"
+	PermintaanDibatalkan = "Dibatalkan"
+)
+
+// Alur permintaan: Diajukan -> Disetujui (BPBD menunjuk pemasok) -> Dikirim (pemasok)
+// -> Diterima (peminta). BPBD boleh menolak yang masih diajukan; peminta boleh
+// membatalkan selama barang belum dikirim.
+var transisiPermintaan = map[string][]string{
+	PermintaanDiajukan:   {PermintaanDisetujui, PermintaanDitolak, PermintaanDibatalkan},
+	PermintaanDisetujui:  {PermintaanDikirim, PermintaanDibatalkan},
+	PermintaanDikirim:    {PermintaanDiterima},
+	PermintaanDitolak:    {},
+	PermintaanDiterima:   {},
+	PermintaanDibatalkan: {},
+}
+
+var ErrTransisiPermintaan = errors.New("transisi status permintaan tidak diizinkan")
+
+// ValidateTransisiPermintaan checks whether a resource request may move from status dari to ke
+func ValidateTransisiPermintaan(dari, ke string) error {
+	for _, s := range transisiPermintaan[dari] {
+		if s == ke {
+			return nil
+		}
+	}
+
+	berikutnya := transisiPermintaan[dari]
+	if len(berikutnya) == 0 {
+		return fmt.Errorf("%w: %q adalah status akhir", ErrTransisiPermintaan, dari)
+	}
+	return fmt.Errorf("%w: dari %q hanya boleh ke %s", ErrTransisiPermintaan, dari, strings.Join(berikutnya, ", "))
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+// KolomDampak describes one casualty/damage figure, dalam urutan tampilan sitrep
+type KolomDampak struct {
+	Kode  string
+	Label string
+	nilai func(d *models.DampakBencana) **int
+}
+
+// DaftarKolomDampak lists every figure of models.DampakBencana
+var DaftarKolomDampak = []KolomDampak{
+	{"meninggal", "Meninggal", func(d *models.DampakBencana) **int { return &d.Meninggal }},
+	{"hilang", "Hilang", func(d *models.DampakBencana) **int { return &d.Hilang }},
+	{"luka_berat", "Luka berat", func(d *models.DampakBencana) **int { return &d.LukaBerat }},
+	{"luka_ringan", "Luka ringan", func(d *models.DampakBencana) **int { return &d.LukaRingan }},
+	{"mengungsi", "Mengungsi", func(d *models.DampakBencana) **int { return &d.Mengungsi }},
+	{"rumah_rusak_berat", "Rumah rusak berat", func(d *models.DampakBencana) **int { return &d.RumahRusakBerat }},
+	{"rumah_rusak_sedang", "Rumah rusak sedang", func(d *models.DampakBencana) **int { return &d.RumahRusakSedang }},
+	{"rumah_rusak_ringan", "Rumah rusak ringan", func(d *models.DampakBencana) **int { return &d.RumahRusakRingan }},
+	{"rumah_terendam", "Rumah terendam", func(d *models.DampakBencana) **int { return &d.RumahTerendam }},
+	{"fasilitas_rusak", "Fasilitas umum rusak", func(d *models.DampakBencana) **int { return &d.FasilitasRusak }},
+}
+
+// Nilai returns the figure of d, or nil when it was not reported
+func (k KolomDampak) Nilai(d models.DampakBencana) *int {
+	return *k.nilai(&d)
+}
+
+// AdaDampak reports whether at least one figure is filled in
+func AdaDampak(d models.DampakBencana) bool {
+	for _, k := range DaftarKolomDampak {
+		if k.Nilai(d) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidasiDampak rejects negative figures
+func ValidasiDampak(d models.DampakBencana) error {
+	for _, k := range DaftarKolomDampak {
+		if v := k.Nilai(d); v != nil && *v < 0 {
+			return fmt.Errorf("%s must not be negative", k.Kode)
+		}
+	}
+	return nil
+}
+
+// RekapDampak combines the timeline of a bencana into current figures. Angka per
+// wilayah (RT/RW) adalah kumulatif: laporan terbaru menggantikan laporan sebelumnya
+// untuk kolom yang diisinya, lalu semua wilayah dijumlahkan.
+func RekapDampak(entri []models.TimelineBencana) models.RekapDampakBencana {
+	urut := make([]models.TimelineBencana, 0, len(entri))
+	for _, e := range entri {
+		if AdaDampak(e.Dampak) {
+			urut = append(urut, e)
+		}
+	}
+	sort.SliceStable(urut, func(i, j int) bool {
+		if !urut[i].Waktu.Equal(urut[j].Waktu) {
+			return urut[i].Waktu.Before(urut[j].Waktu)
+		}
+		return urut[i].ID < urut[j].ID
+	})
+
+	rekap := models.RekapDampakBencana{PerWilayah: []models.DampakWilayah{}, JumlahLaporan: len(urut)}
+	indeks := map[string]int{}
+	for _, e := range urut {
+		rt, rw := strings.TrimSpace(e.RT), strings.TrimSpace(e.RW)
+		kunci := rt + "/" + rw
+		i, ok := indeks[kunci]
+		if !ok {
+			i = len(rekap.PerWilayah)
+			indeks[kunci] = i
+			rekap.PerWilayah = append(rekap.PerWilayah, models.DampakWilayah{RT: rt, RW: rw})
+		}
+		wilayah := &rekap.PerWilayah[i]
+		for _, k := range DaftarKolomDampak {
+			if v := k.Nilai(e.Dampak); v != nil {
+				salinan := *v
+				*k.nilai(&wilayah.Dampak) = &salinan
+			}
+		}
+		wilayah.DiperbaruiPada = e.Waktu
+		waktu := e.Waktu
+		rekap.DiperbaruiPada = &waktu
+	}
+
+	for _, w := range rekap.PerWilayah {
+		for _, k := range DaftarKolomDampak {
+			v := k.Nilai(w.Dampak)
+			if v == nil {
+				continue
+			}
+			total := k.nilai(&rekap.Total)
+			if *total == nil {
+				nol := 0
+				*total = &nol
+			}
+			**total += *v
+		}
+	}
+
+	sort.SliceStable(rekap.PerWilayah, func(i, j int) bool {
+		a, b := rekap.PerWilayah[i], rekap.PerWilayah[j]
+		if a.RW != b.RW {
+			return a.RW < b.RW
+		}
+		return a.RT < b.RT
+	})
+	return rekap
+}
@@ -0,0 +1,102 @@
+// services/titik_kumpul.go
+package services
+
+import (
+	"sort"
+
+	"github.com/HENGKIDWI/Sistem-Mitigasi-Bencana-Berbasis-Komunitas.git/models"
+)
+
+// KebutuhanFasilitas adalah fasilitas titik kumpul yang wajib ada
+type KebutuhanFasilitas struct {
+	ToiletAkses bool `json:"toilet_akses"`
+	PosMedis    bool `json:"pos_medis"`
+	Generator   bool `json:"generator"`
+}
+
+// SaranTitikKumpul adalah titik kumpul yang disarankan beserta alasannya
+type SaranTitikKumpul struct {
+	models.TitikKumpul
+	SisaKapasitas int      `json:"sisa_kapasitas"`
+	JarakKm       *float64 `json:"jarak_km"`
+	Skor          float64  `json:"skor"` // Makin kecil makin disarankan
+}
+
+// FasilitasUntukWarga menurunkan fasilitas yang dibutuhkan dari kategori dan kebutuhan perawatan warga
+func FasilitasUntukWarga(kategori []string, kebutuhan *models.KebutuhanPerawatan) KebutuhanFasilitas {
+	var butuh KebutuhanFasilitas
+	for _, k := range kategori {
+		switch k {
+		case "Disabilitas":
+			butuh.ToiletAkses = true
+		case "Sakit Keras":
+			butuh.PosMedis = true
+		}
+	}
+	if kebutuhan != nil {
+		if kebutuhan.KursiRoda || kebutuhan.Tandu {
+			butuh.ToiletAkses = true
+		}
+		if kebutuhan.Oksigen {
+			// Konsentrator oksigen butuh listrik
+			butuh.Generator = true
+			butuh.PosMedis = true
+		}
+		if kebutuhan.JadwalCuciDarah != "" {
+			butuh.PosMedis = true
+		}
+	}
+	return butuh
+}
+
+// Gabung menggabungkan kebutuhan fasilitas beberapa warga (misal satu keluarga)
+func (k KebutuhanFasilitas) Gabung(lain KebutuhanFasilitas) KebutuhanFasilitas {
+	return KebutuhanFasilitas{
+		ToiletAkses: k.ToiletAkses || lain.ToiletAkses,
+		PosMedis:    k.PosMedis || lain.PosMedis,
+		Generator:   k.Generator || lain.Generator,
+	}
+}
+
+func (k KebutuhanFasilitas) terpenuhi(t models.TitikKumpul) bool {
+	return (!k.ToiletAkses || t.ToiletAkses) &&
+		(!k.PosMedis || t.PosMedis) &&
+		(!k.Generator || t.Generator)
+}
+
+// SarankanTitikKumpul memilih titik kumpul aktif yang masih muat untuk jumlah orang
+// dan punya fasilitas yang dibutuhkan. Urutan berdasarkan jarak yang diperberat
+// okupansi setelah ditempati, sehingga titik kumpul yang hampir penuh
+// hanya disarankan bila jauh lebih dekat.
+func SarankanTitikKumpul(lat, lng float64, adaLokasi bool, jumlah int, butuh KebutuhanFasilitas, titik []models.TitikKumpul) []SaranTitikKumpul {
+	if jumlah < 1 {
+		jumlah = 1
+	}
+
+	var saran []SaranTitikKumpul
+	for _, t := range titik {
+		if !t.Aktif || t.SisaKapasitas() < jumlah || !butuh.terpenuhi(t) {
+			continue
+		}
+
+		s := SaranTitikKumpul{TitikKumpul: t, SisaKapasitas: t.SisaKapasitas()}
+
+		jarak := jarakTidakDiketahuiKm
+		if adaLokasi && AdaKoordinat(t.Latitude, t.Longitude) {
+			jarak = JarakKm(lat, lng, t.Latitude, t.Longitude)
+			s.JarakKm = &jarak
+		}
+
+		okupansi := float64(t.Terisi+jumlah) / float64(t.Kapasitas)
+		s.Skor = jarak * (1 + okupansi)
+		saran = append(saran, s)
+	}
+
+	sort.SliceStable(saran, func(i, j int) bool {
+		if saran[i].Skor != saran[j].Skor {
+			return saran[i].Skor < saran[j].Skor
+		}
+		return saran[i].SisaKapasitas > saran[j].SisaKapasitas
+	})
+	return saran
+}
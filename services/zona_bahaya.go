@@ -0,0 +1,99 @@
+// services/zona_bahaya.go
+package services
+
+import (
+	"math"
+	"strings"
+)
+
+// DaftarJenisBahaya adalah jenis bahaya yang dapat dipetakan sebagai zona
+var DaftarJenisBahaya = []string{
+	"Banjir", "Banjir Rob", "Tanah Longsor", "Gempabumi", "Tsunami",
+	"Angin Puting Beliung", "Kebakaran", "Erupsi Gunung Api", "Kekeringan", "Abrasi",
+}
+
+// JenisBahayaValid reports whether jenis is one of DaftarJenisBahaya
+func JenisBahayaValid(jenis string) bool {
+	for _, j := range DaftarJenisBahaya {
+		if j == jenis {
+			return true
+		}
+	}
+	return false
+}
+
+var peringkatRisiko = map[string]int{"Rendah": 1, "Sedang": 2, "Tinggi": 3}
+
+// KelasRisikoValid reports whether kelas is Rendah, Sedang or Tinggi
+func KelasRisikoValid(kelas string) bool {
+	return peringkatRisiko[kelas] > 0
+}
+
+// PeringkatRisiko returns 3 for Tinggi, 2 for Sedang, 1 for Rendah and 0 otherwise
+func PeringkatRisiko(kelas string) int {
+	return peringkatRisiko[kelas]
+}
+
+// kataKunciBahaya memetakan kata pada jenis bencana / peringatan ke jenis bahaya zona.
+// Urutan penting: "banjir rob" harus dicek sebelum "banjir".
+var kataKunciBahaya = []struct {
+	kata  string
+	jenis []string
+}{
+	{"rob", []string{"Banjir Rob"}},
+	{"banjir", []string{"Banjir"}},
+	{"hujan", []string{"Banjir", "Tanah Longsor"}},
+	{"longsor", []string{"Tanah Longsor"}},
+	{"gerakan tanah", []string{"Tanah Longsor"}},
+	{"tsunami", []string{"Tsunami"}},
+	{"gempa", []string{"Gempabumi"}},
+	{"angin", []string{"Angin Puting Beliung"}},
+	{"puting beliung", []string{"Angin Puting Beliung"}},
+	{"kebakaran", []string{"Kebakaran"}},
+	{"erupsi", []string{"Erupsi Gunung Api"}},
+	{"gunung", []string{"Erupsi Gunung Api"}},
+	{"kekeringan", []string{"Kekeringan"}},
+	{"abrasi", []string{"Abrasi"}},
+	{"gelombang", []string{"Abrasi", "Banjir Rob"}},
+}
+
+// JenisBahayaTerkait maps a disaster or warning type (mis. "Hujan Lebat", "Banjir Bandang")
+// to the hazard zone types it concerns. Bila tidak ada yang cocok, jenis itu sendiri dipakai.
+func JenisBahayaTerkait(jenis string) []string {
+	teks := " " + NormalizeText(jenis) // Cocokkan awal kata: "gempa" mengenai "gempabumi"
+	var hasil []string
+	sudah := make(map[string]bool)
+	for _, k := range kataKunciBahaya {
+		if k.kata == "banjir" && strings.Contains(teks, "banjir rob") {
+			continue
+		}
+		if !strings.Contains(teks, " "+k.kata) {
+			continue
+		}
+		for _, j := range k.jenis {
+			if !sudah[j] {
+				sudah[j] = true
+				hasil = append(hasil, j)
+			}
+		}
+	}
+	if len(hasil) == 0 {
+		return []string{strings.TrimSpace(jenis)}
+	}
+	return hasil
+}
+
+// KotakBatas returns the bounding box of the polygons
+func KotakBatas(poligon []Poligon) (minLat, maxLat, minLng, maxLng float64) {
+	minLat, minLng = math.Inf(1), math.Inf(1)
+	maxLat, maxLng = math.Inf(-1), math.Inf(-1)
+	for _, p := range poligon {
+		for _, t := range p {
+			minLat = math.Min(minLat, t.Lat)
+			maxLat = math.Max(maxLat, t.Lat)
+			minLng = math.Min(minLng, t.Lng)
+			maxLng = math.Max(maxLng, t.Lng)
+		}
+	}
+	return minLat, maxLat, minLng, maxLng
+}